@@ -4,6 +4,7 @@ import (
 	"github.com/cdk8s-team/cdk8s-core-go/cdk8s/v2"
 	networkChaos "github.com/smartcontractkit/chainlink-env/imports/k8s/networkchaos/chaosmeshorg"
 	podChaos "github.com/smartcontractkit/chainlink-env/imports/k8s/podchaos/chaosmeshorg"
+	timeChaos "github.com/smartcontractkit/chainlink-env/imports/k8s/timechaos/chaosmeshorg"
 	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
 )
 
@@ -19,6 +20,12 @@ type Props struct {
 	DurationStr    string
 	FromLabels     *map[string]*string
 	ToLabels       *map[string]*string
+	// TimeOffset is a clock offset for time chaos, for example, "1h30m"
+	TimeOffset string
+	// Latency is a network delay between FromLabels and ToLabels pods, for example, "500ms"
+	Latency string
+	// Name is an experiment resource name, generated if empty, set it to run many experiments of the same kind
+	Name string
 }
 
 func blankManifest(namespace string) (cdk8s.App, cdk8s.Chart) {
@@ -116,3 +123,49 @@ func NewNetworkPartition(namespace string, props *Props) (cdk8s.App, string, str
 	})
 	return app, *c.Name(), "networkchaos"
 }
+
+func NewNetworkLatency(namespace string, props *Props) (cdk8s.App, string, string) {
+	app, root := blankManifest(namespace)
+	c := networkChaos.NewNetworkChaos(root, a.Str("experiment"), &networkChaos.NetworkChaosProps{
+		Spec: &networkChaos.NetworkChaosSpec{
+			Action: networkChaos.NetworkChaosSpecAction_DELAY,
+			Mode:   networkChaos.NetworkChaosSpecMode_ALL,
+			Selector: &networkChaos.NetworkChaosSpecSelector{
+				LabelSelectors: props.FromLabels,
+			},
+			Direction: networkChaos.NetworkChaosSpecDirection_TO,
+			Duration:  a.Str(props.DurationStr),
+			Delay: &networkChaos.NetworkChaosSpecDelay{
+				Latency: a.Str(props.Latency),
+			},
+			Target: &networkChaos.NetworkChaosSpecTarget{
+				Mode: networkChaos.NetworkChaosSpecTargetMode_ALL,
+				Selector: &networkChaos.NetworkChaosSpecTargetSelector{
+					LabelSelectors: props.ToLabels,
+				},
+			},
+		},
+	})
+	return app, *c.Name(), "networkchaos"
+}
+
+func NewTimeOffset(namespace string, props *Props) (cdk8s.App, string, string) {
+	app, root := blankManifest(namespace)
+	var meta *cdk8s.ApiObjectMetadata
+	if props.Name != "" {
+		meta = &cdk8s.ApiObjectMetadata{Name: a.Str(props.Name)}
+	}
+	c := timeChaos.NewTimeChaos(root, a.Str("experiment"), &timeChaos.TimeChaosProps{
+		Metadata: meta,
+		Spec: &timeChaos.TimeChaosSpec{
+			Mode: timeChaos.TimeChaosSpecMode_ALL,
+			Selector: &timeChaos.TimeChaosSpecSelector{
+				LabelSelectors: props.LabelsSelector,
+			},
+			ContainerNames: props.ContainerNames,
+			TimeOffset:     a.Str(props.TimeOffset),
+			Duration:       FOREVER,
+		},
+	})
+	return app, *c.Name(), "timechaos"
+}
@@ -0,0 +1,181 @@
+package chaos
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+	"sigs.k8s.io/yaml"
+)
+
+// experiments are experiment kinds available in scenario files
+var experiments = map[string]ManifestFunc{
+	"pod-kill":          NewKillPods,
+	"pod-failure":       NewFailPods,
+	"container-kill":    NewFailContainers,
+	"network-partition": NewNetworkPartition,
+	"network-latency":   NewNetworkLatency,
+	"time-offset":       NewTimeOffset,
+}
+
+// LookupExperiment returns a manifest constructor of the experiment kind, false if the kind is unknown
+func LookupExperiment(name string) (ManifestFunc, bool) {
+	f, ok := experiments[name]
+	return f, ok
+}
+
+// ExperimentNames returns sorted experiment kinds available in scenario files
+func ExperimentNames() []string {
+	names := make([]string, 0, len(experiments))
+	for k := range experiments {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Scenario is a named sequence of chaos experiments with timings and recovery expectations, for example, a codified GameDay
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Steps       []Step `json:"steps"`
+}
+
+// Step runs one experiment, waits, stops it and checks that the environment recovered
+type Step struct {
+	Name string `json:"name"`
+	// Experiment is a kind of experiment, one of ExperimentNames
+	Experiment string `json:"experiment"`
+	// Selector labels of target pods, for network partitions it's the source side
+	Selector map[string]string `json:"selector"`
+	// To labels of the other side of a network partition
+	To map[string]string `json:"to,omitempty"`
+	// Containers targets only these containers of the pods
+	Containers []string `json:"containers,omitempty"`
+	// Duration of the experiment in Chaosmesh format for experiments which support it, e.g. "30s", the experiment is stopped
+	// after Hold anyway
+	Duration string `json:"duration,omitempty"`
+	// Latency of a network-latency experiment, e.g. "500ms"
+	Latency string `json:"latency,omitempty"`
+	// TimeOffset of a time-offset experiment, e.g. "1h"
+	TimeOffset string `json:"timeOffset,omitempty"`
+	// Before how long to wait before the experiment is started
+	Before string `json:"before,omitempty"`
+	// Hold how long the experiment runs before it's stopped
+	Hold string `json:"hold"`
+	// Keep leaves the experiment running for the next steps
+	Keep bool `json:"keep,omitempty"`
+	// Expect is checked after the experiment is stopped
+	Expect *Expectation `json:"expect,omitempty"`
+}
+
+// Expectation is a recovery assertion of a step
+type Expectation struct {
+	// Ready all pods of the environment become ready
+	Ready bool `json:"ready,omitempty"`
+	// Healthy health checks and conditions of all charts pass
+	Healthy bool `json:"healthy,omitempty"`
+	// Within how long recovery may take, e.g. "3m", environment ready timeout is used if empty
+	Within string `json:"within,omitempty"`
+}
+
+// LoadScenario reads and validates a scenario YAML file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario parses and validates a scenario
+func ParseScenario(data []byte) (*Scenario, error) {
+	s := &Scenario{}
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, errors.Wrap(err, "failed to parse chaos scenario")
+	}
+	if s.Name == "" {
+		return nil, errors.New("chaos scenario has no name")
+	}
+	if len(s.Steps) == 0 {
+		return nil, errors.Errorf("chaos scenario %s has no steps", s.Name)
+	}
+	for i, st := range s.Steps {
+		if err := st.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "step %d (%s) of chaos scenario %s", i, st.Name, s.Name)
+		}
+	}
+	return s, nil
+}
+
+// Validate checks the experiment kind, selectors and durations of the step
+func (s Step) Validate() error {
+	if _, ok := LookupExperiment(s.Experiment); !ok {
+		return errors.Errorf("unknown experiment '%s', one of %v is expected", s.Experiment, ExperimentNames())
+	}
+	if len(s.Selector) == 0 {
+		return errors.New("selector is empty")
+	}
+	if (s.Experiment == "network-partition" || s.Experiment == "network-latency") && len(s.To) == 0 {
+		return errors.Errorf("%s needs 'to' labels", s.Experiment)
+	}
+	if s.Experiment == "network-latency" && s.Latency == "" {
+		return errors.New("network latency needs 'latency'")
+	}
+	if s.Experiment == "time-offset" && s.TimeOffset == "" {
+		return errors.New("time offset needs 'timeOffset'")
+	}
+	for field, v := range map[string]string{"before": s.Before, "hold": s.Hold} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(v); err != nil {
+			return errors.Wrapf(err, "invalid %s", field)
+		}
+	}
+	if s.Expect != nil && s.Expect.Within != "" {
+		if _, err := time.ParseDuration(s.Expect.Within); err != nil {
+			return errors.Wrap(err, "invalid expect.within")
+		}
+	}
+	return nil
+}
+
+// Durations returns parsed before and hold durations, zero if not set, the step must be validated
+func (s Step) Durations() (before time.Duration, hold time.Duration) {
+	before, _ = time.ParseDuration(s.Before)
+	hold, _ = time.ParseDuration(s.Hold)
+	return before, hold
+}
+
+// Props converts a step into experiment props
+func (s Step) Props() *Props {
+	p := &Props{
+		LabelsSelector: a.ConvertLabelsMap(s.Selector),
+		FromLabels:     a.ConvertLabelsMap(s.Selector),
+		DurationStr:    s.DurationStr(),
+		TimeOffset:     s.TimeOffset,
+		Latency:        s.Latency,
+	}
+	if len(s.To) != 0 {
+		p.ToLabels = a.ConvertLabelsMap(s.To)
+	}
+	if len(s.Containers) != 0 {
+		containers := make([]*string, 0, len(s.Containers))
+		for _, c := range s.Containers {
+			containers = append(containers, a.Str(c))
+		}
+		p.ContainerNames = &containers
+	}
+	return p
+}
+
+// DurationStr is the Chaosmesh duration of the experiment, experiments are stopped by the scenario, so it defaults to FOREVER
+func (s Step) DurationStr() string {
+	if s.Duration != "" {
+		return s.Duration
+	}
+	return *FOREVER
+}
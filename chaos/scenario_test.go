@@ -0,0 +1,58 @@
+package chaos
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScenario(t *testing.T) {
+	data, err := os.ReadFile("../examples/gameday/scenario.yaml")
+	require.NoError(t, err)
+	s, err := ParseScenario(data)
+	require.NoError(t, err)
+	require.Equal(t, "node-outages", s.Name)
+	require.Len(t, s.Steps, 2)
+	before, hold := s.Steps[1].Durations()
+	require.Equal(t, "10s", before.String())
+	require.Equal(t, "1m0s", hold.String())
+	require.Equal(t, "3m", s.Steps[0].Expect.Within)
+	p := s.Steps[1].Props()
+	require.Equal(t, "geth", *(*p.ToLabels)["app"])
+	require.Equal(t, *FOREVER, p.DurationStr)
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		err      string
+	}{
+		{"no steps", "name: a", "has no steps"},
+		{"unknown experiment", "name: a\nsteps:\n- experiment: io-delay\n  selector: {app: geth}", "unknown experiment 'io-delay'"},
+		{"no selector", "name: a\nsteps:\n- experiment: pod-kill", "selector is empty"},
+		{"partition without to", "name: a\nsteps:\n- experiment: network-partition\n  selector: {app: geth}", "network-partition needs 'to' labels"},
+		{"latency without latency", "name: a\nsteps:\n- experiment: network-latency\n  selector: {app: geth}\n  to: {app: chainlink-0}", "needs 'latency'"},
+		{"bad hold", "name: a\nsteps:\n- experiment: pod-kill\n  selector: {app: geth}\n  hold: 1x", "invalid hold"},
+		{"unknown field", "name: a\nsteps:\n- experiment: pod-kill\n  selector: {app: geth}\n  wait: 1s", "unknown field"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(tc.scenario))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestLookupExperiment(t *testing.T) {
+	_, ok := LookupExperiment("pod-kill")
+	require.True(t, ok)
+	_, ok = LookupExperiment("pod-explode")
+	require.False(t, ok)
+	names := ExperimentNames()
+	require.True(t, sort.StringsAreSorted(names))
+	require.Contains(t, names, "network-latency")
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// FieldManager owns fields applied by the environment, fields of other managers are taken over on conflicts
+	FieldManager = "chainlink-env"
+	// MappingTimeout how long to wait for resources of just applied CRDs to appear in the API discovery
+	MappingTimeout = 30 * time.Second
+	// DeleteTimeout how long DeleteByLabel waits for resources and their dependents to be deleted
+	DeleteTimeout = 5 * time.Minute
+)
+
+// labelDeletedKinds are kinds deleted by DeleteByLabel, the same as "kubectl delete all,configmap,secret,pvc,serviceaccount,role,rolebinding,ingress"
+var labelDeletedKinds = []schema.GroupKind{
+	{Kind: "Pod"},
+	{Kind: "Service"},
+	{Kind: "ReplicationController"},
+	{Group: "apps", Kind: "Deployment"},
+	{Group: "apps", Kind: "StatefulSet"},
+	{Group: "apps", Kind: "DaemonSet"},
+	{Group: "apps", Kind: "ReplicaSet"},
+	{Group: "batch", Kind: "Job"},
+	{Group: "batch", Kind: "CronJob"},
+	{Group: "autoscaling", Kind: "HorizontalPodAutoscaler"},
+	{Kind: "ConfigMap"},
+	{Kind: "Secret"},
+	{Kind: "PersistentVolumeClaim"},
+	{Kind: "ServiceAccount"},
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"},
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"},
+	{Group: "networking.k8s.io", Kind: "Ingress"},
+}
+
+// DefaultLabelDeletedKinds returns kinds deleted by DeleteByLabel if K8sClient.LabelDeletedKinds is empty,
+// the same as "kubectl delete all,configmap,secret,pvc,serviceaccount,role,rolebinding,ingress"
+func DefaultLabelDeletedKinds() []schema.GroupKind {
+	return append([]schema.GroupKind{}, labelDeletedKinds...)
+}
+
+// ResourceError is an error of a single manifest resource
+type ResourceError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Err       error
+}
+
+func (e *ResourceError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Kind, resourceName(e.Namespace, e.Name), e.Err)
+}
+
+func (e *ResourceError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyError contains errors of all failed resources of a manifest, other resources are applied
+type ApplyError struct {
+	Errors []*ResourceError
+}
+
+func (e *ApplyError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, re := range e.Errors {
+		msgs = append(msgs, re.Error())
+	}
+	return fmt.Sprintf("failed to apply %d resources: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// applyObjects applies manifest resources with server-side apply, or creates them, and writes a line per resource into out,
+// namespaces and CRDs go first, so resources depending on them can be mapped
+func (m *K8sClient) applyObjects(manifest string, create bool, out io.Writer) error {
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+	dyn, mapper, err := m.dynamicClient()
+	if err != nil {
+		return err
+	}
+	defaultNamespace := m.defaultNamespace()
+	applyErr := &ApplyError{}
+	for _, obj := range sortForApply(objs) {
+		if err := m.applyObject(dyn, mapper, obj, defaultNamespace, create); err != nil {
+			applyErr.Errors = append(applyErr.Errors, &ResourceError{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName(), Err: err})
+			fmt.Fprintf(out, "%s/%s failed: %s\n", strings.ToLower(obj.GetKind()), obj.GetName(), err)
+			continue
+		}
+		verb := "serverside-applied"
+		if create {
+			verb = "created"
+		}
+		fmt.Fprintf(out, "%s/%s %s\n", strings.ToLower(obj.GetKind()), obj.GetName(), verb)
+	}
+	if len(applyErr.Errors) != 0 {
+		return applyErr
+	}
+	return nil
+}
+
+func (m *K8sClient) applyObject(dyn dynamic.Interface, mapper *restmapper.DeferredDiscoveryRESTMapper, obj *unstructured.Unstructured, defaultNamespace string, create bool) error {
+	gvk := obj.GroupVersionKind()
+	var mapping *meta.RESTMapping
+	err := PollImmediate(m.Clock(), ContainerStatePollInterval, MappingTimeout, func() (bool, error) {
+		var err error
+		mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			mapper.Reset()
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "no API resource found for %s", gvk)
+	}
+	var ri dynamic.ResourceInterface = dyn.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(defaultNamespace)
+		}
+		ri = dyn.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+	} else {
+		obj.SetNamespace("")
+	}
+	ctx := context.Background()
+	if create {
+		_, err := ri.Create(ctx, obj, metaV1.CreateOptions{FieldManager: FieldManager})
+		return err
+	}
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	force := true
+	_, err = ri.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metaV1.PatchOptions{FieldManager: FieldManager, Force: &force})
+	return err
+}
+
+// dynamicClient returns a dynamic client with a discovery REST mapper, so resources of any kind are applied and deleted
+func (m *K8sClient) dynamicClient() (dynamic.Interface, *restmapper.DeferredDiscoveryRESTMapper, error) {
+	dyn, err := dynamic.NewForConfig(m.RESTConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dyn, restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(m.ClientSet.Discovery())), nil
+}
+
+// DeleteByLabel deletes namespaced resources of K8sClient.LabelDeletedKinds matching the selector with foreground cascading
+// and waits until they are gone, kinds unknown to the cluster are skipped
+func (m *K8sClient) DeleteByLabel(namespace string, selector string) error {
+	dyn, mapper, err := m.dynamicClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	policy := metaV1.DeletePropagationForeground
+	deleting := make(map[string]dynamic.ResourceInterface)
+	kinds := m.LabelDeletedKinds
+	if len(kinds) == 0 {
+		kinds = labelDeletedKinds
+	}
+	for _, gk := range kinds {
+		mapping, err := mapper.RESTMapping(gk)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "no API resource found for %s", gk)
+		}
+		ri := dyn.Resource(mapping.Resource).Namespace(namespace)
+		list, err := ri.List(ctx, metaV1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list %s by %s", mapping.Resource.Resource, selector)
+		}
+		for _, obj := range list.Items {
+			err := ri.Delete(ctx, obj.GetName(), metaV1.DeleteOptions{PropagationPolicy: &policy})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return &ResourceError{Kind: gk.Kind, Namespace: namespace, Name: obj.GetName(), Err: err}
+			}
+		}
+		if len(list.Items) != 0 {
+			deleting[mapping.Resource.Resource] = ri
+		}
+	}
+	err = PollImmediate(m.Clock(), ContainerStatePollInterval, DeleteTimeout, func() (bool, error) {
+		for _, ri := range deleting {
+			list, err := ri.List(ctx, metaV1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return false, err
+			}
+			if len(list.Items) != 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	return errors.Wrapf(err, "resources of %s are not deleted in %s", selector, DeleteTimeout)
+}
+
+// defaultNamespace is a namespace of resources without one, like kubectl it's the current context namespace
+func (m *K8sClient) defaultNamespace() string {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	ns, _, err := kubeConfig.Namespace()
+	if err != nil || ns == "" {
+		return metaV1.NamespaceDefault
+	}
+	return ns
+}
+
+// decodeManifest decodes all resources of a multi-document YAML or JSON manifest, empty documents are skipped
+func decodeManifest(manifest string) ([]*unstructured.Unstructured, error) {
+	dec := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	objs := make([]*unstructured.Unstructured, 0)
+	for {
+		doc := make(map[string]interface{})
+		if err := dec.Decode(&doc); err != nil {
+			if err == io.EOF {
+				return objs, nil
+			}
+			return nil, errors.Wrap(err, "failed to decode manifest")
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: doc}
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			return nil, errors.Errorf("manifest resource %s has no kind or apiVersion", obj.GetName())
+		}
+		objs = append(objs, obj)
+	}
+}
+
+// sortForApply moves namespaces first and CRDs second, the order of other resources is kept
+func sortForApply(objs []*unstructured.Unstructured) []*unstructured.Unstructured {
+	sorted := append([]*unstructured.Unstructured{}, objs...)
+	rank := func(o *unstructured.Unstructured) int {
+		switch o.GetKind() {
+		case "Namespace":
+			return 0
+		case "CustomResourceDefinition":
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank(sorted[i]) < rank(sorted[j])
+	})
+	return sorted
+}
+
+func resourceName(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDecodeManifest(t *testing.T) {
+	t.Run("multi document", func(t *testing.T) {
+		objs, err := decodeManifest(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: geth
+---
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: podchaos.chaos-mesh.org
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: chainlink-test-env
+`)
+		require.NoError(t, err)
+		names := make([]string, 0)
+		for _, o := range sortForApply(objs) {
+			names = append(names, o.GetKind()+"/"+o.GetName())
+		}
+		require.Equal(t, []string{
+			"Namespace/chainlink-test-env",
+			"CustomResourceDefinition/podchaos.chaos-mesh.org",
+			"Deployment/geth",
+		}, names)
+	})
+	t.Run("no kind", func(t *testing.T) {
+		_, err := decodeManifest("metadata:\n  name: geth\n")
+		require.Error(t, err)
+	})
+}
+
+func TestApplyError(t *testing.T) {
+	cause := errors.New("forbidden")
+	var err error = &ApplyError{Errors: []*ResourceError{
+		{Kind: "Deployment", Namespace: "env", Name: "geth", Err: cause},
+		{Kind: "ClusterRole", Name: "chaos", Err: errors.New("conflict")},
+	}}
+	require.Equal(t, "failed to apply 2 resources: Deployment env/geth: forbidden; ClusterRole chaos: conflict", err.Error())
+	var applyErr *ApplyError
+	require.True(t, errors.As(err, &applyErr))
+	require.ErrorIs(t, applyErr.Errors[0], cause)
+}
+
+func TestDefaultLabelDeletedKinds(t *testing.T) {
+	kinds := DefaultLabelDeletedKinds()
+	require.Contains(t, kinds, schema.GroupKind{Group: "apps", Kind: "Deployment"})
+	kinds[0] = schema.GroupKind{Kind: "Namespace"}
+	require.NotContains(t, DefaultLabelDeletedKinds(), schema.GroupKind{Kind: "Namespace"}, "defaults are copied")
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	batchV1 "k8s.io/api/batch/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// Group versions of objects which were moved between K8s versions, the first served one is used,
+// v1beta1 CronJob and PodDisruptionBudget, and v2beta2 HorizontalPodAutoscaler are removed in K8s 1.25 and 1.26
+var (
+	CronJobVersions                 = []string{"batch/v1", "batch/v1beta1"}
+	PodDisruptionBudgetVersions     = []string{"policy/v1", "policy/v1beta1"}
+	HorizontalPodAutoscalerVersions = []string{"autoscaling/v2", "autoscaling/v2beta2", "autoscaling/v1"}
+)
+
+var kubeVersionRe = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)`)
+
+// APICapabilities API versions served by the cluster, so the same environment code deploys on older managed clusters and K8s 1.25+
+type APICapabilities struct {
+	// KubeVersion is a server version without a vendor suffix, e.g. v1.24.3
+	KubeVersion string
+	// CronJob, PodDisruptionBudget and HorizontalPodAutoscaler are group versions of these kinds served by the cluster
+	CronJob                 string
+	PodDisruptionBudget     string
+	HorizontalPodAutoscaler string
+	// APIVersions are all served group versions
+	APIVersions []string
+}
+
+// Capabilities detects API versions served by the cluster, the result is cached
+func (m *K8sClient) Capabilities() (*APICapabilities, error) {
+	m.capabilitiesOnce.Do(func() {
+		m.capabilities, m.capabilitiesErr = m.detectCapabilities()
+	})
+	return m.capabilities, m.capabilitiesErr
+}
+
+func (m *K8sClient) detectCapabilities() (*APICapabilities, error) {
+	v, err := m.ClientSet.Discovery().ServerVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get server version")
+	}
+	groups, err := m.ClientSet.Discovery().ServerGroups()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get server API groups")
+	}
+	served := make([]string, 0)
+	for _, g := range groups.Groups {
+		for _, gv := range g.Versions {
+			served = append(served, gv.GroupVersion)
+		}
+	}
+	caps, err := newAPICapabilities(v.GitVersion, served)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().
+		Str("KubeVersion", caps.KubeVersion).
+		Str("CronJob", caps.CronJob).
+		Str("PodDisruptionBudget", caps.PodDisruptionBudget).
+		Str("HorizontalPodAutoscaler", caps.HorizontalPodAutoscaler).
+		Msg("Detected API capabilities")
+	return caps, nil
+}
+
+// newAPICapabilities selects the first served group version of every moved kind
+func newAPICapabilities(gitVersion string, served []string) (*APICapabilities, error) {
+	match := kubeVersionRe.FindStringSubmatch(gitVersion)
+	if match == nil {
+		return nil, errors.Errorf("unsupported server version %s", gitVersion)
+	}
+	isServed := make(map[string]bool)
+	for _, gv := range served {
+		isServed[gv] = true
+	}
+	caps := &APICapabilities{KubeVersion: "v" + match[1], APIVersions: served}
+	for _, k := range caps.kinds() {
+		for _, gv := range k.candidates {
+			if isServed[gv] {
+				*k.version = gv
+				break
+			}
+		}
+		if *k.version == "" {
+			return nil, errors.Errorf("cluster %s serves none of %s versions %v", gitVersion, k.kind, k.candidates)
+		}
+	}
+	return caps, nil
+}
+
+type movedKind struct {
+	kind       string
+	candidates []string
+	version    *string
+}
+
+func (c *APICapabilities) kinds() []movedKind {
+	return []movedKind{
+		{kind: "CronJob", candidates: CronJobVersions, version: &c.CronJob},
+		{kind: "PodDisruptionBudget", candidates: PodDisruptionBudgetVersions, version: &c.PodDisruptionBudget},
+		{kind: "HorizontalPodAutoscaler", candidates: HorizontalPodAutoscalerVersions, version: &c.HorizontalPodAutoscaler},
+	}
+}
+
+// HelmFlags flags rendering charts for the cluster, charts select API versions by .Capabilities
+func (c *APICapabilities) HelmFlags() []string {
+	flags := []string{"--kube-version", c.KubeVersion}
+	for _, gv := range c.APIVersions {
+		flags = append(flags, "--api-versions", gv)
+	}
+	for _, k := range c.kinds() {
+		flags = append(flags, "--api-versions", fmt.Sprintf("%s/%s", *k.version, k.kind))
+	}
+	return flags
+}
+
+// cronJobs returns a client of CronJobs of the version served by the cluster, batch/v1 objects are sent as they are,
+// their fields we use are the same in batch/v1beta1
+func (m *K8sClient) cronJobs(namespace string) (dynamic.ResourceInterface, string, error) {
+	caps, err := m.Capabilities()
+	if err != nil {
+		return nil, "", err
+	}
+	gv, err := schema.ParseGroupVersion(caps.CronJob)
+	if err != nil {
+		return nil, "", err
+	}
+	dyn, err := dynamic.NewForConfig(m.RESTConfig)
+	if err != nil {
+		return nil, "", err
+	}
+	return dyn.Resource(gv.WithResource("cronjobs")).Namespace(namespace), caps.CronJob, nil
+}
+
+// applyCronJob creates a CronJob or updates its spec if it exists
+func (m *K8sClient) applyCronJob(namespace string, cj *batchV1.CronJob) error {
+	cronJobs, apiVersion, err := m.cronJobs(namespace)
+	if err != nil {
+		return err
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cj)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind("CronJob")
+	ctx := context.Background()
+	_, err = cronJobs.Create(ctx, u, metaV1.CreateOptions{})
+	if k8sErrors.IsAlreadyExists(err) {
+		var patch []byte
+		if patch, err = json.Marshal(map[string]interface{}{"spec": cj.Spec}); err == nil {
+			_, err = cronJobs.Patch(ctx, cj.Name, types.MergePatchType, patch, metaV1.PatchOptions{})
+		}
+	}
+	return err
+}
+
+// listCronJobs lists selected CronJobs
+func (m *K8sClient) listCronJobs(namespace string, selector string) ([]unstructured.Unstructured, error) {
+	cronJobs, _, err := m.cronJobs(namespace)
+	if err != nil {
+		return nil, err
+	}
+	list, err := cronJobs.List(context.Background(), metaV1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// deleteCronJob removes a CronJob, a missing one is not an error
+func (m *K8sClient) deleteCronJob(namespace string, name string) error {
+	cronJobs, _, err := m.cronJobs(namespace)
+	if err != nil {
+		return err
+	}
+	err = cronJobs.Delete(context.Background(), name, metaV1.DeleteOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPICapabilities(t *testing.T) {
+	caps, err := newAPICapabilities("v1.20.15-gke.1000", []string{"v1", "batch/v1", "batch/v1beta1", "policy/v1beta1", "autoscaling/v1", "autoscaling/v2beta2"})
+	require.NoError(t, err)
+	require.Equal(t, "v1.20.15", caps.KubeVersion)
+	require.Equal(t, "batch/v1", caps.CronJob)
+	require.Equal(t, "policy/v1beta1", caps.PodDisruptionBudget)
+	require.Equal(t, "autoscaling/v2beta2", caps.HorizontalPodAutoscaler)
+
+	caps, err = newAPICapabilities("v1.26.1", []string{"v1", "batch/v1", "policy/v1", "autoscaling/v1", "autoscaling/v2"})
+	require.NoError(t, err)
+	require.Equal(t, "policy/v1", caps.PodDisruptionBudget)
+	require.Equal(t, "autoscaling/v2", caps.HorizontalPodAutoscaler)
+	require.Equal(t, []string{
+		"--kube-version", "v1.26.1",
+		"--api-versions", "v1",
+		"--api-versions", "batch/v1",
+		"--api-versions", "policy/v1",
+		"--api-versions", "autoscaling/v1",
+		"--api-versions", "autoscaling/v2",
+		"--api-versions", "batch/v1/CronJob",
+		"--api-versions", "policy/v1/PodDisruptionBudget",
+		"--api-versions", "autoscaling/v2/HorizontalPodAutoscaler",
+	}, caps.HelmFlags())
+
+	_, err = newAPICapabilities("v1.26.1", []string{"v1", "batch/v1", "autoscaling/v2"})
+	require.Error(t, err, "no PodDisruptionBudget version")
+	_, err = newAPICapabilities("unknown", nil)
+	require.Error(t, err)
+}
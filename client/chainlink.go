@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/clock"
+)
+
+const (
+	DefaultChainlinkEmail    = "notreal@fakeemail.ch"
+	DefaultChainlinkPassword = "twochains"
+	ChainlinkRequestTimeout  = 30 * time.Second
+	ChainlinkRunPollInterval = 200 * time.Millisecond
+	ChainlinkRunTimeout      = 2 * time.Minute
+	// chainlinkJobsPageSize jobs are listed page by page, runs of a job by the latest page
+	chainlinkJobsPageSize = 100
+
+	RunStateCompleted = "completed"
+	RunStateErrored   = "errored"
+)
+
+// ChainlinkClient is a minimal Chainlink node API client to manage jobs and trigger runs,
+// an expired session, e.g. after the node restart, is renewed once per request
+type ChainlinkClient struct {
+	URL      string
+	Email    string
+	Password string
+	// Clock of run waits, the real clock if nil
+	Clock clock.Clock
+	http  *http.Client
+}
+
+// ChainlinkJob is a job of a node
+type ChainlinkJob struct {
+	ID            string
+	Name          string
+	Type          string
+	ExternalJobID string
+}
+
+// NewChainlinkClient creates a new node client with a session cookie jar
+func NewChainlinkClient(url string, email string, password string) (*ChainlinkClient, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainlinkClient{
+		URL:      url,
+		Email:    email,
+		Password: password,
+		http:     &http.Client{Jar: jar, Timeout: ChainlinkRequestTimeout},
+	}, nil
+}
+
+// jsonAPIResource is a JSON:API resource returned by the node
+type jsonAPIResource struct {
+	Data jsonAPIData `json:"data"`
+}
+
+// jsonAPIResources is a JSON:API list of resources returned by the node
+type jsonAPIResources struct {
+	Data []jsonAPIData `json:"data"`
+}
+
+type jsonAPIData struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		State         string `json:"state"`
+		Name          string `json:"name"`
+		Type          string `json:"type"`
+		ExternalJobID string `json:"externalJobID"`
+	} `json:"attributes"`
+}
+
+// errUnauthorized the session is missing or expired
+var errUnauthorized = errors.New("unauthorized")
+
+// Login creates a new API session
+func (c *ChainlinkClient) Login() error {
+	return c.request(http.MethodPost, "/sessions", map[string]string{
+		"email":    c.Email,
+		"password": c.Password,
+	}, nil)
+}
+
+// CreateJob creates a job from a TOML spec and returns its ID
+func (c *ChainlinkClient) CreateJob(spec string) (string, error) {
+	var resp jsonAPIResource
+	if err := c.do(http.MethodPost, "/v2/jobs", map[string]string{"toml": spec}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Data.ID, nil
+}
+
+// DeleteJob deletes a job by ID
+func (c *ChainlinkClient) DeleteJob(id string) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/v2/jobs/%s", id), nil, nil)
+}
+
+// ListJobs returns all jobs of the node
+func (c *ChainlinkClient) ListJobs() ([]ChainlinkJob, error) {
+	jobs := make([]ChainlinkJob, 0)
+	for page := 1; ; page++ {
+		var resp jsonAPIResources
+		if err := c.do(http.MethodGet, fmt.Sprintf("/v2/jobs?page=%d&size=%d", page, chainlinkJobsPageSize), nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, d := range resp.Data {
+			jobs = append(jobs, ChainlinkJob{
+				ID:            d.ID,
+				Name:          d.Attributes.Name,
+				Type:          d.Attributes.Type,
+				ExternalJobID: d.Attributes.ExternalJobID,
+			})
+		}
+		if len(resp.Data) < chainlinkJobsPageSize {
+			return jobs, nil
+		}
+	}
+}
+
+// RunJob triggers a run of a webhook job and returns the run ID
+func (c *ChainlinkClient) RunJob(jobID string) (string, error) {
+	var resp jsonAPIResource
+	if err := c.do(http.MethodPost, fmt.Sprintf("/v2/jobs/%s/runs", jobID), nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Data.ID, nil
+}
+
+// JobRuns returns IDs of the latest runs of a job, newest first
+func (c *ChainlinkClient) JobRuns(jobID string) ([]string, error) {
+	var resp jsonAPIResources
+	if err := c.do(http.MethodGet, fmt.Sprintf("/v2/jobs/%s/runs?page=1&size=%d", jobID, chainlinkJobsPageSize), nil, &resp); err != nil {
+		return nil, err
+	}
+	runs := make([]string, 0, len(resp.Data))
+	for _, d := range resp.Data {
+		runs = append(runs, d.ID)
+	}
+	return runs, nil
+}
+
+// WaitRun waits until the job run is completed
+func (c *ChainlinkClient) WaitRun(jobID string, runID string) error {
+	clk := c.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	deadline := clk.Now().Add(ChainlinkRunTimeout)
+	for clk.Now().Before(deadline) {
+		var resp jsonAPIResource
+		if err := c.do(http.MethodGet, fmt.Sprintf("/v2/jobs/%s/runs/%s", jobID, runID), nil, &resp); err != nil {
+			return err
+		}
+		switch resp.Data.Attributes.State {
+		case RunStateCompleted:
+			return nil
+		case RunStateErrored:
+			return errors.Errorf("run %s of job %s errored", runID, jobID)
+		}
+		clk.Sleep(ChainlinkRunPollInterval)
+	}
+	return errors.Errorf("run %s of job %s is not completed in %s", runID, jobID, ChainlinkRunTimeout)
+}
+
+// do sends an API request, logs in and retries once if the session is missing or expired
+func (c *ChainlinkClient) do(method string, path string, body interface{}, out interface{}) error {
+	err := c.request(method, path, body, out)
+	if !errors.Is(err, errUnauthorized) {
+		return err
+	}
+	if err := c.Login(); err != nil {
+		return errors.Wrap(err, "failed to renew the session")
+	}
+	return c.request(method, path, body, out)
+}
+
+func (c *ChainlinkClient) request(method string, path string, body interface{}, out interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = b
+	}
+	req, err := http.NewRequest(method, c.URL+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errors.Wrapf(errUnauthorized, "%s %s", method, path)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("%s %s: unexpected status %d", method, path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainlinkClientJobs(t *testing.T) {
+	jobs := make(map[string]string)
+	for i := 0; i < chainlinkJobsPageSize+5; i++ {
+		jobs[strconv.Itoa(i)] = fmt.Sprintf("job-%d", i)
+	}
+	logins := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sessions", func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		http.SetCookie(w, &http.Cookie{Name: "clsession", Value: strconv.Itoa(logins)})
+	})
+	mux.HandleFunc("/v2/jobs", func(w http.ResponseWriter, r *http.Request) {
+		// the first session expires, e.g. the node is restarted
+		if c, err := r.Cookie("clsession"); err != nil || c.Value == "1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		data := make([]map[string]interface{}, 0)
+		for i := (page - 1) * size; i < page*size && i < len(jobs); i++ {
+			id := strconv.Itoa(i)
+			data = append(data, map[string]interface{}{
+				"id":         id,
+				"attributes": map[string]interface{}{"name": jobs[id], "type": "webhook"},
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := NewChainlinkClient(srv.URL, DefaultChainlinkEmail, DefaultChainlinkPassword)
+	require.NoError(t, err)
+	require.NoError(t, c.Login())
+	list, err := c.ListJobs()
+	require.NoError(t, err)
+	require.Len(t, list, chainlinkJobsPageSize+5)
+	require.Equal(t, ChainlinkJob{ID: "104", Name: "job-104", Type: "webhook"}, list[104])
+	require.Equal(t, 2, logins, "expired session is renewed")
+}
@@ -2,15 +2,23 @@ package client
 
 import (
 	"fmt"
+	"sync"
+	"time"
+
 	"github.com/cdk8s-team/cdk8s-core-go/cdk8s/v2"
 	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/chaos"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // Chaos is controller that manages Chaosmesh CRD instances to run experiments
 type Chaos struct {
-	Client         *K8sClient
+	Client *K8sClient
+	// ResourceByName resources of running experiments by IDs, guarded by mu, use Resource to look experiments up
 	ResourceByName map[string]string
 	Namespace      string
+	mu             sync.Mutex
 }
 
 // NewChaos creates controller to run and stop chaos experiments
@@ -22,13 +30,24 @@ func NewChaos(client *K8sClient, namespace string) *Chaos {
 	}
 }
 
-// Run runs experiment and saves it's ID
+// Run runs experiment and saves it's ID, experiments are not started while the environment is in maintenance
+// and wait while chaos is paused, see K8sClient.PauseChaos
 func (c *Chaos) Run(app cdk8s.App, id string, resource string) (string, error) {
+	if err := c.Client.CheckNotInMaintenance(c.Namespace); err != nil {
+		return id, err
+	}
+	if err := c.Client.WaitChaosResumed(c.Namespace); err != nil {
+		return id, err
+	}
 	log.Info().Msg("Applying chaos experiment")
 	manifest := app.SynthYaml().(string)
 	fmt.Println(manifest)
+	c.mu.Lock()
 	c.ResourceByName[id] = resource
-	if err := c.Client.Apply(manifest); err != nil {
+	c.mu.Unlock()
+	err := c.Client.ApplyNamed(id, manifest)
+	c.record("run", id, resource, err)
+	if err != nil {
 		return id, err
 	}
 	return id, nil
@@ -36,6 +55,76 @@ func (c *Chaos) Run(app cdk8s.App, id string, resource string) (string, error) {
 
 // Stop removes a chaos experiment
 func (c *Chaos) Stop(id string) error {
-	defer delete(c.ResourceByName, id)
-	return c.Client.DeleteResource(c.Namespace, c.ResourceByName[id], id)
+	resource, _ := c.Resource(id)
+	err := c.Client.DeleteResource(c.Namespace, resource, id)
+	c.record("stop", id, resource, err)
+	c.mu.Lock()
+	delete(c.ResourceByName, id)
+	c.mu.Unlock()
+	return err
+}
+
+// Resource returns a resource of a running experiment, false if there is no experiment with the ID,
+// experiments are run and stopped concurrently, e.g. by the environment API
+func (c *Chaos) Resource(id string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resource, ok := c.ResourceByName[id]
+	return resource, ok
+}
+
+// record records an experiment event in the environment history, history errors are only logged
+func (c *Chaos) record(action string, id string, resource string, err error) {
+	target := fmt.Sprintf("%s/%s", resource, id)
+	if herr := c.Client.RecordEvent(c.Namespace, NewHistoryEvent(EventKindChaos, action, target, err)); herr != nil {
+		log.Warn().Err(herr).Str("Experiment", id).Msg("Failed to record chaos event")
+	}
+}
+
+// PodKill kills pods selected by labels once, they are recreated by their workloads, use AwaitRecovery to wait for them
+func (c *Chaos) PodKill(selector map[string]string) (string, error) {
+	return c.Run(chaos.NewKillPods(c.Namespace, &chaos.Props{
+		LabelsSelector: a.ConvertLabelsMap(selector),
+	}))
+}
+
+// Pause makes pods selected by labels unavailable until the experiment is stopped, their containers are replaced with
+// pause containers and restored on Stop
+func (c *Chaos) Pause(selector map[string]string) (string, error) {
+	return c.Run(chaos.NewFailPods(c.Namespace, &chaos.Props{
+		LabelsSelector: a.ConvertLabelsMap(selector),
+		DurationStr:    *chaos.FOREVER,
+	}))
+}
+
+// NetworkPartition drops all traffic between "from" and "to" pods until the experiment is stopped,
+// for example, between a Chainlink node and Geth
+func (c *Chaos) NetworkPartition(from map[string]string, to map[string]string) (string, error) {
+	return c.Run(chaos.NewNetworkPartition(c.Namespace, &chaos.Props{
+		FromLabels:  a.ConvertLabelsMap(from),
+		ToLabels:    a.ConvertLabelsMap(to),
+		DurationStr: *chaos.FOREVER,
+	}))
+}
+
+// NetworkLatency delays packets sent from "from" pods to "to" pods until the experiment is stopped
+func (c *Chaos) NetworkLatency(from map[string]string, to map[string]string, latency time.Duration) (string, error) {
+	return c.Run(chaos.NewNetworkLatency(c.Namespace, &chaos.Props{
+		FromLabels:  a.ConvertLabelsMap(from),
+		ToLabels:    a.ConvertLabelsMap(to),
+		DurationStr: *chaos.FOREVER,
+		Latency:     latency.String(),
+	}))
+}
+
+// AwaitRecovery waits until pods selected by labels are created and ready again, the same way as environment deployment does
+func (c *Chaos) AwaitRecovery(selector map[string]string, timeout time.Duration) error {
+	rcd := &ReadyCheckData{
+		ReadinessProbeCheckSelector: labels.SelectorFromSet(selector).String(),
+		Timeout:                     timeout,
+	}
+	if err := c.Client.WaitPodsCreated(c.Namespace, rcd); err != nil {
+		return err
+	}
+	return c.Client.CheckReady(c.Namespace, rcd)
 }
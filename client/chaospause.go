@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// ChaosPausedAnnotationKey namespace annotation with the reason chaos is paused, new experiments wait until it's removed
+	ChaosPausedAnnotationKey = "chainlink-env/chaos-paused"
+	// ChaosPausedExperimentsAnnotationKey namespace annotation with JSON experiments paused by PauseChaos, they are resumed by ResumeChaos
+	ChaosPausedExperimentsAnnotationKey = "chainlink-env/chaos-paused-experiments"
+	// ChaosMeshPauseAnnotationKey Chaos Mesh pauses experiments and schedules with this annotation
+	ChaosMeshPauseAnnotationKey = "experiment.chaos-mesh.org/pause"
+	// ChaosPauseTimeout how long a new experiment waits for paused chaos to be resumed
+	ChaosPauseTimeout = 15 * time.Minute
+)
+
+// chaosMeshResources experiments and schedules paused by PauseChaos
+var chaosMeshResources = []string{"podchaos", "networkchaos", "timechaos", "iochaos", "stresschaos", "schedules"}
+
+func chaosMeshResource(resource string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: resource}
+}
+
+// PauseChaos pauses running Chaos Mesh experiments and schedules of the namespace, new experiments wait until ResumeChaos,
+// for example, so a pod isn't killed in the middle of a database dump, it returns false if chaos is already paused
+// by someone else, then only they resume it
+func (m *K8sClient) PauseChaos(namespace string, reason string) (bool, error) {
+	ns, err := m.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if _, ok := ns.Annotations[ChaosPausedAnnotationKey]; ok {
+		return false, nil
+	}
+	if err := m.patchNamespaceAnnotations(namespace, map[string]interface{}{ChaosPausedAnnotationKey: reason}); err != nil {
+		return false, err
+	}
+	dyn, err := dynamic.NewForConfig(m.RESTConfig)
+	if err != nil {
+		return true, err
+	}
+	paused := make([]string, 0)
+	for _, resource := range chaosMeshResources {
+		ri := dyn.Resource(chaosMeshResource(resource)).Namespace(namespace)
+		list, err := ri.List(context.Background(), metaV1.ListOptions{})
+		if k8sErrors.IsNotFound(err) {
+			// Chaos Mesh or its resource is not installed
+			continue
+		}
+		if err != nil {
+			return true, errors.Wrapf(err, "failed to list %s", resource)
+		}
+		for _, item := range list.Items {
+			if item.GetAnnotations()[ChaosMeshPauseAnnotationKey] == "true" {
+				continue
+			}
+			if err := setChaosMeshPause(ri, item.GetName(), "true"); err != nil {
+				return true, errors.Wrapf(err, "failed to pause %s %s", resource, item.GetName())
+			}
+			paused = append(paused, fmt.Sprintf("%s/%s", resource, item.GetName()))
+		}
+	}
+	data, err := json.Marshal(paused)
+	if err != nil {
+		return true, err
+	}
+	log.Info().Str("Namespace", namespace).Str("Reason", reason).Strs("Experiments", paused).Msg("Chaos is paused")
+	return true, m.patchNamespaceAnnotations(namespace, map[string]interface{}{ChaosPausedExperimentsAnnotationKey: string(data)})
+}
+
+// ResumeChaos resumes experiments paused by PauseChaos and lets new ones start
+func (m *K8sClient) ResumeChaos(namespace string) error {
+	ns, err := m.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	paused := make([]string, 0)
+	if raw, ok := ns.Annotations[ChaosPausedExperimentsAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(raw), &paused); err != nil {
+			return errors.Wrapf(err, "invalid %s annotation", ChaosPausedExperimentsAnnotationKey)
+		}
+	}
+	if len(paused) > 0 {
+		dyn, err := dynamic.NewForConfig(m.RESTConfig)
+		if err != nil {
+			return err
+		}
+		for _, p := range paused {
+			resource, name, _ := strings.Cut(p, "/")
+			err := setChaosMeshPause(dyn.Resource(chaosMeshResource(resource)).Namespace(namespace), name, nil)
+			if err != nil && !k8sErrors.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to resume %s", p)
+			}
+		}
+	}
+	log.Info().Str("Namespace", namespace).Strs("Experiments", paused).Msg("Chaos is resumed")
+	return m.patchNamespaceAnnotations(namespace, map[string]interface{}{
+		ChaosPausedAnnotationKey:            nil,
+		ChaosPausedExperimentsAnnotationKey: nil,
+	})
+}
+
+// setChaosMeshPause sets the pause annotation of an experiment, nil removes it
+func setChaosMeshPause(ri dynamic.ResourceInterface, name string, value interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{ChaosMeshPauseAnnotationKey: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = ri.Patch(context.Background(), name, types.MergePatchType, patch, metaV1.PatchOptions{})
+	return err
+}
+
+// WaitChaosResumed waits until chaos of the namespace isn't paused, up to ChaosPauseTimeout
+func (m *K8sClient) WaitChaosResumed(namespace string) error {
+	logged := false
+	err := PollImmediate(m.Clock(), ContainerStatePollInterval, ChaosPauseTimeout, func() (bool, error) {
+		ns, err := m.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		reason, ok := ns.Annotations[ChaosPausedAnnotationKey]
+		if ok && !logged {
+			log.Info().Str("Namespace", namespace).Str("Reason", reason).Msg("Chaos is paused, waiting until it's resumed")
+			logged = true
+		}
+		return !ok, nil
+	})
+	return errors.Wrapf(err, "chaos of namespace %s is paused", namespace)
+}
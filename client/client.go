@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/pkg"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
@@ -27,18 +30,36 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/clock"
 )
 
 const (
-	TempDebugManifest          = "tmp-manifest-%s.yaml"
+	ManifestFilePattern        = "%s-*.yaml"
+	ManifestsDirPattern        = "chainlink-env-manifests-*"
 	ContainerStatePollInterval = 3 * time.Second
 	AppLabel                   = "app"
+	// ApplyOutputSuffix apply output of a manifest, a line per resource, is written next to it, the suffix replaces .yaml
+	ApplyOutputSuffix = ".out"
 )
 
 // K8sClient high level k8s client
 type K8sClient struct {
 	ClientSet  *kubernetes.Clientset
 	RESTConfig *rest.Config
+	// ManifestsDir is a directory retaining rendered manifests and apply outputs, nothing is written on disk if empty
+	ManifestsDir string
+	// LabelDeletedKinds are kinds deleted by DeleteByLabel, DefaultLabelDeletedKinds if empty
+	LabelDeletedKinds []schema.GroupKind
+	manifestsMu       sync.Mutex
+	capabilitiesOnce  sync.Once
+	capabilities      *APICapabilities
+	capabilitiesErr   error
+	retryMu           sync.Mutex
+	retryPolicy       *RetryPolicy
+	retryWrapped      bool
+	clock             clock.WithTicker
+	watchMu           sync.Mutex
+	podWatchers       map[string]*podWatcher // shared pods watches by namespaces, see WaitPods
 }
 
 // GetLocalK8sDeps get local k8s context config
@@ -49,6 +70,11 @@ func GetLocalK8sDeps() (*kubernetes.Clientset, *rest.Config, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := SetProxy(k8sConfig, os.Getenv(config.EnvVarProxyURL)); err != nil {
+		return nil, nil, err
+	}
+	// the field manager of typed client updates is the user agent prefix, so all changes of the environment are attributed to FieldManager
+	k8sConfig.UserAgent = fmt.Sprintf("%s/%s", FieldManager, rest.DefaultKubernetesUserAgent())
 	k8sClient, err := kubernetes.NewForConfig(k8sConfig)
 	if err != nil {
 		return nil, nil, err
@@ -132,30 +158,49 @@ func (m *K8sClient) UniqueLabels(namespace string, selector string) ([]string, e
 	return uniqueLabels, nil
 }
 
-// Poll up to timeout seconds for pod to enter running state.
-// Returns an error if the pod never enters the running state.
-func waitForPodRunning(c kubernetes.Interface, namespace, podName string, timeout time.Duration) error {
-	return wait.PollImmediate(2*time.Second, timeout, isPodRunning(c, podName, namespace))
+// podsRunning returns true if all named pods are running or succeeded, an error if any of them failed
+func podsRunning(pods []v1.Pod, names []string) (bool, error) {
+	byName := make(map[string]v1.Pod, len(pods))
+	for _, p := range pods {
+		byName[p.Name] = p
+	}
+	running := true
+	for _, name := range names {
+		p, ok := byName[name]
+		if !ok {
+			running = false
+			continue
+		}
+		switch p.Status.Phase {
+		case v1.PodRunning, v1.PodSucceeded:
+		case v1.PodFailed:
+			return false, errors.Errorf("pod %s failed", name)
+		default:
+			running = false
+		}
+	}
+	return running, nil
 }
 
-// return a condition function that indicates whether the given pod is
-// currently running
-func isPodRunning(c kubernetes.Interface, podName, namespace string) wait.ConditionFunc {
-	return func() (bool, error) {
-		pod, err := c.CoreV1().Pods(namespace).Get(context.Background(), podName, metaV1.GetOptions{})
-		if err != nil {
-			return false, err
+// containersReady returns true if all containers of pods are ready, hook pods and completed pods are skipped
+func containersReady(pods []v1.Pod) bool {
+	allReady := true
+	for _, pod := range pods {
+		if _, hook := pod.Labels[pkg.HookLabelKey]; hook || pod.Status.Phase == v1.PodSucceeded {
+			continue
 		}
-		switch pod.Status.Phase {
-		case v1.PodRunning:
-			fallthrough
-		case v1.PodSucceeded:
-			return true, nil
-		case v1.PodFailed:
-			return false, errors.New("pod failed")
+		for _, c := range pod.Status.ContainerStatuses {
+			if !c.Ready {
+				log.Debug().
+					Str("Pod", pod.Name).
+					Str("Container", c.Name).
+					Interface("Ready", c.Ready).
+					Msg("Container readiness")
+				allReady = false
+			}
 		}
-		return false, nil
 	}
+	return allReady
 }
 
 // AddLabelByPod adds a label to a pod
@@ -186,45 +231,19 @@ func (m *K8sClient) EnumerateInstances(namespace string, selector string) error
 	return nil
 }
 
-// WaitContainersReady waits until all containers ReadinessChecks are passed
+// WaitContainersReady waits until all containers ReadinessChecks are passed, pods are watched, see WaitPods
 func (m *K8sClient) WaitContainersReady(ns string, rcd *ReadyCheckData) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rcd.Timeout)
-	defer cancel()
-	for {
-		select {
-		case <-ctx.Done():
-			return errors.New("timeout waiting container readiness probes")
-		default:
-			podList, err := m.ListPods(ns, rcd.ReadinessProbeCheckSelector)
-			if err != nil {
-				return err
-			}
-			if len(podList.Items) == 0 {
-				return fmt.Errorf("no pods in %s with selector %s", ns, rcd.Timeout)
-			}
-			log.Debug().Interface("Pods", podNames(podList)).Msg("Waiting for pods readiness probes")
-			allReady := true
-			for _, pod := range podList.Items {
-				if pod.Status.Phase == "Succeeded" {
-					continue
-				}
-				for _, c := range pod.Status.ContainerStatuses {
-					if !c.Ready {
-						log.Debug().
-							Str("Pod", pod.Name).
-							Str("Container", c.Name).
-							Interface("Ready", c.Ready).
-							Msg("Container readiness")
-						allReady = false
-					}
-				}
-			}
-			if allReady {
-				return nil
-			}
-			time.Sleep(ContainerStatePollInterval)
+	err := m.WaitPods(ns, rcd.ReadinessProbeCheckSelector, rcd.Timeout, func(pods []v1.Pod) (bool, error) {
+		if len(pods) == 0 {
+			return false, fmt.Errorf("no pods in %s with selector %s", ns, rcd.ReadinessProbeCheckSelector)
 		}
+		log.Debug().Interface("Pods", podNames(&v1.PodList{Items: pods})).Msg("Waiting for pods readiness probes")
+		return containersReady(pods), nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return errors.New("timeout waiting container readiness probes")
 	}
+	return err
 }
 
 // WaitForPodBySelectorRunning Wait up to timeout seconds for all pods in 'namespace' with given 'selector' to enter running state.
@@ -238,13 +257,18 @@ func (m *K8sClient) WaitForPodBySelectorRunning(ns string, rcd *ReadyCheckData)
 		return fmt.Errorf("no pods in %s with selector %s", ns, rcd.Timeout)
 	}
 
-	log.Info().Interface("Pods", podNames(podList)).Msg("Waiting for pods in state Running")
-	for _, pod := range podList.Items {
-		if err := waitForPodRunning(m.ClientSet, ns, pod.Name, rcd.Timeout); err != nil {
-			return err
-		}
-	}
-	return nil
+	names := podNames(podList)
+	log.Info().Interface("Pods", names).Msg("Waiting for pods in state Running")
+	return m.WaitPods(ns, rcd.ReadinessProbeCheckSelector, rcd.Timeout, func(pods []v1.Pod) (bool, error) {
+		return podsRunning(pods, names)
+	})
+}
+
+// WaitPodsCreated waits until at least one pod matching the selector is created
+func (m *K8sClient) WaitPodsCreated(ns string, rcd *ReadyCheckData) error {
+	return m.WaitPods(ns, rcd.ReadinessProbeCheckSelector, rcd.Timeout, func(pods []v1.Pod) (bool, error) {
+		return len(pods) > 0, nil
+	})
 }
 
 // NamespaceExists check if namespace exists
@@ -258,6 +282,7 @@ func (m *K8sClient) NamespaceExists(namespace string) bool {
 // RemoveNamespace removes namespace
 func (m *K8sClient) RemoveNamespace(namespace string) error {
 	log.Info().Str("Namespace", namespace).Msg("Removing namespace")
+	m.StopPodWatches(namespace)
 	if err := m.ClientSet.CoreV1().Namespaces().Delete(context.Background(), namespace, metaV1.DeleteOptions{}); err != nil {
 		return err
 	}
@@ -270,8 +295,12 @@ type ReadyCheckData struct {
 	Timeout                     time.Duration
 }
 
-// CheckReady application heath check using ManifestOutputData params
+// CheckReady application heath check using ManifestOutputData params,
+// stateful sets pods are awaited ordinal by ordinal first, so pods that are not created yet are not missed
 func (m *K8sClient) CheckReady(namespace string, c *ReadyCheckData) error {
+	if err := m.WaitStatefulSetsReady(namespace, c); err != nil {
+		return err
+	}
 	if err := m.WaitForPodBySelectorRunning(namespace, c); err != nil {
 		return err
 	}
@@ -280,13 +309,62 @@ func (m *K8sClient) CheckReady(namespace string, c *ReadyCheckData) error {
 
 // Apply applying a manifest to a currently connected k8s context
 func (m *K8sClient) Apply(manifest string) error {
-	manifestFile := fmt.Sprintf(TempDebugManifest, uuid.NewString())
-	log.Info().Str("File", manifestFile).Msg("Applying manifest")
-	if err := os.WriteFile(manifestFile, []byte(manifest), os.ModePerm); err != nil {
+	return m.ApplyNamed("manifest", manifest)
+}
+
+// ApplyNamed applying a manifest with server-side apply, name is used as a manifest file name prefix, for example, a chart name,
+// failed resources are returned as ApplyError
+func (m *K8sClient) ApplyNamed(name string, manifest string) error {
+	return m.applyManifest("apply", name, manifest)
+}
+
+// applyManifest applies or creates manifest resources, the manifest and the output are retained in ManifestsDir if it's set
+func (m *K8sClient) applyManifest(verb string, name string, manifest string) error {
+	m.manifestsMu.Lock()
+	dir := m.ManifestsDir
+	m.manifestsMu.Unlock()
+	create := verb == "create"
+	if dir == "" {
+		log.Info().Str("Name", name).Str("Command", verb).Msg("Applying manifest")
+		return m.applyObjects(manifest, create, io.Discard)
+	}
+	manifestFile, err := m.WriteManifest(name, manifest)
+	if err != nil {
 		return err
 	}
-	cmd := fmt.Sprintf("kubectl apply -f %s", manifestFile)
-	return ExecCmd(cmd)
+	log.Info().Str("File", manifestFile).Str("Command", verb).Msg("Applying manifest")
+	var out bytes.Buffer
+	err = m.applyObjects(manifest, create, &out)
+	outFile := strings.TrimSuffix(manifestFile, ".yaml") + ApplyOutputSuffix
+	if werr := os.WriteFile(outFile, out.Bytes(), 0644); werr != nil {
+		log.Warn().Err(werr).Str("File", outFile).Msg("Failed to write apply output")
+	}
+	return err
+}
+
+// WriteManifest writes manifest into a new file in ManifestsDir and returns the file path,
+// a temporary directory is created if ManifestsDir is empty
+func (m *K8sClient) WriteManifest(name string, manifest string) (string, error) {
+	m.manifestsMu.Lock()
+	if m.ManifestsDir == "" {
+		dir, err := os.MkdirTemp("", ManifestsDirPattern)
+		if err != nil {
+			m.manifestsMu.Unlock()
+			return "", err
+		}
+		m.ManifestsDir = dir
+	}
+	dir := m.ManifestsDir
+	m.manifestsMu.Unlock()
+	f, err := os.CreateTemp(dir, fmt.Sprintf(ManifestFilePattern, name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(manifest); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
 }
 
 // DeleteResource deletes resource
@@ -294,29 +372,59 @@ func (m *K8sClient) DeleteResource(namespace string, resource string, instance s
 	return ExecCmd(fmt.Sprintf("kubectl delete %s %s --namespace %s", resource, instance, namespace))
 }
 
-// Create creating a manifest to a currently connected k8s context
-func (m *K8sClient) Create(manifest string) error {
-	manifestFile := fmt.Sprintf(TempDebugManifest, uuid.NewString())
-	log.Info().Str("File", manifestFile).Msg("Creating manifest")
-	if err := os.WriteFile(manifestFile, []byte(manifest), os.ModePerm); err != nil {
+// DeletePods deletes pods matching the selector and waits until they are gone, their controllers create replacements
+func (m *K8sClient) DeletePods(namespace string, selector string, timeout time.Duration) error {
+	pods := m.ClientSet.CoreV1().Pods(namespace)
+	pl, err := m.ListPods(namespace, selector)
+	if err != nil {
 		return err
 	}
-	cmd := fmt.Sprintf("kubectl create -f %s", manifestFile)
-	return ExecCmd(cmd)
+	if err := pods.DeleteCollection(context.Background(), metaV1.DeleteOptions{}, metaV1.ListOptions{LabelSelector: selector}); err != nil {
+		return err
+	}
+	deleted := make(map[types.UID]bool)
+	for _, p := range pl.Items {
+		deleted[p.UID] = true
+	}
+	return PollImmediate(m.Clock(), ContainerStatePollInterval, timeout, func() (bool, error) {
+		current, err := m.ListPods(namespace, selector)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range current.Items {
+			if deleted[p.UID] {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// WaitPodsDeleted waits until there are no pods left matching the selector
+func (m *K8sClient) WaitPodsDeleted(namespace string, selector string, timeout time.Duration) error {
+	return m.WaitPods(namespace, selector, timeout, func(pods []v1.Pod) (bool, error) {
+		log.Debug().Interface("Pods", podNames(&v1.PodList{Items: pods})).Msg("Waiting for pods to be deleted")
+		return len(pods) == 0, nil
+	})
+}
+
+// Create creating a manifest to a currently connected k8s context
+func (m *K8sClient) Create(manifest string) error {
+	return m.applyManifest("create", "manifest", manifest)
 }
 
 // DryRun generates manifest and writes it in a file
 func (m *K8sClient) DryRun(manifest string) error {
-	manifestFile := fmt.Sprintf(TempDebugManifest, uuid.NewString())
-	log.Info().Str("File", manifestFile).Msg("Creating manifest")
-	if err := os.WriteFile(manifestFile, []byte(manifest), os.ModePerm); err != nil {
+	manifestFile, err := m.WriteManifest("dry-run", manifest)
+	if err != nil {
 		return err
 	}
+	log.Info().Str("File", manifestFile).Msg("Manifest is written")
 	return nil
 }
 
 // CopyToPod copies src to a particular container. Destination should be in the form of a proper K8s destination path
-// NAMESPACE/POD_NAME:folder/FILE_NAME
+// NAMESPACE/POD_NAME:folder/FILE_NAME, use UploadToPod for big files
 func (m *K8sClient) CopyToPod(namespace, src, destination, containername string) (*bytes.Buffer, *bytes.Buffer, *bytes.Buffer, error) {
 	m.RESTConfig.APIPath = "/api"
 	m.RESTConfig.GroupVersion = &schema.GroupVersion{Version: "v1"} // this targets the core api groups so the url path will be /api/v1
@@ -353,15 +461,26 @@ func (m *K8sClient) CopyToPod(namespace, src, destination, containername string)
 
 // ExecuteInPod is similar to kubectl exec
 func (m *K8sClient) ExecuteInPod(namespace, podName, containerName string, command []string) ([]byte, []byte, error) {
-	log.Info().Interface("Command", command).Msg("Executing command in pod")
+	stdout, stderr, err := m.ExecInPod(context.Background(), namespace, podName, containerName, command)
+	if err != nil {
+		return []byte{}, []byte{}, err
+	}
+	return []byte(stdout), []byte(stderr), nil
+}
+
+// ExecInPod runs a command in a pod container through the API server, kubectl is not required, for example,
+// "chainlink keys eth list" in a node container, the output is returned when the command fails too,
+// a non-zero exit code is a wrapped exec.CodeExitError, the command keeps running in the pod if ctx is done before it exits
+func (m *K8sClient) ExecInPod(ctx context.Context, namespace, pod, container string, cmd []string) (string, string, error) {
+	log.Info().Str("Pod", pod).Str("Container", container).Interface("Command", cmd).Msg("Executing command in pod")
 	req := m.ClientSet.CoreV1().RESTClient().Post().
 		Resource("pods").
-		Name(podName).
+		Name(pod).
 		Namespace(namespace).
 		SubResource("exec")
 	req.VersionedParams(&v1.PodExecOptions{
-		Container: containerName,
-		Command:   command,
+		Container: container,
+		Command:   cmd,
 		Stdin:     false,
 		Stdout:    true,
 		Stderr:    true,
@@ -370,19 +489,27 @@ func (m *K8sClient) ExecuteInPod(namespace, podName, containerName string, comma
 
 	exec, err := remotecommand.NewSPDYExecutor(m.RESTConfig, "POST", req.URL())
 	if err != nil {
-		return []byte{}, []byte{}, err
+		return "", "", err
 	}
 
 	var stdout, stderr bytes.Buffer
-	err = exec.Stream(remotecommand.StreamOptions{
-		Stdin:  nil,
-		Stdout: &stdout,
-		Stderr: &stderr,
-	})
-	if err != nil {
-		return []byte{}, []byte{}, err
+	done := make(chan error, 1)
+	go func() {
+		done <- exec.Stream(remotecommand.StreamOptions{
+			Stdin:  nil,
+			Stdout: &stdout,
+			Stderr: &stderr,
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return stdout.String(), stderr.String(), errors.Wrapf(err, "command %v failed in pod %s container %s", cmd, pod, container)
+		}
+		return stdout.String(), stderr.String(), nil
+	case <-ctx.Done():
+		return "", "", ctx.Err()
 	}
-	return stdout.Bytes(), stderr.Bytes(), nil
 }
 
 func podNames(podItems *v1.PodList) []string {
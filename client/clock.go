@@ -0,0 +1,65 @@
+package client
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/clock"
+)
+
+// SetClock sets a clock of waits, timeouts and TTLs of the client, set it before the client is used,
+// unit tests use a fake clock of k8s.io/utils/clock/testing, so waits don't sleep and time-dependent logic is deterministic
+func (m *K8sClient) SetClock(c clock.WithTicker) {
+	m.clock = c
+}
+
+// Clock returns the clock of the client, the real clock if it's not set
+func (m *K8sClient) Clock() clock.WithTicker {
+	if m == nil || m.clock == nil {
+		return clock.RealClock{}
+	}
+	return m.clock
+}
+
+// PollImmediate is wait.PollImmediate on the clock, the condition is checked right away and then every interval,
+// wait.ErrWaitTimeout is returned after the timeout, 0 timeout means no timeout
+func PollImmediate(c clock.Clock, interval time.Duration, timeout time.Duration, condition wait.ConditionFunc) error {
+	var deadline *time.Time
+	if timeout != 0 {
+		d := c.Now().Add(timeout)
+		deadline = &d
+	}
+	return poll(c, interval, deadline, nil, condition)
+}
+
+// PollImmediateInfinite is wait.PollImmediateInfinite on the clock
+func PollImmediateInfinite(c clock.Clock, interval time.Duration, condition wait.ConditionFunc) error {
+	return poll(c, interval, nil, nil, condition)
+}
+
+// PollImmediateUntil is wait.PollImmediateUntil on the clock, wait.ErrWaitTimeout is returned when stopCh is closed
+func PollImmediateUntil(c clock.Clock, interval time.Duration, condition wait.ConditionFunc, stopCh <-chan struct{}) error {
+	return poll(c, interval, nil, stopCh, condition)
+}
+
+// poll sleeps on the clock between checks, so a fake clock moves forward by itself,
+// with a stop channel it waits for the clock instead, a fake one must be stepped
+func poll(c clock.Clock, interval time.Duration, deadline *time.Time, stopCh <-chan struct{}, condition wait.ConditionFunc) error {
+	for {
+		if done, err := condition(); err != nil || done {
+			return err
+		}
+		if deadline != nil && !c.Now().Before(*deadline) {
+			return wait.ErrWaitTimeout
+		}
+		if stopCh == nil {
+			c.Sleep(interval)
+			continue
+		}
+		select {
+		case <-stopCh:
+			return wait.ErrWaitTimeout
+		case <-c.After(interval):
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestPollImmediateFakeClock(t *testing.T) {
+	start := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	c := clocktesting.NewFakeClock(start)
+	checks := 0
+	err := PollImmediate(c, 3*time.Second, time.Hour, func() (bool, error) {
+		checks++
+		return false, nil
+	})
+	require.ErrorIs(t, err, wait.ErrWaitTimeout)
+	require.Equal(t, 1201, checks)
+	require.Equal(t, start.Add(time.Hour), c.Now())
+
+	checks = 0
+	err = PollImmediate(c, time.Second, time.Minute, func() (bool, error) {
+		checks++
+		return checks == 3, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, start.Add(time.Hour+2*time.Second), c.Now())
+
+	failed := errors.New("failed")
+	err = PollImmediateInfinite(c, time.Second, func() (bool, error) {
+		return false, failed
+	})
+	require.ErrorIs(t, err, failed)
+}
+
+func TestPollImmediateUntilStop(t *testing.T) {
+	c := clocktesting.NewFakeClock(time.Now())
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- PollImmediateUntil(c, time.Second, func() (bool, error) {
+			return false, nil
+		}, stop)
+	}()
+	require.Eventually(t, c.HasWaiters, time.Second, time.Millisecond)
+	close(stop)
+	require.ErrorIs(t, <-done, wait.ErrWaitTimeout)
+}
+
+func TestK8sClientClock(t *testing.T) {
+	var m *K8sClient
+	require.Equal(t, clock.RealClock{}, m.Clock())
+	m = &K8sClient{}
+	require.Equal(t, clock.RealClock{}, m.Clock())
+	fake := clocktesting.NewFakeClock(time.Now())
+	m.SetClock(fake)
+	require.Equal(t, fake, m.Clock())
+}
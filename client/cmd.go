@@ -3,22 +3,47 @@ package client
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 )
 
 func ExecCmd(command string) error {
+	return ExecCmdWithInput(command, nil, io.Discard)
+}
+
+// ExecCmdWithInput executes a command reading stdin from in, stdout is written into out,
+// stderr is printed and appended to out when the command exits
+func ExecCmdWithInput(command string, in io.Reader, out io.Writer) error {
 	c := strings.Split(command, " ")
-	cmd := exec.Command(c[0], c[1:]...)
+	return ExecArgsWithInput(c[0], c[1:], in, out)
+}
+
+// ExecArgs executes a command with separate arguments, so arguments with spaces, like paths, are passed as is
+func ExecArgs(name string, args ...string) error {
+	return ExecArgsWithInput(name, args, nil, io.Discard)
+}
+
+// ExecArgsWithInput is ExecCmdWithInput with separate arguments
+func ExecArgsWithInput(name string, args []string, in io.Reader, out io.Writer) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = in
+	cmd.Stdout = out
 	stderr, _ := cmd.StderrPipe()
 	if err := cmd.Start(); err != nil {
 		return err
 	}
+	var errOut strings.Builder
 	scanner := bufio.NewScanner(stderr)
 	scanner.Split(bufio.ScanLines)
 	for scanner.Scan() {
 		m := scanner.Text()
 		fmt.Println(m)
+		errOut.WriteString(m + "\n")
+	}
+	err := cmd.Wait()
+	if _, werr := io.WriteString(out, errOut.String()); werr != nil && err == nil {
+		err = werr
 	}
-	return cmd.Wait()
+	return err
 }
@@ -24,8 +24,32 @@ const (
 	HTTP
 	// HTTPS : Hypertext Transfer Protocol Secure
 	HTTPS
+	// GRPC : gRPC, converted to host:port
+	GRPC
+	// Postgres : PostgreSQL, converted to a DSN with port credentials
+	Postgres
 )
 
+var protocolNames = map[Protocol]string{
+	WS:       "ws",
+	WSS:      "wss",
+	HTTP:     "http",
+	HTTPS:    "https",
+	GRPC:     "grpc",
+	Postgres: "postgres",
+}
+
+func (p Protocol) String() string {
+	return protocolNames[p]
+}
+
+// Credentials of a port, used to build DSNs
+type Credentials struct {
+	User     string
+	Password string
+	Database string
+}
+
 // URLConverter converts ports to URLs
 type URLConverter struct {
 	ci  ConnectionInfo
@@ -52,6 +76,14 @@ func (m *URLConverter) As(conn ConnectionMode, proto Protocol) (string, error) {
 		port = m.ci.Ports.Local
 	}
 	switch proto {
+	case GRPC:
+		return fmt.Sprintf("%s:%d", host, port), nil
+	case Postgres:
+		creds := m.ci.Credentials
+		if creds == nil {
+			return "", errors.New("no credentials for postgres port")
+		}
+		return fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=disable", creds.User, creds.Password, host, port, creds.Database), nil
 	case HTTP:
 		return fmt.Sprintf("http://%s:%d", host, port), nil
 	case HTTPS:
@@ -64,3 +96,8 @@ func (m *URLConverter) As(conn ConnectionMode, proto Protocol) (string, error) {
 		return "", errors.New("unknown protocol conversion type")
 	}
 }
+
+// URL converts host/port to an URL using the port protocol
+func (m *URLConverter) URL(conn ConnectionMode) (string, error) {
+	return m.As(conn, m.ci.Protocol)
+}
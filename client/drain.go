@@ -0,0 +1,232 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/api/core/v1"
+	policyV1 "k8s.io/api/policy/v1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// EvictionRetryInterval how often an eviction refused by a disruption budget is retried
+	EvictionRetryInterval  = 5 * time.Second
+	mirrorPodAnnotationKey = "kubernetes.io/config.mirror"
+)
+
+// PodPlacement is a pod and a node it's scheduled on
+type PodPlacement struct {
+	Pod  string
+	Node string
+	UID  types.UID
+}
+
+// Rescheduling is a report of evicted pods and their ready replacements, replacements are all ready pods of the selector,
+// so pods which were not evicted are there too
+type Rescheduling struct {
+	Evicted      []PodPlacement
+	Replacements []PodPlacement
+	// Duration from the first eviction until all replacements are ready
+	Duration time.Duration
+}
+
+// CordonNode marks the node unschedulable, running pods are not affected
+func (m *K8sClient) CordonNode(node string) error {
+	return m.setUnschedulable(node, true)
+}
+
+// UncordonNode marks the node schedulable again
+func (m *K8sClient) UncordonNode(node string) error {
+	return m.setUnschedulable(node, false)
+}
+
+func (m *K8sClient) setUnschedulable(node string, unschedulable bool) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable))
+	_, err := m.ClientSet.CoreV1().Nodes().Patch(context.Background(), node, types.StrategicMergePatchType, patch, metaV1.PatchOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set node %s unschedulable to %t", node, unschedulable)
+	}
+	log.Info().Str("Node", node).Bool("Unschedulable", unschedulable).Msg("Node scheduling is changed")
+	return nil
+}
+
+// NodesOf returns names of nodes hosting pods matching the selector
+func (m *K8sClient) NodesOf(namespace string, selector string) ([]string, error) {
+	pl, err := m.ListPods(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	return podNodes(pl.Items), nil
+}
+
+// EvictPod evicts a pod with the Eviction API, unlike deletion it respects disruption budgets,
+// evictions refused by a budget are retried until the timeout
+func (m *K8sClient) EvictPod(namespace string, pod string, timeout time.Duration) error {
+	useV1 := true
+	if caps, err := m.Capabilities(); err == nil {
+		useV1 = caps.PodDisruptionBudget == "policy/v1"
+	}
+	pods := m.ClientSet.CoreV1().Pods(namespace)
+	meta := metaV1.ObjectMeta{Name: pod, Namespace: namespace}
+	err := PollImmediate(m.Clock(), EvictionRetryInterval, timeout, func() (bool, error) {
+		var err error
+		if useV1 {
+			err = pods.EvictV1(context.Background(), &policyV1.Eviction{ObjectMeta: meta})
+		} else {
+			err = pods.EvictV1beta1(context.Background(), &policyV1beta1.Eviction{ObjectMeta: meta})
+		}
+		switch {
+		case err == nil || k8sErrors.IsNotFound(err):
+			return true, nil
+		case k8sErrors.IsTooManyRequests(err):
+			log.Info().Str("Pod", pod).Str("Reason", err.Error()).Msg("Eviction is refused by a disruption budget, retrying")
+			return false, nil
+		default:
+			return false, err
+		}
+	})
+	return errors.Wrapf(err, "failed to evict pod %s", pod)
+}
+
+// EvictPods evicts pods matching the selector one by one, waits until their controllers reschedule them
+// and all replacements are ready, it exercises scheduling, unlike a chaos pod kill
+func (m *K8sClient) EvictPods(namespace string, selector string, timeout time.Duration) (*Rescheduling, error) {
+	pl, err := m.ListPods(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	evict, err := drainablePods(pl.Items)
+	if err != nil {
+		return nil, err
+	}
+	return m.evictAndWait(namespace, selector, evict, len(replacementPods(pl.Items, nil)), timeout)
+}
+
+// DrainNode cordons the node and evicts its pods like kubectl drain, DaemonSet and mirror pods stay,
+// only pods of the namespace are evicted unless it's empty, so other tenants of a shared cluster are not disrupted,
+// the node stays cordoned until UncordonNode
+func (m *K8sClient) DrainNode(node string, namespace string, timeout time.Duration) (*Rescheduling, error) {
+	if err := m.CordonNode(node); err != nil {
+		return nil, err
+	}
+	all, err := m.ClientSet.CoreV1().Pods(namespace).List(context.Background(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	onNode := make([]v1.Pod, 0)
+	for _, p := range all.Items {
+		if p.Spec.NodeName == node {
+			onNode = append(onNode, p)
+		}
+	}
+	evict, err := drainablePods(onNode)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to drain node %s", node)
+	}
+	log.Info().Str("Node", node).Int("Pods", len(evict)).Msg("Draining node")
+	return m.evictAndWait(namespace, "", evict, len(replacementPods(all.Items, nil)), timeout)
+}
+
+// evictAndWait evicts the pods and waits until there are as many ready pods matching the selector as there were before
+func (m *K8sClient) evictAndWait(namespace string, selector string, evict []v1.Pod, expected int, timeout time.Duration) (*Rescheduling, error) {
+	clk := m.Clock()
+	start := clk.Now()
+	r := &Rescheduling{Evicted: make([]PodPlacement, 0)}
+	evicted := make(map[types.UID]bool)
+	for _, p := range evict {
+		if err := m.EvictPod(p.Namespace, p.Name, timeout-clk.Since(start)); err != nil {
+			return r, err
+		}
+		evicted[p.UID] = true
+		r.Evicted = append(r.Evicted, PodPlacement{Pod: p.Name, Node: p.Spec.NodeName, UID: p.UID})
+		log.Info().Str("Pod", p.Name).Str("Node", p.Spec.NodeName).Msg("Pod is evicted")
+	}
+	err := PollImmediate(clk, ContainerStatePollInterval, timeout-clk.Since(start), func() (bool, error) {
+		pl, err := m.ClientSet.CoreV1().Pods(namespace).List(context.Background(), metaV1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return false, err
+		}
+		r.Replacements = replacementPods(pl.Items, evicted)
+		return len(r.Replacements) >= expected, nil
+	})
+	r.Duration = clk.Since(start)
+	if err != nil {
+		return r, errors.Wrapf(err, "%d/%d pods are ready after evicting %d pods", len(r.Replacements), expected, len(r.Evicted))
+	}
+	log.Info().
+		Interface("Evicted", r.Evicted).
+		Interface("Replacements", r.Replacements).
+		Dur("Duration", r.Duration).
+		Msg("Evicted pods are rescheduled")
+	return r, nil
+}
+
+// drainablePods returns running pods to evict, DaemonSet and mirror pods are skipped,
+// pods without a controller are errors because nothing reschedules them
+func drainablePods(pods []v1.Pod) ([]v1.Pod, error) {
+	evict := make([]v1.Pod, 0)
+	for _, p := range runningPods(pods) {
+		if _, ok := p.Annotations[mirrorPodAnnotationKey]; ok {
+			continue
+		}
+		owner := metaV1.GetControllerOf(&p)
+		if owner == nil {
+			return nil, errors.Errorf("pod %s has no controller, it won't be rescheduled", p.Name)
+		}
+		if owner.Kind == "DaemonSet" {
+			continue
+		}
+		evict = append(evict, p)
+	}
+	return evict, nil
+}
+
+// runningPods returns pods which are not finished or being deleted
+func runningPods(pods []v1.Pod) []v1.Pod {
+	running := make([]v1.Pod, 0)
+	for _, p := range pods {
+		if p.DeletionTimestamp != nil || p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed {
+			continue
+		}
+		running = append(running, p)
+	}
+	return running
+}
+
+// replacementPods returns ready pods which were not evicted, sorted by names
+func replacementPods(pods []v1.Pod, evicted map[types.UID]bool) []PodPlacement {
+	ready := make([]PodPlacement, 0)
+	for _, p := range runningPods(pods) {
+		if evicted[p.UID] || !podReady(p) {
+			continue
+		}
+		ready = append(ready, PodPlacement{Pod: p.Name, Node: p.Spec.NodeName, UID: p.UID})
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		return ready[i].Pod < ready[j].Pod
+	})
+	return ready
+}
+
+// podNodes returns sorted unique nodes of scheduled pods
+func podNodes(pods []v1.Pod) []string {
+	seen := make(map[string]bool)
+	nodes := make([]string, 0)
+	for _, p := range pods {
+		if p.Spec.NodeName == "" || seen[p.Spec.NodeName] {
+			continue
+		}
+		seen[p.Spec.NodeName] = true
+		nodes = append(nodes, p.Spec.NodeName)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testPod(name string, node string, owner string, ready bool) v1.Pod {
+	p := v1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, UID: types.UID(name)},
+		Spec:       v1.PodSpec{NodeName: node},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	if owner != "" {
+		controller := true
+		p.OwnerReferences = []metaV1.OwnerReference{{Kind: owner, Name: "owner", Controller: &controller}}
+	}
+	if ready {
+		p.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	}
+	return p
+}
+
+func TestDrainablePods(t *testing.T) {
+	mirror := testPod("kube-proxy", "node-a", "Node", true)
+	mirror.Annotations = map[string]string{mirrorPodAnnotationKey: "hash"}
+	finished := testPod("hook", "node-a", "Job", false)
+	finished.Status.Phase = v1.PodSucceeded
+	evict, err := drainablePods([]v1.Pod{
+		testPod("chainlink-0", "node-a", "StatefulSet", true),
+		testPod("fluent-bit", "node-a", "DaemonSet", true),
+		mirror,
+		finished,
+		testPod("geth-abc", "node-a", "ReplicaSet", true),
+	})
+	require.NoError(t, err)
+	require.Len(t, evict, 2)
+	require.Equal(t, "chainlink-0", evict[0].Name)
+	require.Equal(t, "geth-abc", evict[1].Name)
+
+	_, err = drainablePods([]v1.Pod{testPod("bare", "node-a", "", true)})
+	require.Error(t, err)
+}
+
+func TestReplacementPods(t *testing.T) {
+	pods := []v1.Pod{
+		testPod("chainlink-1", "node-b", "StatefulSet", true),
+		testPod("chainlink-0", "node-a", "StatefulSet", true),
+		testPod("chainlink-2", "node-c", "StatefulSet", false),
+	}
+	require.Len(t, replacementPods(pods, nil), 2)
+	require.Equal(t,
+		[]PodPlacement{{Pod: "chainlink-1", Node: "node-b", UID: "chainlink-1"}},
+		replacementPods(pods, map[types.UID]bool{"chainlink-0": true}),
+	)
+	require.Equal(t, []string{"node-a", "node-b", "node-c"}, podNodes(append(pods, testPod("pending", "", "StatefulSet", false))))
+}
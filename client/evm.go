@@ -0,0 +1,116 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	EVMRequestTimeout = 30 * time.Second
+)
+
+// EVMClient is a minimal JSON-RPC client of an EVM node, enough to deploy contracts from unlocked accounts
+// without go-ethereum dependencies
+type EVMClient struct {
+	URL  string
+	http *http.Client
+}
+
+// EVMReceipt is a mined transaction receipt
+type EVMReceipt struct {
+	TransactionHash string `json:"transactionHash"`
+	BlockNumber     string `json:"blockNumber"`
+	ContractAddress string `json:"contractAddress"`
+	Status          string `json:"status"`
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewEVMClient creates a JSON-RPC client of an HTTP RPC URL
+func NewEVMClient(url string) *EVMClient {
+	return &EVMClient{
+		URL:  url,
+		http: &http.Client{Timeout: EVMRequestTimeout},
+	}
+}
+
+// Call calls the method and decodes its result, null results leave the result untouched
+func (m *EVMClient) Call(result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = make([]interface{}, 0)
+	}
+	b, err := json.Marshal(rpcRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1})
+	if err != nil {
+		return err
+	}
+	resp, err := m.http.Post(m.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrapf(err, "failed to call %s", method)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("%s: unexpected status %d", method, resp.StatusCode)
+	}
+	var r rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return errors.Wrapf(err, "failed to decode %s response", method)
+	}
+	if r.Error != nil {
+		return errors.Errorf("%s: %s (code %d)", method, r.Error.Message, r.Error.Code)
+	}
+	if result == nil || len(r.Result) == 0 || string(r.Result) == "null" {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(r.Result, result), "failed to decode %s result", method)
+}
+
+// Accounts returns accounts managed by the node
+func (m *EVMClient) Accounts() ([]string, error) {
+	accounts := make([]string, 0)
+	err := m.Call(&accounts, "eth_accounts")
+	return accounts, err
+}
+
+// SendTransaction sends a transaction signed by the node with an unlocked account, returns its hash,
+// gas is estimated by the node if 0
+func (m *EVMClient) SendTransaction(from string, to string, data string, gas uint64) (string, error) {
+	tx := map[string]string{"from": from, "data": data}
+	if to != "" {
+		tx["to"] = to
+	}
+	if gas != 0 {
+		tx["gas"] = hexUint(gas)
+	}
+	var hash string
+	err := m.Call(&hash, "eth_sendTransaction", tx)
+	return hash, err
+}
+
+// TransactionReceipt returns a receipt of the transaction, nil if it's not mined yet
+func (m *EVMClient) TransactionReceipt(hash string) (*EVMReceipt, error) {
+	var r *EVMReceipt
+	err := m.Call(&r, "eth_getTransactionReceipt", hash)
+	return r, err
+}
+
+func hexUint(v uint64) string {
+	return "0x" + strconv.FormatUint(v, 16)
+}
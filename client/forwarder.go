@@ -4,13 +4,14 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
-	"net/url"
 	"strings"
 	"sync"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/transport/spdy"
 )
@@ -19,12 +20,28 @@ type Forwarder struct {
 	Client         *K8sClient
 	mu             *sync.Mutex
 	KeepConnection bool
-	Info           map[string]interface{}
+	// PreferRemotePorts forwards to the same local port as the remote one if it's free, otherwise a free port is allocated
+	PreferRemotePorts bool
+	// LocalPort if set, returns a preferred local port of a pod port by "${app}:${instance}/${container}/${port}",
+	// it's used if it's free, a free port is allocated otherwise
+	LocalPort func(key string) uint16
+	Info      map[string]interface{}
+	// Protocols overrides detected protocols of ports by "${container}/${port}"
+	Protocols   map[string]Protocol
+	credentials map[string]*Credentials
+	reserved    map[uint16]bool
+	ssh         *sshTunnel
+	// closers stop port forwarding sessions and close tunnel listeners
+	closers []func()
+	// forwards count forwards of instances by "${app}:${instance}", a restore loop stops once its instance is forwarded again
+	forwards map[string]int
 }
 
 type ConnectionInfo struct {
-	Ports portforward.ForwardedPort
-	Host  string
+	Ports       portforward.ForwardedPort
+	Host        string
+	Protocol    Protocol
+	Credentials *Credentials
 }
 
 func NewForwarder(client *K8sClient, keepConnection bool) *Forwarder {
@@ -33,6 +50,9 @@ func NewForwarder(client *K8sClient, keepConnection bool) *Forwarder {
 		mu:             &sync.Mutex{},
 		KeepConnection: keepConnection,
 		Info:           make(map[string]interface{}),
+		Protocols:      make(map[string]Protocol),
+		credentials:    make(map[string]*Credentials),
+		reserved:       make(map[uint16]bool),
 	}
 }
 
@@ -41,50 +61,187 @@ func (m *Forwarder) forwardPodPorts(pod v1.Pod, namespaceName string) error {
 		log.Debug().Str("Pod", pod.Name).Interface("Phase", pod.Status.Phase).Msg("Skipping pod")
 		return nil
 	}
-	roundTripper, upgrader, err := spdy.RoundTripperFor(m.Client.RESTConfig)
+	portRules := m.portRulesForPod(pod, m.PreferRemotePorts || m.LocalPort != nil)
+	if len(portRules) == 0 {
+		return nil
+	}
+	err := m.forward(pod, namespaceName, portRules)
+	if err != nil && (m.PreferRemotePorts || m.LocalPort != nil) {
+		log.Debug().Err(err).Str("Pod", pod.Name).Msg("Preferred local ports are busy, allocating free ports")
+		err = m.forward(pod, namespaceName, m.portRulesForPod(pod, false))
+	}
+	return err
+}
+
+func (m *Forwarder) forward(pod v1.Pod, namespaceName string, portRules []string) error {
+	stop := m.newSession()
+	forwardedPorts, broken, err := m.portForward(namespaceName, pod.Name, portRules, stop)
 	if err != nil {
 		return err
 	}
-	httpPath := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespaceName, pod.Name)
-	hostIP := strings.TrimLeft(m.Client.RESTConfig.Host, "htps:/")
-	serverURL := url.URL{Scheme: "https", Path: httpPath, Host: hostIP}
+	namedPorts := m.podPortsByName(pod, forwardedPorts)
+	key := fmt.Sprintf("%s:%s", pod.Labels["app"], pod.Labels["instance"])
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Info[key] = namedPorts
+	if m.forwards == nil {
+		m.forwards = make(map[string]int)
+	}
+	m.forwards[key]++
+	go m.keepPodForwarded(namespaceName, pod, key, m.forwards[key], forwardedPorts, stop, broken)
+	return nil
+}
 
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, &serverURL)
+// keepPodForwarded restores broken pod forwarding on the same local ports, like keepServiceForwarded does for services,
+// until stop is closed or the instance is forwarded again, e.g. by Reconnect, which then owns its local ports,
+// a pod replaced by a rollout is found by app and instance labels
+func (m *Forwarder) keepPodForwarded(namespace string, pod v1.Pod, key string, forward int, ports []portforward.ForwardedPort, stop chan struct{}, broken <-chan error) {
+	rules := make([]string, 0, len(ports))
+	for _, p := range ports {
+		rules = append(rules, fmt.Sprintf("%d:%d", p.Local, p.Remote))
+	}
+	superseded := func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.forwards[key] != forward
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case err := <-broken:
+			log.Warn().Err(err).Str("Pod", pod.Name).Msg("Pod port forwarding is broken, reconnecting")
+		}
+		for {
+			select {
+			case <-stop:
+				return
+			case <-m.Client.Clock().After(ForwardRetryInterval):
+			}
+			if superseded() {
+				return
+			}
+			name, err := m.instancePod(namespace, pod)
+			if err == nil {
+				_, broken, err = m.portForward(namespace, name, rules, stop)
+			}
+			if err == nil {
+				log.Info().Str("Pod", name).Msg("Pod port forwarding is restored")
+				break
+			}
+			log.Debug().Err(err).Str("Pod", pod.Name).Msg("Failed to restore pod port forwarding")
+		}
+	}
+}
 
-	stopChan, readyChan := make(chan struct{}, 1), make(chan struct{}, 1)
+// instancePod returns a running pod of the same app instance as the pod, the pod itself if it has no app label
+func (m *Forwarder) instancePod(namespace string, pod v1.Pod) (string, error) {
+	if pod.Labels["app"] == "" {
+		return pod.Name, nil
+	}
+	set := labels.Set{"app": pod.Labels["app"]}
+	if pod.Labels["instance"] != "" {
+		set["instance"] = pod.Labels["instance"]
+	}
+	pods, err := m.Client.ListPods(namespace, set.String())
+	if err != nil {
+		return "", err
+	}
+	for _, p := range pods.Items {
+		if p.Status.Phase == v1.PodRunning && p.DeletionTimestamp == nil {
+			return p.Name, nil
+		}
+	}
+	return "", errors.Errorf("no running pods of %s", set.String())
+}
+
+// portForward forwards pod ports until stop is closed, it returns forwarded ports when they are ready
+// and a channel which receives an error when forwarding stops without being stopped, e.g. the pod is gone
+func (m *Forwarder) portForward(namespace string, pod string, portRules []string, stop chan struct{}) ([]portforward.ForwardedPort, <-chan error, error) {
+	roundTripper, upgrader, err := spdy.RoundTripperFor(m.Client.RESTConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpPath := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespace, pod)
+	serverURL, err := apiServerURL(m.Client.RESTConfig.Host, httpPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, serverURL)
+
+	readyChan := make(chan struct{}, 1)
 	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
 
 	log.Debug().
-		Str("Pod", pod.Name).
+		Str("Pod", pod).
 		Msg("Attempting to forward ports")
 
-	portRules := m.portRulesForPod(pod)
-	if len(portRules) == 0 {
-		return nil
-	}
-	forwarder, err := portforward.New(dialer, portRules, stopChan, readyChan, out, errOut)
+	forwarder, err := portforward.New(dialer, portRules, stop, readyChan, out, errOut)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	errChan := make(chan error, 1)
 	go func() {
-		if err := forwarder.ForwardPorts(); err != nil {
-			log.Error().Str("Pod", pod.Name).Err(err)
+		err := forwarder.ForwardPorts()
+		select {
+		case <-stop:
+			return
+		default:
 		}
+		if err == nil {
+			err = errors.Errorf("lost connection to pod %s", pod)
+		}
+		log.Error().Str("Pod", pod).Err(err).Send()
+		errChan <- err
 	}()
 
-	<-readyChan
+	select {
+	case <-readyChan:
+	case err := <-errChan:
+		return nil, nil, err
+	}
 	if len(errOut.String()) > 0 {
-		return fmt.Errorf("error on forwarding k8s port: %v", errOut.String())
+		return nil, nil, fmt.Errorf("error on forwarding k8s port: %v", errOut.String())
 	}
 	forwardedPorts, err := forwarder.GetPorts()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	namedPorts := m.podPortsByName(pod, forwardedPorts)
+	return forwardedPorts, errChan, nil
+}
+
+// newSession returns a stop channel of a new forwarding session, it's closed by Close
+func (m *Forwarder) newSession() chan struct{} {
+	stop := make(chan struct{})
+	once := &sync.Once{}
+	m.addCloser(func() {
+		once.Do(func() {
+			close(stop)
+		})
+	})
+	return stop
+}
+
+func (m *Forwarder) addCloser(c func()) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.Info[fmt.Sprintf("%s:%s", pod.Labels["app"], pod.Labels["instance"])] = namedPorts
-	return nil
+	m.closers = append(m.closers, c)
+}
+
+// Close stops all port forwarding sessions, closes all tunnel listeners and the bastion connection,
+// forwarded ports info is kept
+func (m *Forwarder) Close() {
+	m.mu.Lock()
+	closers := m.closers
+	m.closers = nil
+	m.mu.Unlock()
+	for _, c := range closers {
+		c()
+	}
+	if m.ssh != nil {
+		m.ssh.close()
+	}
 }
 
 func (m *Forwarder) collectPodPorts(pod v1.Pod) error {
@@ -95,8 +252,9 @@ func (m *Forwarder) collectPodPorts(pod v1.Pod) error {
 				namedPorts[c.Name] = make(map[string]interface{})
 			}
 			namedPorts[c.Name].(map[string]interface{})[cp.Name] = ConnectionInfo{
-				Host:  pod.Status.PodIP,
-				Ports: portforward.ForwardedPort{Remote: uint16(cp.ContainerPort)},
+				Host:     pod.Status.PodIP,
+				Ports:    portforward.ForwardedPort{Remote: uint16(cp.ContainerPort)},
+				Protocol: m.portProtocol(c.Name, cp),
 			}
 		}
 	}
@@ -116,8 +274,9 @@ func (m *Forwarder) podPortsByName(pod v1.Pod, fp []portforward.ForwardedPort) m
 						ports[c.Name] = make(map[string]interface{})
 					}
 					ports[c.Name].(map[string]interface{})[cp.Name] = ConnectionInfo{
-						Host:  pod.Status.PodIP,
-						Ports: forwardedPort,
+						Host:     pod.Status.PodIP,
+						Ports:    forwardedPort,
+						Protocol: m.portProtocol(c.Name, cp),
 					}
 				}
 			}
@@ -126,16 +285,35 @@ func (m *Forwarder) podPortsByName(pod v1.Pod, fp []portforward.ForwardedPort) m
 	return ports
 }
 
-func (m *Forwarder) portRulesForPod(pod v1.Pod) []string {
+// portRulesForPod returns forwarding rules, empty local port means a free port is allocated,
+// preferred local ports are used only if preferred is set
+func (m *Forwarder) portRulesForPod(pod v1.Pod, preferred bool) []string {
 	rules := make([]string, 0)
 	for _, c := range pod.Spec.Containers {
 		for _, port := range c.Ports {
-			rules = append(rules, fmt.Sprintf(":%d", port.ContainerPort))
+			remote := uint16(port.ContainerPort)
+			if preferred && m.PreferRemotePorts && m.reservePort(remote) {
+				rules = append(rules, fmt.Sprintf("%d:%d", remote, remote))
+				continue
+			}
+			if preferred && m.LocalPort != nil {
+				key := fmt.Sprintf("%s:%s/%s", pod.Labels["app"], pod.Labels["instance"], portKey(c.Name, port.Name))
+				if local := m.LocalPort(key); local != 0 && m.reservePort(local) {
+					rules = append(rules, fmt.Sprintf("%d:%d", local, remote))
+					continue
+				}
+			}
+			rules = append(rules, fmt.Sprintf(":%d", remote))
 		}
 	}
 	return rules
 }
 
+// UseSSH switches the forwarder to SSH tunnels through a bastion instead of K8s port forwarding
+func (m *Forwarder) UseSSH(cfg *SSHConfig) {
+	m.ssh = &sshTunnel{cfg: cfg}
+}
+
 func (m *Forwarder) Connect(namespaceName string, selector string, insideK8s bool) error {
 	pods, err := m.Client.ListPods(namespaceName, selector)
 	if err != nil {
@@ -148,6 +326,10 @@ func (m *Forwarder) Connect(namespaceName string, selector string, insideK8s boo
 			eg.Go(func() error {
 				return m.collectPodPorts(p)
 			})
+		} else if m.ssh != nil {
+			eg.Go(func() error {
+				return m.tunnelPodPorts(p)
+			})
 		} else {
 			eg.Go(func() error {
 				return m.forwardPodPorts(p, namespaceName)
@@ -157,9 +339,122 @@ func (m *Forwarder) Connect(namespaceName string, selector string, insideK8s boo
 	return eg.Wait()
 }
 
+// Reconnect forwards ports of pods replaced by a rollout, local ports of the same instances are reused if they are free,
+// so connection URLs stay the same, free ports are allocated otherwise
+func (m *Forwarder) Reconnect(namespaceName string, selector string, insideK8s bool) error {
+	if insideK8s || m.ssh != nil {
+		return m.Connect(namespaceName, selector, insideK8s)
+	}
+	pods, err := m.Client.ListPods(namespaceName, selector)
+	if err != nil {
+		return err
+	}
+	eg := &errgroup.Group{}
+	for _, p := range pods.Items {
+		p := p
+		eg.Go(func() error {
+			return m.reforwardPodPorts(p, namespaceName)
+		})
+	}
+	return eg.Wait()
+}
+
+func (m *Forwarder) reforwardPodPorts(pod v1.Pod, namespaceName string) error {
+	if pod.Status.Phase != v1.PodRunning {
+		log.Debug().Str("Pod", pod.Name).Interface("Phase", pod.Status.Phase).Msg("Skipping pod")
+		return nil
+	}
+	portRules := m.previousPortRules(pod)
+	if portRules == nil {
+		return m.forwardPodPorts(pod, namespaceName)
+	}
+	if err := m.forward(pod, namespaceName, portRules); err != nil {
+		log.Debug().Err(err).Str("Pod", pod.Name).Msg("Previous local ports are busy, allocating free ports")
+		return m.forwardPodPorts(pod, namespaceName)
+	}
+	return nil
+}
+
+// previousPortRules returns forwarding rules to local ports forwarded for the instance of a pod before, nil if there were none
+func (m *Forwarder) previousPortRules(pod v1.Pod) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev, ok := m.Info[fmt.Sprintf("%s:%s", pod.Labels["app"], pod.Labels["instance"])].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	rules := make([]string, 0)
+	for _, c := range pod.Spec.Containers {
+		ports, _ := prev[c.Name].(map[string]interface{})
+		for _, cp := range c.Ports {
+			if ci, ok := ports[cp.Name].(ConnectionInfo); ok && ci.Ports.Local != 0 {
+				rules = append(rules, fmt.Sprintf("%d:%d", ci.Ports.Local, cp.ContainerPort))
+				continue
+			}
+			rules = append(rules, fmt.Sprintf(":%d", cp.ContainerPort))
+		}
+	}
+	return rules
+}
+
+// RemoveApp removes forwarded ports info of all instances of an app
+func (m *Forwarder) RemoveApp(app string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k := range m.Info {
+		if strings.HasPrefix(k, app+":") {
+			delete(m.Info, k)
+		}
+	}
+}
+
 func (m *Forwarder) FindPort(ks ...string) *URLConverter {
 	d, err := lookupMap(m.Info, ks...)
-	return NewURLConverter(d.(ConnectionInfo), err)
+	ci := d.(ConnectionInfo)
+	if err == nil && len(ks) == 3 {
+		ci.Credentials = m.Credentials(ks[1], ks[2])
+	}
+	return NewURLConverter(ci, err)
+}
+
+// SetCredentials sets credentials of a container port for all pods, they are used to build DSNs
+func (m *Forwarder) SetCredentials(container string, port string, creds Credentials) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.credentials[portKey(container, port)] = &creds
+}
+
+// Credentials returns credentials of a container port, nil if not set
+func (m *Forwarder) Credentials(container string, port string) *Credentials {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.credentials[portKey(container, port)]
+}
+
+func portKey(container string, port string) string {
+	return fmt.Sprintf("%s/%s", container, port)
+}
+
+// portProtocol returns the overridden protocol of a port or detects it by the port name and number
+func (m *Forwarder) portProtocol(container string, cp v1.ContainerPort) Protocol {
+	if p, ok := m.Protocols[portKey(container, cp.Name)]; ok {
+		return p
+	}
+	return detectProtocol(cp.Name, cp.ContainerPort)
+}
+
+func detectProtocol(name string, port int32) Protocol {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "grpc"):
+		return GRPC
+	case strings.Contains(name, "postgres") || port == 5432:
+		return Postgres
+	case strings.Contains(name, "ws"):
+		return WS
+	default:
+		return HTTP
+	}
 }
 
 func lookupMap(m map[string]interface{}, ks ...string) (rval interface{}, err error) {
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+const (
+	GRPCDefaultTimeout = 10 * time.Second
+)
+
+// gRPC health serving statuses
+const (
+	GRPCStatusUnknown        = "UNKNOWN"
+	GRPCStatusServing        = "SERVING"
+	GRPCStatusNotServing     = "NOT_SERVING"
+	GRPCStatusServiceUnknown = "SERVICE_UNKNOWN"
+)
+
+// GRPCClient is a plaintext gRPC client for health checks and reflection of test services,
+// it doesn't need generated stubs of the service, so any gRPC service can be checked
+type GRPCClient struct {
+	Addr string
+}
+
+// NewGRPCClient creates a client for a host:port address
+func NewGRPCClient(addr string) *GRPCClient {
+	return &GRPCClient{Addr: addr}
+}
+
+// dial connects to the service, the connection is closed by the caller
+func (c *GRPCClient) dial(ctx context.Context) (*grpc.ClientConn, error) {
+	conn, err := grpc.DialContext(ctx, c.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to gRPC service %s", c.Addr)
+	}
+	return conn, nil
+}
+
+// HealthCheck calls grpc.health.v1 Check and returns the serving status, empty service checks the whole server
+func (c *GRPCClient) HealthCheck(ctx context.Context, service string) (string, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return "", errors.Wrapf(err, "gRPC health check of %s failed", c.Addr)
+	}
+	return resp.GetStatus().String(), nil
+}
+
+// ListServices lists services of the server with the reflection API
+func (c *GRPCClient) ListServices(ctx context.Context) ([]string, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "gRPC reflection of %s failed", c.Addr)
+	}
+	defer func() { _ = stream.CloseSend() }()
+	if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		return nil, errors.Wrapf(err, "gRPC reflection of %s failed", c.Addr)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, errors.Wrapf(err, "gRPC reflection of %s failed", c.Addr)
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, errors.Errorf("reflection request failed with code %d: %s", e.GetErrorCode(), e.GetErrorMessage())
+	}
+	services := make([]string, 0)
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	return services, nil
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func TestGRPCClient(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("mercury.Mercury", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+	reflection.Register(s)
+	go func() { _ = s.Serve(l) }()
+	defer s.Stop()
+
+	c := NewGRPCClient(l.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), GRPCDefaultTimeout)
+	defer cancel()
+	status, err := c.HealthCheck(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, GRPCStatusServing, status)
+	status, err = c.HealthCheck(ctx, "mercury.Mercury")
+	require.NoError(t, err)
+	require.Equal(t, GRPCStatusNotServing, status)
+	_, err = c.HealthCheck(ctx, "unknown.Service")
+	require.Error(t, err)
+	services, err := c.ListServices(ctx)
+	require.NoError(t, err)
+	require.Contains(t, services, "grpc.health.v1.Health")
+	require.Contains(t, services, "grpc.reflection.v1alpha.ServerReflection")
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/config"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// HistoryAnnotationKey namespace annotation with the environment history in JSON
+	HistoryAnnotationKey = "chainlink-env/history"
+	// HistoryMaxEvents only the last events are kept, annotations size is limited
+	HistoryMaxEvents = 100
+)
+
+const (
+	EventKindChaos     = "chaos"
+	EventKindLifecycle = "lifecycle"
+	EventOutcomeOK     = "ok"
+	EventOutcomeFailed = "failed"
+)
+
+// HistoryEvent is a chaos experiment or a lifecycle event recorded in the environment namespace
+type HistoryEvent struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Action  string    `json:"action"`
+	Target  string    `json:"target,omitempty"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+	User    string    `json:"user,omitempty"`
+}
+
+// NewHistoryEvent creates an event, outcome is derived from the error, the time is set by RecordEvent
+func NewHistoryEvent(kind string, action string, target string, err error) HistoryEvent {
+	e := HistoryEvent{
+		Kind:    kind,
+		Action:  action,
+		Target:  target,
+		Outcome: EventOutcomeOK,
+		User:    os.Getenv(config.EnvVarUser),
+	}
+	if err != nil {
+		e.Outcome = EventOutcomeFailed
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// RecordEvent appends an event to the namespace history, events without a time are timed by the client clock
+func (m *K8sClient) RecordEvent(namespace string, event HistoryEvent) error {
+	if event.Time.IsZero() {
+		event.Time = m.Clock().Now().UTC()
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ns, err := m.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		history, err := appendHistory(ns.Annotations[HistoryAnnotationKey], event, HistoryMaxEvents)
+		if err != nil {
+			return err
+		}
+		if ns.Annotations == nil {
+			ns.Annotations = make(map[string]string)
+		}
+		ns.Annotations[HistoryAnnotationKey] = history
+		_, err = m.ClientSet.CoreV1().Namespaces().Update(context.Background(), ns, metaV1.UpdateOptions{})
+		return err
+	})
+}
+
+// History returns all events recorded in the namespace, oldest first
+func (m *K8sClient) History(namespace string) ([]HistoryEvent, error) {
+	ns, err := m.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return parseHistory(ns.Annotations[HistoryAnnotationKey])
+}
+
+func parseHistory(raw string) ([]HistoryEvent, error) {
+	events := make([]HistoryEvent, 0)
+	if raw == "" {
+		return events, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &events); err != nil {
+		return nil, errors.Wrap(err, "failed to parse environment history")
+	}
+	return events, nil
+}
+
+// appendHistory appends an event to the serialized history, keeping only the last max events
+func appendHistory(raw string, event HistoryEvent, max int) (string, error) {
+	events, err := parseHistory(raw)
+	if err != nil {
+		return "", err
+	}
+	events = append(events, event)
+	if len(events) > max {
+		events = events[len(events)-max:]
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendHistory(t *testing.T) {
+	raw, err := appendHistory("", NewHistoryEvent(EventKindLifecycle, "deploy", "", nil), 2)
+	require.NoError(t, err)
+	raw, err = appendHistory(raw, NewHistoryEvent(EventKindChaos, "run", "pod-failure", errors.New("boom")), 2)
+	require.NoError(t, err)
+	raw, err = appendHistory(raw, NewHistoryEvent(EventKindChaos, "stop", "pod-failure", nil), 2)
+	require.NoError(t, err)
+	events, err := parseHistory(raw)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "run", events[0].Action)
+	require.Equal(t, EventOutcomeFailed, events[0].Outcome)
+	require.Equal(t, "boom", events[0].Error)
+	require.Equal(t, "stop", events[1].Action)
+	require.Equal(t, EventOutcomeOK, events[1].Outcome)
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// MaintenanceAnnotationKey namespace annotation with the maintenance expiry unix time, the reaper checks it
+	MaintenanceAnnotationKey       = "chainlink-env/maintenance"
+	MaintenanceReasonAnnotationKey = "chainlink-env/maintenance-reason"
+	MaintenanceUserAnnotationKey   = "chainlink-env/maintenance-user"
+)
+
+// Maintenance is an active maintenance window of an environment, the reaper, TTL cleanup and chaos experiments
+// don't touch the environment until it expires
+type Maintenance struct {
+	Until  time.Time
+	Reason string
+	User   string
+}
+
+// StartMaintenance marks the namespace as in maintenance for the duration and extends its TTL to cover it
+func (m *K8sClient) StartMaintenance(namespace string, d time.Duration, reason string) (*Maintenance, error) {
+	mt := &Maintenance{
+		Until:  m.Clock().Now().Add(d),
+		Reason: reason,
+		User:   os.Getenv(config.EnvVarUser),
+	}
+	if err := m.patchNamespaceAnnotations(namespace, map[string]interface{}{
+		MaintenanceAnnotationKey:       strconv.FormatInt(mt.Until.Unix(), 10),
+		MaintenanceReasonAnnotationKey: mt.Reason,
+		MaintenanceUserAnnotationKey:   mt.User,
+	}); err != nil {
+		return nil, err
+	}
+	if err := m.ExtendTTL(namespace, d); err != nil {
+		return nil, err
+	}
+	err := m.RecordEvent(namespace, NewHistoryEvent(EventKindLifecycle, "maintenance start", reason, nil))
+	return mt, err
+}
+
+// EndMaintenance removes the maintenance mark before it expires
+func (m *K8sClient) EndMaintenance(namespace string) error {
+	if err := m.patchNamespaceAnnotations(namespace, map[string]interface{}{
+		MaintenanceAnnotationKey:       nil,
+		MaintenanceReasonAnnotationKey: nil,
+		MaintenanceUserAnnotationKey:   nil,
+	}); err != nil {
+		return err
+	}
+	return m.RecordEvent(namespace, NewHistoryEvent(EventKindLifecycle, "maintenance end", "", nil))
+}
+
+// Maintenance returns the active maintenance of the namespace, nil if there is none or it's expired
+func (m *K8sClient) Maintenance(namespace string) (*Maintenance, error) {
+	ns, err := m.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return parseMaintenance(ns.Annotations, m.Clock().Now())
+}
+
+// CheckNotInMaintenance returns an error if the namespace is in maintenance
+func (m *K8sClient) CheckNotInMaintenance(namespace string) error {
+	mt, err := m.Maintenance(namespace)
+	if err != nil {
+		return err
+	}
+	if mt != nil {
+		return errors.Errorf("environment %s is in maintenance until %s by %q: %s", namespace, mt.Until.Format(time.RFC3339), mt.User, mt.Reason)
+	}
+	return nil
+}
+
+func parseMaintenance(annotations map[string]string, now time.Time) (*Maintenance, error) {
+	raw, ok := annotations[MaintenanceAnnotationKey]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	unix, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse maintenance expiry")
+	}
+	until := time.Unix(unix, 0)
+	if !until.After(now) {
+		return nil, nil
+	}
+	return &Maintenance{
+		Until:  until,
+		Reason: annotations[MaintenanceReasonAnnotationKey],
+		User:   annotations[MaintenanceUserAnnotationKey],
+	}, nil
+}
+
+// ExtendTTL sets janitor TTL and the TTL label so the namespace lives for at least ttl more from now, janitor counts TTL from the namespace creation,
+// a longer TTL is kept as is
+func (m *K8sClient) ExtendTTL(namespace string, ttl time.Duration) error {
+	ns, err := m.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	extended, ok := extendedTTL(ns.Annotations[pkg.TTLLabelKey], m.Clock().Since(ns.CreationTimestamp.Time), ttl)
+	if !ok {
+		return nil
+	}
+	if err := m.patchNamespaceAnnotations(namespace, map[string]interface{}{pkg.TTLLabelKey: extended}); err != nil {
+		return err
+	}
+	if _, ok := ns.Labels[pkg.NamespaceTTLLabelKey]; !ok {
+		return nil
+	}
+	return m.patchNamespaceLabels(namespace, map[string]interface{}{pkg.NamespaceTTLLabelKey: extended})
+}
+
+// extendedTTL returns the janitor TTL covering ttl more from the namespace age, false if the current TTL is enough,
+// janitor formats that can't be parsed, like days, are overwritten
+func extendedTTL(current string, age time.Duration, ttl time.Duration) (string, bool) {
+	total := age.Round(time.Minute) + ttl
+	if d, err := time.ParseDuration(current); err == nil && d >= total {
+		return "", false
+	}
+	return *a.ShortDur(total), true
+}
+
+// patchNamespaceAnnotations sets namespace annotations, nil values remove them
+func (m *K8sClient) patchNamespaceAnnotations(namespace string, annotations map[string]interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.ClientSet.CoreV1().Namespaces().Patch(context.Background(), namespace, types.MergePatchType, patch, metaV1.PatchOptions{})
+	return errors.Wrapf(err, "failed to annotate namespace %s", namespace)
+}
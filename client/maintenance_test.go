@@ -0,0 +1,41 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMaintenance(t *testing.T) {
+	now := time.Unix(1000, 0)
+	mt, err := parseMaintenance(map[string]string{
+		MaintenanceAnnotationKey:       "2000",
+		MaintenanceReasonAnnotationKey: "debugging OCR",
+		MaintenanceUserAnnotationKey:   "alice",
+	}, now)
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(2000, 0), mt.Until)
+	require.Equal(t, "debugging OCR", mt.Reason)
+	require.Equal(t, "alice", mt.User)
+
+	mt, err = parseMaintenance(map[string]string{MaintenanceAnnotationKey: "500"}, now)
+	require.NoError(t, err)
+	require.Nil(t, mt)
+	mt, err = parseMaintenance(nil, now)
+	require.NoError(t, err)
+	require.Nil(t, mt)
+	_, err = parseMaintenance(map[string]string{MaintenanceAnnotationKey: "tomorrow"}, now)
+	require.Error(t, err)
+}
+
+func TestExtendedTTL(t *testing.T) {
+	ttl, ok := extendedTTL("1h", 50*time.Minute, 30*time.Minute)
+	require.True(t, ok)
+	require.Equal(t, "1h20m", ttl)
+	_, ok = extendedTTL("3h", 50*time.Minute, 30*time.Minute)
+	require.False(t, ok)
+	ttl, ok = extendedTTL("7d", time.Hour, time.Hour)
+	require.True(t, ok)
+	require.Equal(t, "2h", ttl)
+}
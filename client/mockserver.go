@@ -0,0 +1,77 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MockserverRequestTimeout = 10 * time.Second
+)
+
+// MockserverClient sets and clears mockserver expectations
+type MockserverClient struct {
+	URL  string
+	http *http.Client
+}
+
+// NewMockserverClient creates a new mockserver client
+func NewMockserverClient(url string) *MockserverClient {
+	return &MockserverClient{
+		URL:  url,
+		http: &http.Client{Timeout: MockserverRequestTimeout},
+	}
+}
+
+// PutExpectation creates or updates an expectation, expectations with the same "id" are replaced
+func (m *MockserverClient) PutExpectation(exp interface{}) error {
+	return m.put("/expectation", exp, http.StatusCreated)
+}
+
+// SetValuePath sets an external adapter response {"data":{"result":value}} for the path
+func (m *MockserverClient) SetValuePath(path string, value interface{}) error {
+	return m.PutExpectation(map[string]interface{}{
+		"id":          path,
+		"httpRequest": map[string]interface{}{"path": path},
+		"httpResponse": map[string]interface{}{
+			"body": map[string]interface{}{
+				"data": map[string]interface{}{"result": value},
+			},
+		},
+	})
+}
+
+// Clear removes all expectations of the path
+func (m *MockserverClient) Clear(path string) error {
+	return m.put("/clear", map[string]interface{}{"path": path}, http.StatusOK)
+}
+
+// Reset removes all expectations and recorded requests
+func (m *MockserverClient) Reset() error {
+	return m.put("/reset", nil, http.StatusOK)
+}
+
+func (m *MockserverClient) put(path string, body interface{}, expectedStatus int) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, m.URL+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expectedStatus {
+		return errors.Errorf("PUT %s: unexpected status %d", path, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	listersV1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// PodWatchSyncTimeout how long to wait for the initial pods list of a namespace watch,
+	// pods are polled if the watch is not synced in time, e.g. when watches are forbidden or cut by a proxy
+	PodWatchSyncTimeout = 30 * time.Second
+)
+
+// podWatcher is a shared pods informer of a namespace, all waits of the namespace are woken up on every pod change
+type podWatcher struct {
+	lister   listersV1.PodLister
+	stop     chan struct{}
+	stopOnce sync.Once
+	// synced is closed when the initial list is synced or the sync failed with err
+	synced chan struct{}
+	err    error
+	mu     sync.Mutex
+	subs   map[chan struct{}]bool
+}
+
+// close stops the informer, it's safe to call more than once
+func (w *podWatcher) close() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// subscribe returns a channel notified on pod changes, changes are coalesced
+func (w *podWatcher) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs[ch] = true
+	return ch
+}
+
+func (w *podWatcher) unsubscribe(ch chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.subs, ch)
+}
+
+func (w *podWatcher) notify() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// podWatcher returns a started and synced pods watcher of the namespace, watchers are shared by all waits of the client,
+// an error is returned if watches are unavailable, the namespace is polled then, the next wait tries to watch again
+func (m *K8sClient) podWatcher(ns string) (*podWatcher, error) {
+	m.watchMu.Lock()
+	if m.podWatchers == nil {
+		m.podWatchers = make(map[string]*podWatcher)
+	}
+	if w, ok := m.podWatchers[ns]; ok {
+		m.watchMu.Unlock()
+		<-w.synced
+		return w, w.err
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(m.ClientSet, 0, informers.WithNamespace(ns))
+	pods := factory.Core().V1().Pods()
+	w := &podWatcher{
+		lister: pods.Lister(),
+		stop:   make(chan struct{}),
+		synced: make(chan struct{}),
+		subs:   make(map[chan struct{}]bool),
+	}
+	pods.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { w.notify() },
+		UpdateFunc: func(interface{}, interface{}) { w.notify() },
+		DeleteFunc: func(interface{}) { w.notify() },
+	})
+	factory.Start(w.stop)
+	m.podWatchers[ns] = w
+	m.watchMu.Unlock()
+
+	// the sync takes up to PodWatchSyncTimeout, waits of other namespaces don't wait for it
+	timeout := make(chan struct{})
+	timer := time.AfterFunc(PodWatchSyncTimeout, func() { close(timeout) })
+	ok := cache.WaitForCacheSync(timeout, pods.Informer().HasSynced)
+	timer.Stop()
+	if !ok {
+		w.close()
+		w.err = errors.Errorf("pods watch of namespace %s is not synced in %s", ns, PodWatchSyncTimeout)
+		log.Warn().Err(w.err).Msg("Pod watches are unavailable, polling pods")
+		m.watchMu.Lock()
+		if m.podWatchers[ns] == w {
+			delete(m.podWatchers, ns)
+		}
+		m.watchMu.Unlock()
+	}
+	close(w.synced)
+	return w, w.err
+}
+
+// StopPodWatches stops pods watches of the namespace, they are started again by the next wait
+func (m *K8sClient) StopPodWatches(ns string) {
+	m.watchMu.Lock()
+	defer m.watchMu.Unlock()
+	w, ok := m.podWatchers[ns]
+	if !ok {
+		return
+	}
+	w.close()
+	delete(m.podWatchers, ns)
+}
+
+// WaitPods waits until the condition is met for pods matching the selector, it's evaluated right away and on every pod change
+// of the namespace, pods are watched with a shared informer, so large environments don't hammer the API server,
+// and polled every ContainerStatePollInterval if watches are unavailable, wait.ErrWaitTimeout is returned after the timeout,
+// 0 timeout means no timeout
+func (m *K8sClient) WaitPods(ns string, selector string, timeout time.Duration, cond func(pods []v1.Pod) (bool, error)) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	if timeout != 0 {
+		t := m.Clock().NewTimer(timeout)
+		defer t.Stop()
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-t.C():
+				close(done)
+			case <-stop:
+			}
+		}()
+		return m.waitPodsUntil(ns, selector, done, cond)
+	}
+	return m.waitPodsUntil(ns, selector, stop, cond)
+}
+
+// waitPodsUntil waits until the condition is met for pods matching the selector or stopCh is closed
+func (m *K8sClient) waitPodsUntil(ns string, selector string, stopCh <-chan struct{}, cond func(pods []v1.Pod) (bool, error)) error {
+	w, err := m.podWatcher(ns)
+	if err != nil {
+		return PollImmediateUntil(m.Clock(), ContainerStatePollInterval, func() (bool, error) {
+			pl, err := m.ListPods(ns, selector)
+			if err != nil {
+				return false, err
+			}
+			return cond(pl.Items)
+		}, stopCh)
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return errors.Wrapf(err, "invalid pods selector %s", selector)
+	}
+	changed := w.subscribe()
+	defer w.unsubscribe(changed)
+	for {
+		cached, err := w.lister.Pods(ns).List(sel)
+		if err != nil {
+			return err
+		}
+		ok, err := cond(copyPods(cached))
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-changed:
+		case <-stopCh:
+			return wait.ErrWaitTimeout
+		}
+	}
+}
+
+// copyPods copies cached pods sorted by name, so conditions don't mutate the cache and see pods in the same order as in a list
+func copyPods(cached []*v1.Pod) []v1.Pod {
+	pods := make([]v1.Pod, 0, len(cached))
+	for _, p := range cached {
+		pods = append(pods, *p.DeepCopy())
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	return pods
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func phasePod(name string, phase v1.PodPhase, ready ...bool) v1.Pod {
+	p := v1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: name, Labels: map[string]string{}}, Status: v1.PodStatus{Phase: phase}}
+	for _, r := range ready {
+		p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, v1.ContainerStatus{Name: "node", Ready: r})
+	}
+	return p
+}
+
+func TestPodsRunning(t *testing.T) {
+	pods := []v1.Pod{phasePod("geth-0", v1.PodRunning), phasePod("job-0", v1.PodSucceeded), phasePod("chainlink-0", v1.PodPending)}
+	running, err := podsRunning(pods, []string{"geth-0", "job-0"})
+	require.NoError(t, err)
+	require.True(t, running)
+	running, err = podsRunning(pods, []string{"geth-0", "chainlink-0"})
+	require.NoError(t, err)
+	require.False(t, running)
+	running, err = podsRunning(pods[:1], []string{"geth-0", "chainlink-0"})
+	require.NoError(t, err)
+	require.False(t, running)
+	_, err = podsRunning([]v1.Pod{phasePod("geth-0", v1.PodFailed)}, []string{"geth-0"})
+	require.EqualError(t, err, "pod geth-0 failed")
+}
+
+func TestContainersReady(t *testing.T) {
+	hook := phasePod("hook", v1.PodRunning, false)
+	hook.Labels[pkg.HookLabelKey] = "post-install"
+	require.True(t, containersReady([]v1.Pod{phasePod("geth-0", v1.PodRunning, true, true), phasePod("job-0", v1.PodSucceeded, false), hook}))
+	require.False(t, containersReady([]v1.Pod{phasePod("geth-0", v1.PodRunning, true, false)}))
+}
+
+func TestCopyPods(t *testing.T) {
+	b, a := phasePod("b", v1.PodRunning), phasePod("a", v1.PodRunning)
+	pods := copyPods([]*v1.Pod{&b, &a})
+	require.Equal(t, "a", pods[0].Name)
+	require.Equal(t, "b", pods[1].Name)
+	pods[0].Labels["mutated"] = "true"
+	require.Empty(t, a.Labels)
+}
+
+func TestPodWatcherSyncOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("watch") == "true" {
+			<-r.Context().Done()
+			return
+		}
+		// the initial list of the "slow" namespace isn't answered until the test releases it
+		if r.URL.Path == "/api/v1/namespaces/slow/pods" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"PodList","apiVersion":"v1","metadata":{"resourceVersion":"1"},"items":[]}`))
+	}))
+	defer func() {
+		close(release)
+		// watches of stopped informers are still open
+		srv.CloseClientConnections()
+		srv.Close()
+	}()
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	require.NoError(t, err)
+	c := &K8sClient{ClientSet: cs}
+	defer c.StopPodWatches("fast")
+	defer c.StopPodWatches("slow")
+	go func() { _, _ = c.podWatcher("slow") }()
+	require.Eventually(t, func() bool {
+		c.watchMu.Lock()
+		defer c.watchMu.Unlock()
+		return c.podWatchers["slow"] != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	start := time.Now()
+	fast, err := c.podWatcher("fast")
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), PodWatchSyncTimeout/2, "a namespace syncing doesn't block watches of other namespaces")
+	again, err := c.podWatcher("fast")
+	require.NoError(t, err)
+	require.Same(t, fast, again)
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var envVarNameRe = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// PortMapping is a local forwarded port of a container port
+type PortMapping struct {
+	Instance  string `json:"instance"`
+	Container string `json:"container"`
+	Port      string `json:"port"`
+	Protocol  string `json:"protocol"`
+	Remote    uint16 `json:"remote"`
+	Local     uint16 `json:"local"`
+}
+
+// EnvVarName returns env var name for the mapping, for example, CHAINLINK_0_0_NODE_ACCESS_PORT
+func (p PortMapping) EnvVarName() string {
+	return EnvVarName(p.Instance, p.Container, p.Port, "port")
+}
+
+// EnvVarName returns an env var name of parts, e.g. SIMULATED_GETH_HTTP_URL of "Simulated Geth_http" and "url"
+func EnvVarName(parts ...string) string {
+	name := strings.ToUpper(strings.Join(parts, "_"))
+	return strings.Trim(envVarNameRe.ReplaceAllString(name, "_"), "_")
+}
+
+// reservePort reserves a local port if it's free and not reserved by another pod yet
+func (m *Forwarder) reservePort(port uint16) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reserved[port] || !isPortFree(port) {
+		return false
+	}
+	m.reserved[port] = true
+	return true
+}
+
+func isPortFree(port uint16) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	_ = l.Close()
+	return true
+}
+
+// Mapping returns all forwarded ports sorted by instance, container and port name
+func (m *Forwarder) Mapping() []PortMapping {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mapping := make([]PortMapping, 0)
+	for instance, containers := range m.Info {
+		cm, ok := containers.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for container, ports := range cm {
+			pm, ok := ports.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for port, info := range pm {
+				ci, ok := info.(ConnectionInfo)
+				if !ok {
+					continue
+				}
+				mapping = append(mapping, PortMapping{
+					Instance:  instance,
+					Container: container,
+					Port:      port,
+					Protocol:  ci.Protocol.String(),
+					Remote:    ci.Ports.Remote,
+					Local:     ci.Ports.Local,
+				})
+			}
+		}
+	}
+	sort.Slice(mapping, func(i, j int) bool {
+		return mapping[i].EnvVarName() < mapping[j].EnvVarName()
+	})
+	return mapping
+}
+
+// WriteMapping writes forwarded ports into a JSON file if path has .json extension, otherwise into an env file
+func (m *Forwarder) WriteMapping(path string) error {
+	mapping := m.Mapping()
+	if filepath.Ext(path) == ".json" {
+		data, err := json.MarshalIndent(mapping, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, data, 0644)
+	}
+	var sb strings.Builder
+	for _, p := range mapping {
+		sb.WriteString(fmt.Sprintf("%s=%d\n", p.EnvVarName(), p.Local))
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+// Connection is a forwarded port of a pod container
+type Connection struct {
+	App       string
+	Instance  string
+	Container string
+	Port      string
+	Info      ConnectionInfo
+}
+
+// Connections returns all forwarded ports with the protocol sorted by app, instance, container and port names
+func (m *Forwarder) Connections(proto Protocol) []Connection {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conns := make([]Connection, 0)
+	for key, containers := range m.Info {
+		app, instance, _ := strings.Cut(key, ":")
+		cm, ok := containers.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for container, ports := range cm {
+			pm, ok := ports.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for port, info := range pm {
+				ci, ok := info.(ConnectionInfo)
+				if !ok || ci.Protocol != proto {
+					continue
+				}
+				ci.Credentials = m.credentials[portKey(container, port)]
+				conns = append(conns, Connection{App: app, Instance: instance, Container: container, Port: port, Info: ci})
+			}
+		}
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		a, b := conns[i], conns[j]
+		if a.App != b.App {
+			return a.App < b.App
+		}
+		if a.Instance != b.Instance {
+			return a.Instance < b.Instance
+		}
+		if a.Container != b.Container {
+			return a.Container < b.Container
+		}
+		return a.Port < b.Port
+	})
+	return conns
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+)
+
+// SetProxy sets a proxy for K8s API requests and port forwarding, empty proxy keeps a proxy from kubeconfig
+// "proxy-url" or HTTPS_PROXY env var
+func SetProxy(cfg *rest.Config, proxy string) error {
+	if proxy == "" {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse proxy URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return errors.Errorf("unsupported proxy scheme: %s, use http, https or socks5", u.Scheme)
+	}
+	cfg.Proxy = http.ProxyURL(u)
+	return nil
+}
+
+// apiServerURL returns K8s API URL with the path, API host can have a path prefix when it's behind a proxy
+func apiServerURL(host string, path string) (*url.URL, error) {
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = u.Path + path
+	return u, nil
+}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestAPIServerURL(t *testing.T) {
+	u, err := apiServerURL("https://stage.k8s.local:6443", "/api/v1/namespaces/ns/pods/p/portforward")
+	require.NoError(t, err)
+	require.Equal(t, "https://stage.k8s.local:6443/api/v1/namespaces/ns/pods/p/portforward", u.String())
+
+	u, err = apiServerURL("https://rancher.local/k8s/clusters/c-1", "/api/v1/namespaces/ns/pods/p/portforward")
+	require.NoError(t, err)
+	require.Equal(t, "https://rancher.local/k8s/clusters/c-1/api/v1/namespaces/ns/pods/p/portforward", u.String())
+
+	u, err = apiServerURL("10.0.0.1:6443", "/api")
+	require.NoError(t, err)
+	require.Equal(t, "https://10.0.0.1:6443/api", u.String())
+}
+
+func TestSetProxy(t *testing.T) {
+	cfg := &rest.Config{}
+	require.NoError(t, SetProxy(cfg, ""))
+	require.Nil(t, cfg.Proxy)
+	require.NoError(t, SetProxy(cfg, "socks5://localhost:1080"))
+	require.NotNil(t, cfg.Proxy)
+	require.Error(t, SetProxy(cfg, "ftp://localhost:21"))
+}
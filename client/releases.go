@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"sort"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Releases returns sorted names of chart releases which have pods in the namespace
+func (m *K8sClient) Releases(namespace string) ([]string, error) {
+	pods, err := m.ClientSet.CoreV1().Pods(namespace).List(context.Background(), metaV1.ListOptions{LabelSelector: pkg.ReleaseLabelKey})
+	if err != nil {
+		return nil, err
+	}
+	return releaseNames(pods.Items), nil
+}
+
+func releaseNames(pods []v1.Pod) []string {
+	found := make(map[string]bool)
+	names := make([]string, 0)
+	for _, p := range pods {
+		name := p.Labels[pkg.ReleaseLabelKey]
+		if name == "" || found[name] {
+			continue
+		}
+		found[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
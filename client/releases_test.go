@@ -0,0 +1,19 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReleaseNames(t *testing.T) {
+	pod := func(release string) v1.Pod {
+		return v1.Pod{ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{pkg.ReleaseLabelKey: release}}}
+	}
+	require.Equal(t, []string{"chainlink-0", "chainlink-1", "geth"}, releaseNames([]v1.Pod{
+		pod("geth"), pod("chainlink-1"), pod("chainlink-0"), pod("chainlink-1"), {},
+	}))
+}
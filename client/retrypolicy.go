@@ -0,0 +1,226 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
+)
+
+const (
+	DefaultRetryMaxAttempts = 5
+	DefaultRetryBaseDelay   = 500 * time.Millisecond
+	DefaultRetryMaxDelay    = 30 * time.Second
+)
+
+// RetryPolicy retries K8s API requests failed with transient errors: 429, 500, 502, 503, 504 responses and connection errors,
+// delays grow exponentially with full jitter, Retry-After of a response is respected,
+// POST requests are retried only on 429, other failures may come after an object is created
+type RetryPolicy struct {
+	// MaxAttempts including the first one, DefaultRetryMaxAttempts if 0, 1 disables retries
+	MaxAttempts int
+	// BaseDelay is a delay before the first retry, doubled for every next one, DefaultRetryBaseDelay if 0
+	BaseDelay time.Duration
+	// MaxDelay caps delays, including Retry-After, DefaultRetryMaxDelay if 0
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns a retry policy with default settings
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: DefaultRetryMaxAttempts,
+		BaseDelay:   DefaultRetryBaseDelay,
+		MaxDelay:    DefaultRetryMaxDelay,
+	}
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = DefaultRetryBaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = DefaultRetryMaxDelay
+	}
+	return p
+}
+
+// delay returns a jittered delay before the retry after the failed attempt, starting from 1,
+// Retry-After is used if it's longer
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration, r *rand.Rand) time.Duration {
+	backoff := p.MaxDelay
+	if attempt < 32 {
+		if shifted := p.BaseDelay << (attempt - 1); shifted > 0 && shifted < p.MaxDelay {
+			backoff = shifted
+		}
+	}
+	d := time.Duration(r.Int63n(int64(backoff) + 1))
+	if retryAfter > d {
+		d = retryAfter
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// RetryError is returned when all attempts of a request fail, it has errors of all attempts
+type RetryError struct {
+	Method   string
+	URL      string
+	Attempts []error
+}
+
+func (e *RetryError) Error() string {
+	msgs := make([]string, 0, len(e.Attempts))
+	for i, err := range e.Attempts {
+		msgs = append(msgs, fmt.Sprintf("attempt %d: %s", i+1, err))
+	}
+	return fmt.Sprintf("%s %s failed after %d attempts: %s", e.Method, e.URL, len(e.Attempts), strings.Join(msgs, "; "))
+}
+
+// SetRetryPolicy makes all API requests of the client retry transient errors, nil disables retries,
+// streaming requests, watches, upgrades to exec and port forwarding, and evictions refused by disruption budgets are not retried
+func (m *K8sClient) SetRetryPolicy(policy *RetryPolicy) error {
+	m.retryMu.Lock()
+	wrapped := m.retryWrapped
+	m.retryPolicy = policy
+	m.retryWrapped = true
+	m.retryMu.Unlock()
+	if wrapped {
+		return nil
+	}
+	m.RESTConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &retryTransport{
+			next:   rt,
+			policy: m.RetryPolicy,
+			clock:  m.Clock,
+			rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		}
+	})
+	cs, err := kubernetes.NewForConfig(m.RESTConfig)
+	if err != nil {
+		return err
+	}
+	m.ClientSet = cs
+	return nil
+}
+
+// RetryPolicy returns the retry policy of the client, nil if requests are not retried
+func (m *K8sClient) RetryPolicy() *RetryPolicy {
+	m.retryMu.Lock()
+	defer m.retryMu.Unlock()
+	return m.retryPolicy
+}
+
+type retryTransport struct {
+	next   http.RoundTripper
+	policy func() *RetryPolicy
+	clock  func() clock.WithTicker
+	mu     sync.Mutex
+	rand   *rand.Rand
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := t.policy()
+	if policy == nil || !retriable(req) {
+		return t.next.RoundTrip(req)
+	}
+	p := policy.withDefaults()
+	clk := t.clock()
+	attempts := make([]error, 0)
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		resp, err := t.next.RoundTrip(req)
+		if req.Context().Err() != nil || !transient(req.Method, resp, err) {
+			return resp, err
+		}
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), clk.Now())
+			err = fmt.Errorf("%s", resp.Status)
+			// the response is replaced by the next attempt
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		attempts = append(attempts, err)
+		if attempt >= p.MaxAttempts {
+			return nil, &RetryError{Method: req.Method, URL: req.URL.Path, Attempts: attempts}
+		}
+		t.mu.Lock()
+		d := p.delay(attempt, retryAfter, t.rand)
+		t.mu.Unlock()
+		log.Debug().
+			Str("Method", req.Method).
+			Str("URL", req.URL.Path).
+			Int("Attempt", attempt).
+			Dur("Delay", d).
+			Err(err).
+			Msg("Retrying K8s API request")
+		timer := clk.NewTimer(d)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, &RetryError{Method: req.Method, URL: req.URL.Path, Attempts: append(attempts, req.Context().Err())}
+		case <-timer.C():
+		}
+	}
+}
+
+// retriable requests can be replayed and are not streams or disruption budget checks
+func retriable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if strings.EqualFold(req.Header.Get("Connection"), "Upgrade") || req.URL.Query().Get("watch") == "true" {
+		return false
+	}
+	if req.URL.Query().Get("follow") == "true" || strings.HasSuffix(req.URL.Path, "/eviction") {
+		return false
+	}
+	return true
+}
+
+// transient returns true for responses and errors worth retrying
+func transient(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		return method != http.MethodPost
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return method != http.MethodPost
+	}
+	return false
+}
+
+// parseRetryAfter parses Retry-After seconds or an HTTP date, 0 if it's not set or invalid
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
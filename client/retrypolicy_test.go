@@ -0,0 +1,85 @@
+package client
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/clock"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	r := rand.New(rand.NewSource(1))
+	for attempt := 1; attempt < 40; attempt++ {
+		d := p.delay(attempt, 0, r)
+		require.GreaterOrEqual(t, d, time.Duration(0))
+		require.LessOrEqual(t, d, 10*time.Second)
+		if attempt < 4 {
+			require.LessOrEqual(t, d, time.Second<<(attempt-1))
+		}
+	}
+	require.Equal(t, 5*time.Second, p.delay(1, 5*time.Second, r))
+	require.Equal(t, 10*time.Second, p.delay(1, time.Minute, r))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+	require.Equal(t, 10*time.Second, parseRetryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestRetryTransport(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/flaky") && n < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.HasSuffix(r.URL.Path, "/flaky"):
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	policy := &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	rt := &retryTransport{
+		next:   http.DefaultTransport,
+		policy: func() *RetryPolicy { return policy },
+		clock:  func() clock.WithTicker { return clock.RealClock{} },
+		rand:   rand.New(rand.NewSource(1)),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/flaky", nil)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&calls, 0)
+	req, err = http.NewRequest(http.MethodPost, srv.URL+"/api/v1/throttled", strings.NewReader("{}"))
+	require.NoError(t, err)
+	_, err = rt.RoundTrip(req)
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr))
+	require.Len(t, retryErr.Attempts, 3)
+	require.Contains(t, err.Error(), "attempt 3: 429 Too Many Requests")
+
+	atomic.StoreInt32(&calls, 0)
+	req, err = http.NewRequest(http.MethodPost, srv.URL+"/api/v1/namespaces/default/pods/p/eviction", strings.NewReader("{}"))
+	require.NoError(t, err)
+	resp, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// ScaleSchedulesAnnotationKey namespace annotation with JSON scale schedules, CronJobs enforcing them are reconciled from it
+	ScaleSchedulesAnnotationKey = "chainlink-env/scale-schedules"
+	// ScaleScheduleName is a name of the scaling service account and role, and a prefix of schedule CronJobs
+	ScaleScheduleName = "chainlink-env-scale"
+	// ScaleScheduleLabelKey is a label of schedule CronJobs with the schedule name
+	ScaleScheduleLabelKey = "chainlink-env/scale-schedule"
+)
+
+// scheduleNameRe schedule names are parts of CronJob names
+var scheduleNameRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,30}[a-z0-9])?$`)
+
+// ScaleSchedule scales deployments and stateful sets matching the selector to a number of replicas on a Cron schedule,
+// for example, Chainlink nodes to 0 at night and back to 5 in the morning, schedules are in the time zone of the cluster
+type ScaleSchedule struct {
+	Name     string `json:"name"`
+	Selector string `json:"selector"`
+	Schedule string `json:"schedule"`
+	Replicas int32  `json:"replicas"`
+}
+
+// SetScaleSchedules replaces scale schedules of the namespace, they are stored in the namespace annotation
+// and enforced by a CronJob per schedule, CronJobs of removed schedules are deleted, no schedules remove them all
+func (m *K8sClient) SetScaleSchedules(namespace string, schedules []ScaleSchedule) error {
+	if err := validateScaleSchedules(schedules); err != nil {
+		return err
+	}
+	if err := m.installScaleRBAC(namespace); err != nil {
+		return err
+	}
+	var annotation interface{}
+	if len(schedules) > 0 {
+		data, err := json.Marshal(schedules)
+		if err != nil {
+			return err
+		}
+		annotation = string(data)
+	}
+	if err := m.patchNamespaceAnnotations(namespace, map[string]interface{}{ScaleSchedulesAnnotationKey: annotation}); err != nil {
+		return err
+	}
+	return m.reconcileScaleCronJobs(namespace, schedules)
+}
+
+// ScaleSchedules returns scale schedules of the namespace
+func (m *K8sClient) ScaleSchedules(namespace string) ([]ScaleSchedule, error) {
+	ns, err := m.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return parseScaleSchedules(ns.Annotations)
+}
+
+func parseScaleSchedules(annotations map[string]string) ([]ScaleSchedule, error) {
+	schedules := make([]ScaleSchedule, 0)
+	raw, ok := annotations[ScaleSchedulesAnnotationKey]
+	if !ok {
+		return schedules, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &schedules); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s annotation", ScaleSchedulesAnnotationKey)
+	}
+	return schedules, nil
+}
+
+func validateScaleSchedules(schedules []ScaleSchedule) error {
+	names := make(map[string]bool)
+	for _, s := range schedules {
+		if !scheduleNameRe.MatchString(s.Name) {
+			return errors.Errorf("invalid scale schedule name '%s', must be lowercase alphanumeric with '-', up to 32 characters", s.Name)
+		}
+		if names[s.Name] {
+			return errors.Errorf("duplicate scale schedule %s", s.Name)
+		}
+		names[s.Name] = true
+		if s.Schedule == "" {
+			return errors.Errorf("scale schedule %s has no Cron schedule", s.Name)
+		}
+		if s.Selector == "" {
+			return errors.Errorf("scale schedule %s has no selector, it would scale the whole namespace", s.Name)
+		}
+		if _, err := labels.Parse(s.Selector); err != nil {
+			return errors.Wrapf(err, "invalid selector of scale schedule %s", s.Name)
+		}
+		if s.Replicas < 0 {
+			return errors.Errorf("scale schedule %s has negative replicas", s.Name)
+		}
+	}
+	return nil
+}
+
+// installScaleRBAC lets schedule CronJobs scale workloads of the namespace
+func (m *K8sClient) installScaleRBAC(namespace string) error {
+	ctx := context.Background()
+	_, err := m.ClientSet.CoreV1().ServiceAccounts(namespace).Create(ctx, &v1.ServiceAccount{
+		ObjectMeta: metaV1.ObjectMeta{Name: ScaleScheduleName},
+	}, metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create scaling service account")
+	}
+	_, err = m.ClientSet.RbacV1().Roles(namespace).Create(ctx, &rbacV1.Role{
+		ObjectMeta: metaV1.ObjectMeta{Name: ScaleScheduleName},
+		Rules: []rbacV1.PolicyRule{{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments", "statefulsets", "deployments/scale", "statefulsets/scale"},
+			Verbs:     []string{"get", "list", "patch", "update"},
+		}},
+	}, metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create scaling role")
+	}
+	_, err = m.ClientSet.RbacV1().RoleBindings(namespace).Create(ctx, &rbacV1.RoleBinding{
+		ObjectMeta: metaV1.ObjectMeta{Name: ScaleScheduleName},
+		Subjects: []rbacV1.Subject{{
+			Kind:      rbacV1.ServiceAccountKind,
+			Name:      ScaleScheduleName,
+			Namespace: namespace,
+		}},
+		RoleRef: rbacV1.RoleRef{
+			APIGroup: rbacV1.GroupName,
+			Kind:     "Role",
+			Name:     ScaleScheduleName,
+		},
+	}, metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create scaling role binding")
+	}
+	return nil
+}
+
+// reconcileScaleCronJobs creates or updates CronJobs of the schedules and deletes CronJobs of removed ones
+func (m *K8sClient) reconcileScaleCronJobs(namespace string, schedules []ScaleSchedule) error {
+	wanted := make(map[string]bool)
+	for _, s := range schedules {
+		cj := scaleCronJob(s)
+		wanted[cj.Name] = true
+		if err := m.applyCronJob(namespace, cj); err != nil {
+			return errors.Wrapf(err, "failed to install scale schedule %s", s.Name)
+		}
+		log.Info().
+			Str("Namespace", namespace).
+			Str("Name", s.Name).
+			Str("Selector", s.Selector).
+			Str("Schedule", s.Schedule).
+			Int32("Replicas", s.Replicas).
+			Msg("Scale schedule is installed")
+	}
+	existing, err := m.listCronJobs(namespace, ScaleScheduleLabelKey)
+	if err != nil {
+		return err
+	}
+	for _, cj := range existing {
+		if wanted[cj.GetName()] {
+			continue
+		}
+		if err := m.deleteCronJob(namespace, cj.GetName()); err != nil {
+			return errors.Wrapf(err, "failed to remove scale schedule %s", cj.GetLabels()[ScaleScheduleLabelKey])
+		}
+		log.Info().Str("Namespace", namespace).Str("Name", cj.GetLabels()[ScaleScheduleLabelKey]).Msg("Scale schedule is removed")
+	}
+	return nil
+}
+
+func scaleCronJob(s ScaleSchedule) *batchV1.CronJob {
+	backoffLimit := int32(1)
+	name := fmt.Sprintf("%s-%s", ScaleScheduleName, s.Name)
+	script := fmt.Sprintf("kubectl scale deployments,statefulsets -l %s --replicas=%d", shellQuote(s.Selector), s.Replicas)
+	return &batchV1.CronJob{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{AppLabel: ScaleScheduleName, ScaleScheduleLabelKey: s.Name},
+		},
+		Spec: batchV1.CronJobSpec{
+			Schedule:          s.Schedule,
+			ConcurrencyPolicy: batchV1.ForbidConcurrent,
+			JobTemplate: batchV1.JobTemplateSpec{
+				Spec: batchV1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metaV1.ObjectMeta{
+							Labels: map[string]string{AppLabel: ScaleScheduleName},
+						},
+						Spec: v1.PodSpec{
+							ServiceAccountName: ScaleScheduleName,
+							RestartPolicy:      v1.RestartPolicyNever,
+							Containers: []v1.Container{
+								{
+									Name:    ScaleScheduleName,
+									Image:   CleanupImage,
+									Command: []string{"/bin/sh", "-c", script},
+									Resources: v1.ResourceRequirements{
+										Requests: v1.ResourceList{
+											v1.ResourceCPU:    resource.MustParse("10m"),
+											v1.ResourceMemory: resource.MustParse("32Mi"),
+										},
+										Limits: v1.ResourceList{
+											v1.ResourceCPU:    resource.MustParse("100m"),
+											v1.ResourceMemory: resource.MustParse("64Mi"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
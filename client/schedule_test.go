@@ -0,0 +1,28 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaleSchedules(t *testing.T) {
+	schedules := []ScaleSchedule{
+		{Name: "night", Selector: "app=chainlink-0", Schedule: "0 20 * * 1-5", Replicas: 0},
+		{Name: "morning", Selector: "app=chainlink-0", Schedule: "0 7 * * 1-5", Replicas: 5},
+	}
+	require.NoError(t, validateScaleSchedules(schedules))
+	require.Error(t, validateScaleSchedules(append(schedules, schedules[0])), "duplicate name")
+	require.Error(t, validateScaleSchedules([]ScaleSchedule{{Name: "Night", Selector: "app=a", Schedule: "@daily"}}))
+	require.Error(t, validateScaleSchedules([]ScaleSchedule{{Name: "all", Schedule: "@daily"}}), "empty selector")
+	require.Error(t, validateScaleSchedules([]ScaleSchedule{{Name: "neg", Selector: "app=a", Schedule: "@daily", Replicas: -1}}))
+
+	parsed, err := parseScaleSchedules(map[string]string{
+		ScaleSchedulesAnnotationKey: `[{"name":"night","selector":"app=chainlink-0","schedule":"0 20 * * 1-5","replicas":0}]`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, schedules[:1], parsed)
+	parsed, err = parseScaleSchedules(map[string]string{})
+	require.NoError(t, err)
+	require.Empty(t, parsed)
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// ForwardRetryInterval an interval between attempts to restore broken service port forwarding
+	ForwardRetryInterval = 2 * time.Second
+)
+
+// ForwardService forwards service ports, names or numbers, to free local ports and returns local ports by requested ports,
+// all service ports are forwarded if none are requested. Forwarding goes to a ready pod of the service and is restored
+// with the same local ports if the pod goes away, until Close is called
+func (m *Forwarder) ForwardService(namespace string, service string, ports []string) (map[string]uint16, error) {
+	pod, remote, err := m.servicePod(namespace, service, ports)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]string, 0, len(remote))
+	for _, r := range remote {
+		rules = append(rules, fmt.Sprintf(":%d", r))
+	}
+	stop := m.newSession()
+	fp, broken, err := m.portForward(namespace, pod, rules, stop)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to forward service %s ports", service)
+	}
+	local := make(map[string]uint16, len(remote))
+	for key, r := range remote {
+		for _, p := range fp {
+			if p.Remote == r {
+				local[key] = p.Local
+			}
+		}
+	}
+	log.Info().Str("Service", service).Str("Pod", pod).Interface("Ports", local).Msg("Forwarded service ports")
+	go m.keepServiceForwarded(namespace, service, ports, local, stop, broken)
+	return local, nil
+}
+
+// keepServiceForwarded restores broken service forwarding on the same local ports until stop is closed
+func (m *Forwarder) keepServiceForwarded(namespace string, service string, ports []string, local map[string]uint16, stop chan struct{}, broken <-chan error) {
+	for {
+		select {
+		case <-stop:
+			return
+		case err := <-broken:
+			log.Warn().Err(err).Str("Service", service).Msg("Service port forwarding is broken, reconnecting")
+		}
+		for {
+			select {
+			case <-stop:
+				return
+			case <-m.Client.Clock().After(ForwardRetryInterval):
+			}
+			var err error
+			broken, err = m.reforwardService(namespace, service, ports, local, stop)
+			if err == nil {
+				break
+			}
+			log.Debug().Err(err).Str("Service", service).Msg("Failed to restore service port forwarding")
+		}
+	}
+}
+
+func (m *Forwarder) reforwardService(namespace string, service string, ports []string, local map[string]uint16, stop chan struct{}) (<-chan error, error) {
+	pod, remote, err := m.servicePod(namespace, service, ports)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]string, 0, len(remote))
+	for key, r := range remote {
+		rules = append(rules, fmt.Sprintf("%d:%d", local[key], r))
+	}
+	_, broken, err := m.portForward(namespace, pod, rules, stop)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("Service", service).Str("Pod", pod).Msg("Service port forwarding is restored")
+	return broken, nil
+}
+
+// servicePod returns a running pod of the service, ready pods are preferred, and pod ports of the requested service ports
+func (m *Forwarder) servicePod(namespace string, service string, ports []string) (string, map[string]uint16, error) {
+	svc, err := m.Client.ClientSet.CoreV1().Services(namespace).Get(context.Background(), service, metaV1.GetOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return "", nil, errors.Errorf("service %s has no pod selector", service)
+	}
+	pods, err := m.Client.ListPods(namespace, labels.SelectorFromSet(svc.Spec.Selector).String())
+	if err != nil {
+		return "", nil, err
+	}
+	var pod *v1.Pod
+	for i, p := range pods.Items {
+		if p.Status.Phase != v1.PodRunning || p.DeletionTimestamp != nil {
+			continue
+		}
+		if pod == nil || (podReady(p) && !podReady(*pod)) {
+			pod = &pods.Items[i]
+		}
+	}
+	if pod == nil {
+		return "", nil, errors.Errorf("no running pods found for service %s", service)
+	}
+	remote, err := serviceTargetPorts(*svc, *pod, ports)
+	if err != nil {
+		return "", nil, err
+	}
+	return pod.Name, remote, nil
+}
+
+func podReady(p v1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// serviceTargetPorts maps service ports, requested by names or numbers, to container ports of a pod,
+// all service ports are mapped by names, or numbers if unnamed, when none are requested
+func serviceTargetPorts(svc v1.Service, pod v1.Pod, ports []string) (map[string]uint16, error) {
+	requested := make(map[string]bool, len(ports))
+	for _, p := range ports {
+		requested[p] = true
+	}
+	remote := make(map[string]uint16)
+	for _, sp := range svc.Spec.Ports {
+		number := strconv.Itoa(int(sp.Port))
+		key := sp.Name
+		switch {
+		case len(ports) == 0 && key == "":
+			key = number
+		case len(ports) == 0:
+		case requested[sp.Name] && sp.Name != "":
+		case requested[number]:
+			key = number
+		default:
+			continue
+		}
+		target, err := containerPort(pod, sp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "service %s port %s", svc.Name, key)
+		}
+		remote[key] = target
+	}
+	for _, p := range ports {
+		if _, ok := remote[p]; !ok {
+			return nil, errors.Errorf("service %s has no port %s", svc.Name, p)
+		}
+	}
+	return remote, nil
+}
+
+// containerPort resolves a service target port, a named target port is looked up in the pod containers
+func containerPort(pod v1.Pod, sp v1.ServicePort) (uint16, error) {
+	if sp.TargetPort.Type == intstr.Int {
+		if sp.TargetPort.IntVal == 0 {
+			return uint16(sp.Port), nil
+		}
+		return uint16(sp.TargetPort.IntVal), nil
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			if cp.Name == sp.TargetPort.StrVal {
+				return uint16(cp.ContainerPort), nil
+			}
+		}
+	}
+	return 0, errors.Errorf("target port %s not found in pod %s", sp.TargetPort.StrVal, pod.Name)
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestServiceTargetPorts(t *testing.T) {
+	svc := v1.Service{
+		ObjectMeta: metaV1.ObjectMeta{Name: "geth"},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{
+			{Name: "http-rpc", Port: 8544, TargetPort: intstr.FromString("http-rpc")},
+			{Name: "ws-rpc", Port: 8546, TargetPort: intstr.FromInt(8546)},
+			{Port: 30303},
+		}},
+	}
+	pod := v1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: "geth-0"},
+		Spec: v1.PodSpec{Containers: []v1.Container{
+			{Name: "geth", Ports: []v1.ContainerPort{{Name: "http-rpc", ContainerPort: 8545}}},
+		}},
+	}
+	t.Run("all ports", func(t *testing.T) {
+		ports, err := serviceTargetPorts(svc, pod, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]uint16{"http-rpc": 8545, "ws-rpc": 8546, "30303": 30303}, ports)
+	})
+	t.Run("requested by names and numbers", func(t *testing.T) {
+		ports, err := serviceTargetPorts(svc, pod, []string{"http-rpc", "8546"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]uint16{"http-rpc": 8545, "8546": 8546}, ports)
+	})
+	t.Run("unknown port", func(t *testing.T) {
+		_, err := serviceTargetPorts(svc, pod, []string{"grpc"})
+		require.Error(t, err)
+	})
+	t.Run("unknown target port", func(t *testing.T) {
+		_, err := serviceTargetPorts(svc, v1.Pod{}, []string{"http-rpc"})
+		require.Error(t, err)
+	})
+}
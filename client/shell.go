@@ -0,0 +1,83 @@
+package client
+
+import (
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// DefaultShellCommand starts bash if the container has it, sh otherwise
+var DefaultShellCommand = []string{"sh", "-c", "command -v bash >/dev/null && exec bash || exec sh"}
+
+// terminalSize sends the local terminal size once, so the remote shell wraps lines correctly
+type terminalSize struct {
+	sent bool
+}
+
+func (t *terminalSize) Next() *remotecommand.TerminalSize {
+	if t.sent {
+		return nil
+	}
+	t.sent = true
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return nil
+	}
+	return &remotecommand.TerminalSize{Width: uint16(w), Height: uint16(h)}
+}
+
+// ExecInteractive is similar to kubectl exec -it, stdin, stdout and stderr are attached to the process,
+// a TTY is allocated and the local terminal is switched to raw mode if stdin is a terminal
+func (m *K8sClient) ExecInteractive(namespace, podName, containerName string, command []string) error {
+	if len(command) == 0 {
+		command = DefaultShellCommand
+	}
+	fd := int(os.Stdin.Fd())
+	tty := term.IsTerminal(fd)
+	log.Info().
+		Str("Pod", podName).
+		Str("Container", containerName).
+		Interface("Command", command).
+		Bool("TTY", tty).
+		Msg("Opening interactive session in pod")
+	req := m.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec")
+	req.VersionedParams(&v1.PodExecOptions{
+		Container: containerName,
+		Command:   command,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    !tty,
+		TTY:       tty,
+	}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(m.RESTConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	opts := remotecommand.StreamOptions{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Tty:    tty,
+	}
+	if tty {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = term.Restore(fd, state)
+		}()
+		opts.TerminalSizeQueue = &terminalSize{}
+	} else {
+		opts.Stderr = os.Stderr
+	}
+	return exec.Stream(opts)
+}
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// SSHConfig is a bastion host config to access pods through SSH tunnels instead of K8s port forwarding,
+// the bastion must be able to reach pod IPs
+type SSHConfig struct {
+	// Address of the bastion, host:port
+	Address string
+	User    string
+	// KeyFile is a private key path, SSH agent from SSH_AUTH_SOCK is used if empty
+	KeyFile string
+	// KnownHostsFile is used to verify the bastion host key
+	KnownHostsFile string
+	// InsecureIgnoreHostKey skips host key verification if KnownHostsFile is not set
+	InsecureIgnoreHostKey bool
+}
+
+// clientConfig returns the SSH client config and a connection to the SSH agent if it's used for auth, nil otherwise,
+// the agent is only needed during the handshake, so it's closed by the caller right after dialing
+func (c *SSHConfig) clientConfig() (*ssh.ClientConfig, net.Conn, error) {
+	auth := make([]ssh.AuthMethod, 0)
+	var agentConn net.Conn
+	if c.KeyFile != "" {
+		key, err := os.ReadFile(c.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to parse SSH key")
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	} else if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to connect to SSH agent")
+		}
+		agentConn = conn
+		auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	} else {
+		return nil, nil, errors.New("no SSH key file and no SSH agent")
+	}
+	closeAgent := func() {
+		if agentConn != nil {
+			_ = agentConn.Close()
+		}
+	}
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case c.KnownHostsFile != "":
+		cb, err := knownhosts.New(c.KnownHostsFile)
+		if err != nil {
+			closeAgent()
+			return nil, nil, err
+		}
+		hostKeyCallback = cb
+	case c.InsecureIgnoreHostKey:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		closeAgent()
+		return nil, nil, errors.New("set KnownHostsFile or InsecureIgnoreHostKey to verify the bastion host key")
+	}
+	return &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	}, agentConn, nil
+}
+
+// sshTunnel is a shared SSH connection to the bastion, it's dropped when the connection is lost,
+// so the next tunneled connection dials the bastion again
+type sshTunnel struct {
+	cfg    *SSHConfig
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func (t *sshTunnel) connect() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+	cc, agentConn, err := t.cfg.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	c, err := ssh.Dial("tcp", t.cfg.Address, cc)
+	if agentConn != nil {
+		_ = agentConn.Close()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to bastion %s", t.cfg.Address)
+	}
+	log.Info().Str("Bastion", t.cfg.Address).Msg("Connected to SSH bastion")
+	t.client = c
+	go func() {
+		err := c.Wait()
+		if t.drop(c) {
+			log.Warn().Err(err).Str("Bastion", t.cfg.Address).Msg("SSH bastion connection is lost")
+		}
+	}()
+	return c, nil
+}
+
+// drop closes the connection and forgets it if it's still the current one, true if it was
+func (t *sshTunnel) drop(c *ssh.Client) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_ = c.Close()
+	if t.client != c {
+		return false
+	}
+	t.client = nil
+	return true
+}
+
+// dial connects to the target through the bastion, if dialing fails because the bastion connection is closed,
+// which a keepalive request tells, the bastion is dialed again once
+func (t *sshTunnel) dial(target string) (net.Conn, error) {
+	c, err := t.connect()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.Dial("tcp", target)
+	if err == nil {
+		return conn, nil
+	}
+	if _, _, kaErr := c.SendRequest("keepalive@openssh.com", true, nil); kaErr == nil {
+		return nil, err
+	}
+	t.drop(c)
+	if c, err = t.connect(); err != nil {
+		return nil, err
+	}
+	return c.Dial("tcp", target)
+}
+
+// close closes the bastion connection, the next tunneled connection dials it again
+func (t *sshTunnel) close() {
+	t.mu.Lock()
+	c := t.client
+	t.mu.Unlock()
+	if c != nil {
+		t.drop(c)
+	}
+}
+
+// tunnelPodPorts listens on local ports and tunnels connections to the pod ports through the bastion
+func (m *Forwarder) tunnelPodPorts(pod v1.Pod) error {
+	if pod.Status.Phase != v1.PodRunning {
+		log.Debug().Str("Pod", pod.Name).Interface("Phase", pod.Status.Phase).Msg("Skipping pod")
+		return nil
+	}
+	if _, err := m.ssh.connect(); err != nil {
+		return err
+	}
+	forwardedPorts := make([]portforward.ForwardedPort, 0)
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			remote := uint16(cp.ContainerPort)
+			local := ""
+			if m.PreferRemotePorts && m.reservePort(remote) {
+				local = fmt.Sprintf("%d", remote)
+			}
+			l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%s", local))
+			if err != nil {
+				return err
+			}
+			m.addCloser(func() {
+				_ = l.Close()
+			})
+			target := net.JoinHostPort(pod.Status.PodIP, fmt.Sprintf("%d", remote))
+			go acceptTunnel(m.ssh, l, target)
+			forwardedPorts = append(forwardedPorts, portforward.ForwardedPort{
+				Local:  uint16(l.Addr().(*net.TCPAddr).Port),
+				Remote: remote,
+			})
+		}
+	}
+	namedPorts := m.podPortsByName(pod, forwardedPorts)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Info[fmt.Sprintf("%s:%s", pod.Labels["app"], pod.Labels["instance"])] = namedPorts
+	return nil
+}
+
+func acceptTunnel(t *sshTunnel, l net.Listener, target string) {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Str("Target", target).Msg("Tunnel listener stopped")
+			return
+		}
+		go func() {
+			defer conn.Close()
+			remote, err := t.dial(target)
+			if err != nil {
+				log.Error().Err(err).Str("Target", target).Msg("Failed to dial through bastion")
+				return
+			}
+			defer remote.Close()
+			done := make(chan struct{}, 2)
+			go func() {
+				_, _ = io.Copy(remote, conn)
+				done <- struct{}{}
+			}()
+			go func() {
+				_, _ = io.Copy(conn, remote)
+				done <- struct{}{}
+			}()
+			<-done
+		}()
+	}
+}
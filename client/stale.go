@@ -0,0 +1,94 @@
+package client
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// HeartbeatAnnotationKey namespace annotation with the last heartbeat unix time of the test process
+	HeartbeatAnnotationKey = "chainlink-env/heartbeat"
+	// DefaultStaleHeartbeatAge heartbeats are refreshed every few minutes, an older one means the process is gone
+	DefaultStaleHeartbeatAge = 30 * time.Minute
+)
+
+// StaleEnvironment is an environment whose process stopped refreshing the heartbeat, a candidate for cleanup
+type StaleEnvironment struct {
+	Namespace string
+	Heartbeat time.Time
+	// Silence is time since the last heartbeat
+	Silence time.Duration
+	Created time.Time
+}
+
+// StaleNamespaces returns environments with heartbeats older than maxAge, DefaultStaleHeartbeatAge if 0, most silent first,
+// environments without heartbeats, in maintenance or being removed are skipped, so active soaks are never flagged
+func (m *K8sClient) StaleNamespaces(maxAge time.Duration) ([]StaleEnvironment, error) {
+	if maxAge == 0 {
+		maxAge = DefaultStaleHeartbeatAge
+	}
+	nsList, err := m.ListNamespaces("")
+	if err != nil {
+		return nil, err
+	}
+	now := m.Clock().Now()
+	stale := make([]StaleEnvironment, 0)
+	for _, ns := range nsList.Items {
+		if s, ok := heartbeatStale(ns, now, maxAge); ok {
+			stale = append(stale, s)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].Silence > stale[j].Silence
+	})
+	return stale, nil
+}
+
+// CleanupStaleNamespaces removes environments with heartbeats older than maxAge and returns their names, see StaleNamespaces
+func (m *K8sClient) CleanupStaleNamespaces(maxAge time.Duration) ([]string, error) {
+	stale, err := m.StaleNamespaces(maxAge)
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]string, 0)
+	for _, s := range stale {
+		log.Info().
+			Str("Namespace", s.Namespace).
+			Time("Heartbeat", s.Heartbeat).
+			Dur("Silence", s.Silence.Round(time.Minute)).
+			Msg("Environment heartbeat is stale")
+		if err := m.RemoveNamespace(s.Namespace); err != nil {
+			return removed, err
+		}
+		removed = append(removed, s.Namespace)
+	}
+	return removed, nil
+}
+
+// heartbeatStale returns a stale environment if the namespace heartbeat is older than maxAge
+func heartbeatStale(ns v1.Namespace, now time.Time, maxAge time.Duration) (StaleEnvironment, bool) {
+	if ns.DeletionTimestamp != nil || ns.Status.Phase == v1.NamespaceTerminating {
+		return StaleEnvironment{}, false
+	}
+	hb, err := strconv.ParseInt(ns.Annotations[HeartbeatAnnotationKey], 10, 64)
+	if err != nil {
+		return StaleEnvironment{}, false
+	}
+	if mt, err := parseMaintenance(ns.Annotations, now); err != nil || mt != nil {
+		return StaleEnvironment{}, false
+	}
+	last := time.Unix(hb, 0)
+	if now.Sub(last) <= maxAge {
+		return StaleEnvironment{}, false
+	}
+	return StaleEnvironment{
+		Namespace: ns.Name,
+		Heartbeat: last,
+		Silence:   now.Sub(last),
+		Created:   ns.CreationTimestamp.Time,
+	}, true
+}
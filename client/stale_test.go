@@ -0,0 +1,34 @@
+package client
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHeartbeatStale(t *testing.T) {
+	now := time.Unix(time.Now().Unix(), 0)
+	ns := func(silence time.Duration) v1.Namespace {
+		return v1.Namespace{ObjectMeta: metaV1.ObjectMeta{
+			Name:        "chainlink-test",
+			Annotations: map[string]string{HeartbeatAnnotationKey: strconv.FormatInt(now.Add(-silence).Unix(), 10)},
+		}}
+	}
+	s, ok := heartbeatStale(ns(2*time.Hour), now, time.Hour)
+	require.True(t, ok)
+	require.Equal(t, "chainlink-test", s.Namespace)
+	require.Equal(t, 2*time.Hour, s.Silence)
+	_, ok = heartbeatStale(ns(time.Minute), now, time.Hour)
+	require.False(t, ok, "active environment is not stale")
+	_, ok = heartbeatStale(v1.Namespace{}, now, time.Hour)
+	require.False(t, ok, "environment without a heartbeat is not flagged")
+
+	inMaintenance := ns(2 * time.Hour)
+	inMaintenance.Annotations[MaintenanceAnnotationKey] = strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+	_, ok = heartbeatStale(inMaintenance, now, time.Hour)
+	require.False(t, ok)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WaitStatefulSetsReady waits for pods of stateful sets matching the selector ordinal by ordinal,
+// with OrderedReady pod management the next pod is created only when the previous one is ready,
+// e.g. a Postgres primary before replicas, so pods that exist at the moment are not all pods of a stateful set
+func (m *K8sClient) WaitStatefulSetsReady(ns string, rcd *ReadyCheckData) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rcd.Timeout)
+	defer cancel()
+	sets, err := m.ClientSet.AppsV1().StatefulSets(ns).List(ctx, metaV1.ListOptions{LabelSelector: rcd.ReadinessProbeCheckSelector})
+	if err != nil {
+		return err
+	}
+	sort.Slice(sets.Items, func(i, j int) bool {
+		return sets.Items[i].Name < sets.Items[j].Name
+	})
+	for _, s := range sets.Items {
+		pods := ordinalPods(s)
+		for i, pod := range pods {
+			log.Info().
+				Str("StatefulSet", s.Name).
+				Str("Pod", pod).
+				Str("Ordinal", fmt.Sprintf("%d/%d", i+1, len(pods))).
+				Msg("Waiting for stateful set pod readiness")
+			err := m.waitPodsUntil(ns, fmt.Sprintf("%s=%s", appsV1.StatefulSetPodNameLabel, pod), ctx.Done(), func(pods []v1.Pod) (bool, error) {
+				for _, p := range pods {
+					if p.Name == pod {
+						return p.DeletionTimestamp == nil && podReady(p), nil
+					}
+				}
+				return false, nil
+			})
+			if err != nil {
+				return errors.Wrapf(err, "pod %s of stateful set %s is not ready, %d/%d pods are ready", pod, s.Name, i, len(pods))
+			}
+		}
+	}
+	return nil
+}
+
+// ordinalPods returns names of stateful set pods in order of their startup
+func ordinalPods(s appsV1.StatefulSet) []string {
+	replicas := 1
+	if s.Spec.Replicas != nil {
+		replicas = int(*s.Spec.Replicas)
+	}
+	pods := make([]string, 0, replicas)
+	for i := 0; i < replicas; i++ {
+		pods = append(pods, fmt.Sprintf("%s-%d", s.Name, i))
+	}
+	return pods
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOrdinalPods(t *testing.T) {
+	replicas := int32(3)
+	s := appsV1.StatefulSet{
+		ObjectMeta: metaV1.ObjectMeta{Name: "postgres"},
+		Spec:       appsV1.StatefulSetSpec{Replicas: &replicas},
+	}
+	require.Equal(t, []string{"postgres-0", "postgres-1", "postgres-2"}, ordinalPods(s))
+	s.Spec.Replicas = nil
+	require.Equal(t, []string{"postgres-0"}, ordinalPods(s))
+	replicas = 0
+	s.Spec.Replicas = &replicas
+	require.Empty(t, ordinalPods(s))
+}
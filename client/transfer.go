@@ -0,0 +1,625 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	// TransferChunkSize is a size of a file part sent in one exec stream, a broken transfer is resumed from the last complete part
+	TransferChunkSize = 64 << 20
+	// TransferRetries how many times a part is retried before the transfer fails
+	TransferRetries = 5
+	// transferPartSuffix files are written under this suffix until their checksums are verified,
+	// a partially transferred file is resumed by the next transfer to the same destination
+	transferPartSuffix = ".part"
+)
+
+// podContainer is a container files are transferred to or from
+type podContainer struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
+// UploadToPod copies a local file or directory into a container, parts are gzip compressed, a broken stream is resumed from the last
+// complete part, also by the next call after a failure, and the checksum is verified, so big files are never silently truncated,
+// the container needs sh, gzip, tar and sha256sum, e.g. coreutils or busybox
+func (m *K8sClient) UploadToPod(namespace, pod, container, src, dst string) error {
+	p := podContainer{Namespace: namespace, Pod: pod, Container: container}
+	st, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !st.IsDir() {
+		return m.uploadFile(p, src, dst)
+	}
+	// directories are sent as tar archives, archives of the same files are the same, so they can be resumed too
+	archive, err := os.CreateTemp("", "upload-*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	err = tarDir(src, archive)
+	if cerr := archive.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to archive %s", src)
+	}
+	remoteArchive := dst + ".tar"
+	if err := m.uploadFile(p, archive.Name(), remoteArchive); err != nil {
+		return err
+	}
+	_, err = m.shell(p, fmt.Sprintf("mkdir -p %s && tar -xf %s -C %s && rm -f %s",
+		shellQuote(dst), shellQuote(remoteArchive), shellQuote(dst), shellQuote(remoteArchive)))
+	return err
+}
+
+// DownloadFromPod copies a file or directory from a container, see UploadToPod
+func (m *K8sClient) DownloadFromPod(namespace, pod, container, src, dst string) error {
+	p := podContainer{Namespace: namespace, Pod: pod, Container: container}
+	kind, err := m.shell(p, fmt.Sprintf("if [ -d %[1]s ]; then echo dir; elif [ -f %[1]s ]; then echo file; fi", shellQuote(src)))
+	if err != nil {
+		return err
+	}
+	if kind == "file" {
+		return m.downloadFile(p, src, dst)
+	}
+	if kind != "dir" {
+		return errors.Errorf("%s not found in %s/%s", src, pod, container)
+	}
+	// the remote archive is kept until it's downloaded, so a broken download is resumed from the same archive
+	sum := sha256.Sum256([]byte(src))
+	remoteArchive := fmt.Sprintf("/tmp/chainlink-env-%s.tar", hex.EncodeToString(sum[:])[:12])
+	if _, err := m.shell(p, fmt.Sprintf("[ -f %[1]s ] || (tar -cf %[1]s.tmp -C %[2]s . && mv %[1]s.tmp %[1]s)",
+		shellQuote(remoteArchive), shellQuote(src))); err != nil {
+		return err
+	}
+	archive := dst + ".tar"
+	if err := m.downloadFile(p, remoteArchive, archive); err != nil {
+		return err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	err = untar(f, dst)
+	_ = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to extract %s", archive)
+	}
+	if err := os.Remove(archive); err != nil {
+		return err
+	}
+	_, err = m.shell(p, "rm -f "+shellQuote(remoteArchive))
+	return err
+}
+
+// CopyFromPod copies a file or directory from a container, it's a counterpart of CopyToPod streaming a tar archive, source should be
+// in the form of POD_NAME:path or NAMESPACE/POD_NAME:path, a directory is merged into localDst, use DownloadFromPod for big files
+func (m *K8sClient) CopyFromPod(namespace, podColonSrc, localDst, container string) error {
+	pod, src, err := parsePodPath(podColonSrc)
+	if err != nil {
+		return err
+	}
+	p := podContainer{Namespace: namespace, Pod: pod, Container: container}
+	log.Info().
+		Str("Namespace", namespace).
+		Str("Source", podColonSrc).
+		Str("Destination", localDst).
+		Str("Container", container).
+		Msg("Downloading file from pod")
+	kind, err := m.shell(p, fmt.Sprintf("if [ -d %[1]s ]; then echo dir; elif [ -f %[1]s ]; then echo file; fi", shellQuote(src)))
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case "dir":
+		return m.untarFromPod(p, []string{"tar", "-cf", "-", "-C", src, "."}, localDst)
+	case "file":
+		// the file is extracted next to the destination and renamed, so a broken stream doesn't leave a truncated file
+		if err := os.MkdirAll(filepath.Dir(localDst), os.ModePerm); err != nil {
+			return err
+		}
+		tmp, err := os.MkdirTemp(filepath.Dir(localDst), ".copy-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		dir, file := path.Split(src)
+		if dir == "" {
+			dir = "."
+		}
+		if err := m.untarFromPod(p, []string{"tar", "-cf", "-", "-C", dir, file}, tmp); err != nil {
+			return err
+		}
+		return os.Rename(filepath.Join(tmp, file), localDst)
+	default:
+		return errors.Errorf("%s not found in %s/%s", src, pod, container)
+	}
+}
+
+// untarFromPod streams a tar archive written by a command in a container into a directory
+func (m *K8sClient) untarFromPod(p podContainer, command []string, dir string) error {
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := m.execStream(p, command, nil, w)
+		_ = w.CloseWithError(err)
+		done <- err
+	}()
+	err := untar(r, dir)
+	// unblocks the stream if extraction failed before the end of the archive
+	_ = r.CloseWithError(errors.New("extraction stopped"))
+	execErr := <-done
+	if err != nil {
+		return errors.Wrapf(err, "failed to extract archive from %s/%s", p.Pod, p.Container)
+	}
+	return execErr
+}
+
+// parsePodPath parses POD_NAME:path or NAMESPACE/POD_NAME:path, the namespace is ignored
+func parsePodPath(podColonPath string) (string, string, error) {
+	pod, p, ok := strings.Cut(podColonPath, ":")
+	if _, name, found := strings.Cut(pod, "/"); found {
+		pod = name
+	}
+	if !ok || pod == "" || p == "" {
+		return "", "", errors.Errorf("source %s is improperly formatted, see reference 'POD_NAME:folder/FILE_NAME'", podColonPath)
+	}
+	return pod, path.Clean(p), nil
+}
+
+func (m *K8sClient) uploadFile(p podContainer, src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	total := st.Size()
+	sum, err := sha256Hex(io.NewSectionReader(f, 0, total))
+	if err != nil {
+		return err
+	}
+	part := dst + transferPartSuffix
+	size, err := m.remoteSize(p, part)
+	if err != nil {
+		return err
+	}
+	truncate := func(offset int64) error {
+		_, err := m.shell(p, fmt.Sprintf("mkdir -p %s && truncate -s %d %s", shellQuote(path.Dir(dst)), offset, shellQuote(part)))
+		return err
+	}
+	offset := resumeOffset(size, total)
+	if err := truncate(offset); err != nil {
+		return err
+	}
+	log.Info().
+		Str("Pod", p.Pod).
+		Str("Source", src).
+		Str("Destination", dst).
+		Str("Size", formatBytes(total)).
+		Str("Resumed", formatBytes(offset)).
+		Msg("Uploading file to pod")
+	err = transferChunks(src, offset, total,
+		func(offset, n int64) error {
+			return m.uploadChunk(p, f, part, offset, n)
+		},
+		truncate,
+	)
+	if err != nil {
+		return err
+	}
+	remoteSum, err := m.remoteSHA256(p, part)
+	if err != nil {
+		return err
+	}
+	if remoteSum != sum {
+		_, _ = m.shell(p, "rm -f "+shellQuote(part))
+		return errors.Errorf("checksum mismatch of uploaded %s: %s, expected %s", dst, remoteSum, sum)
+	}
+	_, err = m.shell(p, fmt.Sprintf("mv %s %s && chmod %o %s", shellQuote(part), shellQuote(dst), st.Mode().Perm(), shellQuote(dst)))
+	return err
+}
+
+// uploadChunk appends a gzip compressed part of a file to the remote part file
+func (m *K8sClient) uploadChunk(p podContainer, f *os.File, part string, offset, n int64) error {
+	pr, pw := io.Pipe()
+	go func() {
+		gz, err := gzip.NewWriterLevel(pw, gzip.BestSpeed)
+		if err == nil {
+			_, err = io.Copy(gz, io.NewSectionReader(f, offset, n))
+		}
+		if err == nil {
+			err = gz.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	err := m.execStream(p, []string{"sh", "-c", "gzip -dc >> " + shellQuote(part)}, pr, nil)
+	_ = pr.Close()
+	if err != nil {
+		return err
+	}
+	size, err := m.remoteSize(p, part)
+	if err != nil {
+		return err
+	}
+	if size != offset+n {
+		return errors.Errorf("%s has %d bytes, expected %d", part, size, offset+n)
+	}
+	return nil
+}
+
+func (m *K8sClient) downloadFile(p podContainer, src, dst string) error {
+	total, err := m.remoteSize(p, src)
+	if err != nil {
+		return err
+	}
+	sum, err := m.remoteSHA256(p, src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	part := dst + transferPartSuffix
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := resumeOffset(st.Size(), total)
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	log.Info().
+		Str("Pod", p.Pod).
+		Str("Source", src).
+		Str("Destination", dst).
+		Str("Size", formatBytes(total)).
+		Str("Resumed", formatBytes(offset)).
+		Msg("Downloading file from pod")
+	err = transferChunks(src, offset, total,
+		func(offset, n int64) error {
+			return m.downloadChunk(p, src, f, offset, n)
+		},
+		f.Truncate,
+	)
+	if err != nil {
+		return err
+	}
+	localSum, err := sha256Hex(io.NewSectionReader(f, 0, total))
+	if err != nil {
+		return err
+	}
+	if localSum != sum {
+		_ = os.Remove(part)
+		return errors.Errorf("checksum mismatch of downloaded %s: %s, expected %s", src, localSum, sum)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(part, dst)
+}
+
+// downloadChunk writes a gzip compressed part of a remote file at its offset of the local part file
+func (m *K8sClient) downloadChunk(p podContainer, src string, f *os.File, offset, n int64) error {
+	pr, pw := io.Pipe()
+	execErr := make(chan error, 1)
+	go func() {
+		err := m.execStream(p, []string{"sh", "-c", fmt.Sprintf("tail -c +%d %s | head -c %d | gzip -1 -c", offset+1, shellQuote(src), n)}, nil, pw)
+		_ = pw.CloseWithError(err)
+		execErr <- err
+	}()
+	written, err := copyGzip(f, pr, offset)
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+	if eerr := <-execErr; eerr != nil {
+		return eerr
+	}
+	if err != nil {
+		return err
+	}
+	if written != n {
+		return errors.Errorf("received %d bytes of %s, expected %d", written, src, n)
+	}
+	return nil
+}
+
+// copyGzip decompresses r into f at offset
+func copyGzip(f *os.File, r io.Reader, offset int64) (int64, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(f, gz)
+}
+
+// transferChunks sends a file part by part from offset, a failed part is rewound and retried up to TransferRetries times
+func transferChunks(name string, offset, total int64, send func(offset, n int64) error, rewind func(offset int64) error) error {
+	for offset < total {
+		n := total - offset
+		if n > TransferChunkSize {
+			n = TransferChunkSize
+		}
+		var err error
+		for attempt := 0; attempt <= TransferRetries; attempt++ {
+			if attempt > 0 {
+				log.Warn().Err(err).Str("File", name).Int("Attempt", attempt).Msg("Transfer is broken, resuming")
+				if err = rewind(offset); err != nil {
+					continue
+				}
+			}
+			if err = send(offset, n); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "transfer of %s failed at %s", name, transferProgress(offset, total))
+		}
+		offset += n
+		log.Info().Str("File", name).Str("Progress", transferProgress(offset, total)).Msg("Transferring")
+	}
+	return nil
+}
+
+// resumeOffset returns the offset of the last complete part of a partially transferred file, 0 if it's not a part of the file
+func resumeOffset(size int64, total int64) int64 {
+	if size > total {
+		return 0
+	}
+	return size - size%TransferChunkSize
+}
+
+func transferProgress(done int64, total int64) string {
+	percent := int64(100)
+	if total > 0 {
+		percent = done * 100 / total
+	}
+	return fmt.Sprintf("%s/%s (%d%%)", formatBytes(done), formatBytes(total), percent)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func sha256Hex(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// remoteSize returns a size of a remote file, 0 if it doesn't exist
+func (m *K8sClient) remoteSize(p podContainer, file string) (int64, error) {
+	out, err := m.shell(p, fmt.Sprintf("if [ -f %[1]s ]; then wc -c < %[1]s; else echo 0; fi", shellQuote(file)))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(out, 10, 64)
+}
+
+func (m *K8sClient) remoteSHA256(p podContainer, file string) (string, error) {
+	out, err := m.shell(p, "sha256sum "+shellQuote(file))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", errors.Errorf("no checksum of %s", file)
+	}
+	return fields[0], nil
+}
+
+// shell runs a shell command in a container and returns its trimmed output
+func (m *K8sClient) shell(p podContainer, command string) (string, error) {
+	var out bytes.Buffer
+	err := m.execStream(p, []string{"sh", "-c", command}, nil, &out)
+	return strings.TrimSpace(out.String()), err
+}
+
+// execStream runs a command in a container with stdin and stdout attached, stderr is added to the error
+func (m *K8sClient) execStream(p podContainer, command []string, stdin io.Reader, stdout io.Writer) error {
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	req := m.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(p.Pod).
+		Namespace(p.Namespace).
+		SubResource("exec")
+	req.VersionedParams(&v1.PodExecOptions{
+		Container: p.Container,
+		Command:   command,
+		Stdin:     stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+	}, scheme.ParameterCodec)
+	exec, err := remotecommand.NewSPDYExecutor(m.RESTConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	err = exec.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "command %s failed: %s", strings.Join(command, " "), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// shellQuote quotes a string for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// tarDir writes files of a directory into a tar archive, paths are relative to the directory
+func tarDir(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// untar extracts a tar archive into a directory, entries and links leading outside of it are rejected,
+// entries are never written through links extracted before, so chained links can't lead outside either
+func untar(r io.Reader, dir string) error {
+	dir = filepath.Clean(dir)
+	inside := func(p string) bool {
+		return p == dir || strings.HasPrefix(p, dir+string(os.PathSeparator))
+	}
+	// linked returns true if p or any of its parents inside dir is a symlink
+	linked := func(p string) bool {
+		for ; p != dir && inside(p); p = filepath.Dir(p) {
+			if fi, err := os.Lstat(p); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+				return true
+			}
+		}
+		return false
+	}
+	// linkInside resolves the link target lexically, ".." is resolved only in paths without links
+	linkInside := func(target string, linkname string) bool {
+		if filepath.IsAbs(linkname) {
+			return false
+		}
+		p := filepath.Dir(target)
+		for _, part := range strings.Split(filepath.ToSlash(linkname), "/") {
+			if part == ".." {
+				if linked(p) {
+					return false
+				}
+				p = filepath.Dir(p)
+			} else if part != "" && part != "." {
+				p = filepath.Join(p, part)
+			}
+			if !inside(p) {
+				return false
+			}
+		}
+		return true
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !inside(target) || linked(target) {
+			return errors.Errorf("archive entry %s is outside of %s", hdr.Name, dir)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if !linkInside(target, hdr.Linkname) {
+				return errors.Errorf("archive link %s -> %s is outside of %s", hdr.Name, hdr.Linkname, dir)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			log.Debug().Str("Entry", hdr.Name).Msg("Skipping unsupported archive entry")
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumeOffset(t *testing.T) {
+	require.Equal(t, int64(0), resumeOffset(0, 3*TransferChunkSize))
+	require.Equal(t, int64(TransferChunkSize), resumeOffset(TransferChunkSize+100, 3*TransferChunkSize))
+	require.Equal(t, int64(0), resumeOffset(4*TransferChunkSize, 3*TransferChunkSize), "part of another file")
+	require.Equal(t, "1.0MiB/4.0MiB (25%)", transferProgress(1<<20, 4<<20))
+	require.Equal(t, "0B/0B (100%)", transferProgress(0, 0))
+}
+
+func TestTransferChunks(t *testing.T) {
+	total := int64(2*TransferChunkSize + 10)
+	sent := make([]int64, 0)
+	rewound := make([]int64, 0)
+	failed := false
+	err := transferChunks("data", 0, total,
+		func(offset, n int64) error {
+			if offset == TransferChunkSize && !failed {
+				failed = true
+				return errors.New("stream reset")
+			}
+			sent = append(sent, n)
+			return nil
+		},
+		func(offset int64) error {
+			rewound = append(rewound, offset)
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []int64{TransferChunkSize, TransferChunkSize, 10}, sent)
+	require.Equal(t, []int64{TransferChunkSize}, rewound)
+
+	err = transferChunks("data", 0, total,
+		func(offset, n int64) error { return errors.New("stream reset") },
+		func(offset int64) error { return nil },
+	)
+	require.ErrorContains(t, err, "transfer of data failed at 0B/128.0MiB (0%)")
+}
+
+func TestTarDir(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "geth", "chaindata"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "geth", "chaindata", "000001.log"), []byte("blocks"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Join("geth", "chaindata"), filepath.Join(src, "data")))
+	var archive bytes.Buffer
+	require.NoError(t, tarDir(src, &archive))
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, untar(bytes.NewReader(archive.Bytes()), dst))
+	data, err := os.ReadFile(filepath.Join(dst, "data", "000001.log"))
+	require.NoError(t, err)
+	require.Equal(t, "blocks", string(data))
+	st, err := os.Stat(filepath.Join(dst, "geth", "chaindata", "000001.log"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), st.Mode().Perm())
+}
+
+func TestUntarOutside(t *testing.T) {
+	for _, hdr := range []*tar.Header{
+		{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"},
+		{Name: "abs", Typeflag: tar.TypeSymlink, Linkname: "/etc"},
+	} {
+		var archive bytes.Buffer
+		tw := tar.NewWriter(&archive)
+		require.NoError(t, tw.WriteHeader(hdr))
+		require.NoError(t, tw.Close())
+		err := untar(&archive, t.TempDir())
+		require.ErrorContains(t, err, "outside", hdr.Name)
+	}
+
+	chains := [][]*tar.Header{
+		{
+			{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "a/b", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "a/b/evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		},
+		{
+			{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "b", Typeflag: tar.TypeSymlink, Linkname: "a/.."},
+		},
+	}
+	for _, chain := range chains {
+		root := t.TempDir()
+		dir := filepath.Join(root, "dir")
+		var archive bytes.Buffer
+		tw := tar.NewWriter(&archive)
+		for _, hdr := range chain {
+			require.NoError(t, tw.WriteHeader(hdr))
+		}
+		require.NoError(t, tw.Close())
+		err := untar(&archive, dir)
+		require.ErrorContains(t, err, "outside", chain[len(chain)-1].Name)
+		_, err = os.Lstat(filepath.Join(root, "evil"))
+		require.True(t, os.IsNotExist(err), "nothing is written outside through chained links")
+	}
+}
+
+func TestParsePodPath(t *testing.T) {
+	pod, p, err := parsePodPath("postgres-0:/tmp/dump.sql")
+	require.NoError(t, err)
+	require.Equal(t, "postgres-0", pod)
+	require.Equal(t, "/tmp/dump.sql", p)
+	pod, p, err = parsePodPath("chainlink-test-env-abcde/chainlink-0:/home/chainlink/keys/")
+	require.NoError(t, err)
+	require.Equal(t, "chainlink-0", pod)
+	require.Equal(t, "/home/chainlink/keys", p)
+	_, _, err = parsePodPath("/tmp/dump.sql")
+	require.Error(t, err)
+	_, _, err = parsePodPath("chainlink-0:")
+	require.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// CleanupName is a name of the in-cluster cleanup CronJob and its RBAC objects
+	CleanupName  = "chainlink-env-cleanup"
+	CleanupImage = "bitnami/kubectl:1.24"
+	// DefaultCleanupSchedule runs the in-cluster cleanup every 15 minutes
+	DefaultCleanupSchedule = "*/15 * * * *"
+)
+
+// cleanupScript removes namespaces older than their chainlink-env/ttl label, same as CleanupExpiredNamespaces,
+// Go durations of TTL labels are converted to seconds with awk, e.g. "1h30m" is 5400
+const cleanupScript = `now=$(date +%s)
+kubectl get namespaces -l chainlink-env/ttl -o jsonpath='{range .items[*]}{.metadata.name}{" "}{.metadata.creationTimestamp}{" "}{.metadata.labels.chainlink-env/ttl}{" "}{.status.phase}{" "}{.metadata.annotations.chainlink-env/maintenance}{"\n"}{end}' |
+while read -r name created ttl phase maintenance; do
+  [ "$phase" = "Terminating" ] && continue
+  if [ -n "$maintenance" ] && [ "$maintenance" -gt "$now" ]; then
+    echo "Namespace $name is in maintenance, skipping"
+    continue
+  fi
+  seconds=$(echo "$ttl" | awk '{ s = 0; while (match($0, /^[0-9]+(h|m|s)/)) { u = substr($0, RLENGTH, 1); s += substr($0, 1, RLENGTH - 1) * (u == "h" ? 3600 : (u == "m" ? 60 : 1)); $0 = substr($0, RLENGTH + 1) } print s }')
+  [ "$seconds" -gt 0 ] || continue
+  age=$(( now - $(date -d "$created" +%s) ))
+  if [ "$age" -gt "$seconds" ]; then
+    echo "Removing namespace $name, age ${age}s, TTL $ttl"
+    kubectl delete namespace "$name" --wait=false
+  fi
+done
+`
+
+// ExpiredNamespaces returns namespaces living longer than their TTL label, namespaces in maintenance are skipped
+func (m *K8sClient) ExpiredNamespaces() ([]v1.Namespace, error) {
+	nsList, err := m.ListNamespaces(pkg.NamespaceTTLLabelKey)
+	if err != nil {
+		return nil, err
+	}
+	now := m.Clock().Now()
+	expired := make([]v1.Namespace, 0)
+	for _, ns := range nsList.Items {
+		if namespaceExpired(ns, now) {
+			expired = append(expired, ns)
+		}
+	}
+	return expired, nil
+}
+
+// CleanupExpiredNamespaces removes namespaces living longer than their TTL label and returns their names,
+// it's a fallback for clusters without kube-janitor and for namespaces leaked by crashed tests
+func (m *K8sClient) CleanupExpiredNamespaces() ([]string, error) {
+	expired, err := m.ExpiredNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]string, 0)
+	for _, ns := range expired {
+		log.Info().
+			Str("Namespace", ns.Name).
+			Str("TTL", ns.Labels[pkg.NamespaceTTLLabelKey]).
+			Dur("Age", m.Clock().Since(ns.CreationTimestamp.Time).Round(time.Minute)).
+			Msg("Namespace is expired")
+		if err := m.RemoveNamespace(ns.Name); err != nil {
+			return removed, err
+		}
+		removed = append(removed, ns.Name)
+	}
+	return removed, nil
+}
+
+// namespaceExpired true if the namespace is older than its TTL label, it's not in maintenance and not being removed already
+func namespaceExpired(ns v1.Namespace, now time.Time) bool {
+	if ns.DeletionTimestamp != nil || ns.Status.Phase == v1.NamespaceTerminating {
+		return false
+	}
+	ttl, err := time.ParseDuration(ns.Labels[pkg.NamespaceTTLLabelKey])
+	if err != nil || ttl <= 0 {
+		return false
+	}
+	if mt, err := parseMaintenance(ns.Annotations, now); err != nil || mt != nil {
+		return false
+	}
+	return now.Sub(ns.CreationTimestamp.Time) > ttl
+}
+
+// InstallCleanupCronJob installs a CronJob removing expired namespaces of the whole cluster into the namespace,
+// the schedule is in Cron format, DefaultCleanupSchedule if empty, an installed CronJob gets the new schedule
+func (m *K8sClient) InstallCleanupCronJob(namespace string, schedule string) error {
+	if schedule == "" {
+		schedule = DefaultCleanupSchedule
+	}
+	ctx := context.Background()
+	_, err := m.ClientSet.CoreV1().ServiceAccounts(namespace).Create(ctx, &v1.ServiceAccount{
+		ObjectMeta: metaV1.ObjectMeta{Name: CleanupName},
+	}, metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create cleanup service account")
+	}
+	_, err = m.ClientSet.RbacV1().ClusterRoles().Create(ctx, &rbacV1.ClusterRole{
+		ObjectMeta: metaV1.ObjectMeta{Name: CleanupName},
+		Rules: []rbacV1.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"namespaces"},
+			Verbs:     []string{"get", "list", "delete"},
+		}},
+	}, metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create cleanup cluster role")
+	}
+	_, err = m.ClientSet.RbacV1().ClusterRoleBindings().Create(ctx, &rbacV1.ClusterRoleBinding{
+		ObjectMeta: metaV1.ObjectMeta{Name: CleanupName},
+		Subjects: []rbacV1.Subject{{
+			Kind:      rbacV1.ServiceAccountKind,
+			Name:      CleanupName,
+			Namespace: namespace,
+		}},
+		RoleRef: rbacV1.RoleRef{
+			APIGroup: rbacV1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     CleanupName,
+		},
+	}, metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create cleanup cluster role binding")
+	}
+	if err := m.applyCronJob(namespace, cleanupCronJob(schedule)); err != nil {
+		return errors.Wrap(err, "failed to install cleanup cron job")
+	}
+	log.Info().Str("Namespace", namespace).Str("Schedule", schedule).Msg("Namespaces cleanup is installed")
+	return nil
+}
+
+func cleanupCronJob(schedule string) *batchV1.CronJob {
+	backoffLimit := int32(0)
+	return &batchV1.CronJob{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   CleanupName,
+			Labels: map[string]string{AppLabel: CleanupName},
+		},
+		Spec: batchV1.CronJobSpec{
+			Schedule:          schedule,
+			ConcurrencyPolicy: batchV1.ForbidConcurrent,
+			JobTemplate: batchV1.JobTemplateSpec{
+				Spec: batchV1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metaV1.ObjectMeta{
+							Labels: map[string]string{AppLabel: CleanupName},
+						},
+						Spec: v1.PodSpec{
+							ServiceAccountName: CleanupName,
+							RestartPolicy:      v1.RestartPolicyNever,
+							Containers: []v1.Container{
+								{
+									Name:    CleanupName,
+									Image:   CleanupImage,
+									Command: []string{"/bin/sh", "-c", cleanupScript},
+									Resources: v1.ResourceRequirements{
+										Requests: v1.ResourceList{
+											v1.ResourceCPU:    resource.MustParse("10m"),
+											v1.ResourceMemory: resource.MustParse("32Mi"),
+										},
+										Limits: v1.ResourceList{
+											v1.ResourceCPU:    resource.MustParse("100m"),
+											v1.ResourceMemory: resource.MustParse("64Mi"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// patchNamespaceLabels sets namespace labels, nil values remove them
+func (m *K8sClient) patchNamespaceLabels(namespace string, labels map[string]interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": labels,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.ClientSet.CoreV1().Namespaces().Patch(context.Background(), namespace, types.MergePatchType, patch, metaV1.PatchOptions{})
+	return errors.Wrapf(err, "failed to label namespace %s", namespace)
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceExpired(t *testing.T) {
+	now := time.Now()
+	ns := func(ttl string, age time.Duration) v1.Namespace {
+		return v1.Namespace{ObjectMeta: metaV1.ObjectMeta{
+			Name:              "chainlink-test",
+			Labels:            map[string]string{pkg.NamespaceTTLLabelKey: ttl},
+			Annotations:       map[string]string{},
+			CreationTimestamp: metaV1.NewTime(now.Add(-age)),
+		}}
+	}
+	require.True(t, namespaceExpired(ns("4h", 5*time.Hour), now))
+	require.False(t, namespaceExpired(ns("4h", 3*time.Hour), now))
+	require.True(t, namespaceExpired(ns("1h30m", 2*time.Hour), now))
+	require.False(t, namespaceExpired(ns("forever", 100*time.Hour), now), "unparsable TTL is never expired")
+
+	inMaintenance := ns("4h", 5*time.Hour)
+	inMaintenance.Annotations[MaintenanceAnnotationKey] = strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+	require.False(t, namespaceExpired(inMaintenance, now))
+
+	terminating := ns("4h", 5*time.Hour)
+	terminating.Status.Phase = v1.NamespaceTerminating
+	require.False(t, namespaceExpired(terminating, now))
+}
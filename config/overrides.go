@@ -47,6 +47,30 @@ const (
 	EnvVarSlackUser            = "SLACK_USER"
 	EnvVarSlackUserDescription = "The Slack code for the user you want to notify"
 	EnvVarSlackUserExample     = "U000000000"
+
+	EnvVarProxyURL            = "CHAINLINK_ENV_PROXY_URL"
+	EnvVarProxyURLDescription = "Proxy for K8s API and port forwarding, http, https and socks5 are supported, HTTPS_PROXY is used if not set"
+	EnvVarProxyURLExample     = "socks5://localhost:1080"
+
+	EnvVarUpdateGolden            = "CHAINLINK_ENV_UPDATE_GOLDEN"
+	EnvVarUpdateGoldenDescription = "Rewrite environment golden files with the current snapshots instead of comparing"
+	EnvVarUpdateGoldenExample     = "true"
+
+	EnvVarChartsCacheDir            = "CHAINLINK_ENV_CHARTS_CACHE_DIR"
+	EnvVarChartsCacheDirDescription = "Directory of charts downloaded from Helm repositories, user cache directory is used if not set"
+	EnvVarChartsCacheDirExample     = "/tmp/charts"
+
+	EnvVarRemoteRunnerPod            = "CHAINLINK_ENV_REMOTE_RUNNER_POD"
+	EnvVarRemoteRunnerPodDescription = "Set in the remote runner pod, the test connects to the namespace from the inside and doesn't start another runner"
+	EnvVarRemoteRunnerPodExample     = "true"
+
+	EnvVarSeed            = "CHAINLINK_ENV_SEED"
+	EnvVarSeedDescription = "Seed of generated environment values, e.g. of a failed run to reproduce it, random if not set"
+	EnvVarSeedExample     = "1666000000000000000"
+
+	EnvVarRawLogs            = "CHAINLINK_ENV_RAW_LOGS"
+	EnvVarRawLogsDescription = "Keep noisy log lines declared by charts in dumped and streamed logs"
+	EnvVarRawLogsExample     = "true"
 )
 
 func MustMerge(targetVars interface{}, codeVars interface{}) {
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Values layers names, layers are merged in that order, each next layer overrides the previous one
+const (
+	ValuesLayerDefaults = "defaults"
+	ValuesLayerProps    = "props"
+	ValuesLayerPreset   = "preset"
+	ValuesLayerUser     = "user"
+	ValuesLayerEnv      = "env"
+)
+
+// ValuesLayer is a named set of Helm values
+type ValuesLayer struct {
+	Name   string
+	Values map[string]interface{}
+}
+
+// ValuesConflict is a key that was set by more than one layer
+type ValuesConflict struct {
+	Key             string
+	Layer           string
+	Value           interface{}
+	OverriddenLayer string
+	OverriddenValue interface{}
+}
+
+// MergedValues is a result of values layers merge
+type MergedValues struct {
+	// Values final merged values
+	Values map[string]interface{}
+	// Sources layer name for every leaf key in a format of "a.b.c"
+	Sources map[string]string
+	// Conflicts all overridden keys in order of merging
+	Conflicts []ValuesConflict
+}
+
+// MergeValues deep merges values layers, nested maps are merged key by key,
+// any other values including lists are replaced by the later layer
+func MergeValues(layers ...ValuesLayer) *MergedValues {
+	m := &MergedValues{
+		Values:    make(map[string]interface{}),
+		Sources:   make(map[string]string),
+		Conflicts: make([]ValuesConflict, 0),
+	}
+	for _, l := range layers {
+		m.mergeMap(m.Values, l.Values, l.Name, "")
+	}
+	return m
+}
+
+func (m *MergedValues) mergeMap(dst map[string]interface{}, src map[string]interface{}, layer string, prefix string) {
+	for _, k := range sortedKeys(src) {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		srcVal := src[k]
+		srcMap, srcIsMap := asValuesMap(srcVal)
+		dstVal, exists := dst[k]
+		dstMap, dstIsMap := asValuesMap(dstVal)
+		switch {
+		case srcIsMap && exists && dstIsMap:
+			m.mergeMap(dstMap, srcMap, layer, key)
+			dst[k] = dstMap
+		case srcIsMap:
+			if exists {
+				m.overridden(key, layer, srcVal, dstVal)
+			}
+			nested := make(map[string]interface{})
+			m.mergeMap(nested, srcMap, layer, key)
+			dst[k] = nested
+		default:
+			if exists {
+				m.overridden(key, layer, srcVal, dstVal)
+			}
+			dst[k] = srcVal
+			m.Sources[key] = layer
+		}
+	}
+}
+
+// overridden records a conflict and removes sources of the overridden subtree
+func (m *MergedValues) overridden(key string, layer string, value interface{}, prev interface{}) {
+	prevLayer := m.Sources[key]
+	for _, k := range sortedKeys(m.Sources) {
+		if k == key || strings.HasPrefix(k, key+".") {
+			if prevLayer == "" {
+				prevLayer = m.Sources[k]
+			}
+			delete(m.Sources, k)
+		}
+	}
+	m.Conflicts = append(m.Conflicts, ValuesConflict{
+		Key:             key,
+		Layer:           layer,
+		Value:           value,
+		OverriddenLayer: prevLayer,
+		OverriddenValue: prev,
+	})
+}
+
+// Print prints every final key with its value and the layer it came from
+func (m *MergedValues) Print(name string) {
+	for _, k := range sortedKeys(m.Sources) {
+		log.Debug().
+			Str("Chart", name).
+			Str("Key", k).
+			Interface("Value", lookupValue(m.Values, k)).
+			Str("Layer", m.Sources[k]).
+			Msg("Merged value")
+	}
+	for _, c := range m.Conflicts {
+		log.Debug().
+			Str("Chart", name).
+			Str("Key", c.Key).
+			Interface("Value", c.Value).
+			Str("Layer", c.Layer).
+			Interface("OverriddenValue", c.OverriddenValue).
+			Str("OverriddenLayer", c.OverriddenLayer).
+			Msg("Value overridden")
+	}
+}
+
+// MustMergeValues merges values layers, prints the result in debug mode and returns final values
+func MustMergeValues(name string, layers ...ValuesLayer) map[string]interface{} {
+	m := MergeValues(layers...)
+	m.Print(name)
+	return m.Values
+}
+
+// EnvOverrideValues returns values layer built from environment variables
+func EnvOverrideValues() map[string]interface{} {
+	image := os.Getenv(EnvVarCLImage)
+	tag := os.Getenv(EnvVarCLTag)
+	if image == "" || tag == "" {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"chainlink": map[string]interface{}{
+			"image": map[string]interface{}{
+				"image":   image,
+				"version": tag,
+			},
+		},
+	}
+}
+
+func asValuesMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[string]string:
+		res := make(map[string]interface{})
+		for k, v := range m {
+			res[k] = v
+		}
+		return res, true
+	case *map[string]interface{}:
+		if m == nil {
+			return nil, false
+		}
+		return *m, true
+	}
+	return nil, false
+}
+
+func lookupValue(values map[string]interface{}, key string) interface{} {
+	var cur interface{} = values
+	for _, k := range strings.Split(key, ".") {
+		m, ok := asValuesMap(cur)
+		if !ok {
+			return nil
+		}
+		cur = m[k]
+	}
+	return cur
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String returns a human-readable conflict description
+func (c ValuesConflict) String() string {
+	return fmt.Sprintf("%s: %v (%s) -> %v (%s)", c.Key, c.OverriddenValue, c.OverriddenLayer, c.Value, c.Layer)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeValues(t *testing.T) {
+	defaults := map[string]interface{}{
+		"replicas": "1",
+		"chainlink": map[string]interface{}{
+			"image": map[string]interface{}{
+				"image":   "public.ecr.aws/chainlink/chainlink",
+				"version": "1.5.1-root",
+			},
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{
+					"cpu":    "350m",
+					"memory": "1024Mi",
+				},
+			},
+		},
+	}
+	t.Run("nested overrides keep sibling defaults", func(t *testing.T) {
+		m := MergeValues(
+			ValuesLayer{Name: ValuesLayerDefaults, Values: defaults},
+			ValuesLayer{Name: ValuesLayerUser, Values: map[string]interface{}{
+				"chainlink": map[string]interface{}{
+					"resources": map[string]interface{}{
+						"requests": map[string]interface{}{
+							"cpu": "1000m",
+						},
+					},
+				},
+			}},
+		)
+		require.Equal(t, "1000m", lookupValue(m.Values, "chainlink.resources.requests.cpu"))
+		require.Equal(t, "1024Mi", lookupValue(m.Values, "chainlink.resources.requests.memory"))
+		require.Equal(t, "1.5.1-root", lookupValue(m.Values, "chainlink.image.version"))
+		require.Equal(t, ValuesLayerUser, m.Sources["chainlink.resources.requests.cpu"])
+		require.Equal(t, ValuesLayerDefaults, m.Sources["chainlink.resources.requests.memory"])
+		require.Len(t, m.Conflicts, 1)
+		require.Equal(t, "chainlink.resources.requests.cpu", m.Conflicts[0].Key)
+		require.Equal(t, ValuesLayerDefaults, m.Conflicts[0].OverriddenLayer)
+	})
+	t.Run("layers are applied in order", func(t *testing.T) {
+		m := MergeValues(
+			ValuesLayer{Name: ValuesLayerDefaults, Values: defaults},
+			ValuesLayer{Name: ValuesLayerPreset, Values: map[string]interface{}{"replicas": 5}},
+			ValuesLayer{Name: ValuesLayerUser, Values: map[string]interface{}{"replicas": 3}},
+		)
+		require.Equal(t, 3, m.Values["replicas"])
+		require.Equal(t, ValuesLayerUser, m.Sources["replicas"])
+		require.Len(t, m.Conflicts, 2)
+	})
+	t.Run("scalar replaces a map", func(t *testing.T) {
+		m := MergeValues(
+			ValuesLayer{Name: ValuesLayerDefaults, Values: defaults},
+			ValuesLayer{Name: ValuesLayerUser, Values: map[string]interface{}{"chainlink": "none"}},
+		)
+		require.Equal(t, "none", m.Values["chainlink"])
+		require.Equal(t, ValuesLayerUser, m.Sources["chainlink"])
+		_, ok := m.Sources["chainlink.image.version"]
+		require.False(t, ok)
+	})
+	t.Run("inputs are not modified", func(t *testing.T) {
+		user := map[string]interface{}{
+			"chainlink": map[string]interface{}{
+				"image": map[string]interface{}{
+					"version": "2.0.0",
+				},
+			},
+		}
+		m := MergeValues(
+			ValuesLayer{Name: ValuesLayerDefaults, Values: defaults},
+			ValuesLayer{Name: ValuesLayerUser, Values: user},
+		)
+		require.Equal(t, "2.0.0", lookupValue(m.Values, "chainlink.image.version"))
+		require.Equal(t, "1.5.1-root", lookupValue(defaults, "chainlink.image.version"))
+		require.Nil(t, lookupValue(user, "chainlink.image.image"))
+	})
+}
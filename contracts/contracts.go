@@ -0,0 +1,266 @@
+package contracts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"k8s.io/utils/clock"
+)
+
+const (
+	DefaultNetwork        = "Simulated Geth"
+	DefaultReceiptTimeout = 2 * time.Minute
+	ReceiptPollInterval   = 1 * time.Second
+)
+
+// Address is an address constructor argument, e.g. of a contract deployed before
+type Address string
+
+// Contract is a contract to deploy from a compiled artifact
+type Contract struct {
+	Name string
+	// Artifact is a path of a compiled contract JSON, Hardhat, Truffle and Foundry artifacts are supported
+	Artifact string
+	// ArtifactFS is where Artifact is read from, e.g. embedded artifacts, the disk if nil
+	ArtifactFS fs.FS
+	// Bytecode is a hex creation code, used instead of Artifact if set
+	Bytecode string
+	// Args returns constructor arguments, addresses of contracts deployed before are available by names,
+	// static types are supported: Address, *big.Int, signed and unsigned integers, bool and [32]byte
+	Args func(deployed map[string]string) []interface{}
+}
+
+// Props deploy contracts with eth_sendTransaction from an account unlocked on the node, like in the simulated Geth,
+// so no keys and no go-ethereum dependencies are needed
+type Props struct {
+	// Network is a name of the network, its URLs are looked up by "${Network}_http", DefaultNetwork if empty
+	Network string
+	// From is a deployer account, the first account of the node if empty
+	From string
+	// Gas limit of deployments, estimated by the node if 0
+	Gas uint64
+	// ReceiptTimeout to wait for a deployment to be mined, DefaultReceiptTimeout if 0
+	ReceiptTimeout time.Duration
+	// Contracts are deployed in order
+	Contracts []Contract
+}
+
+// Deployer deploys contracts on the environment chain, see environment.Config.Bootstrap
+type Deployer struct {
+	Props *Props
+}
+
+// New creates a deployer
+func New(props *Props) *Deployer {
+	if props == nil {
+		props = &Props{}
+	}
+	if props.Network == "" {
+		props.Network = DefaultNetwork
+	}
+	if props.ReceiptTimeout == 0 {
+		props.ReceiptTimeout = DefaultReceiptTimeout
+	}
+	return &Deployer{Props: props}
+}
+
+// Bootstrap deploys contracts through the HTTP RPC of the network, the in-cluster one inside K8s
+func (m *Deployer) Bootstrap(e *environment.Environment) (map[string]string, error) {
+	key := m.Props.Network + "_http"
+	if e.Cfg.InsideK8s {
+		key = m.Props.Network + "_internal_http"
+	}
+	urls := e.URLs[key]
+	if len(urls) == 0 {
+		return nil, errors.Errorf("network %s has no HTTP RPC URL %s", m.Props.Network, key)
+	}
+	return m.deployAll(client.NewEVMClient(urls[0]), e.Client.Clock())
+}
+
+// Deploy deploys contracts in order and returns their addresses by names
+func (m *Deployer) Deploy(c *client.EVMClient) (map[string]string, error) {
+	return m.deployAll(c, clock.RealClock{})
+}
+
+// deployAll polls receipts on the clock, the environment client clock when bootstrapped
+func (m *Deployer) deployAll(c *client.EVMClient, clk clock.Clock) (map[string]string, error) {
+	from := m.Props.From
+	if from == "" {
+		accounts, err := c.Accounts()
+		if err != nil {
+			return nil, err
+		}
+		if len(accounts) == 0 {
+			return nil, errors.New("node has no accounts to deploy contracts from")
+		}
+		from = accounts[0]
+	}
+	deployed := make(map[string]string)
+	for _, contract := range m.Props.Contracts {
+		addr, err := m.deploy(c, clk, from, contract, deployed)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to deploy contract %s", contract.Name)
+		}
+		deployed[contract.Name] = addr
+		log.Info().Str("Contract", contract.Name).Str("Address", addr).Msg("Contract is deployed")
+	}
+	return deployed, nil
+}
+
+func (m *Deployer) deploy(c *client.EVMClient, clk clock.Clock, from string, contract Contract, deployed map[string]string) (string, error) {
+	bytecode := contract.Bytecode
+	if bytecode == "" {
+		var err error
+		bytecode, err = loadArtifact(contract.ArtifactFS, contract.Artifact)
+		if err != nil {
+			return "", err
+		}
+	}
+	var args []interface{}
+	if contract.Args != nil {
+		args = contract.Args(deployed)
+	}
+	encoded, err := EncodeArgs(args...)
+	if err != nil {
+		return "", err
+	}
+	hash, err := c.SendTransaction(from, "", "0x"+strings.TrimPrefix(bytecode, "0x")+hex.EncodeToString(encoded), m.Props.Gas)
+	if err != nil {
+		return "", err
+	}
+	var receipt *client.EVMReceipt
+	err = client.PollImmediate(clk, ReceiptPollInterval, m.Props.ReceiptTimeout, func() (bool, error) {
+		receipt, err = c.TransactionReceipt(hash)
+		return receipt != nil, err
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "transaction %s is not mined", hash)
+	}
+	if receipt.Status != "0x1" {
+		return "", errors.Errorf("transaction %s is reverted", hash)
+	}
+	return receipt.ContractAddress, nil
+}
+
+// LoadArtifact returns a creation code of a compiled contract, "bytecode" of Hardhat and Truffle artifacts,
+// or "bytecode.object" of Foundry artifacts
+func LoadArtifact(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return parseArtifact(data)
+}
+
+// loadArtifact is LoadArtifact reading from fsys if it's set
+func loadArtifact(fsys fs.FS, path string) (string, error) {
+	if fsys == nil {
+		return LoadArtifact(path)
+	}
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return "", err
+	}
+	return parseArtifact(data)
+}
+
+func parseArtifact(data []byte) (string, error) {
+	var artifact struct {
+		Bytecode json.RawMessage `json:"bytecode"`
+	}
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return "", errors.Wrap(err, "failed to parse contract artifact")
+	}
+	var bytecode string
+	if err := json.Unmarshal(artifact.Bytecode, &bytecode); err != nil {
+		var object struct {
+			Object string `json:"object"`
+		}
+		if err := json.Unmarshal(artifact.Bytecode, &object); err != nil {
+			return "", errors.New("contract artifact has no bytecode")
+		}
+		bytecode = object.Object
+	}
+	bytecode = strings.TrimPrefix(bytecode, "0x")
+	if bytecode == "" {
+		return "", errors.New("contract artifact has empty bytecode, abstract contracts can't be deployed")
+	}
+	if strings.Contains(bytecode, "__") {
+		return "", errors.New("contract artifact has unlinked libraries")
+	}
+	return bytecode, nil
+}
+
+// EncodeArgs encodes static constructor arguments, every argument is a 32 bytes word
+func EncodeArgs(args ...interface{}) ([]byte, error) {
+	encoded := make([]byte, 0, 32*len(args))
+	for i, arg := range args {
+		word, err := encodeWord(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "argument %d", i)
+		}
+		encoded = append(encoded, word...)
+	}
+	return encoded, nil
+}
+
+var (
+	maxWord   = new(big.Int).Lsh(big.NewInt(1), 256)
+	minInt256 = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 255))
+)
+
+func encodeWord(arg interface{}) ([]byte, error) {
+	var v *big.Int
+	switch a := arg.(type) {
+	case Address:
+		b, err := hex.DecodeString(strings.TrimPrefix(string(a), "0x"))
+		if err != nil || len(b) != 20 {
+			return nil, errors.Errorf("invalid address %q", a)
+		}
+		v = new(big.Int).SetBytes(b)
+	case *big.Int:
+		v = new(big.Int).Set(a)
+	case int:
+		v = big.NewInt(int64(a))
+	case int64:
+		v = big.NewInt(a)
+	case int32:
+		v = big.NewInt(int64(a))
+	case uint:
+		v = new(big.Int).SetUint64(uint64(a))
+	case uint64:
+		v = new(big.Int).SetUint64(a)
+	case uint32:
+		v = new(big.Int).SetUint64(uint64(a))
+	case uint8:
+		v = new(big.Int).SetUint64(uint64(a))
+	case bool:
+		v = big.NewInt(0)
+		if a {
+			v = big.NewInt(1)
+		}
+	case [32]byte:
+		return a[:], nil
+	default:
+		return nil, errors.Errorf("unsupported type %T, only static types are supported", arg)
+	}
+	if v.Cmp(minInt256) < 0 || v.Cmp(maxWord) >= 0 {
+		return nil, errors.Errorf("value %v doesn't fit 256 bits", arg)
+	}
+	if v.Sign() < 0 {
+		// two's complement of signed integers
+		v.Add(v, maxWord)
+	}
+	word := make([]byte, 32)
+	v.FillBytes(word)
+	return word, nil
+}
@@ -0,0 +1,82 @@
+package contracts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestEncodeArgs(t *testing.T) {
+	encoded, err := EncodeArgs(uint8(8), int64(-1), Address("0x00000000000000000000000000000000000000aa"), true, big.NewInt(256))
+	require.NoError(t, err)
+	require.Equal(t,
+		"0000000000000000000000000000000000000000000000000000000000000008"+
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"+
+			"00000000000000000000000000000000000000000000000000000000000000aa"+
+			"0000000000000000000000000000000000000000000000000000000000000001"+
+			"0000000000000000000000000000000000000000000000000000000000000100",
+		hex.EncodeToString(encoded))
+	_, err = EncodeArgs(Address("0x01"))
+	require.Error(t, err)
+	_, err = EncodeArgs("dynamic")
+	require.Error(t, err)
+	_, err = EncodeArgs(new(big.Int).Lsh(big.NewInt(1), 256))
+	require.Error(t, err)
+}
+
+func TestParseArtifact(t *testing.T) {
+	bytecode, err := parseArtifact([]byte(`{"contractName":"LinkToken","bytecode":"0x6080"}`))
+	require.NoError(t, err)
+	require.Equal(t, "6080", bytecode)
+	bytecode, err = parseArtifact([]byte(`{"bytecode":{"object":"0x6060"}}`))
+	require.NoError(t, err)
+	require.Equal(t, "6060", bytecode)
+	_, err = parseArtifact([]byte(`{"bytecode":"0x"}`))
+	require.Error(t, err)
+	_, err = parseArtifact([]byte(`{"bytecode":"0x60__$lib$__"}`))
+	require.Error(t, err)
+}
+
+func TestDeployWaitsOnClock(t *testing.T) {
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		result := "null"
+		switch req.Method {
+		case "eth_accounts":
+			result = `["0x00000000000000000000000000000000000000aa"]`
+		case "eth_sendTransaction":
+			result = `"0x01"`
+		case "eth_getTransactionReceipt":
+			// mined after a minute of 1s polls, the fake clock moves forward by itself
+			polls++
+			if polls > 60 {
+				result = `{"transactionHash":"0x01","contractAddress":"0x00000000000000000000000000000000000000bb","status":"0x1"}`
+			}
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	defer srv.Close()
+	d := New(&Props{Contracts: []Contract{{
+		Name:       LinkToken,
+		Artifact:   "LinkToken.json",
+		ArtifactFS: fstest.MapFS{"LinkToken.json": {Data: []byte(`{"bytecode":"0x6080"}`)}},
+	}}})
+	start := time.Now()
+	deployed, err := d.deployAll(client.NewEVMClient(srv.URL), clocktesting.NewFakeClock(start))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{LinkToken: "0x00000000000000000000000000000000000000bb"}, deployed)
+	require.Less(t, time.Since(start), DefaultReceiptTimeout)
+}
@@ -0,0 +1,78 @@
+package contracts
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+const (
+	// LinkToken, Aggregator and Oracle are names of feed contracts, see environment.Environment.ContractAddress
+	LinkToken  = "link"
+	Aggregator = "aggregator"
+	Oracle     = "oracle"
+	// DefaultDecimals of the aggregator answer
+	DefaultDecimals = 8
+)
+
+// feedArtifacts are compiled LinkToken.json, MockV3Aggregator.json and Oracle.json
+//
+//go:embed artifacts
+var feedArtifacts embed.FS
+
+// FeedProps deploy LinkToken, MockV3Aggregator and Oracle from artifacts embedded into the package
+type FeedProps struct {
+	// ArtifactsDir overrides embedded artifacts, LinkToken.json, MockV3Aggregator.json and Oracle.json of the directory,
+	// as Hardhat or Foundry compiles them
+	ArtifactsDir string
+	// Network and From are the deployer settings, see Props
+	Network string
+	From    string
+	// Decimals of the aggregator answer, DefaultDecimals if 0
+	Decimals uint8
+	// InitialAnswer of the aggregator
+	InitialAnswer int64
+}
+
+// NewFeed creates a deployer of contracts needed by a simple feed test: a LINK token, a mock aggregator
+// and an oracle paid in LINK
+func NewFeed(props *FeedProps) *Deployer {
+	if props == nil {
+		props = &FeedProps{}
+	}
+	decimals := props.Decimals
+	if decimals == 0 {
+		decimals = DefaultDecimals
+	}
+	artifacts, _ := fs.Sub(feedArtifacts, "artifacts")
+	if props.ArtifactsDir != "" {
+		artifacts = os.DirFS(props.ArtifactsDir)
+	}
+	return New(&Props{
+		Network: props.Network,
+		From:    props.From,
+		Contracts: []Contract{
+			{
+				Name:       LinkToken,
+				Artifact:   "LinkToken.json",
+				ArtifactFS: artifacts,
+			},
+			{
+				Name:       Aggregator,
+				Artifact:   "MockV3Aggregator.json",
+				ArtifactFS: artifacts,
+				Args: func(deployed map[string]string) []interface{} {
+					return []interface{}{decimals, props.InitialAnswer}
+				},
+			},
+			{
+				Name:       Oracle,
+				Artifact:   "Oracle.json",
+				ArtifactFS: artifacts,
+				Args: func(deployed map[string]string) []interface{} {
+					return []interface{}{Address(deployed[LinkToken])}
+				},
+			},
+		},
+	})
+}
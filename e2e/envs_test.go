@@ -1,7 +1,6 @@
 package e2e_test
 
 import (
-	"fmt"
 	"os"
 	"path"
 	"testing"
@@ -19,10 +18,9 @@ const (
 )
 
 var (
-	testSelector  = fmt.Sprintf("envType=%s", TestEnvType)
 	testEnvConfig = &environment.Config{
 		NamespacePrefix: TestEnvType,
-		Labels:          []string{testSelector},
+		Labels:          map[string]string{"envType": TestEnvType},
 	}
 )
 
@@ -85,6 +83,13 @@ func TestSimpleEnv(t *testing.T) {
 		defer e.Shutdown()
 		require.NoError(t, err)
 	})
+	t.Run("test 5 nodes + solana validator env", func(t *testing.T) {
+		e := presets.EnvSolana(testEnvConfig)
+		err := e.Run()
+		// nolint
+		defer e.Shutdown()
+		require.NoError(t, err)
+	})
 	t.Run("test multiple instances of the same type", func(t *testing.T) {
 		e := environment.New(testEnvConfig).
 			AddHelm(ethereum.New(nil)).
@@ -0,0 +1,295 @@
+package environment
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/chaos"
+)
+
+const (
+	// APIChartsPath lists charts with their statuses
+	APIChartsPath = "/api/charts"
+	// APIURLsPath returns URLs of the environment
+	APIURLsPath = "/api/urls"
+	// APIConnectionPath returns connection info, see ConnectionInfo
+	APIConnectionPath = "/api/connection"
+	// APIDumpPath dumps logs, POST {"path": "mytest"}, the path is relative to APIDumpDir, a generated path is used if it's empty
+	APIDumpPath = "/api/dump"
+	// APIChaosPath starts a chaos experiment with POST of a chaos.Step, DELETE ${APIChaosPath}/${id} stops it
+	APIChaosPath = "/api/chaos"
+	// APITeardownPath removes the environment with POST, Run returns when it's removed
+	APITeardownPath = "/api/teardown"
+	// APIDumpDir is a directory logs are dumped into through the API, requested paths can't leave it
+	APIDumpDir = "logs"
+)
+
+// APIChart is a chart of the environment served by the API
+type APIChart struct {
+	Name   string      `json:"name"`
+	Path   string      `json:"path"`
+	Status ChartStatus `json:"status"`
+}
+
+// APIDumpRequest is a body of a logs dump request
+type APIDumpRequest struct {
+	Path string `json:"path"`
+}
+
+// APIChaosResponse is an ID of a started chaos experiment
+type APIChaosResponse struct {
+	ID string `json:"id"`
+}
+
+// ServeAPI serves a local REST API to drive the environment on addr until ctx is done, so non-Go tools and curl
+// list charts, get URLs, dump logs, run chaos experiments and remove the environment, see APIHandler,
+// addr must be a loopback address, the API can remove the environment
+func (m *Environment) ServeAPI(ctx context.Context, addr string) error {
+	if err := checkLoopbackAddr(addr); err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serve environment API on %s", addr)
+	}
+	srv := &http.Server{Handler: m.APIHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	log.Info().Str("URL", fmt.Sprintf("http://%s", l.Addr())).Msg("Serving environment API")
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Err(err).Msg("Environment API server stopped")
+		}
+	}()
+	return nil
+}
+
+// APIHandler returns a handler of the environment API, see API*Path constants for endpoints,
+// requests must have a bearer token of Config.APIToken, a random one is generated and logged at the debug level if it's not set,
+// requests with a non-loopback Host and requests with a body other than JSON are rejected
+func (m *Environment) APIHandler() http.Handler {
+	if m.Cfg.APIToken == "" {
+		token, err := generateAPIToken()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to generate environment API token, all requests will be rejected")
+		} else {
+			m.Cfg.APIToken = token
+			log.Info().Msg("Generated environment API token, it's logged at the debug level, set Config.APIToken to use a known one")
+			log.Debug().Str("Token", token).Msg("Environment API token, use it in the \"Authorization: Bearer\" header")
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(APIChartsPath, apiMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIJSON(w, http.StatusOK, m.apiCharts())
+	}))
+	mux.HandleFunc(APIURLsPath, apiMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIJSON(w, http.StatusOK, m.URLs)
+	}))
+	mux.HandleFunc(APIConnectionPath, apiMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		info, err := m.ConnectionInfo()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, info)
+	}))
+	mux.HandleFunc(APIDumpPath, apiMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		req := APIDumpRequest{}
+		if !readAPIJSON(w, r, &req) {
+			return
+		}
+		path, err := apiDumpPath(req.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := m.DumpLogs(path); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc(APIChaosPath, apiMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		st := chaos.Step{}
+		if !readAPIJSON(w, r, &st) {
+			return
+		}
+		if err := st.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		newExperiment, _ := chaos.LookupExperiment(st.Experiment)
+		id, err := m.Chaos.Run(newExperiment(m.Cfg.Namespace, st.Props()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeAPIJSON(w, http.StatusCreated, APIChaosResponse{ID: id})
+	}))
+	mux.HandleFunc(APIChaosPath+"/", apiMethod(http.MethodDelete, func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, APIChaosPath+"/")
+		if _, ok := m.Chaos.Resource(id); !ok {
+			http.Error(w, fmt.Sprintf("chaos experiment %s not found", id), http.StatusNotFound)
+			return
+		}
+		if err := m.Chaos.Stop(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc(APITeardownPath, apiMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		if err := m.Shutdown(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		m.markTornDown()
+	}))
+	return apiGuard(m.Cfg.APIToken, mux)
+}
+
+// generateAPIToken returns a random API token
+func generateAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate environment API token")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// apiGuard rejects requests with a non-loopback Host, so DNS rebinding can't reach the API,
+// requests with a body without a JSON content type, so browsers can't send them cross-site without a preflight,
+// and requests without the bearer token, all requests are rejected if the token is empty
+func apiGuard(token string, h http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			http.Error(w, fmt.Sprintf("host %s is not a loopback address", r.Host), http.StatusForbidden)
+			return
+		}
+		// body-less requests, e.g. DELETE of an experiment, have nothing to type, the length is -1 for chunked bodies
+		if r.ContentLength != 0 && !isJSONContentType(r.Header.Get("Content-Type")) {
+			http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "invalid API token", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// isJSONContentType checks a Content-Type header is application/json, parameters like charset are allowed
+func isJSONContentType(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	return err == nil && mt == "application/json"
+}
+
+// isLoopbackHost checks a Host header is localhost or a loopback IP, with or without a port
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkLoopbackAddr returns an error if addr is not a loopback address, an empty host listens on all interfaces
+func checkLoopbackAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid environment API address %s", addr)
+	}
+	if isLoopbackHost(host) {
+		return nil
+	}
+	return errors.Errorf("environment API address %s is not a loopback address, use localhost or 127.0.0.1", addr)
+}
+
+// apiDumpPath resolves a requested dump path under APIDumpDir, an empty path stays empty, so a generated one is used
+func apiDumpPath(p string) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+	if filepath.IsAbs(p) {
+		return "", errors.Errorf("dump path %s must be relative to %s", p, APIDumpDir)
+	}
+	clean := filepath.Clean(p)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("dump path %s is outside of %s", p, APIDumpDir)
+	}
+	return filepath.Join(APIDumpDir, clean), nil
+}
+
+// apiCharts returns charts with their statuses sorted by name
+func (m *Environment) apiCharts() []APIChart {
+	statuses := m.ChartsStatus()
+	charts := make([]APIChart, 0, len(m.Charts))
+	for _, c := range m.Charts {
+		charts = append(charts, APIChart{Name: c.GetName(), Path: c.GetPath(), Status: statuses[c.GetName()]})
+	}
+	sort.Slice(charts, func(i, j int) bool { return charts[i].Name < charts[j].Name })
+	return charts
+}
+
+// markTornDown notifies Run the environment is removed through the API
+func (m *Environment) markTornDown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.tornDown:
+	default:
+		close(m.tornDown)
+	}
+}
+
+// apiMethod allows only one method of an endpoint
+func apiMethod(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, fmt.Sprintf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// readAPIJSON decodes a request body, an empty body is allowed, writes a bad request response and returns false if it's invalid
+func readAPIJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("invalid request body: %s", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeAPIJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warn().Err(err).Msg("Failed to write environment API response")
+	}
+}
@@ -0,0 +1,146 @@
+package environment
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIHandler(t *testing.T) {
+	e := &Environment{
+		Cfg:         &Config{Namespace: "chainlink-test-env-abcde", APIToken: "secret"},
+		URLs:        map[string][]string{"geth": {"http://localhost:8545"}},
+		Chaos:       client.NewChaos(nil, "chainlink-test-env-abcde"),
+		chartStatus: map[string]ChartStatus{},
+		mu:          &sync.Mutex{},
+	}
+	h := e.APIHandler()
+	do := func(method string, path string, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "http://localhost:8089"+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Content-Type", "application/json")
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, APIURLsPath, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	urls := map[string][]string{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &urls))
+	require.Equal(t, e.URLs, urls)
+
+	rec = do(http.MethodGet, APIChartsPath, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `[]`, rec.Body.String())
+
+	rec = do(http.MethodPost, APIURLsPath, "")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	require.Equal(t, http.MethodGet, rec.Header().Get("Allow"))
+
+	rec = do(http.MethodPost, APIChaosPath, `{"experiment": "pod-explode", "selector": {"app": "geth"}}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "unknown experiment")
+
+	rec = do(http.MethodPost, APIChaosPath, `{"experiment":`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "invalid request body")
+
+	rec = do(http.MethodDelete, APIChaosPath+"/kill-geth", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAPIGuard(t *testing.T) {
+	h := apiGuard("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	do := func(method string, host string, contentType string, auth string) int {
+		rec := httptest.NewRecorder()
+		var body io.Reader
+		if method != http.MethodGet {
+			body = strings.NewReader("{}")
+		}
+		req := httptest.NewRequest(method, APITeardownPath, body)
+		req.Host = host
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	jsonType := "application/json"
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "localhost:8089", jsonType, ""))
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "localhost:8089", jsonType, "Bearer wrong"))
+	require.Equal(t, http.StatusNoContent, do(http.MethodPost, "localhost:8089", jsonType, "Bearer secret"))
+	require.Equal(t, http.StatusNoContent, do(http.MethodPost, "127.0.0.1:8089", "application/json; charset=utf-8", "Bearer secret"))
+	require.Equal(t, http.StatusNoContent, do(http.MethodGet, "[::1]:8089", "", "Bearer secret"))
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "attacker.example.com:8089", "", "Bearer secret"))
+	require.Equal(t, http.StatusForbidden, do(http.MethodPost, "10.0.0.5:8089", jsonType, "Bearer secret"))
+	require.Equal(t, http.StatusUnsupportedMediaType, do(http.MethodPost, "localhost:8089", "", "Bearer secret"))
+	require.Equal(t, http.StatusUnsupportedMediaType, do(http.MethodPost, "localhost:8089", "text/plain", "Bearer secret"))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8089"+APITeardownPath, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code, "requests without a body need no content type")
+
+	open := apiGuard("", http.NotFoundHandler())
+	rec = httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost:8089"+APIURLsPath, nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAPIHandlerGeneratesToken(t *testing.T) {
+	e := &Environment{Cfg: &Config{}, mu: &sync.Mutex{}}
+	h := e.APIHandler()
+	require.Len(t, e.Cfg.APIToken, 64)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:8089"+APIURLsPath, nil)
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = httptest.NewRecorder()
+	req.Header.Set("Authorization", "Bearer "+e.Cfg.APIToken)
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	out, err := json.Marshal(e.Cfg)
+	require.NoError(t, err)
+	require.NotContains(t, string(out), e.Cfg.APIToken)
+}
+
+func TestCheckLoopbackAddr(t *testing.T) {
+	for _, addr := range []string{"localhost:8089", "127.0.0.1:8089", "[::1]:8089", "127.0.0.1:0"} {
+		require.NoError(t, checkLoopbackAddr(addr), addr)
+	}
+	for _, addr := range []string{":8089", "0.0.0.0:8089", "10.0.0.5:8089", "example.com:8089", "localhost"} {
+		require.Error(t, checkLoopbackAddr(addr), addr)
+	}
+}
+
+func TestAPIDumpPath(t *testing.T) {
+	p, err := apiDumpPath("")
+	require.NoError(t, err)
+	require.Equal(t, "", p)
+	p, err = apiDumpPath("debug/run-1")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(APIDumpDir, "debug", "run-1"), p)
+	p, err = apiDumpPath("debug/../run-1")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(APIDumpDir, "run-1"), p)
+	for _, bad := range []string{"/etc", "..", "../outside", "debug/../../outside", "."} {
+		_, err := apiDumpPath(bad)
+		require.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,325 @@
+package environment
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ArchiveSpecFile       = "spec.json"
+	ArchiveManifestFile   = "manifest.yaml"
+	ArchiveConnectionFile = "connection.json"
+	ArchiveManifestsDir   = "manifests"
+	ArchiveDataDir        = "data"
+)
+
+// ArchiveChart is a chart description stored in the archive
+type ArchiveChart struct {
+	Name             string                  `json:"name"`
+	Path             string                  `json:"path"`
+	DeploymentNeeded bool                    `json:"deploymentNeeded"`
+	Values           *map[string]interface{} `json:"values,omitempty"`
+}
+
+// ArchiveSpec is an environment spec stored in the archive
+type ArchiveSpec struct {
+	Namespace string         `json:"namespace"`
+	Created   time.Time      `json:"created"`
+	Config    *Config        `json:"config"`
+	Charts    []ArchiveChart `json:"charts"`
+}
+
+// ArchiveConnection is a connection info of the exported environment
+type ArchiveConnection struct {
+	URLs  map[string][]string  `json:"urls"`
+	DNS   map[string]string    `json:"dns"`
+	Ports []client.PortMapping `json:"ports"`
+}
+
+// ExportOptions environment export options
+type ExportOptions struct {
+	// WithData adds logs and database dumps of all pods into the archive
+	WithData bool
+	// DBName database to dump, "chainlink" by default
+	DBName string
+}
+
+// Export writes the environment spec, rendered manifests, connection info and optionally data snapshots
+// into a single tar.gz archive, the environment can be recreated from it with Import
+func (m *Environment) Export(path string, opts *ExportOptions) error {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	log.Info().Str("Namespace", m.Cfg.Namespace).Str("Path", path).Msg("Exporting environment")
+	files := make(map[string][]byte)
+	var err error
+	if files[ArchiveSpecFile], err = json.MarshalIndent(m.archiveSpec(), "", "  "); err != nil {
+		return err
+	}
+	manifest, err := m.manifest()
+	if err != nil {
+		return err
+	}
+	files[ArchiveManifestFile] = []byte(manifest)
+	conn := ArchiveConnection{URLs: m.URLs, DNS: m.DNS, Ports: m.Fwd.Mapping()}
+	if files[ArchiveConnectionFile], err = json.MarshalIndent(conn, "", "  "); err != nil {
+		return err
+	}
+	if err := addDirFiles(files, m.Client.ManifestsDir, ArchiveManifestsDir); err != nil {
+		return err
+	}
+	if opts.WithData {
+		if err := m.exportData(files, opts.DBName); err != nil {
+			return err
+		}
+	}
+	return writeArchive(path, files)
+}
+
+// archiveSpec describes the environment config and charts
+func (m *Environment) archiveSpec() *ArchiveSpec {
+	spec := &ArchiveSpec{
+		Namespace: m.Cfg.Namespace,
+		Created:   time.Now(),
+		Config:    m.Cfg,
+		Charts:    make([]ArchiveChart, 0),
+	}
+	for _, c := range m.Charts {
+		spec.Charts = append(spec.Charts, ArchiveChart{
+			Name:             c.GetName(),
+			Path:             c.GetPath(),
+			DeploymentNeeded: c.IsDeploymentNeeded(),
+			Values:           c.GetValues(),
+		})
+	}
+	return spec
+}
+
+// exportData dumps pods logs and databases into a temporary directory and adds them to the archive files
+func (m *Environment) exportData(files map[string][]byte, dbName string) error {
+	if dbName == "" {
+		dbName = "chainlink"
+	}
+	dir, err := os.MkdirTemp("", fmt.Sprintf("%s-data-*", m.Cfg.Namespace))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	arts, err := NewArtifacts(m.Client, m.Cfg.Namespace)
+	if err != nil {
+		return err
+	}
+	arts.Pausables = m.pausables
+	if err := arts.DumpTestResult(dir, dbName); err != nil {
+		return err
+	}
+	return addDirFiles(files, dir, ArchiveDataDir)
+}
+
+// Import recreates an environment from the archive written by Export in a new namespace,
+// cfg overrides the exported config, call Run to deploy it
+func Import(path string, cfg *Config) (*Environment, error) {
+	files, err := readArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	specData, ok := files[ArchiveSpecFile]
+	if !ok {
+		return nil, errors.Errorf("%s not found in the archive %s", ArchiveSpecFile, path)
+	}
+	manifest, ok := files[ArchiveManifestFile]
+	if !ok {
+		return nil, errors.Errorf("%s not found in the archive %s", ArchiveManifestFile, path)
+	}
+	var spec ArchiveSpec
+	if err := json.Unmarshal(specData, &spec); err != nil {
+		return nil, errors.Wrap(err, "failed to parse environment spec")
+	}
+	if spec.Config == nil {
+		spec.Config = &Config{}
+	}
+	if cfg != nil {
+		config.MustMerge(spec.Config, cfg)
+	}
+	spec.Config.Namespace = ""
+	e := New(spec.Config)
+	imported, err := rebaseManifest(string(manifest), spec.Namespace, e.Cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	e.imported = imported
+	log.Info().
+		Str("From", spec.Namespace).
+		Str("Namespace", e.Cfg.Namespace).
+		Interface("Charts", spec.Charts).
+		Msg("Environment imported")
+	return e, nil
+}
+
+// rebaseManifest moves manifest into another namespace, namespace objects are dropped
+// because a new environment creates its own, metadata and subjects namespaces and in-cluster DNS names of the old namespace
+// are rewritten, other values containing the namespace text stay as they are
+func rebaseManifest(manifest string, from string, to string) (string, error) {
+	split, err := splitManifest(manifest)
+	if err != nil {
+		return "", err
+	}
+	docs := make([]string, 0, len(split))
+	for _, d := range split {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil {
+			return "", errors.Wrap(err, "failed to parse manifest")
+		}
+		if obj == nil || obj["kind"] == "Namespace" {
+			continue
+		}
+		if from != "" {
+			rebaseObject(obj, from, to)
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return "", err
+			}
+			d = string(data)
+		}
+		docs = append(docs, d)
+	}
+	return joinManifest(docs), nil
+}
+
+// rebaseObject sets namespaces of the object and of its RBAC subjects and rewrites in-cluster DNS names of services
+func rebaseObject(obj map[string]interface{}, from string, to string) {
+	if meta, ok := obj["metadata"].(map[string]interface{}); ok && meta["namespace"] == from {
+		meta["namespace"] = to
+	}
+	if subjects, ok := obj["subjects"].([]interface{}); ok {
+		for _, s := range subjects {
+			if subject, ok := s.(map[string]interface{}); ok && subject["namespace"] == from {
+				subject["namespace"] = to
+			}
+		}
+	}
+	dnsRe := regexp.MustCompile(`\.` + regexp.QuoteMeta(from) + `(\.svc\b)`)
+	var rebase func(v interface{}) interface{}
+	rebase = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case string:
+			return dnsRe.ReplaceAllString(v, "."+to+"$1")
+		case map[string]interface{}:
+			for k, e := range v {
+				v[k] = rebase(e)
+			}
+		case []interface{}:
+			for i, e := range v {
+				v[i] = rebase(e)
+			}
+		}
+		return v
+	}
+	rebase(obj)
+}
+
+// addDirFiles adds all files of the dir to the archive files under the prefix
+func addDirFiles(files map[string][]byte, dir string, prefix string) error {
+	if dir == "" {
+		return nil
+	}
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(filepath.Join(prefix, rel))] = data
+		return nil
+	})
+}
+
+// writeArchive writes files as a tar.gz archive, files are sorted by name
+func writeArchive(path string, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(files[name])),
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// readArchive reads all files of a tar.gz archive
+func readArchive(path string) (map[string][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read archive %s", path)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	files := make(map[string][]byte)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read archive %s", path)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[h.Name] = data
+	}
+	return files, nil
+}
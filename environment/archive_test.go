@@ -0,0 +1,73 @@
+package environment
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env.tar.gz")
+	files := map[string][]byte{
+		ArchiveSpecFile:           []byte(`{"namespace":"chainlink-test-env-abcde"}`),
+		ArchiveManifestFile:       []byte(testManifest),
+		"manifests/geth-123.yaml": []byte("kind: Deployment"),
+	}
+	require.NoError(t, writeArchive(path, files))
+	read, err := readArchive(path)
+	require.NoError(t, err)
+	require.Equal(t, files, read)
+}
+
+func TestRebaseManifest(t *testing.T) {
+	manifest := testManifest + `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: geth-ws
+  namespace: chainlink-test-env-abcde
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: chainlink-cm
+  namespace: chainlink-test-env-abcde
+data:
+  url: ws://geth.chainlink-test-env-abcde.svc.cluster.local:8546
+  note: chainlink-test-env-abcde is not a DNS name
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: runner
+  namespace: chainlink-test-env-abcde
+subjects:
+  - kind: ServiceAccount
+    name: default
+    namespace: chainlink-test-env-abcde
+`
+	out, err := rebaseManifest(manifest, "chainlink-test-env-abcde", "chainlink-test-env-fghij")
+	require.NoError(t, err)
+	require.NotContains(t, out, "kind: Namespace")
+	require.Equal(t, 4, strings.Count(out, "namespace: chainlink-test-env-fghij"))
+	require.Contains(t, out, "ws://geth.chainlink-test-env-fghij.svc.cluster.local:8546")
+	require.Contains(t, out, "note: chainlink-test-env-abcde is not a DNS name")
+	docs, err := splitManifest(out)
+	require.NoError(t, err)
+	require.Len(t, docs, 6)
+}
+
+func TestArchiveSpecConfig(t *testing.T) {
+	cfg := &Config{NamespacePrefix: "chainlink-ocr", Clock: clocktesting.NewFakeClock(time.Now())}
+	data, err := json.Marshal(&ArchiveSpec{Config: cfg})
+	require.NoError(t, err)
+	var spec ArchiveSpec
+	require.NoError(t, json.Unmarshal(data, &spec))
+	require.Equal(t, "chainlink-ocr", spec.Config.NamespacePrefix)
+	require.Nil(t, spec.Config.Clock)
+}
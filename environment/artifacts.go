@@ -1,22 +1,22 @@
 package environment
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/smartcontractkit/chainlink-env/client"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/pkg"
 	coreV1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes/scheme"
 	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
-	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/kubectl/pkg/describe"
 )
 
 // Artifacts is an artifacts dumping structure that copies logs and database dumps for all deployed pods
@@ -24,7 +24,13 @@ type Artifacts struct {
 	Namespace  string
 	DBName     string
 	Client     *client.K8sClient
+	Drift      *DriftWatcher
+	Usage      *UsageSampler
+	Pausables  []Pausable
+	LogFilter  *LogFilter // drops noisy lines declared by charts from container logs
 	podsClient clientV1.PodInterface
+	reportsMu  sync.Mutex
+	reports    map[string]interface{}
 }
 
 // NewArtifacts create new artifacts instance for provided environment
@@ -33,19 +39,53 @@ func NewArtifacts(client *client.K8sClient, namespace string) (*Artifacts, error
 		Namespace:  namespace,
 		Client:     client,
 		podsClient: client.ClientSet.CoreV1().Pods(namespace),
+		reports:    make(map[string]interface{}),
 	}, nil
 }
 
-// DumpTestResult dumps all pods logs and db dump in a separate test dir
+// AddReport adds a report which is written as ${name}.json into the test dir, for example, load test stats
+func (a *Artifacts) AddReport(name string, report interface{}) {
+	a.reportsMu.Lock()
+	defer a.reportsMu.Unlock()
+	a.reports[name] = report
+}
+
+func (a *Artifacts) writeReports(testDir string) error {
+	a.reportsMu.Lock()
+	defer a.reportsMu.Unlock()
+	for name, r := range a.reports {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(testDir, fmt.Sprintf("%s.json", name)), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpTestResult dumps all pods logs and db dump in a separate test dir, chaos and pausables are paused meanwhile
 func (a *Artifacts) DumpTestResult(testDir string, dbName string) error {
 	a.DBName = dbName
 	if err := mkdirIfNotExists(testDir); err != nil {
 		return err
 	}
+	defer a.pause()()
 	if err := a.writePodArtifacts(testDir); err != nil {
 		return err
 	}
-	return nil
+	if a.Drift != nil {
+		if err := a.Drift.WriteReport(testDir); err != nil {
+			return err
+		}
+	}
+	if a.Usage != nil {
+		if err := a.Usage.WriteReport(testDir); err != nil {
+			return err
+		}
+	}
+	return a.writeReports(testDir)
 }
 
 func (a *Artifacts) writePodArtifacts(testDir string) error {
@@ -69,6 +109,9 @@ func (a *Artifacts) writePodArtifacts(testDir string) error {
 		if err := mkdirIfNotExists(appDir); err != nil {
 			return err
 		}
+		if err := a.writePodDescription(pod, appDir); err != nil {
+			log.Warn().Err(err).Str("Pod", pod.Name).Msg("Failed to describe pod")
+		}
 		err = a.writePodLogs(pod, appDir)
 		if err != nil {
 			log.Err(err).
@@ -77,89 +120,81 @@ func (a *Artifacts) writePodArtifacts(testDir string) error {
 				Msg("Error writing logs for pod")
 		}
 	}
+	if err := a.writeEvents(testDir); err != nil {
+		log.Warn().Err(err).Str("Namespace", a.Namespace).Msg("Failed to write events")
+	}
 	return nil
 }
 
-func (a *Artifacts) dumpDB(pod coreV1.Pod, container coreV1.Container) (string, error) {
-	postRequestBase := a.Client.ClientSet.CoreV1().RESTClient().Post().
-		Namespace(pod.Namespace).Resource("pods").Name(pod.Name).SubResource("exec")
-	exportDBRequest := postRequestBase.VersionedParams(
-		&coreV1.PodExecOptions{
-			Container: container.Name,
-			Command:   []string{"/bin/sh", "-c", "pg_dump", a.DBName},
-			Stdin:     true,
-			Stdout:    true,
-			Stderr:    true,
-			TTY:       false,
-		}, scheme.ParameterCodec)
-	exec, err := remotecommand.NewSPDYExecutor(a.Client.RESTConfig, "POST", exportDBRequest.URL())
+// writePodDescription writes the same description of a pod as kubectl describe, with its conditions, container states,
+// restart reasons and events, as ${pod}.describe.txt
+func (a *Artifacts) writePodDescription(pod coreV1.Pod, appDir string) error {
+	d := &describe.PodDescriber{Interface: a.Client.ClientSet}
+	out, err := d.Describe(pod.Namespace, pod.Name, describe.DescriberSettings{ShowEvents: true, ChunkSize: 500})
 	if err != nil {
-		return "", err
-	}
-	outBuff, errBuff := &bytes.Buffer{}, &bytes.Buffer{}
-	err = exec.Stream(remotecommand.StreamOptions{
-		Stdin:  &bytes.Reader{},
-		Stdout: outBuff,
-		Stderr: errBuff,
-		Tty:    false,
-	})
-	if err != nil || errBuff.Len() > 0 {
-		return "", fmt.Errorf("error in dumping DB contents | STDOUT: %v | STDERR: %v", outBuff.String(),
-			errBuff.String())
-	}
-	return outBuff.String(), err
+		return err
+	}
+	return os.WriteFile(filepath.Join(appDir, pod.Name+PodDescriptionSuffix), []byte(out), 0644)
 }
 
+// writePostgresDump dumps the database into a file in the container and copies it out, so big dumps are not kept in memory
 func (a *Artifacts) writePostgresDump(podDir string, pod coreV1.Pod, cont coreV1.Container) error {
-	dumpContents, err := a.dumpDB(pod, cont)
-	if err != nil {
-		return err
-	}
-	logFile, err := os.Create(filepath.Join(podDir, fmt.Sprintf("%s_dump.sql", cont.Name)))
+	remoteDump := fmt.Sprintf("/tmp/chainlink-env-%s_dump.sql", a.DBName)
+	_, stderr, err := a.Client.ExecInPod(context.Background(), pod.Namespace, pod.Name, cont.Name,
+		[]string{"pg_dump", "-f", remoteDump, a.DBName})
 	if err != nil {
-		return err
-	}
-	_, err = logFile.WriteString(dumpContents)
-	if err != nil {
-		return err
+		return errors.Wrapf(err, "error in dumping DB contents: %s", strings.TrimSpace(stderr))
 	}
-	if err = logFile.Close(); err != nil {
-		return err
-	}
-	return nil
+	defer func() {
+		if _, _, err := a.Client.ExecInPod(context.Background(), pod.Namespace, pod.Name, cont.Name, []string{"rm", "-f", remoteDump}); err != nil {
+			log.Warn().Err(err).Str("Pod", pod.Name).Msg("Failed to remove DB dump from the container")
+		}
+	}()
+	return a.Client.CopyFromPod(pod.Namespace, fmt.Sprintf("%s:%s", pod.Name, remoteDump),
+		filepath.Join(podDir, fmt.Sprintf("%s_dump.sql", cont.Name)), cont.Name)
 }
 
 func (a *Artifacts) writeContainerLogs(podDir string, pod coreV1.Pod, cont coreV1.Container) error {
-	logFile, err := os.Create(filepath.Join(podDir, cont.Name) + ".log")
-	if err != nil {
-		return err
-	}
-	podLogRequest := a.podsClient.GetLogs(pod.Name, &coreV1.PodLogOptions{Container: cont.Name})
+	return a.writeLogs(filepath.Join(podDir, cont.Name)+".log", pod, cont.Name, false)
+}
+
+// writeLogs writes logs of a container into a file, previous selects logs of a previous instance of a restarted container
+func (a *Artifacts) writeLogs(path string, pod coreV1.Pod, container string, previous bool) error {
+	podLogRequest := a.podsClient.GetLogs(pod.Name, &coreV1.PodLogOptions{Container: container, Previous: previous})
 	podLogs, err := podLogRequest.Stream(context.Background())
 	if err != nil {
 		return err
 	}
-	buf := new(bytes.Buffer)
-	_, err = io.Copy(buf, podLogs)
+	defer podLogs.Close()
+	logFile, err := os.Create(path)
 	if err != nil {
 		return err
 	}
-	_, err = logFile.Write(buf.Bytes())
+	dropped, err := a.LogFilter.Copy(logFile, podLogs, pod.Labels[pkg.ReleaseLabelKey], container)
 	if err != nil {
+		_ = logFile.Close()
 		return err
 	}
-
-	if err = logFile.Close(); err != nil {
-		return err
-	}
-	if err = podLogs.Close(); err != nil {
-		return err
+	if dropped > 0 {
+		log.Debug().Str("Pod", pod.Name).Str("Container", container).Int("Lines", dropped).Msg("Noisy log lines are filtered")
 	}
-	return nil
+	return logFile.Close()
 }
 
-// Writes logs for each container in a pod
+// Writes logs for each container in a pod, including init containers, logs of previous instances of restarted containers
+// are written as ${container}.previous.log
 func (a *Artifacts) writePodLogs(pod coreV1.Pod, appDir string) error {
+	restarted := restartedContainers(pod)
+	for _, c := range pod.Spec.InitContainers {
+		if err := a.writeContainerLogs(appDir, pod, c); err != nil {
+			log.Warn().Err(err).Str("Pod", pod.Name).Str("Container", c.Name).Msg("Failed to write init container logs")
+		}
+		if restarted[c.Name] {
+			if err := a.writeLogs(filepath.Join(appDir, c.Name+PreviousLogSuffix), pod, c.Name, true); err != nil {
+				log.Warn().Err(err).Str("Pod", pod.Name).Str("Container", c.Name).Msg("Failed to write previous container logs")
+			}
+		}
+	}
 	for _, c := range pod.Spec.Containers {
 		log.Info().
 			Str("Container", c.Name).
@@ -167,6 +202,11 @@ func (a *Artifacts) writePodLogs(pod coreV1.Pod, appDir string) error {
 		if err := a.writeContainerLogs(appDir, pod, c); err != nil {
 			return err
 		}
+		if restarted[c.Name] {
+			if err := a.writeLogs(filepath.Join(appDir, c.Name+PreviousLogSuffix), pod, c.Name, true); err != nil {
+				log.Warn().Err(err).Str("Pod", pod.Name).Str("Container", c.Name).Msg("Failed to write previous container logs")
+			}
+		}
 		if strings.Contains(c.Image, "postgres") {
 			if err := a.writePostgresDump(appDir, pod, c); err != nil {
 				return err
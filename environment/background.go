@@ -0,0 +1,93 @@
+package environment
+
+const (
+	// ProgressBufferSize is how many progress messages are kept if nobody reads them, newer messages are dropped
+	ProgressBufferSize = 100
+)
+
+// RunHandle is a handle of an environment being deployed in background
+type RunHandle struct {
+	ready    chan struct{}
+	err      chan error
+	progress chan string
+}
+
+// Ready is closed when the environment is deployed and connected
+func (h *RunHandle) Ready() <-chan struct{} {
+	return h.ready
+}
+
+// Err receives an error if the deployment failed, it is closed when the deployment is finished
+func (h *RunHandle) Err() <-chan error {
+	return h.err
+}
+
+// Progress receives human-readable deployment stages, it is closed when the deployment is finished
+func (h *RunHandle) Progress() <-chan string {
+	return h.progress
+}
+
+// Wait blocks until the deployment is finished and returns its error
+func (h *RunHandle) Wait() error {
+	return <-h.err
+}
+
+// RunBackground deploys or connects to the environment like Run but returns immediately,
+// so environment provisioning can overlap with other test setup
+func (m *Environment) RunBackground() *RunHandle {
+	return m.runBackground(m.Run)
+}
+
+func (m *Environment) runBackground(run func() error) *RunHandle {
+	h := &RunHandle{
+		ready:    make(chan struct{}),
+		err:      make(chan error, 1),
+		progress: make(chan string, ProgressBufferSize),
+	}
+	m.mu.Lock()
+	m.progress = h.progress
+	m.ready = h.ready
+	m.mu.Unlock()
+	go func() {
+		defer close(h.err)
+		defer close(h.progress)
+		err := run()
+		m.mu.Lock()
+		m.progress = nil
+		if err != nil {
+			m.ready = nil
+		}
+		m.mu.Unlock()
+		if err != nil {
+			h.err <- err
+			return
+		}
+		m.markReady()
+	}()
+	return h
+}
+
+// markReady signals the background run handle that the environment is ready
+func (m *Environment) markReady() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ready == nil {
+		return
+	}
+	close(m.ready)
+	m.ready = nil
+}
+
+// reportProgress sends a deployment stage to the background run handle if there is one,
+// the stage is dropped if the progress buffer is full
+func (m *Environment) reportProgress(msg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.progress == nil {
+		return
+	}
+	select {
+	case m.progress <- msg:
+	default:
+	}
+}
@@ -0,0 +1,65 @@
+package environment
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// drain returns all progress messages until the channel is closed
+func drain(progress <-chan string) []string {
+	msgs := make([]string, 0)
+	for msg := range progress {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestRunBackground(t *testing.T) {
+	e := &Environment{mu: &sync.Mutex{}}
+	h := e.runBackground(func() error {
+		e.reportProgress("Applying manifest")
+		e.reportProgress("Environment is ready")
+		return nil
+	})
+	require.NoError(t, h.Wait())
+	<-h.Ready()
+	_, open := <-h.Err()
+	require.False(t, open, "errors are closed on success")
+	require.Equal(t, []string{"Applying manifest", "Environment is ready"}, drain(h.Progress()))
+	e.reportProgress("Upgrading chart geth")
+	e.markReady()
+
+	e = &Environment{mu: &sync.Mutex{}}
+	h = e.runBackground(func() error {
+		e.reportProgress("Applying manifest")
+		return errors.New("chart geth failed")
+	})
+	require.EqualError(t, h.Wait(), "chart geth failed")
+	_, open = <-h.Err()
+	require.False(t, open, "errors are closed on failure")
+	require.Equal(t, []string{"Applying manifest"}, drain(h.Progress()))
+	select {
+	case <-h.Ready():
+		t.Fatal("ready is not closed on failure")
+	default:
+	}
+	e.markReady()
+}
+
+func TestReportProgressFull(t *testing.T) {
+	e := &Environment{mu: &sync.Mutex{}}
+	h := e.runBackground(func() error {
+		for i := 0; i < ProgressBufferSize; i++ {
+			e.reportProgress("Deploying chart")
+		}
+		e.reportProgress("Environment is ready")
+		return nil
+	})
+	require.NoError(t, h.Wait())
+	msgs := drain(h.Progress())
+	require.Len(t, msgs, ProgressBufferSize)
+	require.NotContains(t, msgs, "Environment is ready", "newer messages are dropped if nobody reads them")
+}
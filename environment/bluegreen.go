@@ -0,0 +1,124 @@
+package environment
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// DeployGreen deploys a "green" copy of a running "blue" chart next to it, e.g. a new Chainlink version:
+//
+//	e.DeployGreen("chainlink-0", chainlink.New(1, map[string]interface{}{"chainlink": ...}))
+//
+// the green chart must have its own name, it's deployed with hooks and connected, but URLs and DNS names
+// still point to the blue chart until SwapGreen, a chart may have only one green copy at a time
+func (m *Environment) DeployGreen(blue string, green ConnectedChart) error {
+	if m.chart(blue) == nil {
+		return errors.Errorf("chart %s not found in the environment", blue)
+	}
+	if m.chartStatus[blue] != ChartStatusReady {
+		return errors.Errorf("chart %s is not deployed yet", blue)
+	}
+	if g, ok := m.green[blue]; ok {
+		return errors.Errorf("chart %s already has a green copy %s, swap or abort it first", blue, g)
+	}
+	if m.chart(green.GetName()) != nil {
+		return errors.Errorf("green chart must have its own name, chart %s is already in the environment", green.GetName())
+	}
+	if !green.IsDeploymentNeeded() {
+		return errors.Errorf("green chart %s must be a Helm chart deployed by the environment", green.GetName())
+	}
+	log.Info().Str("Blue", blue).Str("Green", green.GetName()).Msg("Deploying green chart")
+	m.reportProgress(fmt.Sprintf("Deploying green chart %s", green.GetName()))
+	m.green[blue] = green.GetName()
+	m.AddHelm(green)
+	err := m.Update()
+	m.recordEvent("deploy green", green.GetName(), err)
+	return errors.Wrapf(err, "failed to deploy green chart %s", green.GetName())
+}
+
+// SwapGreen switches URLs and DNS names from the blue chart to its green copy in one step, the green chart
+// takes the place of the blue one in the charts order, then the blue chart is removed,
+// so clients re-reading URLs never get endpoints of removed pods
+func (m *Environment) SwapGreen(blue string) error {
+	green, ok := m.green[blue]
+	if !ok {
+		return errors.Errorf("chart %s has no green copy, use DeployGreen first", blue)
+	}
+	if m.chartStatus[green] != ChartStatusReady {
+		return errors.Errorf("green chart %s is not ready", green)
+	}
+	log.Info().Str("Blue", blue).Str("Green", green).Msg("Swapping to green chart")
+	m.Charts = swapCharts(m.Charts, blue, green)
+	delete(m.green, blue)
+	m.URLs = make(map[string][]string)
+	if err := m.PrintExportData(); err != nil {
+		return err
+	}
+	err := m.removeBlue(blue)
+	m.recordEvent("swap green", fmt.Sprintf("%s->%s", blue, green), err)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove blue chart %s", blue)
+	}
+	log.Info().Str("Chart", green).Msg("Green chart is serving")
+	return nil
+}
+
+// AbortGreen removes the green copy of a chart, the blue chart keeps serving
+func (m *Environment) AbortGreen(blue string) error {
+	green, ok := m.green[blue]
+	if !ok {
+		return errors.Errorf("chart %s has no green copy", blue)
+	}
+	log.Info().Str("Blue", blue).Str("Green", green).Msg("Aborting green chart")
+	if err := m.RemoveChart(green); err != nil {
+		return err
+	}
+	delete(m.green, blue)
+	return nil
+}
+
+// isGreen true if the chart is a green copy waiting for SwapGreen
+func (m *Environment) isGreen(name string) bool {
+	for _, g := range m.green {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
+
+// removeBlue removes resources of a swapped blue chart, the chart is already out of the charts list
+func (m *Environment) removeBlue(name string) error {
+	if err := m.deleteRelease(name); err != nil {
+		return err
+	}
+	if err := m.Client.WaitPodsDeleted(m.Cfg.Namespace, releaseSelector(name), m.Cfg.ReadyCheckData.Timeout); err != nil {
+		return err
+	}
+	m.removeChart(name)
+	m.Fwd.RemoveApp(name)
+	return nil
+}
+
+// swapCharts returns charts with the green chart at the position of the blue one and the blue chart removed
+func swapCharts(charts []ConnectedChart, blue string, green string) []ConnectedChart {
+	var g ConnectedChart
+	for _, c := range charts {
+		if c.GetName() == green {
+			g = c
+		}
+	}
+	swapped := make([]ConnectedChart, 0, len(charts))
+	for _, c := range charts {
+		switch c.GetName() {
+		case blue:
+			swapped = append(swapped, g)
+		case green:
+		default:
+			swapped = append(swapped, c)
+		}
+	}
+	return swapped
+}
@@ -0,0 +1,25 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type namedChart struct {
+	ConnectedChart
+	name string
+}
+
+func (c namedChart) GetName() string {
+	return c.name
+}
+
+func TestSwapCharts(t *testing.T) {
+	charts := []ConnectedChart{namedChart{name: "geth"}, namedChart{name: "chainlink-0"}, namedChart{name: "mockserver"}, namedChart{name: "chainlink-1"}}
+	names := make([]string, 0)
+	for _, c := range swapCharts(charts, "chainlink-0", "chainlink-1") {
+		names = append(names, c.GetName())
+	}
+	require.Equal(t, []string{"geth", "chainlink-1", "mockserver"}, names)
+}
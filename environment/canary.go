@@ -0,0 +1,151 @@
+package environment
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultCanaryWindow = 2 * time.Minute
+	// CanaryReportPrefix analyses are added to artifacts as ${prefix}${chart}.json reports
+	CanaryReportPrefix = "canary-"
+)
+
+// Canary is an analysis run after UpgradeHelm of a chart, the chart is rolled back to the previous values if any check fails
+type Canary struct {
+	// Window how long the upgraded chart runs before it's checked, logs are compared with the same window
+	// before the upgrade, DefaultCanaryWindow if 0
+	Window time.Duration
+	Checks []CanaryCheck
+}
+
+// CanaryCheck compares the upgraded chart with its baseline before the upgrade, either a PromQL query
+// evaluated with Config.PrometheusURL or a rate of chart pods log lines collected with Config.CollectLogs
+type CanaryCheck struct {
+	Name string
+	// PromQL query evaluated before the upgrade and at the end of the window, fails if the value changes by more than MaxDelta
+	PromQL   string
+	MaxDelta float64
+	// LogPattern lines of the chart pods matching it are counted per minute in both windows,
+	// fails if the rate grows by more than MaxRateIncrease lines per minute
+	LogPattern      string
+	MaxRateIncrease float64
+}
+
+// CanaryResult is a result of a single check
+type CanaryResult struct {
+	Check    string
+	Baseline float64
+	Canary   float64
+	Passed   bool
+	Error    string `json:",omitempty"`
+}
+
+// CanaryAnalysis is a report of a canary analysis of an upgraded chart
+type CanaryAnalysis struct {
+	Chart      string
+	Start      time.Time
+	Window     time.Duration
+	Passed     bool
+	RolledBack bool
+	Results    []CanaryResult
+}
+
+// SetCanary registers a canary analysis of a chart for the following UpgradeHelm calls, nil removes it
+func (m *Environment) SetCanary(chart string, canary *Canary) {
+	if canary == nil {
+		delete(m.canaries, chart)
+		return
+	}
+	if canary.Window == 0 {
+		canary.Window = DefaultCanaryWindow
+	}
+	m.canaries[chart] = canary
+}
+
+// canaryBaseline measures all checks of the chart before the upgrade
+func (m *Environment) canaryBaseline(name string, canary *Canary, start time.Time) ([]float64, error) {
+	baseline := make([]float64, len(canary.Checks))
+	for i, c := range canary.Checks {
+		v, err := m.canaryMeasure(name, c, start.Add(-canary.Window), start)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to measure baseline of canary check %s", c.Name)
+		}
+		baseline[i] = v
+	}
+	return baseline, nil
+}
+
+// analyzeCanary waits for the window after the upgrade, compares checks with the baseline and reports the analysis
+func (m *Environment) analyzeCanary(name string, canary *Canary, baseline []float64) *CanaryAnalysis {
+	start := m.clock().Now()
+	log.Info().Str("Chart", name).Dur("Window", canary.Window).Msg("Running canary analysis")
+	m.reportProgress(fmt.Sprintf("Running canary analysis of chart %s", name))
+	m.clock().Sleep(canary.Window)
+	a := &CanaryAnalysis{
+		Chart:   name,
+		Start:   start,
+		Window:  canary.Window,
+		Passed:  true,
+		Results: make([]CanaryResult, 0),
+	}
+	for i, c := range canary.Checks {
+		var r CanaryResult
+		v, err := m.canaryMeasure(name, c, start, start.Add(canary.Window))
+		if err != nil {
+			r = CanaryResult{Check: c.Name, Baseline: baseline[i], Error: err.Error()}
+		} else {
+			r = evaluateCanaryCheck(c, baseline[i], v)
+		}
+		if !r.Passed {
+			a.Passed = false
+		}
+		log.Info().
+			Str("Chart", name).
+			Str("Check", r.Check).
+			Float64("Baseline", r.Baseline).
+			Float64("Canary", r.Canary).
+			Bool("Passed", r.Passed).
+			Msg("Canary check")
+		a.Results = append(a.Results, r)
+	}
+	return a
+}
+
+// reportCanary adds the analysis to the environment and artifacts
+func (m *Environment) reportCanary(a *CanaryAnalysis) {
+	m.CanaryAnalyses = append(m.CanaryAnalyses, *a)
+	if m.Artifacts != nil {
+		m.Artifacts.AddReport(CanaryReportPrefix+a.Chart, a)
+	}
+}
+
+// canaryMeasure returns a PromQL value or a rate of chart log lines per minute between from and to
+func (m *Environment) canaryMeasure(name string, c CanaryCheck, from time.Time, to time.Time) (float64, error) {
+	if c.PromQL != "" {
+		return m.promQLValue(c.PromQL)
+	}
+	if m.Logs == nil {
+		return 0, errors.New("log checks require Config.CollectLogs")
+	}
+	lines, err := m.Logs.FindBetween(releaseSelector(name), c.LogPattern, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return float64(len(lines)) / to.Sub(from).Minutes(), nil
+}
+
+// evaluateCanaryCheck compares a canary value with the baseline
+func evaluateCanaryCheck(c CanaryCheck, baseline float64, canary float64) CanaryResult {
+	r := CanaryResult{Check: c.Name, Baseline: baseline, Canary: canary}
+	if c.PromQL != "" {
+		r.Passed = math.Abs(canary-baseline) <= c.MaxDelta
+	} else {
+		r.Passed = canary-baseline <= c.MaxRateIncrease
+	}
+	return r
+}
@@ -0,0 +1,19 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateCanaryCheck(t *testing.T) {
+	latency := CanaryCheck{Name: "p99 latency", PromQL: "histogram_quantile(0.99, ...)", MaxDelta: 0.5}
+	require.True(t, evaluateCanaryCheck(latency, 1.2, 0.8).Passed)
+	require.False(t, evaluateCanaryCheck(latency, 1.2, 1.8).Passed)
+
+	errorRate := CanaryCheck{Name: "errors", LogPattern: `\[ERROR]`, MaxRateIncrease: 1}
+	require.True(t, evaluateCanaryCheck(errorRate, 3, 0).Passed, "fewer errors than the baseline")
+	require.True(t, evaluateCanaryCheck(errorRate, 0, 1).Passed)
+	r := evaluateCanaryCheck(errorRate, 0, 1.5)
+	require.Equal(t, CanaryResult{Check: "errors", Baseline: 0, Canary: 1.5, Passed: false}, r)
+}
@@ -0,0 +1,137 @@
+package environment
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"golang.org/x/sync/errgroup"
+)
+
+// ChainlinkURLsKey URLs of Chainlink nodes API, forwarded ones or in-cluster if the test runs inside K8s
+const ChainlinkURLsKey = "chainlink_local"
+
+// ChainlinkNodes manages jobs of all Chainlink nodes of the environment through their API,
+// nodes are indexed in order of their URLs, sessions are created on the first request to a node
+type ChainlinkNodes struct {
+	env      *Environment
+	Email    string
+	Password string
+	mu       sync.Mutex
+	clients  map[string]*client.ChainlinkClient
+}
+
+func newChainlinkNodes(e *Environment) *ChainlinkNodes {
+	return &ChainlinkNodes{
+		env:      e,
+		Email:    client.DefaultChainlinkEmail,
+		Password: client.DefaultChainlinkPassword,
+		clients:  make(map[string]*client.ChainlinkClient),
+	}
+}
+
+// Count returns the number of nodes
+func (c *ChainlinkNodes) Count() int {
+	return len(c.env.URLs[ChainlinkURLsKey])
+}
+
+// Node returns an API client of a node, clients are reused, so URLs kept by UpgradeHelm keep their sessions
+func (c *ChainlinkNodes) Node(index int) (*client.ChainlinkClient, error) {
+	urls := c.env.URLs[ChainlinkURLsKey]
+	if index < 0 || index >= len(urls) {
+		return nil, errors.Errorf("no Chainlink node %d, the environment has %d nodes", index, len(urls))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if nc, ok := c.clients[urls[index]]; ok {
+		return nc, nil
+	}
+	nc, err := client.NewChainlinkClient(urls[index], c.Email, c.Password)
+	if err != nil {
+		return nil, err
+	}
+	nc.Clock = c.env.clock()
+	if err := nc.Login(); err != nil {
+		return nil, errors.Wrapf(err, "failed to log in to Chainlink node %d", index)
+	}
+	c.clients[urls[index]] = nc
+	return nc, nil
+}
+
+// CreateJob creates a job from a TOML spec on a node and returns its ID
+func (c *ChainlinkNodes) CreateJob(index int, spec string) (string, error) {
+	nc, err := c.Node(index)
+	if err != nil {
+		return "", err
+	}
+	id, err := nc.CreateJob(spec)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create job on Chainlink node %d", index)
+	}
+	log.Debug().Int("Node", index).Str("Job", id).Msg("Job created")
+	return id, nil
+}
+
+// DeleteJob deletes a job of a node by ID
+func (c *ChainlinkNodes) DeleteJob(index int, id string) error {
+	nc, err := c.Node(index)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(nc.DeleteJob(id), "failed to delete job %s on Chainlink node %d", id, index)
+}
+
+// ListJobs returns all jobs of a node
+func (c *ChainlinkNodes) ListJobs(index int) ([]client.ChainlinkJob, error) {
+	nc, err := c.Node(index)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := nc.ListJobs()
+	return jobs, errors.Wrapf(err, "failed to list jobs of Chainlink node %d", index)
+}
+
+// CreateJobs creates a job on every node concurrently and returns job IDs by node index,
+// spec returns a spec for a node, e.g. with its own transmitter address, so one template serves all nodes
+func (c *ChainlinkNodes) CreateJobs(spec func(index int) (string, error)) ([]string, error) {
+	ids := make([]string, c.Count())
+	eg := &errgroup.Group{}
+	for i := range ids {
+		i := i
+		eg.Go(func() error {
+			s, err := spec(i)
+			if err != nil {
+				return errors.Wrapf(err, "failed to render job spec of Chainlink node %d", i)
+			}
+			ids[i], err = c.CreateJob(i, s)
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return ids, err
+	}
+	log.Info().Int("Nodes", len(ids)).Msg("Jobs created on all Chainlink nodes")
+	return ids, nil
+}
+
+// DeleteJobs deletes all jobs of all nodes
+func (c *ChainlinkNodes) DeleteJobs() error {
+	eg := &errgroup.Group{}
+	for i := 0; i < c.Count(); i++ {
+		i := i
+		eg.Go(func() error {
+			jobs, err := c.ListJobs(i)
+			if err != nil {
+				return err
+			}
+			for _, j := range jobs {
+				if err := c.DeleteJob(i, j.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
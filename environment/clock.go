@@ -0,0 +1,10 @@
+package environment
+
+import (
+	"k8s.io/utils/clock"
+)
+
+// clock returns the clock of the environment client, see Config.Clock
+func (m *Environment) clock() clock.WithTicker {
+	return m.Client.Clock()
+}
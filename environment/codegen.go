@@ -0,0 +1,208 @@
+package environment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const chartsImportPrefix = "github.com/smartcontractkit/chainlink-env/pkg/helm/"
+
+// chartConstructor generates a Go expression creating a chart of this repository from its release name and values literal
+type chartConstructor struct {
+	pkg   string
+	alias string
+	expr  func(name string, values string) string
+}
+
+// chartConstructors constructors of charts by their paths, other charts are generated as comments
+var chartConstructors = map[string]chartConstructor{
+	"chainlink-qa/chainlink": {pkg: "chainlink", expr: func(name string, values string) string {
+		return fmt.Sprintf("chainlink.New(%d, %s)", releaseIndex(name), values)
+	}},
+	"chainlink-qa/geth": {pkg: "ethereum", expr: func(name string, values string) string {
+		return fmt.Sprintf("ethereum.New(&ethereum.Props{Name: %q, Simulated: true, Values: %s})", name, values)
+	}},
+	"chainlink-qa/mockserver": {pkg: "mockserver", expr: func(name string, values string) string {
+		return fmt.Sprintf("mockserver.New(%s)", values)
+	}},
+	"chainlink-qa/mockserver-config": {pkg: "mockserver-cfg", alias: "mockservercfg", expr: func(name string, values string) string {
+		return fmt.Sprintf("mockservercfg.New(%s)", values)
+	}},
+	"chainlink-qa/solana-validator": {pkg: "sol", expr: func(name string, values string) string {
+		return fmt.Sprintf("sol.New(&sol.Props{Name: %q, NetworkName: %q, Values: %s})", name, name, values)
+	}},
+	"chainlink-qa/remote-test-runner": {pkg: "remotetestrunner", expr: func(name string, values string) string {
+		return fmt.Sprintf("remotetestrunner.New(%s)", values)
+	}},
+	"bitnami/kafka": {pkg: "kafka", expr: func(name string, values string) string {
+		return fmt.Sprintf("kafka.New(%s)", values)
+	}},
+	"chainlink-qa/kafka-rest": {pkg: "kafka-rest", alias: "kafkarest", expr: func(name string, values string) string {
+		return fmt.Sprintf("kafkarest.New(%s)", values)
+	}},
+	"chainlink-qa/schema-registry": {pkg: "schema-registry", alias: "schemaregistry", expr: func(name string, values string) string {
+		return fmt.Sprintf("schemaregistry.New(%s)", values)
+	}},
+}
+
+// eaConstructor external adapters are mockserver charts named ea-${index}
+var eaConstructor = chartConstructor{pkg: "ea", expr: func(name string, values string) string {
+	return fmt.Sprintf("ea.New(%d, %s)", releaseIndex(name), values)
+}}
+
+// GoCode generates a Go program recreating the environment with environment.New and AddHelm calls with chart values,
+// so an environment built by hand can be turned into repeatable test code
+func (m *Environment) GoCode() ([]byte, error) {
+	return GoCode(m.archiveSpec())
+}
+
+// GoCodeFromArchive generates a Go program recreating the environment exported into the archive, see GoCode
+func GoCodeFromArchive(path string) ([]byte, error) {
+	files, err := readArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	specData, ok := files[ArchiveSpecFile]
+	if !ok {
+		return nil, errors.Errorf("%s not found in the archive %s", ArchiveSpecFile, path)
+	}
+	var spec ArchiveSpec
+	if err := json.Unmarshal(specData, &spec); err != nil {
+		return nil, errors.Wrap(err, "failed to parse environment spec")
+	}
+	return GoCode(&spec)
+}
+
+// GoCode generates a Go program recreating the environment of the spec, charts without known constructors are left as comments
+func GoCode(spec *ArchiveSpec) ([]byte, error) {
+	imports := map[string]string{"github.com/smartcontractkit/chainlink-env/environment": ""}
+	unknown := make([]string, 0)
+	var body strings.Builder
+	body.WriteString("e := environment.New(" + configLiteral(spec.Config, imports) + ")")
+	for _, c := range spec.Charts {
+		values := "nil"
+		if c.Values != nil {
+			values = goLiteral(*c.Values)
+		}
+		ctor, ok := chartConstructors[c.Path]
+		if ok && c.Path == "chainlink-qa/mockserver" && strings.HasPrefix(c.Name, "ea-") {
+			ctor = eaConstructor
+		}
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("// chart %s of %q has no known constructor, add it with AddHelm\n", c.Name, c.Path))
+			continue
+		}
+		imports[chartsImportPrefix+ctor.pkg] = ctor.alias
+		body.WriteString(".\nAddHelm(" + ctor.expr(c.Name, values) + ")")
+	}
+	var src bytes.Buffer
+	src.WriteString("package main\n\nimport (\n")
+	paths := make([]string, 0, len(imports))
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	// standard library imports go first
+	sort.SliceStable(paths, func(i, j int) bool {
+		return !strings.Contains(paths[i], ".") && strings.Contains(paths[j], ".")
+	})
+	for i, p := range paths {
+		if i > 0 && !strings.Contains(paths[i-1], ".") && strings.Contains(p, ".") {
+			src.WriteString("\n")
+		}
+		src.WriteString(fmt.Sprintf("%s %q\n", imports[p], p))
+	}
+	src.WriteString(")\n\nfunc main() {\n")
+	if spec.Namespace != "" {
+		src.WriteString(fmt.Sprintf("// generated from environment %s\n", spec.Namespace))
+	}
+	src.WriteString(body.String() + "\n")
+	src.WriteString(strings.Join(unknown, ""))
+	src.WriteString("if err := e.Run(); err != nil {\npanic(err)\n}\n}\n")
+	code, err := format.Source(src.Bytes())
+	return code, errors.Wrap(err, "failed to format generated code")
+}
+
+// configLiteral returns a config literal with the namespace prefix and TTL, other settings are taken from the environment
+func configLiteral(cfg *Config, imports map[string]string) string {
+	if cfg == nil {
+		return "nil"
+	}
+	fields := make([]string, 0)
+	if cfg.NamespacePrefix != "" {
+		fields = append(fields, fmt.Sprintf("NamespacePrefix: %q", cfg.NamespacePrefix))
+	}
+	if cfg.TTL != 0 {
+		imports["time"] = ""
+		fields = append(fields, "TTL: "+durationLiteral(cfg.TTL))
+	}
+	if len(fields) == 0 {
+		return "nil"
+	}
+	return "&environment.Config{" + strings.Join(fields, ", ") + "}"
+}
+
+func durationLiteral(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d * time.Hour", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d * time.Minute", d/time.Minute)
+	default:
+		return fmt.Sprintf("%d * time.Second", d/time.Second)
+	}
+}
+
+// goLiteral returns a Go literal of chart values, map keys are sorted so the code is stable
+func goLiteral(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "nil"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var sb strings.Builder
+		sb.WriteString("map[string]interface{}{\n")
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("%q: %s,\n", k, goLiteral(val[k])))
+		}
+		sb.WriteString("}")
+		return sb.String()
+	case []interface{}:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, goLiteral(item))
+		}
+		return "[]interface{}{" + strings.Join(items, ", ") + "}"
+	case string:
+		return strconv.Quote(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%#v", val)
+	}
+}
+
+// releaseIndex returns an index of an indexed release, e.g. 1 of chainlink-1
+func releaseIndex(name string) int {
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return 0
+	}
+	n, err := strconv.Atoi(name[i+1:])
+	if err != nil {
+		return 0
+	}
+	return n
+}
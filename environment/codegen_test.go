@@ -0,0 +1,44 @@
+package environment
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoCode(t *testing.T) {
+	spec := &ArchiveSpec{
+		Namespace: "chainlink-env-abc",
+		Config:    &Config{NamespacePrefix: "chainlink-env", TTL: 2 * time.Hour},
+		Charts: []ArchiveChart{
+			{Name: "geth", Path: "chainlink-qa/geth", Values: &map[string]interface{}{"replicas": float64(1)}},
+			{Name: "chainlink-1", Path: "chainlink-qa/chainlink", Values: &map[string]interface{}{
+				"chainlink": map[string]interface{}{"image": map[string]interface{}{"version": "1.6.0"}},
+				"env":       map[string]interface{}{"eth_chain_id": "1337", "feature": true},
+				"ports":     []interface{}{float64(6688), 0.5},
+			}},
+			{Name: "postgres", Path: "/cache/postgresql-12.1.0"},
+			{Name: "ea-2", Path: "chainlink-qa/mockserver"},
+		},
+	}
+	code, err := GoCode(spec)
+	require.NoError(t, err)
+	src := string(code)
+	_, err = parser.ParseFile(token.NewFileSet(), "env.go", code, 0)
+	require.NoError(t, err)
+	require.Contains(t, src, `TTL: 2 * time.Hour`)
+	require.Contains(t, src, `ethereum.New(&ethereum.Props{Name: "geth", Simulated: true, Values: map[string]interface{}{`)
+	require.Contains(t, src, `chainlink.New(1, map[string]interface{}{`)
+	require.Contains(t, src, `"ports": []interface{}{6688, 0.5},`)
+	require.Contains(t, src, `ea.New(2, nil)`)
+	require.Contains(t, src, `"github.com/smartcontractkit/chainlink-env/pkg/helm/ea"`)
+	require.NotContains(t, src, `pkg/helm/mockserver"`)
+	require.Contains(t, src, `// chart postgres of "/cache/postgresql-12.1.0" has no known constructor`)
+
+	again, err := GoCode(spec)
+	require.NoError(t, err)
+	require.Equal(t, src, string(again))
+}
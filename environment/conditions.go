@@ -0,0 +1,241 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+const (
+	ConditionRequestTimeout = 10 * time.Second
+)
+
+// Condition is a declarative post-ready condition of a chart, either an HTTP request to a chart port with a value
+// selected by JSONPath or a PromQL query, the value is compared to Value with Op
+type Condition struct {
+	Name string
+	// App, Instance, Container and Port select a forwarded port for HTTP conditions
+	App       string
+	Instance  int
+	Container string
+	Port      string
+	Method    string
+	Path      string
+	Body      string
+	// JSONPath dot separated path of the value in the JSON response, e.g. "result" or "data.0.status", the whole body if empty
+	JSONPath string
+	// PromQL query evaluated with Config.PrometheusURL, must return one sample
+	PromQL string
+	// Op is one of ==, !=, >, >=, <, <=
+	Op    string
+	Value float64
+}
+
+// ConditionedChart is a chart declaring post-ready conditions, they are waited for as a part of CheckHealth,
+// for example, a block height of a chain greater than 1
+type ConditionedChart interface {
+	ConnectedChart
+	Conditions() []Condition
+}
+
+// checkConditions returns an error if any condition of the chart is not met
+func (m *Environment) checkConditions(c ConditionedChart) error {
+	for _, cond := range c.Conditions() {
+		var value float64
+		var err error
+		if cond.PromQL != "" {
+			value, err = m.promQLValue(cond.PromQL)
+		} else {
+			value, err = m.httpConditionValue(cond)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "condition %s", cond.Name)
+		}
+		ok, err := compareCondition(value, cond.Op, cond.Value)
+		if err != nil {
+			return errors.Wrapf(err, "condition %s", cond.Name)
+		}
+		if !ok {
+			return errors.Errorf("condition %s is not met: %v %s %v", cond.Name, value, cond.Op, cond.Value)
+		}
+	}
+	return nil
+}
+
+func (m *Environment) httpConditionValue(cond Condition) (float64, error) {
+	base, err := m.Fwd.FindPort(fmt.Sprintf("%s:%d", cond.App, cond.Instance), cond.Container, cond.Port).
+		As(m.connectionMode(), client.HTTP)
+	if err != nil {
+		return 0, err
+	}
+	method := cond.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ConditionRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, base+cond.Path, strings.NewReader(cond.Body))
+	if err != nil {
+		return 0, err
+	}
+	if cond.Body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	body, err := doConditionRequest(req)
+	if err != nil {
+		return 0, err
+	}
+	return jsonPathNumber(body, cond.JSONPath)
+}
+
+func (m *Environment) promQLValue(query string) (float64, error) {
+	if m.Cfg.PrometheusURL == "" {
+		return 0, errors.New("PrometheusURL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ConditionRequestTimeout)
+	defer cancel()
+	u := fmt.Sprintf("%s/api/v1/query?query=%s", strings.TrimSuffix(m.Cfg.PrometheusURL, "/"), url.QueryEscape(query))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	body, err := doConditionRequest(req)
+	if err != nil {
+		return 0, err
+	}
+	return parsePromQLValue(body)
+}
+
+func doConditionRequest(req *http.Request) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, errors.Errorf("%s %s: unexpected status %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+	return body, nil
+}
+
+// jsonPathNumber selects a value by a dot separated path and converts it to a number
+func jsonPathNumber(body []byte, path string) (float64, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return 0, errors.Wrap(err, "response is not JSON")
+	}
+	if path != "" {
+		for _, p := range strings.Split(path, ".") {
+			switch o := v.(type) {
+			case map[string]interface{}:
+				field, ok := o[p]
+				if !ok {
+					return 0, errors.Errorf("no field %s in path %s", p, path)
+				}
+				v = field
+			case []interface{}:
+				i, err := strconv.Atoi(p)
+				if err != nil || i < 0 || i >= len(o) {
+					return 0, errors.Errorf("bad index %s in path %s", p, path)
+				}
+				v = o[i]
+			default:
+				return 0, errors.Errorf("can't select %s in path %s", p, path)
+			}
+		}
+	}
+	return toNumber(v)
+}
+
+// toNumber converts JSON numbers, booleans, decimal and hex strings, like eth_blockNumber results, to a number
+func toNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if strings.HasPrefix(n, "0x") {
+			i, err := strconv.ParseUint(strings.TrimPrefix(n, "0x"), 16, 64)
+			return float64(i), err
+		}
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, errors.Errorf("value %v is not a number", v)
+}
+
+// parsePromQLValue parses a Prometheus instant query response with one vector sample or a scalar
+func parsePromQLValue(body []byte) (float64, error) {
+	var resp struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, err
+	}
+	if resp.Status != "success" {
+		return 0, errors.Errorf("query failed: %s", resp.Error)
+	}
+	var sample []interface{}
+	switch resp.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(resp.Data.Result, &sample); err != nil {
+			return 0, err
+		}
+	case "vector":
+		var vector []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(resp.Data.Result, &vector); err != nil {
+			return 0, err
+		}
+		if len(vector) != 1 {
+			return 0, errors.Errorf("query must return one sample, got %d", len(vector))
+		}
+		sample = vector[0].Value
+	default:
+		return 0, errors.Errorf("unsupported result type %s", resp.Data.ResultType)
+	}
+	if len(sample) != 2 {
+		return 0, errors.New("bad sample")
+	}
+	return toNumber(sample[1])
+}
+
+func compareCondition(value float64, op string, expected float64) (bool, error) {
+	switch op {
+	case "==":
+		return value == expected, nil
+	case "!=":
+		return value != expected, nil
+	case ">":
+		return value > expected, nil
+	case ">=":
+		return value >= expected, nil
+	case "<":
+		return value < expected, nil
+	case "<=":
+		return value <= expected, nil
+	}
+	return false, errors.Errorf("unknown operator %q", op)
+}
@@ -0,0 +1,45 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPathNumber(t *testing.T) {
+	v, err := jsonPathNumber([]byte(`{"jsonrpc":"2.0","result":"0x1a"}`), "result")
+	require.NoError(t, err)
+	require.Equal(t, float64(26), v)
+	v, err = jsonPathNumber([]byte(`{"data":[{"count":3},{"count":5}]}`), "data.1.count")
+	require.NoError(t, err)
+	require.Equal(t, float64(5), v)
+	v, err = jsonPathNumber([]byte(`true`), "")
+	require.NoError(t, err)
+	require.Equal(t, float64(1), v)
+	_, err = jsonPathNumber([]byte(`{"data":[]}`), "data.0")
+	require.Error(t, err)
+}
+
+func TestParsePromQLValue(t *testing.T) {
+	v, err := parsePromQLValue([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1665000000.1,"0"]}]}}`))
+	require.NoError(t, err)
+	require.Equal(t, float64(0), v)
+	v, err = parsePromQLValue([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1665000000.1,"2.5"]}}`))
+	require.NoError(t, err)
+	require.Equal(t, 2.5, v)
+	_, err = parsePromQLValue([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	require.Error(t, err)
+	_, err = parsePromQLValue([]byte(`{"status":"error","error":"parse error"}`))
+	require.Error(t, err)
+}
+
+func TestCompareCondition(t *testing.T) {
+	ok, err := compareCondition(2, ">", 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = compareCondition(1, "==", 0)
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, err = compareCondition(1, "=", 1)
+	require.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package environment
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+// connectionMode in-cluster connection if the test runs inside K8s, forwarded ports otherwise
+func (m *Environment) connectionMode() client.ConnectionMode {
+	if m.Cfg.InsideK8s {
+		return client.RemoteConnection
+	}
+	return client.LocalConnection
+}
+
+// URL returns an URL of a pod port using the port protocol
+func (m *Environment) URL(app string, instance int, container string, port string) (string, error) {
+	return m.Fwd.FindPort(fmt.Sprintf("%s:%d", app, instance), container, port).URL(m.connectionMode())
+}
+
+// EVMWebsocketURL returns an URL of the first websocket port in the environment, simulated EVM chains are the only websocket services
+func (m *Environment) EVMWebsocketURL() (string, error) {
+	conns := m.Fwd.Connections(client.WS)
+	if len(conns) == 0 {
+		return "", errors.New("no websocket ports found in the environment")
+	}
+	return client.NewURLConverter(conns[0].Info, nil).URL(m.connectionMode())
+}
+
+// PostgresDSN returns a DSN of the first postgres port of a pod, port credentials are set by the chart
+func (m *Environment) PostgresDSN(app string, instance int) (string, error) {
+	for _, c := range m.Fwd.Connections(client.Postgres) {
+		if c.App == app && c.Instance == fmt.Sprintf("%d", instance) {
+			return client.NewURLConverter(c.Info, nil).URL(m.connectionMode())
+		}
+	}
+	return "", errors.Errorf("no postgres ports found for %s:%d", app, instance)
+}
+
+// ConnectionsChart is a chart with typed connection info, e.g. chainlink.Connections, so tests don't parse URLs,
+// charts still export URLs for compatibility
+type ConnectionsChart interface {
+	ConnectedChart
+	Connections(e *Environment) (interface{}, error)
+}
+
+// Connections returns typed connection info of a deployed chart, see ConnectionsChart and Get
+func (m *Environment) Connections(name string) (interface{}, error) {
+	c := m.chart(name)
+	if c == nil {
+		return nil, errors.Errorf("chart %s not found", name)
+	}
+	cc, ok := c.(ConnectionsChart)
+	if !ok {
+		return nil, errors.Errorf("chart %s has no typed connections, use URLs", name)
+	}
+	return cc.Connections(m)
+}
+
+// Get returns typed connection info of a deployed chart, e.g. environment.Get[*chainlink.Connections](e, "chainlink-0")
+func Get[T any](e *Environment, name string) (T, error) {
+	var typed T
+	conns, err := e.Connections(name)
+	if err != nil {
+		return typed, err
+	}
+	typed, ok := conns.(T)
+	if !ok {
+		return typed, errors.Errorf("connections of chart %s are %T, not %T", name, conns, typed)
+	}
+	return typed, nil
+}
@@ -0,0 +1,124 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ContractsAnnotationKey namespace annotation with addresses of bootstrapped contracts in JSON
+	ContractsAnnotationKey = "chainlink-env/contracts"
+	// ContractsReport artifacts report with addresses of bootstrapped contracts
+	ContractsReport = "contracts"
+)
+
+// ChainBootstrapper deploys contracts on the environment chain once it's ready and returns their addresses by names,
+// implementations with on-chain dependencies live outside of this package, see contracts.NewFeed
+type ChainBootstrapper interface {
+	Bootstrap(e *Environment) (map[string]string, error)
+}
+
+// ContractAddress returns an address of a bootstrapped contract, see Config.Bootstrap
+func (m *Environment) ContractAddress(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	addr, ok := m.Contracts[name]
+	if !ok {
+		return "", errors.Errorf("contract %s is not bootstrapped", name)
+	}
+	return addr, nil
+}
+
+// bootstrapContracts runs bootstrappers of the config and records contract addresses in the namespace,
+// an environment connected to already has its contracts, so nothing is deployed twice
+func (m *Environment) bootstrapContracts() error {
+	if len(m.Cfg.Bootstrap) == 0 {
+		return nil
+	}
+	recorded, err := m.loadContracts()
+	if err != nil {
+		return err
+	}
+	if len(recorded) == 0 {
+		for _, b := range m.Cfg.Bootstrap {
+			addresses, err := b.Bootstrap(m)
+			if err != nil {
+				return errors.Wrap(err, "failed to bootstrap contracts")
+			}
+			if err := mergeContracts(recorded, addresses); err != nil {
+				return err
+			}
+		}
+		if err := m.recordContracts(recorded); err != nil {
+			return err
+		}
+	}
+	m.mu.Lock()
+	for name, addr := range recorded {
+		m.Contracts[name] = addr
+	}
+	m.mu.Unlock()
+	log.Info().Interface("Contracts", recorded).Msg("Contracts are bootstrapped")
+	return nil
+}
+
+// mergeContracts adds addresses of a bootstrapper, names must be unique across bootstrappers
+func mergeContracts(dst map[string]string, src map[string]string) error {
+	names := make([]string, 0, len(src))
+	for name := range src {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := dst[name]; ok {
+			return errors.Errorf("contract %s is bootstrapped twice", name)
+		}
+		dst[name] = src[name]
+	}
+	return nil
+}
+
+// recordContracts stores contract addresses in the namespace annotation
+func (m *Environment) recordContracts(contracts map[string]string) error {
+	data, err := json.Marshal(contracts)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{ContractsAnnotationKey: string(data)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.Client.ClientSet.CoreV1().Namespaces().Patch(context.Background(), m.Cfg.Namespace, types.MergePatchType, patch, metaV1.PatchOptions{})
+	return errors.Wrap(err, "failed to record contract addresses")
+}
+
+// loadContracts returns contract addresses recorded in the namespace, empty if nothing is bootstrapped yet
+func (m *Environment) loadContracts() (map[string]string, error) {
+	ns, err := m.Client.ClientSet.CoreV1().Namespaces().Get(context.Background(), m.Cfg.Namespace, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return parseContracts(ns.Annotations)
+}
+
+func parseContracts(annotations map[string]string) (map[string]string, error) {
+	contracts := make(map[string]string)
+	raw, ok := annotations[ContractsAnnotationKey]
+	if !ok {
+		return contracts, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &contracts); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s annotation", ContractsAnnotationKey)
+	}
+	return contracts, nil
+}
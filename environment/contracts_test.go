@@ -0,0 +1,25 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContracts(t *testing.T) {
+	contracts, err := parseContracts(map[string]string{})
+	require.NoError(t, err)
+	require.Empty(t, contracts)
+	contracts, err = parseContracts(map[string]string{ContractsAnnotationKey: `{"link":"0x01"}`})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"link": "0x01"}, contracts)
+	_, err = parseContracts(map[string]string{ContractsAnnotationKey: `{`})
+	require.Error(t, err)
+}
+
+func TestMergeContracts(t *testing.T) {
+	dst := map[string]string{"link": "0x01"}
+	require.NoError(t, mergeContracts(dst, map[string]string{"oracle": "0x02"}))
+	require.Equal(t, map[string]string{"link": "0x01", "oracle": "0x02"}, dst)
+	require.Error(t, mergeContracts(dst, map[string]string{"link": "0x03"}))
+}
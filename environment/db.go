@@ -0,0 +1,109 @@
+package environment
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// postgresDriver is a database/sql driver name of lib/pq and pgx stdlib drivers,
+// the framework doesn't depend on any driver, tests import the one they use
+const postgresDriver = "postgres"
+
+// PodDB is a postgres database of a pod, reachable through a forwarded port or in-cluster
+type PodDB struct {
+	App      string
+	Instance int
+	DSN      string
+	err      error
+}
+
+// DB returns a database of a pod postgres port, for example, chainlink node database e.DB("chainlink-0", 0),
+// a postgres driver must be registered by the test, e.g. import _ "github.com/lib/pq"
+func (m *Environment) DB(app string, instance int) *PodDB {
+	dsn, err := m.PostgresDSN(app, instance)
+	return &PodDB{App: app, Instance: instance, DSN: dsn, err: err}
+}
+
+func (d *PodDB) open() (*sql.DB, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	for _, drv := range sql.Drivers() {
+		if drv == postgresDriver {
+			return sql.Open(postgresDriver, d.DSN)
+		}
+	}
+	return nil, errors.Errorf("no %s database driver registered, import one in tests, e.g. _ \"github.com/lib/pq\"", postgresDriver)
+}
+
+// Query runs a query and returns rows as column name to value maps, []byte values are returned as strings
+func (d *PodDB) Query(query string, args ...interface{}) ([]map[string]interface{}, error) {
+	db, err := d.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "query failed on %s", d)
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(cols))
+		for i, col := range cols {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+				continue
+			}
+			row[col] = values[i]
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// QueryInt runs a query returning a single number, for example, select count(*) from pipeline_runs
+func (d *PodDB) QueryInt(query string, args ...interface{}) (int64, error) {
+	db, err := d.open()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	var n int64
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		return 0, errors.Wrapf(err, "query failed on %s", d)
+	}
+	return n, nil
+}
+
+// Exec runs a statement, for example, to prepare a node state before the test
+func (d *PodDB) Exec(query string, args ...interface{}) error {
+	db, err := d.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.Exec(query, args...); err != nil {
+		return errors.Wrapf(err, "statement failed on %s", d)
+	}
+	return nil
+}
+
+func (d *PodDB) String() string {
+	return fmt.Sprintf("%s:%d database", d.App, d.Instance)
+}
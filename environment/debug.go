@@ -0,0 +1,36 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ValuesFilePattern Helm values of a chart are written as ${chart}-values-*.yaml in debug mode, every render is retained
+	ValuesFilePattern = "%s-values-*.yaml"
+)
+
+// writeDebugValues writes final Helm values of a chart into the debug directory, does nothing if Config.Debug is off
+func (m *Environment) writeDebugValues(name string, values *map[string]interface{}) {
+	dir := m.Client.ManifestsDir
+	if dir == "" || values == nil {
+		return
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		log.Warn().Err(err).Str("Chart", name).Msg("Failed to marshal chart values")
+		return
+	}
+	f, err := os.CreateTemp(dir, fmt.Sprintf(ValuesFilePattern, name))
+	if err != nil {
+		log.Warn().Err(err).Str("Dir", dir).Msg("Failed to create values file")
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		log.Warn().Err(err).Str("File", f.Name()).Msg("Failed to write chart values")
+	}
+}
@@ -0,0 +1,145 @@
+package environment
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// DependentChart is a chart deployed only after charts it depends on are ready, dependencies which are not
+// in the environment are ignored, so a chart can depend on the default release names of optional charts
+type DependentChart interface {
+	ConnectedChart
+	Dependencies() []string
+}
+
+// DependsOn declares that a chart is deployed only after the other charts are ready,
+// in addition to dependencies of DependentChart and charts publishing outputs it references
+func (m *Environment) DependsOn(chart string, deps ...string) *Environment {
+	m.dependencies[chart] = append(m.dependencies[chart], deps...)
+	return m
+}
+
+// chartDependencies returns dependencies of every chart in the environment: declared ones and charts
+// which outputs are referenced in the release manifest, only charts of the environment are returned
+func (m *Environment) chartDependencies(releases map[string]*releaseManifest) map[string][]string {
+	known := make(map[string]bool)
+	for _, c := range m.Charts {
+		known[c.GetName()] = true
+	}
+	deps := make(map[string][]string)
+	for _, c := range m.Charts {
+		name := c.GetName()
+		all := append([]string{}, m.dependencies[name]...)
+		if dc, ok := c.(DependentChart); ok {
+			all = append(all, dc.Dependencies()...)
+		}
+		if rm, ok := releases[name]; ok {
+			for _, ref := range outputRefPattern.FindAllStringSubmatch(rm.Manifest, -1) {
+				all = append(all, ref[1])
+			}
+		}
+		deps[name] = filterDependencies(name, all, known)
+	}
+	return deps
+}
+
+// filterDependencies returns sorted unique dependencies which are known charts, except the chart itself
+func filterDependencies(name string, deps []string, known map[string]bool) []string {
+	seen := make(map[string]bool)
+	res := make([]string, 0)
+	for _, d := range deps {
+		if d == name || !known[d] || seen[d] {
+			continue
+		}
+		seen[d] = true
+		res = append(res, d)
+	}
+	sort.Strings(res)
+	return res
+}
+
+type chartDeployResult struct {
+	name string
+	err  error
+}
+
+// deployCharts deploys charts which are not ready yet with up to Config.DeployWorkers charts at a time in their order,
+// a chart starts when all its dependencies are ready, no charts are started after a failure
+func (m *Environment) deployCharts(releases map[string]*releaseManifest) error {
+	workers := m.Cfg.DeployWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	deps := m.chartDependencies(releases)
+	ready := make(map[string]bool)
+	pending := make([]string, 0)
+	for _, c := range m.Charts {
+		name := c.GetName()
+		if m.ChartStatus(name) == ChartStatusReady {
+			log.Info().Str("Chart", name).Msg("Chart is ready, skipping")
+			ready[name] = true
+			continue
+		}
+		pending = append(pending, name)
+	}
+	results := make(chan chartDeployResult)
+	running := 0
+	var deployErr error
+	for {
+		if deployErr == nil {
+			pending = startCharts(pending, deps, ready, workers-running, func(name string) {
+				running++
+				log.Debug().Str("Chart", name).Strs("Dependencies", deps[name]).Msg("Deploying chart")
+				go func() {
+					results <- chartDeployResult{name: name, err: m.deployChart(name, releases[name])}
+				}()
+			})
+		}
+		if running == 0 {
+			break
+		}
+		r := <-results
+		running--
+		if r.err != nil {
+			m.setChartStatus(r.name, ChartStatusFailed)
+			if deployErr == nil {
+				deployErr = errors.Wrapf(r.err, "failed to deploy chart %s", r.name)
+			}
+			continue
+		}
+		m.setChartStatus(r.name, ChartStatusReady)
+		ready[r.name] = true
+	}
+	if deployErr != nil {
+		return deployErr
+	}
+	if len(pending) != 0 {
+		return errors.Errorf("charts %v have circular dependencies", pending)
+	}
+	return nil
+}
+
+// startCharts starts up to n pending charts which dependencies are ready in order and returns charts left pending
+func startCharts(pending []string, deps map[string][]string, ready map[string]bool, n int, start func(name string)) []string {
+	left := make([]string, 0, len(pending))
+	for _, name := range pending {
+		if n > 0 && dependenciesReady(deps[name], ready) {
+			start(name)
+			n--
+			continue
+		}
+		left = append(left, name)
+	}
+	return left
+}
+
+func dependenciesReady(deps []string, ready map[string]bool) bool {
+	for _, d := range deps {
+		if !ready[d] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterDependencies(t *testing.T) {
+	known := map[string]bool{"geth": true, "mockserver": true, "chainlink-0": true}
+	deps := filterDependencies("chainlink-0", []string{"sol", "mockserver", "geth", "chainlink-0", "geth"}, known)
+	require.Equal(t, []string{"geth", "mockserver"}, deps)
+}
+
+func TestStartCharts(t *testing.T) {
+	deps := map[string][]string{
+		"chainlink-0": {"geth"},
+		"chainlink-1": {"geth"},
+	}
+	ready := make(map[string]bool)
+	var started []string
+	start := func(name string) { started = append(started, name) }
+
+	pending := startCharts([]string{"geth", "chainlink-0", "mockserver", "chainlink-1"}, deps, ready, 4, start)
+	require.Equal(t, []string{"geth", "mockserver"}, started)
+	require.Equal(t, []string{"chainlink-0", "chainlink-1"}, pending)
+
+	ready["geth"] = true
+	started = nil
+	pending = startCharts(pending, deps, ready, 1, start)
+	require.Equal(t, []string{"chainlink-0"}, started)
+	require.Equal(t, []string{"chainlink-1"}, pending)
+
+	// circular dependencies are never started
+	deps = map[string][]string{"a": {"b"}, "b": {"a"}}
+	started = nil
+	pending = startCharts([]string{"a", "b"}, deps, ready, 4, start)
+	require.Empty(t, started)
+	require.Equal(t, []string{"a", "b"}, pending)
+}
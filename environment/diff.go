@@ -0,0 +1,236 @@
+package environment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ChartAnnotationKey Helm chart path or repo of the release resources
+	ChartAnnotationKey = "chainlink-env/chart"
+	// ValuesHashAnnotationKey hash of the final Helm values of the release resources
+	ValuesHashAnnotationKey = "chainlink-env/values-hash"
+	// HelmChartLabelKey standard Helm label with the chart name and version
+	HelmChartLabelKey = "helm.sh/chart"
+)
+
+// valuesHash returns a short hash of the values, map keys are sorted when marshaled, so equal values have equal hashes
+func valuesHash(values *map[string]interface{}) string {
+	if values == nil {
+		return ""
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])[:12]
+}
+
+// ContainerSnapshot container settings compared by Diff
+type ContainerSnapshot struct {
+	Image    string
+	Requests map[string]string
+	Limits   map[string]string
+}
+
+// ReleaseSnapshot chart release settings compared by Diff
+type ReleaseSnapshot struct {
+	Chart        string
+	ChartVersion string
+	ValuesHash   string
+	// Containers by "${workload}/${container}"
+	Containers map[string]ContainerSnapshot
+	// Replicas by workload name
+	Replicas map[string]int32
+}
+
+// Snapshot is a part of an environment state compared by Diff
+type Snapshot struct {
+	Namespace string
+	// Releases by release name, resources without a release label are grouped under an empty name
+	Releases map[string]*ReleaseSnapshot
+	// Metrics key metrics added by tests, e.g. a number of nodes or jobs, compared as strings
+	Metrics map[string]string
+}
+
+// Difference is a setting that differs in two environments, an empty value means it's missing
+type Difference struct {
+	Release string
+	Field   string
+	A       string
+	B       string
+}
+
+// TakeSnapshot reads charts, values hashes, images and resources of all deployments and stateful sets in the namespace
+func TakeSnapshot(c *client.K8sClient, namespace string) (*Snapshot, error) {
+	s := &Snapshot{Namespace: namespace, Releases: make(map[string]*ReleaseSnapshot)}
+	deployments, err := c.ClientSet.AppsV1().Deployments(namespace).List(context.Background(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		s.addWorkload(d.ObjectMeta, d.Spec.Replicas, d.Spec.Template.Spec)
+	}
+	statefulSets, err := c.ClientSet.AppsV1().StatefulSets(namespace).List(context.Background(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ss := range statefulSets.Items {
+		s.addWorkload(ss.ObjectMeta, ss.Spec.Replicas, ss.Spec.Template.Spec)
+	}
+	return s, nil
+}
+
+func (s *Snapshot) addWorkload(meta metaV1.ObjectMeta, replicas *int32, spec coreV1.PodSpec) {
+	name := meta.Labels[pkg.ReleaseLabelKey]
+	r, ok := s.Releases[name]
+	if !ok {
+		r = &ReleaseSnapshot{
+			Chart:        meta.Annotations[ChartAnnotationKey],
+			ChartVersion: meta.Labels[HelmChartLabelKey],
+			ValuesHash:   meta.Annotations[ValuesHashAnnotationKey],
+			Containers:   make(map[string]ContainerSnapshot),
+			Replicas:     make(map[string]int32),
+		}
+		s.Releases[name] = r
+	}
+	// K8s defaults replicas to 1
+	r.Replicas[meta.Name] = 1
+	if replicas != nil {
+		r.Replicas[meta.Name] = *replicas
+	}
+	for _, c := range spec.Containers {
+		cs := ContainerSnapshot{
+			Image:    c.Image,
+			Requests: make(map[string]string),
+			Limits:   make(map[string]string),
+		}
+		for k, v := range c.Resources.Requests {
+			cs.Requests[string(k)] = v.String()
+		}
+		for k, v := range c.Resources.Limits {
+			cs.Limits[string(k)] = v.String()
+		}
+		r.Containers[fmt.Sprintf("%s/%s", meta.Name, c.Name)] = cs
+	}
+}
+
+// DiffEnvironments compares chart versions, values hashes, images and resources of two environments
+func DiffEnvironments(namespaceA string, namespaceB string) ([]Difference, error) {
+	c := client.NewK8sClient()
+	a, err := TakeSnapshot(c, namespaceA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := TakeSnapshot(c, namespaceB)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(a, b), nil
+}
+
+// Diff returns all differences of two snapshots sorted by release and field, metrics differences go last
+func Diff(a *Snapshot, b *Snapshot) []Difference {
+	diffs := make([]Difference, 0)
+	add := func(release string, field string, va string, vb string) {
+		if va != vb {
+			diffs = append(diffs, Difference{Release: release, Field: field, A: va, B: vb})
+		}
+	}
+	for _, name := range unionKeys(a.Releases, b.Releases) {
+		ra, okA := a.Releases[name]
+		rb, okB := b.Releases[name]
+		if !okA || !okB {
+			add(name, "release", presence(okA), presence(okB))
+			continue
+		}
+		add(name, "chart", ra.Chart, rb.Chart)
+		add(name, "chart version", ra.ChartVersion, rb.ChartVersion)
+		add(name, "values hash", ra.ValuesHash, rb.ValuesHash)
+		for _, cn := range unionKeys(ra.Containers, rb.Containers) {
+			ca, okA := ra.Containers[cn]
+			cb, okB := rb.Containers[cn]
+			if !okA || !okB {
+				add(name, cn, presence(okA), presence(okB))
+				continue
+			}
+			add(name, cn+" image", ca.Image, cb.Image)
+			for _, k := range unionKeys(ca.Requests, cb.Requests) {
+				add(name, fmt.Sprintf("%s requests.%s", cn, k), ca.Requests[k], cb.Requests[k])
+			}
+			for _, k := range unionKeys(ca.Limits, cb.Limits) {
+				add(name, fmt.Sprintf("%s limits.%s", cn, k), ca.Limits[k], cb.Limits[k])
+			}
+		}
+		for _, w := range unionKeys(ra.Replicas, rb.Replicas) {
+			add(name, w+" replicas", replicas(ra.Replicas, w), replicas(rb.Replicas, w))
+		}
+	}
+	for _, k := range unionKeys(a.Metrics, b.Metrics) {
+		add("", "metric "+k, a.Metrics[k], b.Metrics[k])
+	}
+	return diffs
+}
+
+func replicas(r map[string]int32, workload string) string {
+	n, ok := r[workload]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func presence(ok bool) string {
+	if ok {
+		return "present"
+	}
+	return ""
+}
+
+func unionKeys[V any](a map[string]V, b map[string]V) []string {
+	set := make(map[string]struct{})
+	for k := range a {
+		set[k] = struct{}{}
+	}
+	for k := range b {
+		set[k] = struct{}{}
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PrintDiff logs all differences of two environments
+func PrintDiff(namespaceA string, namespaceB string) error {
+	diffs, err := DiffEnvironments(namespaceA, namespaceB)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		log.Info().Str("A", namespaceA).Str("B", namespaceB).Msg("Environments are equal")
+		return nil
+	}
+	for _, d := range diffs {
+		log.Warn().
+			Str("Release", d.Release).
+			Str("Field", d.Field).
+			Str(namespaceA, d.A).
+			Str(namespaceB, d.B).
+			Msg("Environments differ")
+	}
+	return nil
+}
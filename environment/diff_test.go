@@ -0,0 +1,45 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValuesHash(t *testing.T) {
+	a := &map[string]interface{}{"replicas": 1, "env": map[string]interface{}{"A": "1", "B": "2"}}
+	b := &map[string]interface{}{"env": map[string]interface{}{"B": "2", "A": "1"}, "replicas": 1}
+	c := &map[string]interface{}{"replicas": 2}
+	require.Equal(t, valuesHash(a), valuesHash(b))
+	require.NotEqual(t, valuesHash(a), valuesHash(c))
+	require.Equal(t, "", valuesHash(nil))
+}
+
+func TestDiff(t *testing.T) {
+	a := &Snapshot{Releases: map[string]*ReleaseSnapshot{
+		"chainlink-0": {
+			ChartVersion: "chainlink-0.1.0",
+			ValuesHash:   "aaa",
+			Containers: map[string]ContainerSnapshot{
+				"chainlink-0/node": {Image: "chainlink:1.5.1", Requests: map[string]string{"cpu": "350m"}},
+			},
+		},
+		"geth": {},
+	}}
+	b := &Snapshot{Releases: map[string]*ReleaseSnapshot{
+		"chainlink-0": {
+			ChartVersion: "chainlink-0.1.0",
+			ValuesHash:   "bbb",
+			Containers: map[string]ContainerSnapshot{
+				"chainlink-0/node": {Image: "chainlink:1.6.0", Requests: map[string]string{"cpu": "350m", "memory": "1Gi"}},
+			},
+		},
+	}}
+	require.Equal(t, []Difference{
+		{Release: "chainlink-0", Field: "values hash", A: "aaa", B: "bbb"},
+		{Release: "chainlink-0", Field: "chainlink-0/node image", A: "chainlink:1.5.1", B: "chainlink:1.6.0"},
+		{Release: "chainlink-0", Field: "chainlink-0/node requests.memory", A: "", B: "1Gi"},
+		{Release: "geth", Field: "release", A: "present", B: ""},
+	}, Diff(a, b))
+	require.Empty(t, Diff(a, a))
+}
@@ -0,0 +1,66 @@
+package environment
+
+import (
+	"fmt"
+
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/smartcontractkit/chainlink-env/imports/k8s"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+)
+
+// StableService is a headless service which name doesn't change across chart upgrades and pod restarts
+type StableService struct {
+	Name     string
+	Selector map[string]string
+}
+
+// StableDNSChart is implemented by charts which pods are referenced by name, for example, in node TOML configs,
+// a headless service is created for every StableService as a part of the chart release
+type StableDNSChart interface {
+	StableServices() []StableService
+}
+
+// ServiceDNSName returns in-cluster DNS name of a service
+func ServiceDNSName(service string, namespace string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", service, namespace)
+}
+
+// addStableServices adds headless services of the chart, not ready pods are published too,
+// so peers can resolve each other while starting
+func (m *Environment) addStableServices(scope constructs.Construct, name string, chart ConnectedChart) {
+	sc, ok := chart.(StableDNSChart)
+	if !ok {
+		return
+	}
+	for _, s := range sc.StableServices() {
+		k8s.NewKubeService(scope, a.Str(fmt.Sprintf("dns-%s", s.Name)), &k8s.KubeServiceProps{
+			Metadata: &k8s.ObjectMeta{
+				Name:   a.Str(s.Name),
+				Labels: a.ConvertLabelsMap(map[string]string{pkg.ReleaseLabelKey: name}),
+			},
+			Spec: &k8s.ServiceSpec{
+				ClusterIp:                a.Str("None"),
+				PublishNotReadyAddresses: a.Bool(true),
+				Selector:                 a.ConvertLabelsMap(s.Selector),
+			},
+		})
+	}
+}
+
+// exportDNS fills stable DNS names of all charts
+func (m *Environment) exportDNS() {
+	m.DNS = make(map[string]string)
+	for _, c := range m.Charts {
+		if m.isGreen(c.GetName()) {
+			continue
+		}
+		sc, ok := c.(StableDNSChart)
+		if !ok {
+			continue
+		}
+		for _, s := range sc.StableServices() {
+			m.DNS[s.Name] = ServiceDNSName(s.Name, m.Cfg.Namespace)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package environment
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+// EvictPods evicts environment pods matching the selector with the Eviction API and waits until they are rescheduled,
+// disruption budgets are respected, see client.K8sClient.EvictPods
+func (m *Environment) EvictPods(selector string, timeout time.Duration) (*client.Rescheduling, error) {
+	r, err := m.Client.EvictPods(m.Cfg.Namespace, selector, timeout)
+	m.recordEvent("evict", selector, err)
+	return r, err
+}
+
+// DrainNodesOf drains nodes hosting environment pods matching the selector one by one, like cluster maintenance does,
+// only pods of the environment are evicted, drained nodes stay cordoned until UncordonNodes or Shutdown
+func (m *Environment) DrainNodesOf(selector string, timeout time.Duration) ([]*client.Rescheduling, error) {
+	nodes, err := m.Client.NodesOf(m.Cfg.Namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]*client.Rescheduling, 0)
+	for _, node := range nodes {
+		m.mu.Lock()
+		m.cordoned = append(m.cordoned, node)
+		m.mu.Unlock()
+		r, err := m.Client.DrainNode(node, m.Cfg.Namespace, timeout)
+		m.recordEvent("drain", node, err)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+// UncordonNodes marks nodes drained by DrainNodesOf schedulable again
+func (m *Environment) UncordonNodes() error {
+	m.mu.Lock()
+	nodes := m.cordoned
+	m.cordoned = nil
+	m.mu.Unlock()
+	var lastErr error
+	for _, node := range nodes {
+		if err := m.Client.UncordonNode(node); err != nil {
+			log.Warn().Err(err).Str("Node", node).Msg("Failed to uncordon node")
+			lastErr = err
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,310 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+const (
+	DriftReportFile = "drift.json"
+)
+
+// DriftRecord is a modification of an environment resource made outside of this environment process
+type DriftRecord struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Event     string    `json:"event"`
+	Manager   string    `json:"manager"`
+	Operation string    `json:"operation"`
+}
+
+// driftIgnoredManagers are field managers of cluster controllers, their changes are not out-of-band
+var driftIgnoredManagers = []string{
+	"kube-controller-manager",
+	"kube-scheduler",
+	"kubelet",
+	"k3s",
+}
+
+// driftSource describes how to list, watch and fingerprint one kind of resource
+type driftSource struct {
+	kind        string
+	resource    string
+	client      func(cs *kubernetes.Clientset) cache.Getter
+	fingerprint func(obj runtime.Object) string
+}
+
+// driftSources are kinds of watched resources, a fingerprint changes only when the resource spec or data changes
+var driftSources = []driftSource{
+	{
+		kind:        "Deployment",
+		resource:    "deployments",
+		client:      func(cs *kubernetes.Clientset) cache.Getter { return cs.AppsV1().RESTClient() },
+		fingerprint: generationFingerprint,
+	},
+	{
+		kind:        "StatefulSet",
+		resource:    "statefulsets",
+		client:      func(cs *kubernetes.Clientset) cache.Getter { return cs.AppsV1().RESTClient() },
+		fingerprint: generationFingerprint,
+	},
+	{
+		kind:     "Service",
+		resource: "services",
+		client:   func(cs *kubernetes.Clientset) cache.Getter { return cs.CoreV1().RESTClient() },
+		fingerprint: func(obj runtime.Object) string {
+			spec, _ := json.Marshal(obj.(*coreV1.Service).Spec)
+			return string(spec)
+		},
+	},
+	{
+		kind:     "ConfigMap",
+		resource: "configmaps",
+		client:   func(cs *kubernetes.Clientset) cache.Getter { return cs.CoreV1().RESTClient() },
+		fingerprint: func(obj runtime.Object) string {
+			cm := obj.(*coreV1.ConfigMap)
+			data, _ := json.Marshal([]interface{}{cm.Data, cm.BinaryData})
+			return string(data)
+		},
+	},
+}
+
+func generationFingerprint(obj runtime.Object) string {
+	o, ok := obj.(metaV1.Object)
+	if !ok {
+		return ""
+	}
+	return strconv.FormatInt(o.GetGeneration(), 10)
+}
+
+// DriftWatcher watches environment resources and records any out-of-band modifications,
+// for example someone editing a deployment with kubectl in the middle of a test on a shared cluster,
+// changes are attributed by the field manager of the latest change, so late watch events of the environment's own changes are ignored too
+type DriftWatcher struct {
+	Namespace string
+	Client    *client.K8sClient
+	// IgnoredManagers changes of these field managers are not out-of-band, DefaultDriftIgnoredManagers by default,
+	// changes of client.FieldManager, the environment itself, are always ignored
+	IgnoredManagers []string
+	mu              *sync.Mutex
+	fingerprints    map[string]string
+	removing        map[string]bool // "${kind}/${release}" removed by the environment, until the release is added again
+	records         []DriftRecord
+	cancel          context.CancelFunc
+}
+
+// NewDriftWatcher creates a new drift watcher for a namespace
+func NewDriftWatcher(client *client.K8sClient, namespace string) *DriftWatcher {
+	return &DriftWatcher{
+		Namespace:       namespace,
+		Client:          client,
+		IgnoredManagers: DefaultDriftIgnoredManagers(),
+		mu:              &sync.Mutex{},
+		fingerprints:    make(map[string]string),
+		removing:        make(map[string]bool),
+		records:         make([]DriftRecord, 0),
+	}
+}
+
+// DefaultDriftIgnoredManagers returns field managers of cluster controllers, their changes are not out-of-band
+func DefaultDriftIgnoredManagers() []string {
+	return append([]string{}, driftIgnoredManagers...)
+}
+
+// Start records current state of resources as a baseline and starts watching for changes
+func (d *DriftWatcher) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	for _, src := range driftSources {
+		src := src
+		lw := cache.NewListWatchFromClient(src.client(d.Client.ClientSet), src.resource, d.Namespace, fields.Everything())
+		list, err := lw.List(metaV1.ListOptions{})
+		if err != nil {
+			cancel()
+			return err
+		}
+		objs, err := meta.ExtractList(list)
+		if err != nil {
+			cancel()
+			return err
+		}
+		d.mu.Lock()
+		for _, o := range objs {
+			d.fingerprints[driftKey(src.kind, o)] = src.fingerprint(o)
+		}
+		d.mu.Unlock()
+		listMeta, err := meta.ListAccessor(list)
+		if err != nil {
+			cancel()
+			return err
+		}
+		w, err := watchtools.NewRetryWatcher(listMeta.GetResourceVersion(), lw)
+		if err != nil {
+			cancel()
+			return err
+		}
+		go func() {
+			defer w.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case ev, ok := <-w.ResultChan():
+					if !ok {
+						return
+					}
+					d.handle(src, ev)
+				}
+			}
+		}()
+	}
+	log.Info().Str("Namespace", d.Namespace).Msg("Watching for out-of-band resource changes")
+	return nil
+}
+
+// Stop stops watching, recorded changes are kept
+func (d *DriftWatcher) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// ExpectRemoval marks resources of a release as removed by the environment, their deletions are not recorded
+// until the release is added again
+func (d *DriftWatcher) ExpectRemoval(release string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, src := range driftSources {
+		d.removing[fmt.Sprintf("%s/%s", src.kind, release)] = true
+	}
+}
+
+// Records returns all recorded out-of-band changes
+func (d *DriftWatcher) Records() []DriftRecord {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DriftRecord{}, d.records...)
+}
+
+// WriteReport writes recorded changes into a test dir, nothing is written if there were no changes
+func (d *DriftWatcher) WriteReport(testDir string) error {
+	records := d.Records()
+	if len(records) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	log.Warn().Int("Changes", len(records)).Msg("Out-of-band resource changes were detected during the test")
+	return os.WriteFile(filepath.Join(testDir, DriftReportFile), data, 0644)
+}
+
+// handle records a change of a resource if its fingerprint changed and it's not made by the environment or a cluster controller,
+// deletions are recorded unless the release is removed by the environment, the deleter isn't known from the object
+func (d *DriftWatcher) handle(src driftSource, ev watch.Event) {
+	obj, ok := ev.Object.(metaV1.Object)
+	if !ok {
+		return
+	}
+	key := driftKey(src.kind, ev.Object)
+	releaseKey := fmt.Sprintf("%s/%s", src.kind, obj.GetLabels()[pkg.ReleaseLabelKey])
+	manager, operation := lastManager(obj)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch ev.Type {
+	case watch.Deleted:
+		delete(d.fingerprints, key)
+		if d.removing[releaseKey] {
+			return
+		}
+	case watch.Added, watch.Modified:
+		fp := src.fingerprint(ev.Object)
+		prev, known := d.fingerprints[key]
+		d.fingerprints[key] = fp
+		if ev.Type == watch.Added {
+			delete(d.removing, releaseKey)
+		}
+		if known && prev == fp {
+			return
+		}
+		if d.ignored(manager) {
+			return
+		}
+	default:
+		return
+	}
+	r := DriftRecord{
+		Time:      d.Client.Clock().Now(),
+		Kind:      src.kind,
+		Name:      obj.GetName(),
+		Event:     string(ev.Type),
+		Manager:   manager,
+		Operation: operation,
+	}
+	log.Warn().
+		Str("Kind", r.Kind).
+		Str("Name", r.Name).
+		Str("Event", r.Event).
+		Str("Manager", r.Manager).
+		Msg("Out-of-band resource change detected")
+	d.records = append(d.records, r)
+}
+
+// ignored returns true if changes of the field manager are made by the environment or an ignored manager
+func (d *DriftWatcher) ignored(manager string) bool {
+	if manager == client.FieldManager {
+		return true
+	}
+	for _, m := range d.IgnoredManagers {
+		if manager == m {
+			return true
+		}
+	}
+	return false
+}
+
+// lastManager returns the field manager who made the latest change to an object, entries without a time are skipped
+func lastManager(obj metaV1.Object) (string, string) {
+	var latest *metaV1.ManagedFieldsEntry
+	for i, mf := range obj.GetManagedFields() {
+		if mf.Time == nil {
+			continue
+		}
+		if latest == nil || latest.Time.Before(mf.Time) {
+			latest = &obj.GetManagedFields()[i]
+		}
+	}
+	if latest == nil {
+		return "", ""
+	}
+	return latest.Manager, string(latest.Operation)
+}
+
+func driftKey(kind string, obj runtime.Object) string {
+	o, ok := obj.(metaV1.Object)
+	if !ok {
+		return kind
+	}
+	return fmt.Sprintf("%s/%s", kind, o.GetName())
+}
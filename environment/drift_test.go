@@ -0,0 +1,83 @@
+package environment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func driftDeployment(name string, generation int64, manager string) *appsV1.Deployment {
+	return &appsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{
+		Name:          name,
+		Generation:    generation,
+		Labels:        map[string]string{pkg.ReleaseLabelKey: name},
+		ManagedFields: []metaV1.ManagedFieldsEntry{{Manager: manager, Operation: metaV1.ManagedFieldsOperationUpdate, Time: &metaV1.Time{}}},
+	}}
+}
+
+func TestDriftWatcherHandle(t *testing.T) {
+	c := &client.K8sClient{}
+	now := time.Unix(1700000000, 0)
+	c.SetClock(clocktesting.NewFakeClock(now))
+	d := NewDriftWatcher(c, "chainlink-test-env-abcde")
+	src := driftSources[0]
+	events := func() []string {
+		names := make([]string, 0)
+		for _, r := range d.Records() {
+			names = append(names, r.Event+" "+r.Name+" "+r.Manager)
+		}
+		return names
+	}
+
+	d.handle(src, watch.Event{Type: watch.Added, Object: driftDeployment("geth", 1, client.FieldManager)})
+	d.handle(src, watch.Event{Type: watch.Modified, Object: driftDeployment("geth", 2, client.FieldManager)})
+	d.handle(src, watch.Event{Type: watch.Modified, Object: driftDeployment("geth", 3, "kube-controller-manager")})
+	require.Empty(t, events(), "changes of the environment and controllers are not drift")
+
+	d.handle(src, watch.Event{Type: watch.Modified, Object: driftDeployment("geth", 4, "kubectl-edit")})
+	d.handle(src, watch.Event{Type: watch.Modified, Object: driftDeployment("geth", 4, "kubectl-edit")})
+	require.Equal(t, []string{"MODIFIED geth kubectl-edit"}, events(), "status updates don't change the fingerprint")
+	require.Equal(t, now, d.Records()[0].Time, "changes are timed by the client clock")
+
+	d.IgnoredManagers = append(d.IgnoredManagers, "argocd-controller")
+	d.handle(src, watch.Event{Type: watch.Modified, Object: driftDeployment("geth", 5, "argocd-controller")})
+	require.Len(t, events(), 1, "changes of managers ignored by the watcher are not drift")
+	require.NotContains(t, DefaultDriftIgnoredManagers(), "argocd-controller", "defaults are not changed by a watcher")
+
+	d.ExpectRemoval("chainlink-0")
+	d.handle(src, watch.Event{Type: watch.Deleted, Object: driftDeployment("chainlink-0", 1, client.FieldManager)})
+	require.Len(t, events(), 1, "releases removed by the environment are not drift")
+
+	d.handle(src, watch.Event{Type: watch.Added, Object: driftDeployment("chainlink-0", 1, client.FieldManager)})
+	d.handle(src, watch.Event{Type: watch.Deleted, Object: driftDeployment("chainlink-0", 1, client.FieldManager)})
+	d.handle(src, watch.Event{Type: watch.Deleted, Object: driftDeployment("geth", 4, "kubectl-edit")})
+	require.Equal(t, []string{
+		"MODIFIED geth kubectl-edit",
+		"DELETED chainlink-0 chainlink-env",
+		"DELETED geth kubectl-edit",
+	}, events(), "deletions are drift once the release is added again")
+}
+
+func TestLastManager(t *testing.T) {
+	older := metaV1.NewTime(time.Now().Add(-time.Minute))
+	newer := metaV1.NewTime(time.Now())
+	d := &appsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{ManagedFields: []metaV1.ManagedFieldsEntry{
+		{Manager: client.FieldManager, Operation: metaV1.ManagedFieldsOperationApply, Time: &older},
+		{Manager: "kubectl-edit", Operation: metaV1.ManagedFieldsOperationUpdate, Time: &newer},
+	}}}
+	manager, operation := lastManager(d)
+	require.Equal(t, "kubectl-edit", manager)
+	require.Equal(t, "Update", operation)
+	d.ManagedFields = append([]metaV1.ManagedFieldsEntry{{Manager: "kube-controller-manager", Operation: metaV1.ManagedFieldsOperationUpdate}}, d.ManagedFields...)
+	manager, _ = lastManager(d)
+	require.Equal(t, "kubectl-edit", manager, "entries without a time are skipped")
+	manager, _ = lastManager(&appsV1.Deployment{})
+	require.Equal(t, "", manager)
+}
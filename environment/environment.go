@@ -1,16 +1,19 @@
 package environment
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 
 	cdk8s "github.com/cdk8s-team/cdk8s-core-go/cdk8s/v2"
-	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"github.com/smartcontractkit/chainlink-env/client"
 	"github.com/smartcontractkit/chainlink-env/config"
@@ -18,10 +21,7 @@ import (
 	"github.com/smartcontractkit/chainlink-env/logging"
 	"github.com/smartcontractkit/chainlink-env/pkg"
 	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
-)
-
-var (
-	defaultAnnotations = map[string]*string{"prometheus.io/scrape": a.Str("true")}
+	"k8s.io/utils/clock"
 )
 
 // ConnectedChart interface to interact both with cdk8s apps and helm charts
@@ -44,15 +44,19 @@ type ConnectedChart interface {
 
 // Config is an environment common configuration, labels, annotations, connection types, readiness check, etc.
 type Config struct {
-	// TTL is time to live for the environment, used with kube-janitor
+	// TTL is time to live for the environment, used with kube-janitor and client.CleanupExpiredNamespaces
 	TTL time.Duration
 	// NamespacePrefix is a static namespace prefix
 	NamespacePrefix string
-	// Namespace is full namespace name
+	// Namespace is full namespace name, if set, this static namespace is used instead of a generated one,
+	// charts which are not installed in an existing namespace are deployed into it, and Shutdown removes only them
 	Namespace string
-	// Labels is a set of labels applied to the namespace in a format of "key=value"
-	Labels   []string
-	nsLabels *map[string]*string
+	// SkipTeardown Shutdown leaves the namespace and all charts running, e.g. for long-lived shared environments
+	SkipTeardown bool
+	// Labels is a set of labels applied to the namespace, keys and values must be valid K8s labels
+	Labels        map[string]string
+	nsLabels      *map[string]*string
+	nsAnnotations *map[string]*string
 	// ReadyCheckData is settings for readiness probes checks for all deployment components
 	// checking that all pods are ready by default with 8 minutes timeout
 	//	&client.ReadyCheckData{
@@ -60,22 +64,122 @@ type Config struct {
 	//		Timeout:                     8 * time.Minute,
 	//	}
 	ReadyCheckData *client.ReadyCheckData
-	// DryRun if true, app will just generate a manifest in local dir
+	// PodReadyTimeout if set, readiness timeouts are derived from the number of pods instead of the fixed ReadyCheckData.Timeout,
+	// ReadyTimeoutBase plus PodReadyTimeout for every pod of a chart, or of the whole environment for the final check
+	PodReadyTimeout time.Duration
+	// ReadyTimeoutBase is a part of the readiness timeout that doesn't depend on the number of pods, DefaultReadyTimeoutBase if 0
+	ReadyTimeoutBase time.Duration
+	// DryRun if true, app will just generate a manifest in ManifestsDir
 	DryRun bool
+	// Debug retains all rendered manifests, apply outputs and Helm values in a per-environment debug directory,
+	// nothing is written on disk if false
+	Debug bool
+	// ManifestsDir is a parent directory for debug and dry-run output, every environment creates its own temporary directory in it,
+	// system temporary directory is used if empty
+	ManifestsDir string
 	// InsideK8s used for long-running soak tests where you connect to env from the inside
 	InsideK8s bool
 	// KeepConnection keeps connection until interrupted with a signal, useful when prototyping and debugging a new env
 	KeepConnection bool
+	// RemoteRunner runs the test inside the namespace when the environment is ready: the test binary is uploaded into a Job
+	// and run with the same arguments, its logs are streamed back and the process exits with its exit code,
+	// so long soak tests don't die with the laptop
+	RemoteRunner bool
+	// RemoteRunnerImage image of the remote runner, DefaultRemoteRunnerImage if empty
+	RemoteRunnerImage string
+	// RemoteRunnerBinary test binary built for Linux to run remotely, e.g. with 'GOOS=linux go test -c',
+	// the current binary is used on Linux if empty
+	RemoteRunnerBinary string
+	// RemoteRunnerEnv additional environment variables of the remote runner
+	RemoteRunnerEnv map[string]string
+	// StatusAddr if set with KeepConnection, a status page with charts, pods readiness, forwarded ports health and URLs
+	// is served on this address, e.g. "localhost:8088"
+	StatusAddr string
+	// APIAddr if set with KeepConnection, a local REST API to list charts, get URLs, dump logs, run chaos experiments
+	// and remove the environment is served on this loopback address, e.g. "localhost:8089", see ServeAPI
+	APIAddr string
+	// APIToken API requests must have an "Authorization: Bearer ${APIToken}" header, a random token is generated and logged at the debug level if it's empty
+	APIToken string `json:"-"`
 	// RemoveOnInterrupt automatically removes an environment on interrupt
 	RemoveOnInterrupt bool
 	// UpdateWaitInterval an interval to wait for deployment update started
 	UpdateWaitInterval time.Duration
+	// CollectLogs continuously collects logs of all pods during the run, used for assertions with Environment.Logs
+	CollectLogs bool
+	// CollectLogsMaxLines how many collected lines are kept in memory, the oldest are dropped first, DefaultLogsMaxLines if 0
+	CollectLogsMaxLines int
+	// LogLevelsDir if set with CollectLogs, Chainlink node logs are split by level into error, warn and all files per node in it
+	LogLevelsDir string
+	// LogStream if set, logs of pods matching its selector are written into rotated files from the environment start,
+	// so they survive pod restarts, see LogStream
+	LogStream *LogStreamConfig
+	// LogMetrics are counters and values extracted from logs streamed with LogStream, or collected with CollectLogs,
+	// they are aggregated per pod and added to artifacts, see LogMetric
+	LogMetrics []LogMetric
+	// RawLogs keeps noisy lines declared by charts in dumped and streamed logs, CHAINLINK_ENV_RAW_LOGS sets it too, see NoisyLogsChart
+	RawLogs bool
+	// WatchDrift records out-of-band modifications of environment resources and writes them into artifacts,
+	// changes of the environment and of cluster controllers are told by their field managers, see DriftWatcher.IgnoredManagers
+	WatchDrift bool
+	// SampleResources samples pods usage from metrics-server and writes requested vs peak usage per chart into artifacts
+	SampleResources bool
+	// ImageMirror registry or repository rewrite rules applied to all container images at render time,
+	// e.g. "docker.io" -> "mirror.internal/dockerhub", images without a registry are treated as docker.io images
+	ImageMirror map[string]string
+	// PrometheusURL is used to evaluate PromQL conditions of charts, see ConditionedChart
+	PrometheusURL string
+	// ServiceMonitors generates Prometheus Operator ServiceMonitors and PodMonitors for charts declaring metrics ports,
+	// see MetricsChart, the cluster must have Prometheus Operator CRDs
+	ServiceMonitors bool
+	// ServiceMonitorLabels are added to generated monitors, so Prometheus selects them, e.g. "release": "kube-prometheus-stack"
+	ServiceMonitorLabels map[string]string
+	// HostAliases are added to all pods, so they resolve custom hostnames, see also DNSConfiguredChart
+	HostAliases []HostAlias
+	// SkipSmokeTests do not run charts smoke tests after deployment
+	SkipSmokeTests bool
+	// HeartbeatTimeout if set, an in-cluster reaper removes the environment when the test process stops refreshing
+	// the heartbeat for this long, for example, when it's killed, or when MarkCompleted is called
+	HeartbeatTimeout time.Duration
+	// PreferRemotePorts forwards to the same local ports as the remote ones when they are free, free ports are allocated otherwise
+	PreferRemotePorts bool
+	// PortsFile if set, forwarded ports mapping is written into it, JSON if it has .json extension, env file otherwise
+	PortsFile string
+	// SSH if set, pods are accessed through SSH tunnels via a bastion instead of K8s port forwarding,
+	// for clusters where the API server blocks port forwarding
+	SSH *client.SSHConfig
+	// FailureBehavior what to do with the environment when deployment fails, removes the namespace by default
+	FailureBehavior FailureBehavior
+	// ScaleSchedules scale workloads on Cron schedules after the deployment, e.g. nodes to 0 at night,
+	// to save cost of persistent environments, see client.ScaleSchedule
+	ScaleSchedules []client.ScaleSchedule
+	// FreezeTTL how long a frozen environment is kept after the failure, DefaultFreezeTTL if 0
+	FreezeTTL time.Duration
+	// Seed of generated values: namespace suffix, forwarded ports, passwords and chain IDs, CHAINLINK_ENV_SEED or random if 0,
+	// set it to the seed of a previous run to reproduce its values, see Reproducibility
+	Seed int64
+	// ResourceBudget if set, deployment fails before anything is applied if total requests or limits of all pods exceed it,
+	// ResourceQuotas of an existing namespace are checked the same way
+	ResourceBudget *ResourceBudget
+	// Bootstrap deploy contracts on the environment chain after smoke tests, their addresses are recorded in the namespace,
+	// so an environment connected to is not bootstrapped again, see ChainBootstrapper and Environment.ContractAddress,
+	// bootstrappers are not exported into archives
+	Bootstrap []ChainBootstrapper `json:"-"`
+	// K8sRetryPolicy retries K8s API requests failed with transient errors, e.g. 429 and 503 of a flaky API server,
+	// client.DefaultRetryPolicy if nil, set MaxAttempts to 1 to disable retries
+	K8sRetryPolicy *client.RetryPolicy
+	// Clock of waits, timeouts, TTLs and heartbeats, the real clock if nil, unit tests set a fake clock of k8s.io/utils/clock/testing,
+	// see client.K8sClient.SetClock, the clock is not exported into archives
+	Clock clock.WithTicker `json:"-"`
+	// DeployWorkers number of charts deployed concurrently, charts are deployed one by one in order if 0 or 1,
+	// a chart starts when charts it depends on are ready, see DependentChart and DependsOn
+	DeployWorkers int
 }
 
 func defaultEnvConfig() *Config {
 	return &Config{
 		TTL:             20 * time.Minute,
 		NamespacePrefix: "chainlink-test-env",
+		K8sRetryPolicy:  client.DefaultRetryPolicy(),
 		ReadyCheckData: &client.ReadyCheckData{
 			ReadinessProbeCheckSelector: "",
 			Timeout:                     8 * time.Minute,
@@ -83,17 +187,58 @@ func defaultEnvConfig() *Config {
 	}
 }
 
+const (
+	DefaultReadyTimeoutBase = 1 * time.Minute
+)
+
 // Environment describes a launched test environment
 type Environment struct {
-	App       cdk8s.App
-	root      cdk8s.Chart
-	Charts    []ConnectedChart  // All connected charts in the
-	Cfg       *Config           // The environment specific config
-	Client    *client.K8sClient // Client connecting to the K8s cluster
-	Fwd       *client.Forwarder // Used to forward ports from local machine to the K8s cluster
-	Artifacts *Artifacts
-	Chaos     *client.Chaos
-	URLs      map[string][]string // General URLs of launched resources. Uses '_local' to delineate forwarded ports
+	App              cdk8s.App
+	root             cdk8s.Chart
+	Charts           []ConnectedChart  // All connected charts in the
+	Cfg              *Config           // The environment specific config
+	Client           *client.K8sClient // Client connecting to the K8s cluster
+	Fwd              *client.Forwarder // Used to forward ports from local machine to the K8s cluster
+	Artifacts        *Artifacts
+	Logs             *Logs         // Continuously collected logs, available if Config.CollectLogs is set
+	LogStream        *LogStream    // Logs streamed into files, available if Config.LogStream is set
+	LogMetrics       *LogMetrics   // Metrics extracted from logs, available if Config.LogMetrics is set, see AddLogMetric
+	Drift            *DriftWatcher // Out-of-band resource changes watcher, available if Config.WatchDrift is set
+	Usage            *UsageSampler // Resources usage sampler, available if Config.SampleResources is set
+	Chaos            *client.Chaos
+	Time             *Time                // Moves chain time and node clocks forward
+	Chainlink        *ChainlinkNodes      // Manages jobs of Chainlink nodes through their API
+	URLs             map[string][]string  // General URLs of launched resources. Uses '_local' to delineate forwarded ports
+	DNS              map[string]string    // Stable in-cluster DNS names of chart services, see StableDNSChart
+	SmokeTestResults []SmokeTestResult    // Per-chart smoke tests report of the last Run
+	HookResults      []HookResult         // Hook jobs results with logs, see HookedChart
+	CanaryAnalyses   []CanaryAnalysis     // Canary analyses of upgraded charts, see SetCanary
+	Outputs          map[string]string    // Published chart outputs by "chart.key", see OutputsChart
+	Contracts        map[string]string    // Addresses of bootstrapped contracts by names, see Config.Bootstrap
+	Seed             int64                // Seed of generated values, see Config.Seed
+	Resources        []ComponentResources // Requests and limits of deployed charts, see Config.ResourceBudget
+	Components       []Component          // Releases found in the namespace connected to, see Connect
+	progress         chan string          // guarded by mu, see RunBackground
+	ready            chan struct{}        // guarded by mu, see RunBackground
+	chartStatus      map[string]ChartStatus
+	green            map[string]string // green copies of charts by blue chart names, see DeployGreen
+	canaries         map[string]*Canary
+	dependencies     map[string][]string    // declared chart dependencies, see DependsOn
+	readiness        map[string][]Readiness // readiness checks added to charts, see AddReadinessChecks
+	mu               *sync.Mutex            // guards chart statuses, outputs and hook results of concurrently deployed charts
+	pausables        []Pausable             // paused while artifacts are collected, see AddPausable
+	cordoned         []string               // nodes drained by DrainNodesOf, uncordoned on Shutdown
+	seedMu           *sync.Mutex
+	generated        map[string]string // generated values by key, see Generate
+	heartbeatStop    chan struct{}
+	imported         string        // manifest imported from an archive, deployed along with the charts
+	static           bool          // namespace is set in the config, see Config.Namespace
+	attached         bool          // static namespace existed before Run, only installed releases are removed on Shutdown
+	installed        []string      // releases applied by this process
+	connect          string        // namespace of an existing environment to connect to, see Connect
+	tornDown         chan struct{} // closed when the environment is removed through the API, see ServeAPI
+	remoteFinished   bool          // the test finished in the remote runner, the launcher removed the namespace if the runner asked
+	labelsErr        error         // invalid Config.Labels, returned by Run
 }
 
 // New creates new environment
@@ -104,49 +249,110 @@ func New(cfg *Config) *Environment {
 	}
 	targetCfg := defaultEnvConfig()
 	config.MustMerge(targetCfg, cfg)
+	if insideRemoteRunner() {
+		targetCfg.InsideK8s = true
+		targetCfg.RemoteRunner = false
+	}
 	c := client.NewK8sClient()
+	if err := c.SetRetryPolicy(targetCfg.K8sRetryPolicy); err != nil {
+		log.Fatal().Err(err).Msg("Failed to set K8s API retry policy")
+	}
+	if targetCfg.Clock != nil {
+		c.SetClock(targetCfg.Clock)
+	}
+	if _, err := c.Capabilities(); err != nil {
+		log.Warn().Err(err).Msg("Failed to detect API capabilities, charts are rendered for the default Helm K8s version")
+	}
 	e := &Environment{
-		URLs:   make(map[string][]string),
-		Charts: make([]ConnectedChart, 0),
-		Client: c,
-		Cfg:    targetCfg,
-		Fwd:    client.NewForwarder(c, targetCfg.KeepConnection),
+		URLs:         make(map[string][]string),
+		DNS:          make(map[string]string),
+		Outputs:      make(map[string]string),
+		Contracts:    make(map[string]string),
+		Charts:       make([]ConnectedChart, 0),
+		Client:       c,
+		Cfg:          targetCfg,
+		Fwd:          client.NewForwarder(c, targetCfg.KeepConnection),
+		chartStatus:  make(map[string]ChartStatus),
+		green:        make(map[string]string),
+		canaries:     make(map[string]*Canary),
+		dependencies: make(map[string][]string),
+		readiness:    make(map[string][]Readiness),
+		mu:           &sync.Mutex{},
+		seedMu:       &sync.Mutex{},
+		generated:    make(map[string]string),
+		tornDown:     make(chan struct{}),
+	}
+	seed, err := newSeed(targetCfg.Seed)
+	if err != nil {
+		log.Fatal().Err(err).Send()
+	}
+	e.Seed = seed
+	if e.Cfg.Namespace != "" {
+		e.static = true
+		e.initApp(e.Cfg.Namespace)
+	} else {
+		e.initApp(fmt.Sprintf("%s-%s", e.Cfg.NamespacePrefix, e.Generate("namespace", namespaceSuffix)))
 	}
-	e.initApp(fmt.Sprintf("%s-%s", e.Cfg.NamespacePrefix, uuid.NewString()[0:5]))
 	k8s.NewKubeNamespace(e.root, a.Str("namespace"), &k8s.KubeNamespaceProps{
 		Metadata: &k8s.ObjectMeta{
 			Name:        a.Str(e.Cfg.Namespace),
 			Labels:      e.Cfg.nsLabels,
-			Annotations: &defaultAnnotations,
+			Annotations: e.Cfg.nsAnnotations,
 		},
 	})
+	if e.Cfg.HeartbeatTimeout != 0 {
+		addReaper(e.root, e.Cfg.Namespace, e.Cfg.HeartbeatTimeout)
+	}
+	if e.Cfg.Debug || e.Cfg.DryRun {
+		dir, err := os.MkdirTemp(e.Cfg.ManifestsDir, fmt.Sprintf("%s-*", e.Cfg.Namespace))
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create manifests directory")
+		}
+		c.ManifestsDir = dir
+		log.Info().Str("Dir", dir).Msg("Retaining manifests and debug output")
+	}
 	e.Chaos = client.NewChaos(c, e.Cfg.Namespace)
+	e.Time = newTime(e)
+	e.Chainlink = newChainlinkNodes(e)
+	e.Fwd.PreferRemotePorts = e.Cfg.PreferRemotePorts
+	e.Fwd.LocalPort = e.GeneratePort
+	if e.Cfg.SSH != nil {
+		e.Fwd.UseSSH(e.Cfg.SSH)
+	}
 	return e
 }
 
 func (m *Environment) initApp(namespace string) {
-	var err error
 	m.App = cdk8s.NewApp(&cdk8s.AppProps{
 		YamlOutputType: cdk8s.YamlOutputType_FILE_PER_APP,
 	})
 	m.Cfg.Namespace = namespace
-	m.Cfg.Labels = append(m.Cfg.Labels, "generatedBy=cdk8s")
-	m.Cfg.Labels = append(m.Cfg.Labels, fmt.Sprintf("owner=%s", os.Getenv(config.EnvVarUser)))
+	labels := make(map[string]string)
+	for k, v := range m.Cfg.Labels {
+		labels[k] = v
+	}
+	labels[GeneratedByLabelKey] = GeneratedByLabelValue
+	if m.Cfg.TTL != 0 {
+		labels[pkg.NamespaceTTLLabelKey] = *a.ShortDur(m.Cfg.TTL)
+	}
+	m.labelsErr = ValidateLabels(labels)
+	// values derived from env vars are sanitized, e.g. an email of the user
+	labels["owner"] = SanitizeLabelValue(os.Getenv(config.EnvVarUser))
 
 	if os.Getenv(config.EnvVarCLCommitSha) != "" {
-		m.Cfg.Labels = append(m.Cfg.Labels, fmt.Sprintf("commit=%s", os.Getenv(config.EnvVarCLCommitSha)))
+		labels["commit"] = SanitizeLabelValue(os.Getenv(config.EnvVarCLCommitSha))
 	}
 	if os.Getenv(config.EnvVarTestTrigger) != "" {
-		m.Cfg.Labels = append(m.Cfg.Labels, fmt.Sprintf("triggered-by=%s", os.Getenv(config.EnvVarTestTrigger)))
+		labels["triggered-by"] = SanitizeLabelValue(os.Getenv(config.EnvVarTestTrigger))
 	} else { // Assume default is manual launch
-		m.Cfg.Labels = append(m.Cfg.Labels, "triggered-by=manual")
+		labels["triggered-by"] = "manual"
 	}
-
-	m.Cfg.nsLabels, err = a.ConvertLabels(m.Cfg.Labels)
-	if err != nil {
-		log.Fatal().Err(err).Send()
+	m.Cfg.Labels = labels
+	m.Cfg.nsLabels = a.ConvertLabelsMap(labels)
+	m.Cfg.nsAnnotations = &map[string]*string{
+		"prometheus.io/scrape": a.Str("true"),
+		pkg.TTLLabelKey:        a.ShortDur(m.Cfg.TTL),
 	}
-	defaultAnnotations[pkg.TTLLabelKey] = a.ShortDur(m.Cfg.TTL)
 	m.root = cdk8s.NewChart(m.App, a.Str("root-chart"), &cdk8s.ChartProps{
 		Labels:    m.Cfg.nsLabels,
 		Namespace: a.Str(m.Cfg.Namespace),
@@ -155,7 +361,9 @@ func (m *Environment) initApp(namespace string) {
 
 // AddChart adds a chart to the deployment
 func (m *Environment) AddChart(f func(root cdk8s.Chart) ConnectedChart) *Environment {
-	m.Charts = append(m.Charts, f(m.root))
+	c := f(m.root)
+	m.addMonitors(c.GetName(), c)
+	m.Charts = append(m.Charts, c)
 	return m
 }
 
@@ -165,28 +373,28 @@ func (m *Environment) removeChart(name string) {
 			m.Charts = append(m.Charts[:i], m.Charts[i+1:]...)
 		}
 	}
+	delete(m.chartStatus, name)
 	m.root.Node().TryRemoveChild(a.Str(name))
+	// manifest charts are separate cdk8s charts of the app
+	m.App.Node().TryRemoveChild(a.Str(name))
+	m.removeMonitors(name)
+}
+
+// chart returns a chart of the environment by name, nil if there is no such chart
+func (m *Environment) chart(name string) ConnectedChart {
+	for _, c := range m.Charts {
+		if c.GetName() == name {
+			return c
+		}
+	}
+	return nil
 }
 
 // ModifyHelm modifies helm chart in deployment
 func (m *Environment) ModifyHelm(name string, chart ConnectedChart) *Environment {
 	m.removeChart(name)
 	if chart.IsDeploymentNeeded() {
-		log.Trace().
-			Str("Chart", chart.GetName()).
-			Str("Path", chart.GetPath()).
-			Interface("Props", chart.GetProps()).
-			Interface("Values", chart.GetValues()).
-			Msg("Chart deployment values")
-		cdk8s.NewHelm(m.root, a.Str(name), &cdk8s.HelmProps{
-			Chart: a.Str(chart.GetPath()),
-			HelmFlags: &[]*string{
-				a.Str("--namespace"),
-				a.Str(m.Cfg.Namespace),
-			},
-			ReleaseName: a.Str(name),
-			Values:      chart.GetValues(),
-		})
+		m.newHelm(name, chart)
 	}
 	m.Charts = append(m.Charts, chart)
 	return m
@@ -194,35 +402,133 @@ func (m *Environment) ModifyHelm(name string, chart ConnectedChart) *Environment
 
 func (m *Environment) AddHelm(chart ConnectedChart) *Environment {
 	if chart.IsDeploymentNeeded() {
-		log.Trace().
-			Str("Chart", chart.GetName()).
-			Str("Path", chart.GetPath()).
-			Interface("Props", chart.GetProps()).
-			Interface("Values", chart.GetValues()).
-			Msg("Chart deployment values")
-		cdk8s.NewHelm(m.root, a.Str(chart.GetName()), &cdk8s.HelmProps{
-			Chart: a.Str(chart.GetPath()),
-			HelmFlags: &[]*string{
-				a.Str("--namespace"),
-				a.Str(m.Cfg.Namespace),
-			},
-			ReleaseName: a.Str(chart.GetName()),
-			Values:      chart.GetValues(),
-		})
+		m.newHelm(chart.GetName(), chart)
 	}
 	m.Charts = append(m.Charts, chart)
 	return m
 }
 
-// PrintExportData prints export data
+// newHelm renders a helm chart as a part of the root chart, all chart resources are labeled with a release label
+func (m *Environment) newHelm(name string, chart ConnectedChart) {
+	log.Trace().
+		Str("Chart", chart.GetName()).
+		Str("Path", chart.GetPath()).
+		Interface("Props", chart.GetProps()).
+		Interface("Values", chart.GetValues()).
+		Msg("Chart deployment values")
+	h := cdk8s.NewHelm(m.root, a.Str(name), &cdk8s.HelmProps{
+		Chart:       a.Str(chart.GetPath()),
+		HelmFlags:   m.helmFlags(),
+		ReleaseName: a.Str(name),
+		Values:      chart.GetValues(),
+	})
+	m.writeDebugValues(name, chart.GetValues())
+	podLabelPath := fmt.Sprintf("/spec/template/metadata/labels/%s", strings.ReplaceAll(pkg.ReleaseLabelKey, "/", "~1"))
+	hash := valuesHash(chart.GetValues())
+	workloads := make([]workloadRef, 0)
+	for _, obj := range *h.ApiObjects() {
+		obj.Metadata().AddLabel(a.Str(pkg.ReleaseLabelKey), a.Str(name))
+		obj.Metadata().AddAnnotation(a.Str(ChartAnnotationKey), a.Str(chart.GetPath()))
+		obj.Metadata().AddAnnotation(a.Str(ValuesHashAnnotationKey), a.Str(hash))
+		if isLabeledWorkload(*obj.Kind()) {
+			obj.AddJsonPatch(cdk8s.JsonPatch_Add(a.Str(podLabelPath), name))
+			workloads = append(workloads, workloadRef{Kind: *obj.Kind(), Name: *obj.Name()})
+		}
+	}
+	m.addStableServices(h, name, chart)
+	m.addResilience(h, name, chart, workloads)
+	m.addMonitors(name, chart)
+}
+
+// helmFlags renders charts into the namespace, charts select API versions served by the cluster if they are detected
+func (m *Environment) helmFlags() *[]*string {
+	flags := []*string{a.Str("--namespace"), a.Str(m.Cfg.Namespace)}
+	if caps, err := m.Client.Capabilities(); err == nil {
+		for _, f := range caps.HelmFlags() {
+			flags = append(flags, a.Str(f))
+		}
+	}
+	return &flags
+}
+
+// RemoveChart uninstalls only resources of the selected Helm chart, waits for their deletion
+// and updates environment charts, forwarded ports and URLs
+func (m *Environment) RemoveChart(name string) error {
+	found := false
+	for _, c := range m.Charts {
+		if c.GetName() == name {
+			found = true
+		}
+	}
+	if !found {
+		return errors.Errorf("chart %s not found in the environment", name)
+	}
+	log.Info().Str("Chart", name).Msg("Removing chart")
+	if err := m.deleteRelease(name); err != nil {
+		return err
+	}
+	if err := m.Client.WaitPodsDeleted(m.Cfg.Namespace, releaseSelector(name), m.Cfg.ReadyCheckData.Timeout); err != nil {
+		return err
+	}
+	m.removeChart(name)
+	m.Fwd.RemoveApp(name)
+	m.recordEvent("remove chart", name, nil)
+	m.URLs = make(map[string][]string)
+	return m.PrintExportData()
+}
+
+// deleteRelease deletes resources of a release, their deletions are not recorded as drift
+func (m *Environment) deleteRelease(name string) error {
+	if m.Drift != nil {
+		m.Drift.ExpectRemoval(name)
+	}
+	return m.Client.DeleteByLabel(m.Cfg.Namespace, releaseSelector(name))
+}
+
+// Update deploys charts added after Run, charts that are already ready are skipped,
+// forwards ports of deployed charts and updates URLs
+func (m *Environment) Update() error {
+	pending := make([]string, 0)
+	for _, c := range m.Charts {
+		if m.chartStatus[c.GetName()] != ChartStatusReady {
+			pending = append(pending, c.GetName())
+		}
+	}
+	manifest, err := m.manifest()
+	if err != nil {
+		return err
+	}
+	err = m.Deploy(manifest)
+	m.recordEvent("update", strings.Join(pending, ","), err)
+	if err != nil {
+		return err
+	}
+	for _, name := range pending {
+		log.Info().Str("Chart", name).Msg("Connecting chart")
+		if err := m.Fwd.Connect(m.Cfg.Namespace, releaseSelector(name), m.Cfg.InsideK8s); err != nil {
+			return err
+		}
+	}
+	m.URLs = make(map[string][]string)
+	return m.PrintExportData()
+}
+
+// PrintExportData prints export data, green charts are skipped until SwapGreen
 func (m *Environment) PrintExportData() error {
 	for _, c := range m.Charts {
+		if m.isGreen(c.GetName()) {
+			continue
+		}
 		err := c.ExportData(m)
 		if err != nil {
 			return err
 		}
+		if err := m.publishOutputs(c.GetName()); err != nil {
+			return err
+		}
 	}
-	log.Debug().Interface("URLs", m.URLs).Msg("Connection URLs")
+	m.exportDNS()
+	log.Debug().Interface("URLs", m.URLs).Interface("DNS", m.DNS).Msg("Connection URLs")
 	return nil
 }
 
@@ -232,8 +538,14 @@ func (m *Environment) DumpLogs(path string) error {
 	if err != nil {
 		return err
 	}
+	arts.Drift = m.Drift
+	arts.Usage = m.Usage
+	arts.Pausables = m.pausables
+	if arts.LogFilter, err = m.logFilter(); err != nil {
+		return err
+	}
 	if path == "" {
-		path = fmt.Sprintf("logs/%s-%d", m.Cfg.Namespace, time.Now().Unix())
+		path = fmt.Sprintf("logs/%s-%d", m.Cfg.Namespace, m.clock().Now().Unix())
 	}
 	return arts.DumpTestResult(path, "chainlink")
 }
@@ -265,55 +577,237 @@ func (m *Environment) ResourcesSummary(selector string) (map[string]map[string]s
 // ClearCharts recreates cdk8s app
 func (m *Environment) ClearCharts() {
 	m.Charts = make([]ConnectedChart, 0)
+	m.chartStatus = make(map[string]ChartStatus)
+	m.green = make(map[string]string)
 	m.initApp(m.Cfg.Namespace)
 }
 
+// Connect connects to an already created environment in the namespace, like ENV_NAMESPACE but for this environment only
+func (m *Environment) Connect(namespace string) error {
+	m.connect = namespace
+	return m.Run()
+}
+
 // Run deploys or connects to already created environment
 func (m *Environment) Run() error {
-	ns := os.Getenv(config.EnvVarNamespace)
+	if m.labelsErr != nil {
+		return m.labelsErr
+	}
+	ns := m.connect
+	if ns == "" {
+		ns = os.Getenv(config.EnvVarNamespace)
+	}
+	if ns == "" && m.static {
+		ns = m.Cfg.Namespace
+	}
+	attach := m.static && ns == m.Cfg.Namespace
+	if len(m.Cfg.LogMetrics) > 0 && m.LogMetrics == nil {
+		lm, err := NewLogMetrics(m.Cfg.LogMetrics...)
+		if err != nil {
+			return err
+		}
+		m.LogMetrics = lm
+	}
+	if m.Cfg.LogStream != nil && m.LogStream == nil && !m.Cfg.DryRun {
+		if err := m.startLogStream(ns); err != nil {
+			return err
+		}
+		m.attachLogMetrics()
+	}
 	if !m.Client.NamespaceExists(ns) {
-		manifest := m.App.SynthYaml().(string)
+		m.reportProgress("Synthesizing manifest")
+		manifest, err := m.manifest()
+		if err != nil {
+			return err
+		}
+		if err := m.checkResources(manifest); err != nil {
+			return err
+		}
+		// the namespace of a new environment is final, it's heartbeating during the deployment, so the reaper tells a killed deployment
+		m.startHeartbeat()
 		if err := m.Deploy(manifest); err != nil {
 			log.Error().Err(err).Msg("Error deploying environment")
+			if m.Cfg.FailureBehavior == FailureBehaviorKeep {
+				m.recordEvent("deploy", "", err)
+				log.Warn().
+					Str("Namespace", m.Cfg.Namespace).
+					Interface("Charts", m.ChartsStatus()).
+					Msg("Environment is kept, call Run again to resume the deployment")
+				return err
+			}
+			if m.Cfg.FailureBehavior == FailureBehaviorFreeze {
+				return m.freeze(err)
+			}
 			_ = m.Shutdown()
 			return err
 		}
+		m.recordEvent("deploy", "", nil)
+		if len(m.Cfg.ScaleSchedules) > 0 && !m.Cfg.DryRun {
+			if err := m.Client.SetScaleSchedules(m.Cfg.Namespace, m.Cfg.ScaleSchedules); err != nil {
+				return err
+			}
+		}
 	} else {
 		log.Info().Str("Namespace", ns).Msg("Namespace found")
 		m.Cfg.Namespace = ns
+		m.Chaos.Namespace = ns
+		if err := m.loadReproducibility(ns); err != nil {
+			return err
+		}
+		if err := m.markReleasesReady(); err != nil {
+			return err
+		}
+		if attach && !m.Cfg.DryRun {
+			if err := m.attach(); err != nil {
+				return err
+			}
+		}
 	}
 	if m.Cfg.DryRun {
-		log.Info().Msg("Dry-run mode, manifest synthesized and saved as tmp-manifest.yaml")
+		log.Info().Str("Dir", m.Client.ManifestsDir).Msg("Dry-run mode, manifest synthesized and saved")
+		m.printResources()
 		return nil
 	}
+	// the namespace connected to is final only here, it replaces the generated one
+	m.startHeartbeat()
+	m.reportProgress("Forwarding ports")
 	if err := m.Fwd.Connect(m.Cfg.Namespace, "", m.Cfg.InsideK8s); err != nil {
 		return err
 	}
 	log.Debug().Interface("Ports", m.Fwd.Info).Msg("Forwarded ports")
+	if m.Cfg.PortsFile != "" {
+		if err := m.Fwd.WriteMapping(m.Cfg.PortsFile); err != nil {
+			return err
+		}
+	}
 	if err := m.PrintExportData(); err != nil {
 		return err
 	}
+	if err := m.CheckHealth(); err != nil {
+		return m.checkFailed(err)
+	}
+	if !m.Cfg.SkipSmokeTests {
+		results, err := m.RunSmokeTests()
+		m.SmokeTestResults = results
+		if err != nil {
+			return m.checkFailed(err)
+		}
+	}
+	if err := m.bootstrapContracts(); err != nil {
+		return m.checkFailed(err)
+	}
+	if m.Cfg.WatchDrift && m.Drift == nil {
+		m.Drift = NewDriftWatcher(m.Client, m.Cfg.Namespace)
+		if err := m.Drift.Start(); err != nil {
+			return err
+		}
+	}
 	arts, err := NewArtifacts(m.Client, m.Cfg.Namespace)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create artifacts client")
 	}
+	if m.Cfg.SampleResources && m.Usage == nil {
+		m.Usage = NewUsageSampler(m.Client, m.Cfg.Namespace)
+		m.Usage.Start()
+	}
+	arts.Drift = m.Drift
+	arts.Usage = m.Usage
+	arts.Pausables = m.pausables
+	if arts.LogFilter, err = m.logFilter(); err != nil {
+		return err
+	}
 	m.Artifacts = arts
+	if len(m.Contracts) > 0 {
+		m.Artifacts.AddReport(ContractsReport, m.Contracts)
+	}
+	if err := m.recordReproducibility(); err != nil {
+		log.Warn().Err(err).Msg("Failed to record reproducibility")
+	}
+	if m.Cfg.CollectLogs && m.Logs == nil {
+		m.Logs = NewLogs(m.Client, m.Cfg.Namespace)
+		m.Logs.MaxLines = m.Cfg.CollectLogsMaxLines
+		if m.Cfg.LogLevelsDir != "" {
+			if err := m.Logs.SplitLevels(m.Cfg.LogLevelsDir); err != nil {
+				return err
+			}
+		}
+		m.Logs.Start()
+	}
+	if m.LogMetrics != nil && m.LogStream == nil && m.Logs == nil {
+		log.Warn().Msg("Log metrics are not extracted, set Config.LogStream or Config.CollectLogs")
+	}
+	m.attachLogMetrics()
+	m.reportProgress("Environment is ready")
+	m.markReady()
+	m.printResources()
+	if m.Cfg.RemoteRunner {
+		code, err := m.runRemote()
+		if err != nil {
+			return err
+		}
+		m.remoteFinished = true
+		if code == 0 {
+			return errors.Wrap(ErrRemotePassed, m.Cfg.Namespace)
+		}
+		return &RemoteExitError{Namespace: m.Cfg.Namespace, Code: code}
+	}
 	if m.Cfg.KeepConnection {
 		log.Info().Msg("Keeping forwarder connections, press Ctrl+C to interrupt")
 		if m.Cfg.RemoveOnInterrupt {
 			log.Warn().Msg("Environment will be removed on interrupt")
 		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if m.Cfg.StatusAddr != "" {
+			if err := m.ServeStatus(ctx, m.Cfg.StatusAddr); err != nil {
+				return err
+			}
+		}
+		if m.Cfg.APIAddr != "" {
+			if err := m.ServeAPI(ctx, m.Cfg.APIAddr); err != nil {
+				return err
+			}
+		}
 		ch := make(chan os.Signal, 1)
 		signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
-		<-ch
-		log.Warn().Msg("Interrupted")
-		if m.Cfg.RemoveOnInterrupt {
-			return m.Client.RemoveNamespace(m.Cfg.Namespace)
+		select {
+		case <-ch:
+		case <-m.tornDown:
+			log.Info().Msg("Environment is removed through the API")
+			return nil
 		}
+		return m.interrupted()
 	}
 	return nil
 }
 
+// interrupted tears the environment down through Shutdown if Config.RemoveOnInterrupt is set,
+// so an attached static namespace keeps releases this process didn't install
+func (m *Environment) interrupted() error {
+	log.Warn().Msg("Interrupted")
+	if m.Cfg.RemoveOnInterrupt {
+		return m.Shutdown()
+	}
+	return nil
+}
+
+// manifest synthesizes the environment manifest, imported manifest is appended, images are mirrored
+func (m *Environment) manifest() (string, error) {
+	manifest := m.App.SynthYaml().(string)
+	if m.imported != "" {
+		manifest = joinManifest([]string{manifest, m.imported})
+	}
+	return mirrorImages(manifest, m.Cfg.ImageMirror)
+}
+
+// checkFailed freezes the environment on a failed health check or smoke test if FailureBehaviorFreeze is set
+func (m *Environment) checkFailed(err error) error {
+	if m.Cfg.FailureBehavior == FailureBehaviorFreeze {
+		return m.freeze(err)
+	}
+	return err
+}
+
 func (m *Environment) enumerateApps() error {
 	apps, err := m.Client.UniqueLabels(m.Cfg.Namespace, "app")
 	if err != nil {
@@ -327,7 +821,8 @@ func (m *Environment) enumerateApps() error {
 	return nil
 }
 
-// Deploy deploy synthesized manifest and check logs for readiness
+// Deploy deploy synthesized manifest and check logs for readiness,
+// charts are deployed one by one, charts that are already ready are skipped, so Deploy can resume after a failure
 func (m *Environment) Deploy(manifest string) error {
 	log.Info().Str("Namespace", m.Cfg.Namespace).Msg("Deploying namespace")
 	if m.Cfg.DryRun {
@@ -336,19 +831,174 @@ func (m *Environment) Deploy(manifest string) error {
 		}
 		return nil
 	}
-	if err := m.Client.Apply(manifest); err != nil {
+	releases, common, err := groupManifestByRelease(manifest)
+	if err != nil {
 		return err
 	}
-	if int64(m.Cfg.UpdateWaitInterval) != 0 {
-		time.Sleep(m.Cfg.UpdateWaitInterval)
+	m.reportProgress("Applying manifest")
+	if common != "" {
+		if err := m.Client.ApplyNamed("common", common); err != nil {
+			return err
+		}
+	}
+	if m.Cfg.HeartbeatTimeout != 0 {
+		if err := m.setReaperOwner(); err != nil {
+			return err
+		}
+	}
+	if err := m.deployCharts(releases); err != nil {
+		return err
 	}
-	if err := m.Client.CheckReady(m.Cfg.Namespace, m.Cfg.ReadyCheckData); err != nil {
+	if err := m.deployReleasesWithoutCharts(releases); err != nil {
+		return err
+	}
+	m.reportProgress("Waiting for pods readiness")
+	pods := 0
+	for _, rm := range releases {
+		pods += rm.Pods
+	}
+	rcd := &client.ReadyCheckData{
+		ReadinessProbeCheckSelector: m.Cfg.ReadyCheckData.ReadinessProbeCheckSelector,
+		Timeout:                     m.readyTimeout(pods),
+	}
+	if err := m.Client.CheckReady(m.Cfg.Namespace, rcd); err != nil {
 		return err
 	}
 	return m.enumerateApps()
 }
 
+// deployChart applies chart manifest and waits until chart pods are ready
+func (m *Environment) deployChart(name string, rm *releaseManifest) error {
+	if rm == nil {
+		// chart is not deployed by us, for example an external network
+		return m.publishOutputs(name)
+	}
+	if err := m.runHooks(name, HookPreInstall); err != nil {
+		return err
+	}
+	manifest, err := m.renderRelease(name, rm)
+	if err != nil {
+		return err
+	}
+	m.setChartStatus(name, ChartStatusDeployed)
+	m.reportProgress(fmt.Sprintf("Deploying chart %s", name))
+	m.markInstalled(name)
+	if err := m.Client.ApplyNamed(name, manifest); err != nil {
+		return err
+	}
+	if rm.HasPods {
+		if int64(m.Cfg.UpdateWaitInterval) != 0 {
+			m.clock().Sleep(m.Cfg.UpdateWaitInterval)
+		}
+		rcd := &client.ReadyCheckData{
+			ReadinessProbeCheckSelector: releaseSelector(name),
+			Timeout:                     m.readyTimeout(rm.Pods),
+		}
+		if err := m.Client.WaitPodsCreated(m.Cfg.Namespace, rcd); err != nil {
+			return err
+		}
+		if err := m.Client.CheckReady(m.Cfg.Namespace, rcd); err != nil {
+			return err
+		}
+	}
+	if err := m.publishOutputs(name); err != nil {
+		return err
+	}
+	return m.runHooks(name, HookPostInstall)
+}
+
+// renderRelease returns a release manifest with pod DNS settings and release labels injected and outputs of other charts resolved
+func (m *Environment) renderRelease(name string, rm *releaseManifest) (string, error) {
+	dns, err := m.chartPodDNS(name)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := injectPodDNS(rm.Manifest, dns)
+	if err != nil {
+		return "", err
+	}
+	if manifest, err = injectPodReleaseLabel(manifest, name); err != nil {
+		return "", err
+	}
+	return resolveManifestOutputs(manifest, m.outputs())
+}
+
+// deployReleasesWithoutCharts deploys releases that have no chart in the environment, for example, imported ones
+func (m *Environment) deployReleasesWithoutCharts(releases map[string]*releaseManifest) error {
+	charts := make(map[string]bool)
+	for _, c := range m.Charts {
+		charts[c.GetName()] = true
+	}
+	names := make([]string, 0)
+	for name := range releases {
+		if !charts[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := m.deployChart(name, releases[name]); err != nil {
+			return errors.Wrapf(err, "failed to deploy release %s", name)
+		}
+	}
+	return nil
+}
+
+// readyTimeout returns readiness timeout for a number of pods, ReadyCheckData.Timeout if PodReadyTimeout is not set
+func (m *Environment) readyTimeout(pods int) time.Duration {
+	if m.Cfg.PodReadyTimeout == 0 {
+		return m.Cfg.ReadyCheckData.Timeout
+	}
+	base := m.Cfg.ReadyTimeoutBase
+	if base == 0 {
+		base = DefaultReadyTimeoutBase
+	}
+	return base + time.Duration(pods)*m.Cfg.PodReadyTimeout
+}
+
+// releaseSelector selects all pods of a chart release
+func releaseSelector(name string) string {
+	return fmt.Sprintf("%s=%s", pkg.ReleaseLabelKey, name)
+}
+
 // Shutdown environment, remove namespace
 func (m *Environment) Shutdown() error {
+	if m.Logs != nil {
+		m.Logs.Stop()
+	}
+	if m.LogStream != nil {
+		m.LogStream.Stop()
+	}
+	if m.Drift != nil {
+		m.Drift.Stop()
+	}
+	if m.Usage != nil {
+		m.Usage.Stop()
+	}
+	m.Fwd.Close()
+	heartbeating := m.heartbeatStop != nil
+	m.stopHeartbeat()
+	if err := m.UncordonNodes(); err != nil {
+		log.Warn().Err(err).Msg("Nodes drained by the environment are left cordoned")
+	}
+	m.chartStatus = make(map[string]ChartStatus)
+	if m.remoteFinished {
+		log.Info().Str("Namespace", m.Cfg.Namespace).Msg("Test finished in the remote runner, the namespace is removed only if the runner called Shutdown")
+		return nil
+	}
+	if m.Cfg.SkipTeardown {
+		log.Info().Str("Namespace", m.Cfg.Namespace).Msg("Teardown is skipped, environment is left running")
+		return m.keep(heartbeating)
+	}
+	if m.attached {
+		if err := m.keep(heartbeating); err != nil {
+			return err
+		}
+		return m.removeInstalled()
+	}
+	if insideRemoteRunner() {
+		// the runner pod can't remove its own namespace, the launcher does it when the runner exits
+		return writeRemoteShutdown()
+	}
 	return m.Client.RemoveNamespace(m.Cfg.Namespace)
 }
@@ -0,0 +1,135 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
+)
+
+// eventContainerPath matches a container field path of an event, e.g. spec.containers{node}
+var eventContainerPath = regexp.MustCompile(`containers\{(.+)}`)
+
+// EventsOptions filters and formats tailed events
+type EventsOptions struct {
+	// AllTypes shows Normal events too, only Warning events are shown by default
+	AllTypes bool
+	// NoColor disables ANSI colors, e.g. when the output is not a terminal
+	NoColor bool
+}
+
+// TailEvents writes existing and new events of the namespace into out until ctx is done, it's used to watch an environment
+// come up and spot scheduling, image and volume errors live, every line has a release and a container of the involved pod
+func TailEvents(ctx context.Context, c *client.K8sClient, namespace string, opts EventsOptions, out io.Writer) error {
+	events := c.ClientSet.CoreV1().Events(namespace)
+	listOpts := metaV1.ListOptions{}
+	if !opts.AllTypes {
+		listOpts.FieldSelector = fmt.Sprintf("type=%s", coreV1.EventTypeWarning)
+	}
+	l, err := events.List(ctx, listOpts)
+	if err != nil {
+		return err
+	}
+	releases := make(map[string]string)
+	release := func(e coreV1.Event) string {
+		if e.InvolvedObject.Kind != "Pod" {
+			return ""
+		}
+		if r, ok := releases[e.InvolvedObject.Name]; ok {
+			return r
+		}
+		p, err := c.ClientSet.CoreV1().Pods(namespace).Get(ctx, e.InvolvedObject.Name, metaV1.GetOptions{})
+		if err == nil {
+			releases[p.Name] = p.Labels[pkg.ReleaseLabelKey]
+		}
+		return releases[e.InvolvedObject.Name]
+	}
+	write := func(e coreV1.Event) error {
+		_, err := io.WriteString(out, formatEventLine(e, release(e), !opts.NoColor))
+		return err
+	}
+	for _, e := range sortEvents(l.Items) {
+		if err := write(e); err != nil {
+			return err
+		}
+	}
+	w, err := watchtools.NewRetryWatcher(l.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(o metaV1.ListOptions) (watch.Interface, error) {
+			o.FieldSelector = listOpts.FieldSelector
+			return events.Watch(ctx, o)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			e, isEvent := ev.Object.(*coreV1.Event)
+			if !isEvent || ev.Type == watch.Deleted {
+				continue
+			}
+			if err := write(*e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// formatEventLine formats an event as "time type reason kind/name [release/container] (xcount): message",
+// warnings are highlighted if color is set
+func formatEventLine(e coreV1.Event, release string, color bool) string {
+	paint := func(code string, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + colorReset
+	}
+	typ := paint(colorGray, e.Type)
+	if e.Type == coreV1.EventTypeWarning {
+		typ = paint(colorYellow, e.Type)
+	}
+	ctx := release
+	if m := eventContainerPath.FindStringSubmatch(e.InvolvedObject.FieldPath); m != nil {
+		ctx = strings.TrimPrefix(fmt.Sprintf("%s/%s", release, m[1]), "/")
+	}
+	if ctx != "" {
+		ctx = fmt.Sprintf(" [%s]", ctx)
+	}
+	count := ""
+	if e.Count > 1 {
+		count = fmt.Sprintf(" (x%d)", e.Count)
+	}
+	return fmt.Sprintf("%s %s %s %s%s%s: %s\n",
+		eventTime(e).Local().Format("15:04:05"),
+		typ,
+		paint(colorBold, e.Reason),
+		paint(colorCyan, fmt.Sprintf("%s/%s", e.InvolvedObject.Kind, e.InvolvedObject.Name)),
+		ctx,
+		count,
+		strings.TrimSpace(e.Message),
+	)
+}
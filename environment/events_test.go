@@ -0,0 +1,36 @@
+package environment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFormatEventLine(t *testing.T) {
+	e := coreV1.Event{
+		Type:          coreV1.EventTypeWarning,
+		Reason:        "BackOff",
+		Message:       "Back-off restarting failed container",
+		Count:         4,
+		LastTimestamp: metaV1.NewTime(time.Now()),
+		InvolvedObject: coreV1.ObjectReference{
+			Kind:      "Pod",
+			Name:      "chainlink-0-node-0",
+			FieldPath: "spec.containers{node}",
+		},
+	}
+	line := formatEventLine(e, "chainlink-0", false)
+	require.True(t, strings.HasSuffix(line, " Warning BackOff Pod/chainlink-0-node-0 [chainlink-0/node] (x4): Back-off restarting failed container\n"), line)
+
+	e.InvolvedObject = coreV1.ObjectReference{Kind: "PersistentVolumeClaim", Name: "data"}
+	e.Count = 1
+	line = formatEventLine(e, "", false)
+	require.True(t, strings.HasSuffix(line, " Warning BackOff PersistentVolumeClaim/data: Back-off restarting failed container\n"), line)
+
+	colored := formatEventLine(e, "", true)
+	require.Contains(t, colored, colorYellow+coreV1.EventTypeWarning+colorReset)
+}
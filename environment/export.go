@@ -0,0 +1,125 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
+)
+
+// ConnectionInfo is everything external tools need to reach the environment: URLs, database DSNs with credentials set by charts,
+// contract addresses and chart outputs, URLs are in-cluster ones inside K8s and forwarded ones otherwise
+type ConnectionInfo struct {
+	Namespace string              `json:"namespace"`
+	URLs      map[string][]string `json:"urls"`
+	DSNs      map[string]string   `json:"dsns"`
+	Contracts map[string]string   `json:"contracts"`
+	Outputs   map[string]string   `json:"outputs"`
+}
+
+// ConnectionInfo returns connection info of the environment, see ExportDotenv and ExportJSON
+func (m *Environment) ConnectionInfo() (*ConnectionInfo, error) {
+	info := &ConnectionInfo{
+		Namespace: m.Cfg.Namespace,
+		URLs:      make(map[string][]string, len(m.URLs)),
+		DSNs:      make(map[string]string),
+		Contracts: make(map[string]string),
+		Outputs:   m.outputs(),
+	}
+	for k, urls := range m.URLs {
+		info.URLs[k] = append([]string{}, urls...)
+	}
+	for _, c := range m.Fwd.Connections(client.Postgres) {
+		dsn, err := client.NewURLConverter(c.Info, nil).URL(m.connectionMode())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get DSN of %s:%s", c.App, c.Instance)
+		}
+		info.DSNs[strings.Join([]string{c.App, c.Instance, c.Container, c.Port}, "_")] = dsn
+	}
+	m.mu.Lock()
+	for k, v := range m.Contracts {
+		info.Contracts[k] = v
+	}
+	m.mu.Unlock()
+	return info, nil
+}
+
+// ExportJSON writes connection info into a JSON file, so non-Go tools, e.g. Hardhat scripts or k6, use the environment
+// as the single source of connection truth
+func (m *Environment) ExportJSON(path string) error {
+	info, err := m.ConnectionInfo()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(path, data)
+}
+
+// ExportDotenv writes connection info into an env file, e.g. for Postman or docker compose, ENV_NAMESPACE is written too,
+// so sourcing it connects tests to the environment, see ConnectionInfo.Dotenv
+func (m *Environment) ExportDotenv(path string) error {
+	info, err := m.ConnectionInfo()
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(path, []byte(info.Dotenv()))
+}
+
+// writePrivateFile writes a file readable only by its owner, connection files have credentials,
+// an existing file is restricted too, WriteFile keeps its mode
+func writePrivateFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+// Dotenv returns sorted env file lines: ${KEY}_URL, or ${KEY}_URL_${i} for several URLs of a key,
+// ${NAME}_DSN, CONTRACT_${NAME}_ADDRESS and OUTPUT_${CHART}_${KEY}
+func (i *ConnectionInfo) Dotenv() string {
+	vars := map[string]string{config.EnvVarNamespace: i.Namespace}
+	for k, urls := range i.URLs {
+		if len(urls) == 1 {
+			vars[client.EnvVarName(k, "url")] = urls[0]
+			continue
+		}
+		for n, u := range urls {
+			vars[client.EnvVarName(k, "url", fmt.Sprint(n))] = u
+		}
+	}
+	for k, dsn := range i.DSNs {
+		vars[client.EnvVarName(k, "dsn")] = dsn
+	}
+	for k, addr := range i.Contracts {
+		vars[client.EnvVarName("contract", k, "address")] = addr
+	}
+	for k, v := range i.Outputs {
+		vars[client.EnvVarName("output", k)] = v
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("%s=%s\n", name, dotenvValue(vars[name])))
+	}
+	return sb.String()
+}
+
+// dotenvValue quotes values with spaces, quotes or shell special characters
+func dotenvValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\n\"'#$\\`") {
+		return fmt.Sprintf("%q", v)
+	}
+	return v
+}
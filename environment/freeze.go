@@ -0,0 +1,120 @@
+package environment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/config"
+)
+
+const (
+	DefaultFreezeTTL = 2 * time.Hour
+)
+
+// FrozenError is a deployment or check error of an environment kept for debugging, see FailureBehaviorFreeze
+type FrozenError struct {
+	Namespace string
+	// TTL how long the environment is kept from now
+	TTL    time.Duration
+	Charts map[string]ChartStatus
+	// Instructions commands to connect to the environment and inspect it
+	Instructions string
+	Err          error
+}
+
+func (e *FrozenError) Error() string {
+	return fmt.Sprintf("environment %s is frozen for %s: %s\n%s", e.Namespace, e.TTL, e.Err, e.Instructions)
+}
+
+func (e *FrozenError) Unwrap() error {
+	return e.Err
+}
+
+// frozenPod is a pod with its container ports, used to print forward commands
+type frozenPod struct {
+	Name  string
+	Ports []int32
+}
+
+// freeze keeps the environment, extends its TTL, prints connect instructions and returns a FrozenError
+func (m *Environment) freeze(cause error) error {
+	ttl := m.Cfg.FreezeTTL
+	if ttl == 0 {
+		ttl = DefaultFreezeTTL
+	}
+	if err := m.Client.ExtendTTL(m.Cfg.Namespace, ttl); err != nil {
+		log.Warn().Err(err).Str("Namespace", m.Cfg.Namespace).Msg("Failed to extend environment TTL")
+	}
+	pods := make([]frozenPod, 0)
+	pl, err := m.Client.ListPods(m.Cfg.Namespace, "")
+	if err != nil {
+		log.Warn().Err(err).Str("Namespace", m.Cfg.Namespace).Msg("Failed to list pods")
+	} else {
+		for _, p := range pl.Items {
+			fp := frozenPod{Name: p.Name}
+			for _, c := range p.Spec.Containers {
+				for _, port := range c.Ports {
+					fp.Ports = append(fp.Ports, port.ContainerPort)
+				}
+			}
+			pods = append(pods, fp)
+		}
+	}
+	charts := m.ChartsStatus()
+	m.recordEvent("freeze", "", cause)
+	fe := &FrozenError{
+		Namespace:    m.Cfg.Namespace,
+		TTL:          ttl,
+		Charts:       charts,
+		Instructions: freezeInstructions(m.Cfg.Namespace, charts, pods),
+		Err:          cause,
+	}
+	log.Error().
+		Err(cause).
+		Str("Namespace", fe.Namespace).
+		Dur("TTL", fe.TTL).
+		Interface("Charts", fe.Charts).
+		Msg("Environment is frozen for debugging")
+	// instructions are in the error as well, for callers that don't show logs
+	log.Info().Str("Namespace", fe.Namespace).Msg(fe.Instructions)
+	return fe
+}
+
+// freezeInstructions returns commands to inspect not ready charts, forward pods ports and reconnect to the environment
+func freezeInstructions(namespace string, charts map[string]ChartStatus, pods []frozenPod) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Environment %s is kept for debugging\n", namespace))
+	sb.WriteString(fmt.Sprintf("Reconnect from tests: %s=%s\n", config.EnvVarNamespace, namespace))
+	names := make([]string, 0, len(charts))
+	for name := range charts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if charts[name] == ChartStatusReady {
+			continue
+		}
+		selector := releaseSelector(name)
+		sb.WriteString(fmt.Sprintf("Chart %s is %s:\n", name, charts[name]))
+		sb.WriteString(fmt.Sprintf("  kubectl get pods -n %s -l %s\n", namespace, selector))
+		sb.WriteString(fmt.Sprintf("  kubectl logs -n %s -l %s --all-containers\n", namespace, selector))
+	}
+	if len(pods) != 0 {
+		sb.WriteString("Forward ports:\n")
+	}
+	for _, p := range pods {
+		if len(p.Ports) == 0 {
+			continue
+		}
+		ports := make([]string, 0, len(p.Ports))
+		for _, port := range p.Ports {
+			ports = append(ports, fmt.Sprintf("%d", port))
+		}
+		sb.WriteString(fmt.Sprintf("  kubectl port-forward -n %s pod/%s %s\n", namespace, p.Name, strings.Join(ports, " ")))
+	}
+	sb.WriteString(fmt.Sprintf("Remove when done: kubectl delete namespace %s\n", namespace))
+	return sb.String()
+}
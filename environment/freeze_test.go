@@ -0,0 +1,22 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeInstructions(t *testing.T) {
+	s := freezeInstructions("env-1", map[string]ChartStatus{
+		"geth":        ChartStatusReady,
+		"chainlink-0": ChartStatusFailed,
+	}, []frozenPod{
+		{Name: "chainlink-0-abc", Ports: []int32{6688, 5432}},
+		{Name: "janitor"},
+	})
+	require.Contains(t, s, "ENV_NAMESPACE=env-1")
+	require.Contains(t, s, "kubectl logs -n env-1 -l chainlink-env/release=chainlink-0 --all-containers")
+	require.NotContains(t, s, "release=geth")
+	require.Contains(t, s, "kubectl port-forward -n env-1 pod/chainlink-0-abc 6688 5432")
+	require.NotContains(t, s, "pod/janitor")
+}
@@ -0,0 +1,120 @@
+package environment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/chaos"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+// StepResult is a result of a chaos scenario step
+type StepResult struct {
+	Step       string
+	Experiment string
+	Started    time.Time
+	// Recovered how long it took the environment to meet step expectations after the experiment was stopped
+	Recovered time.Duration
+	Err       error
+}
+
+// RunScenarioFile loads a chaos scenario from a YAML file and runs it, see RunScenario
+func (m *Environment) RunScenarioFile(path string) ([]StepResult, error) {
+	s, err := chaos.LoadScenario(path)
+	if err != nil {
+		return nil, err
+	}
+	return m.RunScenario(s)
+}
+
+// RunScenario runs scenario steps one by one against the environment, every step runs an experiment, stops it after
+// the hold time, unless it's kept, and checks that the environment recovered, the scenario stops at the first failed step,
+// kept experiments are stopped when the scenario ends
+func (m *Environment) RunScenario(s *chaos.Scenario) ([]StepResult, error) {
+	log.Info().Str("Scenario", s.Name).Int("Steps", len(s.Steps)).Msg("Running chaos scenario")
+	m.recordEvent("scenario", s.Name, nil)
+	results := make([]StepResult, 0, len(s.Steps))
+	kept := make([]string, 0)
+	defer func() {
+		for _, id := range kept {
+			if err := m.Chaos.Stop(id); err != nil {
+				log.Warn().Err(err).Str("Experiment", id).Msg("Failed to stop kept chaos experiment")
+			}
+		}
+	}()
+	for i, st := range s.Steps {
+		m.reportProgress(fmt.Sprintf("Chaos scenario %s: step %d/%d %s", s.Name, i+1, len(s.Steps), st.Name))
+		res, id := m.runStep(st)
+		if id != "" && st.Keep {
+			kept = append(kept, id)
+		}
+		results = append(results, res)
+		if res.Err != nil {
+			m.recordEvent("scenario", s.Name, res.Err)
+			return results, errors.Wrapf(res.Err, "chaos scenario %s failed at step %s", s.Name, st.Name)
+		}
+		log.Info().
+			Str("Step", st.Name).
+			Str("Experiment", st.Experiment).
+			Dur("Recovered", res.Recovered).
+			Msg("Chaos scenario step passed")
+	}
+	return results, nil
+}
+
+// runStep runs a step and returns its result and an ID of the started experiment
+func (m *Environment) runStep(st chaos.Step) (StepResult, string) {
+	res := StepResult{Step: st.Name, Experiment: st.Experiment}
+	before, hold := st.Durations()
+	m.clock().Sleep(before)
+	res.Started = m.clock().Now()
+	newExperiment, ok := chaos.LookupExperiment(st.Experiment)
+	if !ok {
+		res.Err = errors.Errorf("unknown experiment '%s', one of %v is expected", st.Experiment, chaos.ExperimentNames())
+		return res, ""
+	}
+	id, err := m.Chaos.Run(newExperiment(m.Cfg.Namespace, st.Props()))
+	if err != nil {
+		res.Err = err
+		return res, ""
+	}
+	m.clock().Sleep(hold)
+	if !st.Keep {
+		if err := m.Chaos.Stop(id); err != nil {
+			res.Err = err
+			return res, id
+		}
+	}
+	start := m.clock().Now()
+	res.Err = m.checkExpectation(st.Expect)
+	res.Recovered = m.clock().Since(start)
+	return res, id
+}
+
+// checkExpectation waits until pods are ready and charts are healthy if the expectation asks for it
+func (m *Environment) checkExpectation(e *chaos.Expectation) error {
+	if e == nil {
+		return nil
+	}
+	timeout := m.Cfg.ReadyCheckData.Timeout
+	if e.Within != "" {
+		timeout, _ = time.ParseDuration(e.Within)
+	}
+	if e.Ready {
+		rcd := &client.ReadyCheckData{
+			ReadinessProbeCheckSelector: m.Cfg.ReadyCheckData.ReadinessProbeCheckSelector,
+			Timeout:                     timeout,
+		}
+		if err := m.Client.CheckReady(m.Cfg.Namespace, rcd); err != nil {
+			return errors.Wrap(err, "environment is not ready")
+		}
+	}
+	if e.Healthy {
+		if err := m.CheckHealth(); err != nil {
+			return errors.Wrap(err, "environment is not healthy")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/config"
+)
+
+// Snapshot takes a snapshot of the environment state which doesn't depend on the namespace,
+// so it can be compared with a golden file committed with the tests
+func (m *Environment) Snapshot() (*Snapshot, error) {
+	s, err := TakeSnapshot(m.Client, m.Cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	s.Namespace = ""
+	return s, nil
+}
+
+// AddMetric adds a key metric of the environment, for example, a number of nodes or a block height after the setup
+func (s *Snapshot) AddMetric(name string, value interface{}) {
+	if s.Metrics == nil {
+		s.Metrics = make(map[string]string)
+	}
+	s.Metrics[name] = fmt.Sprintf("%v", value)
+}
+
+// WriteGolden writes a snapshot into a golden file, map keys are sorted, so files are stable between runs
+func WriteGolden(s *Snapshot, path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ReadGolden reads a snapshot from a golden file
+func ReadGolden(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse golden file %s", path)
+	}
+	return s, nil
+}
+
+// CompareGolden returns differences of a golden file (A) and a snapshot (B),
+// if config.EnvVarUpdateGolden is set the golden file is rewritten instead and no differences are returned
+func CompareGolden(s *Snapshot, path string) ([]Difference, error) {
+	if os.Getenv(config.EnvVarUpdateGolden) != "" {
+		log.Info().Str("File", path).Msg("Updating golden file")
+		return nil, WriteGolden(s, path)
+	}
+	golden, err := ReadGolden(path)
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, errors.Errorf("golden file %s doesn't exist, run with %s=true to create it", path, config.EnvVarUpdateGolden)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Diff(golden, s), nil
+}
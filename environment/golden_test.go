@@ -0,0 +1,47 @@
+package environment
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolden(t *testing.T) {
+	snapshot := func() *Snapshot {
+		s := &Snapshot{Releases: map[string]*ReleaseSnapshot{
+			"geth": {
+				ValuesHash: "aaa",
+				Containers: map[string]ContainerSnapshot{"geth/geth": {Image: "ethereum/client-go:v1.10.17"}},
+				Replicas:   map[string]int32{"geth": 1},
+			},
+		}}
+		s.AddMetric("nodes", 5)
+		return s
+	}
+	path := filepath.Join(t.TempDir(), "testdata", "env.golden.json")
+
+	_, err := CompareGolden(snapshot(), path)
+	require.Error(t, err)
+
+	t.Setenv(config.EnvVarUpdateGolden, "true")
+	diffs, err := CompareGolden(snapshot(), path)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+	t.Setenv(config.EnvVarUpdateGolden, "")
+
+	diffs, err = CompareGolden(snapshot(), path)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	s := snapshot()
+	s.Releases["geth"].Replicas["geth"] = 2
+	s.AddMetric("nodes", 3)
+	diffs, err = CompareGolden(s, path)
+	require.NoError(t, err)
+	require.Equal(t, []Difference{
+		{Release: "geth", Field: "geth replicas", A: "1", B: "2"},
+		{Release: "", Field: "metric nodes", A: "5", B: "3"},
+	}, diffs)
+}
@@ -0,0 +1,103 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+// GRPCService is a gRPC server of a chart pod
+type GRPCService struct {
+	App       string
+	Instance  int
+	Container string
+	Port      string
+	// HealthService service name for grpc.health.v1 checks, the whole server is checked if empty
+	HealthService string
+	// Services expected to be listed by the reflection API in the smoke test, the smoke test is skipped if empty
+	Services []string
+}
+
+// GRPCChart is a chart exposing gRPC services, their ports are forwarded as any other port, health is checked with
+// grpc.health.v1 as a part of CheckHealth and the reflection API is checked as a part of smoke tests
+type GRPCChart interface {
+	ConnectedChart
+	GRPCServices() []GRPCService
+}
+
+// GRPCClient returns a client of the service connected locally or in-cluster
+func (m *Environment) GRPCClient(s GRPCService) (*client.GRPCClient, error) {
+	addr, err := m.Fwd.FindPort(fmt.Sprintf("%s:%d", s.App, s.Instance), s.Container, s.Port).
+		As(m.connectionMode(), client.GRPC)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewGRPCClient(addr), nil
+}
+
+// checkGRPCHealth returns an error if any gRPC service of the chart is not serving
+func (m *Environment) checkGRPCHealth(c GRPCChart) error {
+	for _, s := range c.GRPCServices() {
+		gc, err := m.GRPCClient(s)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), client.GRPCDefaultTimeout)
+		status, err := gc.HealthCheck(ctx, s.HealthService)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if status != client.GRPCStatusServing {
+			return errors.Errorf("gRPC service %s of %s:%d is %s", s.HealthService, s.App, s.Instance, status)
+		}
+	}
+	return nil
+}
+
+// grpcSmokeTests checks that every gRPC server lists all expected services with the reflection API
+func grpcSmokeTests(c GRPCChart) []SmokeTest {
+	tests := make([]SmokeTest, 0)
+	for _, s := range c.GRPCServices() {
+		s := s
+		if len(s.Services) == 0 {
+			continue
+		}
+		tests = append(tests, SmokeTest{
+			Name: fmt.Sprintf("grpc reflection %s:%d", s.App, s.Instance),
+			Run: func(e *Environment) error {
+				gc, err := e.GRPCClient(s)
+				if err != nil {
+					return err
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), client.GRPCDefaultTimeout)
+				defer cancel()
+				listed, err := gc.ListServices(ctx)
+				if err != nil {
+					return err
+				}
+				return checkServicesListed(s.Services, listed)
+			},
+		})
+	}
+	return tests
+}
+
+func checkServicesListed(expected []string, listed []string) error {
+	set := make(map[string]bool)
+	for _, l := range listed {
+		set[l] = true
+	}
+	missing := make([]string, 0)
+	for _, e := range expected {
+		if !set[e] {
+			missing = append(missing, e)
+		}
+	}
+	if len(missing) != 0 {
+		return errors.Errorf("gRPC services are not listed: %v, listed: %v", missing, listed)
+	}
+	return nil
+}
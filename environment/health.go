@@ -0,0 +1,76 @@
+package environment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+const (
+	HealthCheckPollInterval = 5 * time.Second
+)
+
+// HealthCheckedChart is a chart that can check its application health beyond the container readiness probes,
+// for example, that the application is connected to all its dependencies
+type HealthCheckedChart interface {
+	ConnectedChart
+	// CheckHealth returns an error if application is not healthy yet, it is called after ExportData
+	CheckHealth(e *Environment) error
+}
+
+// CheckHealth waits until readiness checks of all charts pass, all charts implementing HealthCheckedChart are healthy,
+// all gRPC services of GRPCChart charts are serving and all conditions of ConditionedChart charts are met
+func (m *Environment) CheckHealth() error {
+	for _, c := range m.Charts {
+		if err := m.checkReadiness(c); err != nil {
+			return err
+		}
+		check := m.healthCheck(c)
+		if check == nil {
+			continue
+		}
+		m.reportProgress(fmt.Sprintf("Checking health of chart %s", c.GetName()))
+		var lastErr error
+		err := client.PollImmediate(m.clock(), HealthCheckPollInterval, m.Cfg.ReadyCheckData.Timeout, func() (bool, error) {
+			if lastErr = check(); lastErr != nil {
+				log.Debug().Err(lastErr).Str("Chart", c.GetName()).Msg("Chart is not healthy yet")
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			return errors.Wrapf(lastErr, "chart %s is not healthy", c.GetName())
+		}
+		log.Info().Str("Chart", c.GetName()).Msg("Chart is healthy")
+	}
+	return nil
+}
+
+// healthCheck returns a health check of the chart, nil if chart has nothing to check
+func (m *Environment) healthCheck(c ConnectedChart) func() error {
+	hc, healthChecked := c.(HealthCheckedChart)
+	gc, grpc := c.(GRPCChart)
+	cc, conditioned := c.(ConditionedChart)
+	if !healthChecked && !grpc && !conditioned {
+		return nil
+	}
+	return func() error {
+		if healthChecked {
+			if err := hc.CheckHealth(m); err != nil {
+				return err
+			}
+		}
+		if grpc {
+			if err := m.checkGRPCHealth(gc); err != nil {
+				return err
+			}
+		}
+		if conditioned {
+			return m.checkConditions(cc)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package environment
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+// History returns chaos experiments and lifecycle events recorded in the environment namespace, oldest first
+func History(namespace string) ([]client.HistoryEvent, error) {
+	return client.NewK8sClient().History(namespace)
+}
+
+// PrintHistory logs all recorded events of the environment namespace
+func PrintHistory(namespace string) error {
+	events, err := History(namespace)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		log.Info().
+			Time("Time", e.Time).
+			Str("Kind", e.Kind).
+			Str("Action", e.Action).
+			Str("Target", e.Target).
+			Str("Outcome", e.Outcome).
+			Str("Error", e.Error).
+			Str("User", e.User).
+			Msg("Environment event")
+	}
+	return nil
+}
+
+// recordEvent records a lifecycle event in the namespace history, history errors are only logged
+func (m *Environment) recordEvent(action string, target string, err error) {
+	if m.Cfg.DryRun {
+		return
+	}
+	if herr := m.Client.RecordEvent(m.Cfg.Namespace, client.NewHistoryEvent(client.EventKindLifecycle, action, target, err)); herr != nil {
+		log.Warn().Err(herr).Str("Action", action).Msg("Failed to record environment event")
+	}
+}
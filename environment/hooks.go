@@ -0,0 +1,268 @@
+package environment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultHookTimeout  = 5 * time.Minute
+	HookPollInterval    = 2 * time.Second
+	hookDeletionTimeout = 1 * time.Minute
+)
+
+// HookPhase is a moment of the chart deployment when a hook job runs
+type HookPhase string
+
+const (
+	// HookPreInstall runs before the chart manifest is applied, for example, genesis generation
+	HookPreInstall HookPhase = "pre-install"
+	// HookPostInstall runs after the chart pods are ready, for example, contracts deployment
+	HookPostInstall HookPhase = "post-install"
+)
+
+// HookJob is a Helm-style hook, a Job that must complete at its phase of the chart deployment
+type HookJob struct {
+	Name    string
+	Phase   HookPhase
+	Image   string
+	Command []string
+	Args    []string
+	Env     map[string]string
+	// BackoffLimit number of retries before the hook is failed
+	BackoffLimit int32
+	// Timeout to wait for the hook completion, DefaultHookTimeout if 0
+	Timeout time.Duration
+	// Outputs parses hook logs into chart outputs, for example, deployed contract addresses
+	Outputs func(logs string) (map[string]string, error)
+}
+
+// HookedChart is a chart with hook jobs, hooks of a phase run in the declared order, deployment fails if any hook fails
+type HookedChart interface {
+	ConnectedChart
+	Hooks() []HookJob
+}
+
+// HookResult is a result of one hook job with its logs
+type HookResult struct {
+	Chart    string        `json:"chart"`
+	Name     string        `json:"name"`
+	Phase    HookPhase     `json:"phase"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Logs     string        `json:"logs"`
+	Duration time.Duration `json:"duration"`
+}
+
+// runHooks runs hooks of the chart for the phase
+func (m *Environment) runHooks(name string, phase HookPhase) error {
+	hc, ok := m.chart(name).(HookedChart)
+	if !ok {
+		return nil
+	}
+	for _, h := range hc.Hooks() {
+		if h.Phase != phase {
+			continue
+		}
+		m.reportProgress(fmt.Sprintf("Running %s hook %s of chart %s", phase, h.Name, name))
+		start := m.clock().Now()
+		logs, err := m.runHook(name, h)
+		r := HookResult{
+			Chart:    name,
+			Name:     h.Name,
+			Phase:    phase,
+			Passed:   err == nil,
+			Logs:     logs,
+			Duration: m.clock().Since(start),
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		m.mu.Lock()
+		m.HookResults = append(m.HookResults, r)
+		m.mu.Unlock()
+		if err != nil {
+			log.Error().Err(err).Str("Chart", name).Str("Hook", h.Name).Str("Logs", logs).Msg("Hook failed")
+			return errors.Wrapf(err, "%s hook %s failed", phase, h.Name)
+		}
+		log.Info().Str("Chart", name).Str("Hook", h.Name).Dur("Duration", r.Duration).Msg("Hook completed")
+		if h.Outputs == nil {
+			continue
+		}
+		outputs, err := h.Outputs(logs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse outputs of hook %s", h.Name)
+		}
+		for k, v := range outputs {
+			m.Publish(name, k, v)
+		}
+	}
+	return nil
+}
+
+// runHook recreates the hook job, waits for its completion and returns logs of its pods
+func (m *Environment) runHook(chart string, h HookJob) (string, error) {
+	jobs := m.Client.ClientSet.BatchV1().Jobs(m.Cfg.Namespace)
+	job := hookJob(chart, h)
+	if err := resolveJobOutputs(job, m.outputs()); err != nil {
+		return "", err
+	}
+	if err := m.deleteHookJob(job.Name); err != nil {
+		return "", err
+	}
+	if _, err := jobs.Create(context.Background(), job, metaV1.CreateOptions{}); err != nil {
+		return "", err
+	}
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultHookTimeout
+	}
+	var jobErr error
+	err := client.PollImmediate(m.clock(), HookPollInterval, timeout, func() (bool, error) {
+		j, err := jobs.Get(context.Background(), job.Name, metaV1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, c := range j.Status.Conditions {
+			if c.Status != coreV1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case batchV1.JobComplete:
+				return true, nil
+			case batchV1.JobFailed:
+				jobErr = errors.Errorf("job %s failed: %s", job.Name, c.Message)
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	logs := m.hookLogs(job.Name)
+	if err != nil {
+		return logs, errors.Wrapf(err, "job %s is not completed", job.Name)
+	}
+	return logs, jobErr
+}
+
+// deleteHookJob deletes the job left from a previous run with its pods
+func (m *Environment) deleteHookJob(name string) error {
+	jobs := m.Client.ClientSet.BatchV1().Jobs(m.Cfg.Namespace)
+	if _, err := jobs.Get(context.Background(), name, metaV1.GetOptions{}); err != nil {
+		return nil
+	}
+	propagation := metaV1.DeletePropagationForeground
+	if err := jobs.Delete(context.Background(), name, metaV1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+		return err
+	}
+	return client.PollImmediate(m.clock(), HookPollInterval, hookDeletionTimeout, func() (bool, error) {
+		_, err := jobs.Get(context.Background(), name, metaV1.GetOptions{})
+		return err != nil, nil
+	})
+}
+
+// hookLogs returns logs of all hook job pods, errors are logged, so logs are best effort
+func (m *Environment) hookLogs(job string) string {
+	pods := m.Client.ClientSet.CoreV1().Pods(m.Cfg.Namespace)
+	pl, err := pods.List(context.Background(), metaV1.ListOptions{LabelSelector: fmt.Sprintf("job-name=%s", job)})
+	if err != nil {
+		log.Debug().Err(err).Str("Job", job).Msg("Failed to list hook pods")
+		return ""
+	}
+	sort.Slice(pl.Items, func(i, j int) bool {
+		return pl.Items[i].CreationTimestamp.Before(&pl.Items[j].CreationTimestamp)
+	})
+	buf := new(bytes.Buffer)
+	for _, p := range pl.Items {
+		stream, err := pods.GetLogs(p.Name, &coreV1.PodLogOptions{}).Stream(context.Background())
+		if err != nil {
+			log.Debug().Err(err).Str("Pod", p.Name).Msg("Failed to get hook logs")
+			continue
+		}
+		_, err = io.Copy(buf, stream)
+		_ = stream.Close()
+		if err != nil {
+			log.Debug().Err(err).Str("Pod", p.Name).Msg("Failed to read hook logs")
+		}
+	}
+	return buf.String()
+}
+
+// resolveJobOutputs replaces output references in the job container image, command, args and env
+func resolveJobOutputs(job *batchV1.Job, outputs map[string]string) error {
+	for i := range job.Spec.Template.Spec.Containers {
+		c := &job.Spec.Template.Spec.Containers[i]
+		fields := []*string{&c.Image}
+		for j := range c.Command {
+			fields = append(fields, &c.Command[j])
+		}
+		for j := range c.Args {
+			fields = append(fields, &c.Args[j])
+		}
+		for j := range c.Env {
+			fields = append(fields, &c.Env[j].Value)
+		}
+		for _, f := range fields {
+			resolved, err := resolveOutputRefs(*f, outputs)
+			if err != nil {
+				return err
+			}
+			*f = resolved
+		}
+	}
+	return nil
+}
+
+// hookJob returns the hook job, pods have the release label, so they are removed with the chart
+func hookJob(chart string, h HookJob) *batchV1.Job {
+	name := fmt.Sprintf("%s-%s", chart, h.Name)
+	labels := map[string]string{
+		pkg.ReleaseLabelKey: chart,
+		pkg.HookLabelKey:    h.Name,
+	}
+	envNames := make([]string, 0, len(h.Env))
+	for k := range h.Env {
+		envNames = append(envNames, k)
+	}
+	sort.Strings(envNames)
+	env := make([]coreV1.EnvVar, 0, len(envNames))
+	for _, k := range envNames {
+		env = append(env, coreV1.EnvVar{Name: k, Value: h.Env[k]})
+	}
+	backoff := h.BackoffLimit
+	return &batchV1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: batchV1.JobSpec{
+			BackoffLimit: &backoff,
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: labels},
+				Spec: coreV1.PodSpec{
+					RestartPolicy: coreV1.RestartPolicyNever,
+					Containers: []coreV1.Container{
+						{
+							Name:    h.Name,
+							Image:   h.Image,
+							Command: h.Command,
+							Args:    h.Args,
+							Env:     env,
+						},
+					},
+				},
+			},
+		},
+	}
+}
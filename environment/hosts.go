@@ -0,0 +1,159 @@
+package environment
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// HostAlias points hostnames at an IP or at a service of the environment, for example, a production hostname
+// hardcoded in an adapter at an in-cluster mock
+type HostAlias struct {
+	Hostnames []string
+	// IP address the hostnames are resolved to
+	IP string
+	// Service name of the environment, its ClusterIP is used if IP is empty, the service must be deployed before the chart using it
+	Service string
+}
+
+// PodDNS is DNS configuration of chart pods
+type PodDNS struct {
+	HostAliases []HostAlias
+	Nameservers []string
+	Searches    []string
+	// Options resolver options, e.g. "ndots": "2", empty value for options without a value
+	Options map[string]string
+}
+
+// DNSConfiguredChart is a chart that needs custom DNS configuration of its pods without changing the chart itself,
+// its host aliases are added to Config.HostAliases
+type DNSConfiguredChart interface {
+	ConnectedChart
+	PodDNS() PodDNS
+}
+
+// chartPodDNS returns DNS configuration of the chart pods with resolved service IPs, nil if there is nothing to inject
+func (m *Environment) chartPodDNS(name string) (*PodDNS, error) {
+	dns := PodDNS{HostAliases: append([]HostAlias{}, m.Cfg.HostAliases...)}
+	if dc, ok := m.chart(name).(DNSConfiguredChart); ok {
+		cd := dc.PodDNS()
+		dns.HostAliases = append(dns.HostAliases, cd.HostAliases...)
+		dns.Nameservers = cd.Nameservers
+		dns.Searches = cd.Searches
+		dns.Options = cd.Options
+	}
+	if len(dns.HostAliases) == 0 && len(dns.Nameservers) == 0 && len(dns.Searches) == 0 && len(dns.Options) == 0 {
+		return nil, nil
+	}
+	for i, ha := range dns.HostAliases {
+		if ha.IP != "" {
+			continue
+		}
+		if ha.Service == "" {
+			return nil, errors.Errorf("host alias %v has no IP and no service", ha.Hostnames)
+		}
+		svc, err := m.Client.ClientSet.CoreV1().Services(m.Cfg.Namespace).Get(context.Background(), ha.Service, metaV1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve host alias service %s", ha.Service)
+		}
+		if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == "None" {
+			return nil, errors.Errorf("host alias service %s has no cluster IP", ha.Service)
+		}
+		dns.HostAliases[i].IP = svc.Spec.ClusterIP
+	}
+	return &dns, nil
+}
+
+// injectPodDNS adds host aliases and DNS config to all pod specs of the manifest
+func injectPodDNS(manifest string, dns *PodDNS) (string, error) {
+	if dns == nil {
+		return manifest, nil
+	}
+	docs, err := splitManifest(manifest)
+	if err != nil {
+		return "", err
+	}
+	for i, d := range docs {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil {
+			return "", errors.Wrap(err, "failed to parse manifest")
+		}
+		if !injectPodSpecDNS(obj, dns) {
+			continue
+		}
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		docs[i] = string(out)
+	}
+	return joinManifest(docs), nil
+}
+
+// injectPodSpecDNS walks the object and updates every pod spec, returns true if anything changed
+func injectPodSpecDNS(obj interface{}, dns *PodDNS) bool {
+	changed := false
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		if _, ok := o["containers"]; ok {
+			setPodSpecDNS(o, dns)
+			return true
+		}
+		for _, v := range o {
+			if injectPodSpecDNS(v, dns) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, v := range o {
+			if injectPodSpecDNS(v, dns) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func setPodSpecDNS(spec map[string]interface{}, dns *PodDNS) {
+	if len(dns.HostAliases) != 0 {
+		aliases, _ := spec["hostAliases"].([]interface{})
+		for _, ha := range dns.HostAliases {
+			hostnames := make([]interface{}, 0, len(ha.Hostnames))
+			for _, h := range ha.Hostnames {
+				hostnames = append(hostnames, h)
+			}
+			aliases = append(aliases, map[string]interface{}{"ip": ha.IP, "hostnames": hostnames})
+		}
+		spec["hostAliases"] = aliases
+	}
+	if len(dns.Nameservers) == 0 && len(dns.Searches) == 0 && len(dns.Options) == 0 {
+		return
+	}
+	dc := make(map[string]interface{})
+	if len(dns.Nameservers) != 0 {
+		dc["nameservers"] = dns.Nameservers
+	}
+	if len(dns.Searches) != 0 {
+		dc["searches"] = dns.Searches
+	}
+	if len(dns.Options) != 0 {
+		names := make([]string, 0, len(dns.Options))
+		for n := range dns.Options {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		opts := make([]interface{}, 0, len(names))
+		for _, n := range names {
+			opt := map[string]interface{}{"name": n}
+			if v := dns.Options[n]; v != "" {
+				opt["value"] = v
+			}
+			opts = append(opts, opt)
+		}
+		dc["options"] = opts
+	}
+	spec["dnsConfig"] = dc
+}
@@ -0,0 +1,53 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestInjectPodDNS(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: node
+spec:
+  template:
+    spec:
+      hostAliases:
+      - ip: 10.0.0.1
+        hostnames:
+        - existing.local
+      containers:
+      - name: node
+        image: node:1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: node
+`
+	out, err := injectPodDNS(manifest, &PodDNS{
+		HostAliases: []HostAlias{{Hostnames: []string{"api.coingecko.com"}, IP: "10.0.0.2"}},
+		Options:     map[string]string{"ndots": "2", "single-request": ""},
+	})
+	require.NoError(t, err)
+	docs, err := splitManifest(out)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	var obj map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(docs[0]), &obj))
+	spec := obj["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	aliases := spec["hostAliases"].([]interface{})
+	require.Len(t, aliases, 2)
+	require.Equal(t, "10.0.0.2", aliases[1].(map[string]interface{})["ip"])
+	opts := spec["dnsConfig"].(map[string]interface{})["options"].([]interface{})
+	require.Equal(t, map[string]interface{}{"name": "ndots", "value": "2"}, opts[0])
+	require.Equal(t, map[string]interface{}{"name": "single-request"}, opts[1])
+	require.NotContains(t, docs[1], "hostAliases")
+
+	same, err := injectPodDNS(manifest, nil)
+	require.NoError(t, err)
+	require.Equal(t, manifest, same)
+}
@@ -0,0 +1,108 @@
+package environment
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	DefaultRegistry = "docker.io"
+)
+
+// normalizeImage returns a fully qualified image name, "postgres:13.6" becomes "docker.io/library/postgres:13.6"
+func normalizeImage(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return image
+	}
+	if len(parts) == 1 {
+		return DefaultRegistry + "/library/" + image
+	}
+	return DefaultRegistry + "/" + image
+}
+
+// mirrorImage rewrites an image using the longest matching registry or repository prefix rule
+func mirrorImage(image string, rules map[string]string) string {
+	full := normalizeImage(image)
+	prefixes := make([]string, 0, len(rules))
+	for p := range rules {
+		prefixes = append(prefixes, p)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, p := range prefixes {
+		prefix := strings.TrimSuffix(p, "/") + "/"
+		if strings.HasPrefix(full, prefix) {
+			return strings.TrimSuffix(rules[p], "/") + "/" + strings.TrimPrefix(full, prefix)
+		}
+	}
+	return image
+}
+
+// mirrorImages rewrites images of all containers in the manifest according to the mirror rules
+func mirrorImages(manifest string, rules map[string]string) (string, error) {
+	if len(rules) == 0 {
+		return manifest, nil
+	}
+	docs, err := splitManifest(manifest)
+	if err != nil {
+		return "", err
+	}
+	for i, d := range docs {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil {
+			return "", errors.Wrap(err, "failed to parse manifest")
+		}
+		if !rewriteContainerImages(obj, rules) {
+			continue
+		}
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		docs[i] = string(out)
+	}
+	return joinManifest(docs), nil
+}
+
+// rewriteContainerImages walks the object and rewrites every container image, returns true if anything changed
+func rewriteContainerImages(obj interface{}, rules map[string]string) bool {
+	changed := false
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		for k, v := range o {
+			if k == "containers" || k == "initContainers" {
+				if containers, ok := v.([]interface{}); ok {
+					for _, c := range containers {
+						cm, ok := c.(map[string]interface{})
+						if !ok {
+							continue
+						}
+						image, ok := cm["image"].(string)
+						if !ok {
+							continue
+						}
+						if mirrored := mirrorImage(image, rules); mirrored != image {
+							log.Debug().Str("Image", image).Str("Mirror", mirrored).Msg("Rewriting image")
+							cm["image"] = mirrored
+							changed = true
+						}
+					}
+				}
+			}
+			if rewriteContainerImages(v, rules) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, v := range o {
+			if rewriteContainerImages(v, rules) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
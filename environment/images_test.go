@@ -0,0 +1,48 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorImage(t *testing.T) {
+	rules := map[string]string{
+		"docker.io":                 "mirror.local/dockerhub",
+		"public.ecr.aws/chainlink/": "mirror.local/chainlink",
+	}
+	require.Equal(t, "mirror.local/dockerhub/library/postgres:13.6", mirrorImage("postgres:13.6", rules))
+	require.Equal(t, "mirror.local/dockerhub/ethereum/client-go:v1.10.17", mirrorImage("ethereum/client-go:v1.10.17", rules))
+	require.Equal(t, "mirror.local/chainlink/chainlink:1.5.1-root", mirrorImage("public.ecr.aws/chainlink/chainlink:1.5.1-root", rules))
+	require.Equal(t, "gcr.io/some/image:1", mirrorImage("gcr.io/some/image:1", rules))
+	require.Equal(t, "localhost:5000/image", mirrorImage("localhost:5000/image", rules))
+}
+
+func TestMirrorImages(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: geth
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox
+      containers:
+        - name: geth
+          image: ethereum/client-go:v1.10.17
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  image: postgres
+`
+	out, err := mirrorImages(manifest, map[string]string{"docker.io": "mirror.local"})
+	require.NoError(t, err)
+	require.Contains(t, out, "image: mirror.local/library/busybox")
+	require.Contains(t, out, "image: mirror.local/ethereum/client-go:v1.10.17")
+	require.Contains(t, out, "image: postgres")
+}
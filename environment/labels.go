@@ -0,0 +1,94 @@
+package environment
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// GeneratedByLabelKey is a label every environment namespace has, used to select only environments
+	GeneratedByLabelKey   = "generatedBy"
+	GeneratedByLabelValue = "cdk8s"
+)
+
+// ValidateLabels checks that all keys and values are valid K8s labels
+func ValidateLabels(labels map[string]string) error {
+	problems := make([]string, 0)
+	for k, v := range labels {
+		for _, msg := range validation.IsQualifiedName(k) {
+			problems = append(problems, fmt.Sprintf("key '%s': %s", k, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(v) {
+			problems = append(problems, fmt.Sprintf("value '%s' of key '%s': %s", v, k, msg))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.Errorf("invalid labels: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// labelValueInvalidChars characters which are not allowed in label values
+var labelValueInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SanitizeLabelValue makes a valid label value, invalid characters are replaced with "-",
+// the value is truncated to the max length and trimmed to start and end with an alphanumeric character
+func SanitizeLabelValue(v string) string {
+	v = labelValueInvalidChars.ReplaceAllString(v, "-")
+	if len(v) > validation.LabelValueMaxLength {
+		v = v[:validation.LabelValueMaxLength]
+	}
+	return strings.TrimFunc(v, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})
+}
+
+// environmentsSelector restricts any selector to environment namespaces only
+func environmentsSelector(selector string) string {
+	envSelector := fmt.Sprintf("%s=%s", GeneratedByLabelKey, GeneratedByLabelValue)
+	if selector == "" {
+		return envSelector
+	}
+	return fmt.Sprintf("%s,%s", selector, envSelector)
+}
+
+// ListEnvironments lists namespaces of all environments matching the selector, e.g. "commit=abc,envType=evm-5-minimal"
+func ListEnvironments(selector string) ([]coreV1.Namespace, error) {
+	c := client.NewK8sClient()
+	nsList, err := c.ListNamespaces(environmentsSelector(selector))
+	if err != nil {
+		return nil, err
+	}
+	return nsList.Items, nil
+}
+
+// DeleteEnvironments removes all environments matching the selector and returns removed namespaces names,
+// selector can't be empty to prevent removing all environments in the cluster by accident
+func DeleteEnvironments(selector string) ([]string, error) {
+	if selector == "" {
+		return nil, errors.New("selector can't be empty")
+	}
+	c := client.NewK8sClient()
+	nsList, err := c.ListNamespaces(environmentsSelector(selector))
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]string, 0)
+	for _, ns := range nsList.Items {
+		if err := c.RemoveNamespace(ns.Name); err != nil {
+			return removed, err
+		}
+		removed = append(removed, ns.Name)
+	}
+	log.Info().Str("Selector", selector).Strs("Namespaces", removed).Msg("Environments removed")
+	return removed, nil
+}
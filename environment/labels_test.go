@@ -0,0 +1,24 @@
+package environment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeLabelValue(t *testing.T) {
+	for in, out := range map[string]string{
+		"john.doe@example.com":         "john.doe-example.com",
+		"ci / nightly":                 "ci-nightly",
+		"_merge:main_":                 "merge-main",
+		"":                             "",
+		"abc123":                       "abc123",
+		strings.Repeat("a", 70):        strings.Repeat("a", 63),
+		strings.Repeat("a", 62) + "@b": strings.Repeat("a", 62),
+	} {
+		v := SanitizeLabelValue(in)
+		require.Equal(t, out, v, in)
+		require.NoError(t, ValidateLabels(map[string]string{"owner": v}), in)
+	}
+}
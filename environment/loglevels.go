@@ -0,0 +1,181 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// NodeContainer is a Chainlink node container name, its logs are split by level
+	NodeContainer  = "node"
+	LevelAllFile   = "all.log"
+	LevelWarnFile  = "warn.log"
+	LevelErrorFile = "error.log"
+)
+
+// consoleLogLevel matches a level of a console formatted log line, e.g. "[ERROR]"
+var consoleLogLevel = regexp.MustCompile(`\[(DEBUG|INFO|WARN|ERROR|CRIT|PANIC|FATAL)]`)
+
+// LevelCount is a number of warnings and errors of a node in a time bucket
+type LevelCount struct {
+	Time  time.Time
+	Warn  int
+	Error int
+}
+
+// levelFiles are per level log files of a node
+type levelFiles struct {
+	all   *os.File
+	warn  *os.File
+	error *os.File
+}
+
+// SplitLevels writes Chainlink node lines into ${dir}/${pod}/all.log, warn.log and error.log as they are collected,
+// error.log includes critical, panic and fatal lines, call it before Start to capture the whole run
+func (l *Logs) SplitLevels(dir string) error {
+	if err := mkdirIfNotExists(dir); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levelsDir = dir
+	l.levelFiles = make(map[string]*levelFiles)
+	return nil
+}
+
+// writeLevels writes a line into per level files of its node, l.mu must be held
+func (l *Logs) writeLevels(line LogLine) {
+	if l.levelsDir == "" || line.Container != NodeContainer {
+		return
+	}
+	f, ok := l.levelFiles[line.Pod]
+	if !ok {
+		var err error
+		if f, err = openLevelFiles(filepath.Join(l.levelsDir, line.Pod)); err != nil {
+			log.Warn().Err(err).Str("Pod", line.Pod).Msg("Failed to create log level files")
+			l.levelFiles[line.Pod] = nil
+			return
+		}
+		l.levelFiles[line.Pod] = f
+	}
+	if f == nil {
+		return
+	}
+	text := fmt.Sprintf("%s %s\n", line.Time.UTC().Format(time.RFC3339Nano), line.Text)
+	_, _ = f.all.WriteString(text)
+	switch parseLogLevel(line.Text) {
+	case "warn":
+		_, _ = f.warn.WriteString(text)
+	case "error":
+		_, _ = f.error.WriteString(text)
+	}
+}
+
+// closeLevels closes level files, lines collected later are not written, l.mu must be held
+func (l *Logs) closeLevels() {
+	for _, f := range l.levelFiles {
+		if f == nil {
+			continue
+		}
+		for _, file := range []*os.File{f.all, f.warn, f.error} {
+			_ = file.Close()
+		}
+	}
+	l.levelsDir = ""
+	l.levelFiles = nil
+}
+
+func openLevelFiles(dir string) (*levelFiles, error) {
+	if err := mkdirIfNotExists(dir); err != nil {
+		return nil, err
+	}
+	files := make([]*os.File, 0, 3)
+	for _, name := range []string{LevelAllFile, LevelWarnFile, LevelErrorFile} {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return &levelFiles{all: files[0], warn: files[1], error: files[2]}, nil
+}
+
+// LevelCounts returns numbers of warnings and errors of every Chainlink node pod in time buckets, oldest first,
+// buckets without warnings and errors are skipped
+func (l *Logs) LevelCounts(bucket time.Duration) map[string][]LevelCount {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return countLevels(l.collected(), bucket)
+}
+
+// countLevels counts warnings and errors of node lines per pod and time bucket
+func countLevels(lines []LogLine, bucket time.Duration) map[string][]LevelCount {
+	buckets := make(map[string]map[time.Time]*LevelCount)
+	for _, line := range lines {
+		if line.Container != NodeContainer {
+			continue
+		}
+		level := parseLogLevel(line.Text)
+		if level != "warn" && level != "error" {
+			continue
+		}
+		if buckets[line.Pod] == nil {
+			buckets[line.Pod] = make(map[time.Time]*LevelCount)
+		}
+		ts := line.Time.Truncate(bucket)
+		c, ok := buckets[line.Pod][ts]
+		if !ok {
+			c = &LevelCount{Time: ts}
+			buckets[line.Pod][ts] = c
+		}
+		if level == "warn" {
+			c.Warn++
+		} else {
+			c.Error++
+		}
+	}
+	counts := make(map[string][]LevelCount)
+	for pod, b := range buckets {
+		for _, c := range b {
+			counts[pod] = append(counts[pod], *c)
+		}
+		sort.Slice(counts[pod], func(i, j int) bool {
+			return counts[pod][i].Time.Before(counts[pod][j].Time)
+		})
+	}
+	return counts
+}
+
+// parseLogLevel returns "debug", "info", "warn" or "error" level of a JSON or console formatted Chainlink log line,
+// critical, panic and fatal are errors, empty if the line has no level
+func parseLogLevel(text string) string {
+	var level string
+	if strings.HasPrefix(text, "{") {
+		var l struct {
+			Level string `json:"level"`
+		}
+		if err := json.Unmarshal([]byte(text), &l); err == nil {
+			level = strings.ToLower(l.Level)
+		}
+	} else if m := consoleLogLevel.FindStringSubmatch(text); m != nil {
+		level = strings.ToLower(m[1])
+	}
+	switch level {
+	case "crit", "dpanic", "panic", "fatal":
+		return "error"
+	case "warning":
+		return "warn"
+	}
+	return level
+}
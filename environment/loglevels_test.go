@@ -0,0 +1,58 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	require.Equal(t, "error", parseLogLevel(`{"level":"error","msg":"OCR round failed"}`))
+	require.Equal(t, "error", parseLogLevel(`{"level":"crit","msg":"db is gone"}`))
+	require.Equal(t, "warn", parseLogLevel(`2022-10-01T10:00:00Z [WARN]  RPC is slow`))
+	require.Equal(t, "info", parseLogLevel(`2022-10-01T10:00:00Z [INFO]  OCR round finished`))
+	require.Equal(t, "", parseLogLevel(`plain text`))
+	require.Equal(t, "", parseLogLevel(`{"msg":"no level"}`))
+}
+
+func TestCountLevels(t *testing.T) {
+	base := time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC)
+	lines := []LogLine{
+		{Pod: "chainlink-0", Container: NodeContainer, Time: base.Add(10 * time.Second), Text: `{"level":"error"}`},
+		{Pod: "chainlink-0", Container: NodeContainer, Time: base.Add(20 * time.Second), Text: `{"level":"warn"}`},
+		{Pod: "chainlink-0", Container: NodeContainer, Time: base.Add(30 * time.Second), Text: `{"level":"info"}`},
+		{Pod: "chainlink-0", Container: NodeContainer, Time: base.Add(2 * time.Minute), Text: `{"level":"panic"}`},
+		{Pod: "chainlink-0", Container: "chainlink-db", Time: base, Text: `[ERROR] db error`},
+		{Pod: "chainlink-1", Container: NodeContainer, Time: base, Text: `[INFO] ok`},
+	}
+	counts := countLevels(lines, time.Minute)
+	require.Len(t, counts, 1)
+	require.Equal(t, []LevelCount{
+		{Time: base, Warn: 1, Error: 1},
+		{Time: base.Add(2 * time.Minute), Error: 1},
+	}, counts["chainlink-0"])
+}
+
+func TestSplitLevels(t *testing.T) {
+	dir := t.TempDir()
+	l := &Logs{mu: &sync.Mutex{}}
+	require.NoError(t, l.SplitLevels(dir))
+	now := time.Now()
+	l.writeLevels(LogLine{Pod: "chainlink-0", Container: NodeContainer, Time: now, Text: `{"level":"error"}`})
+	l.writeLevels(LogLine{Pod: "chainlink-0", Container: NodeContainer, Time: now, Text: `{"level":"info"}`})
+	l.writeLevels(LogLine{Pod: "chainlink-0", Container: "chainlink-db", Time: now, Text: `{"level":"warn"}`})
+	l.closeLevels()
+	lines := func(name string) int {
+		data, err := os.ReadFile(filepath.Join(dir, "chainlink-0", name))
+		require.NoError(t, err)
+		return strings.Count(string(data), "\n")
+	}
+	require.Equal(t, 2, lines(LevelAllFile))
+	require.Equal(t, 0, lines(LevelWarnFile))
+	require.Equal(t, 1, lines(LevelErrorFile))
+}
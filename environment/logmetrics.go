@@ -0,0 +1,171 @@
+package environment
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LogMetricsReport metrics extracted from logs are added to artifacts as ${LogMetricsReport}.json
+	LogMetricsReport = "log-metrics"
+)
+
+// LogMetric is a counter or a value extracted from log lines, every line matching Pattern is counted,
+// the first capture group, if any, is a value, e.g. "OCR round took (\d+)ms" collects round durations
+type LogMetric struct {
+	Name    string
+	Pattern string
+	// Unit of captured values in the report, e.g. "ms"
+	Unit string
+}
+
+// LogMetricStats are aggregated matches of a metric in logs of a pod, Values is a number of captured numeric values
+type LogMetricStats struct {
+	Count  int
+	Values int
+	Unit   string `json:",omitempty"`
+	Sum    float64
+	Min    float64
+	Max    float64
+	Mean   float64
+}
+
+type compiledLogMetric struct {
+	LogMetric
+	re *regexp.Regexp
+}
+
+// LogMetrics aggregates log metrics per pod while logs are collected, so soak tests get cheap performance insight
+// without metrics infrastructure, see Config.LogMetrics
+type LogMetrics struct {
+	mu      *sync.Mutex
+	metrics []compiledLogMetric
+	// stats by metric and pod
+	stats map[string]map[string]*LogMetricStats
+}
+
+// NewLogMetrics creates a log metrics aggregator
+func NewLogMetrics(metrics ...LogMetric) (*LogMetrics, error) {
+	lm := &LogMetrics{
+		mu:      &sync.Mutex{},
+		metrics: make([]compiledLogMetric, 0),
+		stats:   make(map[string]map[string]*LogMetricStats),
+	}
+	for _, m := range metrics {
+		if err := lm.Add(m); err != nil {
+			return nil, err
+		}
+	}
+	return lm, nil
+}
+
+// Add adds a metric, lines collected before are not counted
+func (lm *LogMetrics) Add(m LogMetric) error {
+	if m.Name == "" {
+		return errors.New("log metric name is empty")
+	}
+	re, err := regexp.Compile(m.Pattern)
+	if err != nil {
+		return errors.Wrapf(err, "invalid pattern of log metric %s", m.Name)
+	}
+	if re.NumSubexp() > 1 {
+		return errors.Errorf("log metric %s pattern has %d capture groups, only one value can be captured", m.Name, re.NumSubexp())
+	}
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	for _, existing := range lm.metrics {
+		if existing.Name == m.Name {
+			return errors.Errorf("log metric %s is already added", m.Name)
+		}
+	}
+	lm.metrics = append(lm.metrics, compiledLogMetric{LogMetric: m, re: re})
+	lm.stats[m.Name] = make(map[string]*LogMetricStats)
+	return nil
+}
+
+// observe applies all metrics to a line of the pod, captured values which are not numbers are only counted
+func (lm *LogMetrics) observe(pod string, text string) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	for _, m := range lm.metrics {
+		match := m.re.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		s, ok := lm.stats[m.Name][pod]
+		if !ok {
+			s = &LogMetricStats{Unit: m.Unit}
+			lm.stats[m.Name][pod] = s
+		}
+		s.Count++
+		if len(match) < 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			continue
+		}
+		if s.Values == 0 || v < s.Min {
+			s.Min = v
+		}
+		if s.Values == 0 || v > s.Max {
+			s.Max = v
+		}
+		s.Values++
+		s.Sum += v
+		s.Mean = s.Sum / float64(s.Values)
+	}
+}
+
+// Report returns stats of all metrics by metric names and pods
+func (lm *LogMetrics) Report() map[string]map[string]LogMetricStats {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	report := make(map[string]map[string]LogMetricStats, len(lm.stats))
+	for name, pods := range lm.stats {
+		report[name] = make(map[string]LogMetricStats, len(pods))
+		for pod, s := range pods {
+			report[name][pod] = *s
+		}
+	}
+	return report
+}
+
+// MarshalJSON writes the report as of the moment artifacts are dumped
+func (lm *LogMetrics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(lm.Report())
+}
+
+// AddLogMetric adds a metric extracted from logs collected from now on, see Config.LogMetrics
+func (m *Environment) AddLogMetric(metric LogMetric) error {
+	if m.LogMetrics == nil {
+		lm, err := NewLogMetrics()
+		if err != nil {
+			return err
+		}
+		m.LogMetrics = lm
+		m.attachLogMetrics()
+	}
+	return m.LogMetrics.Add(metric)
+}
+
+// attachLogMetrics makes streamed logs, or collected logs without a stream, extract metrics, so lines are counted once,
+// and adds the metrics report to artifacts
+func (m *Environment) attachLogMetrics() {
+	if m.LogMetrics == nil {
+		return
+	}
+	switch {
+	case m.LogStream != nil:
+		m.LogStream.ExtractMetrics(m.LogMetrics)
+	case m.Logs != nil:
+		m.Logs.ExtractMetrics(m.LogMetrics)
+	}
+	if m.Artifacts != nil {
+		m.Artifacts.AddReport(LogMetricsReport, m.LogMetrics)
+	}
+}
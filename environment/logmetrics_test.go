@@ -0,0 +1,37 @@
+package environment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMetrics(t *testing.T) {
+	lm, err := NewLogMetrics(
+		LogMetric{Name: "ocr_round", Pattern: `OCR round took (\d+)ms`, Unit: "ms"},
+		LogMetric{Name: "errors", Pattern: `\[ERROR\]`},
+	)
+	require.NoError(t, err)
+	for _, l := range []struct{ pod, text string }{
+		{"chainlink-0", "OCR round took 120ms"},
+		{"chainlink-0", "OCR round took 80ms"},
+		{"chainlink-0", "[ERROR] failed to transmit"},
+		{"chainlink-1", "OCR round took 100ms"},
+		{"chainlink-1", "unrelated line"},
+	} {
+		lm.observe(l.pod, l.text)
+	}
+	report := lm.Report()
+	require.Equal(t, LogMetricStats{Count: 2, Values: 2, Unit: "ms", Sum: 200, Min: 80, Max: 120, Mean: 100}, report["ocr_round"]["chainlink-0"])
+	require.Equal(t, LogMetricStats{Count: 1, Values: 1, Unit: "ms", Sum: 100, Min: 100, Max: 100, Mean: 100}, report["ocr_round"]["chainlink-1"])
+	require.Equal(t, map[string]LogMetricStats{"chainlink-0": {Count: 1}}, report["errors"])
+
+	data, err := json.Marshal(lm)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"errors":{"chainlink-0":{"Count":1,"Values":0,"Sum":0,"Min":0,"Max":0,"Mean":0}}`)
+
+	require.Error(t, lm.Add(LogMetric{Name: "errors", Pattern: "panic"}))
+	require.Error(t, lm.Add(LogMetric{Name: "two", Pattern: `(\d+) and (\d+)`}))
+	require.Error(t, lm.Add(LogMetric{Name: "broken", Pattern: `(`}))
+}
@@ -0,0 +1,105 @@
+package environment
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/config"
+)
+
+// LogNoise is a pattern of noisy log lines of a chart, e.g. Geth peer discovery spam or Postgres checkpoints
+type LogNoise struct {
+	// Container is a container which lines are filtered, all containers of the chart if empty
+	Container string
+	// Pattern is a regular expression of noisy lines
+	Pattern string
+}
+
+// NoisyLogsChart is a chart declaring noisy log lines, they are filtered out of dumped and streamed logs
+// unless Config.RawLogs is set, logs collected for assertions and log metrics see all lines
+type NoisyLogsChart interface {
+	ConnectedChart
+	NoisyLogs() []LogNoise
+}
+
+// LogFilter drops noisy lines of charts, lines are matched by the release label of their pods
+type LogFilter struct {
+	noise map[string][]compiledNoise
+}
+
+type compiledNoise struct {
+	container string
+	re        *regexp.Regexp
+}
+
+// NewLogFilter compiles noise patterns by chart names
+func NewLogFilter(noise map[string][]LogNoise) (*LogFilter, error) {
+	f := &LogFilter{noise: make(map[string][]compiledNoise)}
+	for chart, patterns := range noise {
+		for _, n := range patterns {
+			re, err := regexp.Compile(n.Pattern)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid log noise pattern of chart %s", chart)
+			}
+			f.noise[chart] = append(f.noise[chart], compiledNoise{container: n.Container, re: re})
+		}
+	}
+	return f, nil
+}
+
+// Noisy returns true if the line of the container of a chart release is noise, a nil filter keeps all lines
+func (f *LogFilter) Noisy(release string, container string, line string) bool {
+	if f == nil {
+		return false
+	}
+	for _, n := range f.noise[release] {
+		if (n.container == "" || n.container == container) && n.re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Copy copies lines which are not noise and returns the number of dropped lines
+func (f *LogFilter) Copy(dst io.Writer, src io.Reader, release string, container string) (int, error) {
+	if f == nil || len(f.noise[release]) == 0 {
+		_, err := io.Copy(dst, src)
+		return 0, err
+	}
+	r := bufio.NewReader(src)
+	dropped := 0
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			if f.Noisy(release, container, strings.TrimSuffix(line, "\n")) {
+				dropped++
+			} else if _, werr := io.WriteString(dst, line); werr != nil {
+				return dropped, werr
+			}
+		}
+		if err == io.EOF {
+			return dropped, nil
+		}
+		if err != nil {
+			return dropped, err
+		}
+	}
+}
+
+// logFilter returns a filter of noisy lines declared by charts, nil if raw logs are requested
+func (m *Environment) logFilter() (*LogFilter, error) {
+	if m.Cfg.RawLogs || os.Getenv(config.EnvVarRawLogs) != "" {
+		return nil, nil
+	}
+	noise := make(map[string][]LogNoise)
+	for _, c := range m.Charts {
+		if nc, ok := c.(NoisyLogsChart); ok {
+			noise[c.GetName()] = nc.NoisyLogs()
+		}
+	}
+	return NewLogFilter(noise)
+}
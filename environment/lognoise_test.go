@@ -0,0 +1,48 @@
+package environment
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogFilter(t *testing.T) {
+	f, err := NewLogFilter(map[string][]LogNoise{
+		"geth":        {{Container: "geth-network", Pattern: `Looking for peers`}},
+		"chainlink-0": {{Pattern: `checkpoint (starting|complete)`}},
+	})
+	require.NoError(t, err)
+	require.True(t, f.Noisy("geth", "geth-network", "INFO Looking for peers peercount=0"))
+	require.False(t, f.Noisy("geth", "other", "INFO Looking for peers peercount=0"))
+	require.False(t, f.Noisy("geth-2", "geth-network", "INFO Looking for peers peercount=0"))
+	require.True(t, f.Noisy("chainlink-0", "chainlink-db", "LOG:  checkpoint complete: wrote 3 buffers"))
+	require.False(t, (*LogFilter)(nil).Noisy("geth", "geth-network", "INFO Looking for peers"))
+
+	_, err = NewLogFilter(map[string][]LogNoise{"geth": {{Pattern: `(`}}})
+	require.Error(t, err)
+}
+
+func TestLogFilterCopy(t *testing.T) {
+	f, err := NewLogFilter(map[string][]LogNoise{"geth": {{Pattern: `Looking for peers`}}})
+	require.NoError(t, err)
+	src := "block 1\nLooking for peers\nblock 2\nLooking for peers\nno newline"
+	var dst bytes.Buffer
+	dropped, err := f.Copy(&dst, strings.NewReader(src), "geth", "geth-network")
+	require.NoError(t, err)
+	require.Equal(t, 2, dropped)
+	require.Equal(t, "block 1\nblock 2\nno newline", dst.String())
+
+	dst.Reset()
+	dropped, err = f.Copy(&dst, strings.NewReader(src), "chainlink", "node")
+	require.NoError(t, err)
+	require.Equal(t, 0, dropped)
+	require.Equal(t, src, dst.String())
+
+	dst.Reset()
+	dropped, err = (*LogFilter)(nil).Copy(&dst, strings.NewReader(src), "geth", "geth-network")
+	require.NoError(t, err)
+	require.Equal(t, 0, dropped)
+	require.Equal(t, src, dst.String())
+}
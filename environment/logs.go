@@ -0,0 +1,327 @@
+package environment
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// LogsMaxLineSize max size of a single log line we can read, JSON logs can be quite long
+	LogsMaxLineSize = 1024 * 1024
+	// DefaultLogsMaxLines how many collected lines are kept in memory, the oldest lines are dropped first,
+	// use LogStream to keep all lines in files
+	DefaultLogsMaxLines = 200_000
+)
+
+// LogLine is a single container log line collected from the environment
+type LogLine struct {
+	Pod       string
+	Container string
+	Labels    map[string]string
+	Time      time.Time
+	Text      string
+	// Restarts is a restart count of the container instance which wrote the line,
+	// lines of crashed instances are read from their previous logs after restarts
+	Restarts int32
+}
+
+// Logs continuously collects logs of all pods in the namespace, so tests can assert on them at the end of a run
+type Logs struct {
+	Namespace string
+	Client    *client.K8sClient
+	// MaxLines how many lines are kept, DefaultLogsMaxLines if 0
+	MaxLines int
+	mu       *sync.Mutex
+	// lines is a ring buffer of collected lines, head is the oldest line once it's full
+	lines     []LogLine
+	head      int
+	dropped   int
+	following map[string]bool
+	lastSeen  map[string]time.Time
+	restarts  map[string]int32
+	cancel    context.CancelFunc
+	// levelsDir is a directory of per level node logs, see SplitLevels
+	levelsDir  string
+	levelFiles map[string]*levelFiles
+	metrics    *LogMetrics
+}
+
+// NewLogs creates new logs collector for a namespace
+func NewLogs(client *client.K8sClient, namespace string) *Logs {
+	return &Logs{
+		Namespace: namespace,
+		Client:    client,
+		mu:        &sync.Mutex{},
+		lines:     make([]LogLine, 0),
+		following: make(map[string]bool),
+		lastSeen:  make(map[string]time.Time),
+		restarts:  make(map[string]int32),
+	}
+}
+
+// Start starts following logs of all pods in the namespace, pods created or restarted later are picked up too
+func (l *Logs) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	go func() {
+		for {
+			if err := l.followNewContainers(ctx); err != nil {
+				log.Warn().Err(err).Str("Namespace", l.Namespace).Msg("Failed to discover pods for logs collection")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-l.Client.Clock().After(client.ContainerStatePollInterval):
+			}
+		}
+	}()
+}
+
+// Stop stops all log followers and closes level files, collected lines are kept
+func (l *Logs) Stop() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closeLevels()
+}
+
+func (l *Logs) followNewContainers(ctx context.Context) error {
+	pods, err := l.Client.ListPods(l.Namespace, "")
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != coreV1.PodRunning {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			key := fmt.Sprintf("%s/%s", pod.Name, c.Name)
+			restarts := containerRestarts(pod, c.Name)
+			l.mu.Lock()
+			if l.following[key] {
+				l.mu.Unlock()
+				continue
+			}
+			l.following[key] = true
+			// the previous instance is read once after every restart, or on start if the container was restarted before
+			previous := restarts > l.restarts[key]
+			l.restarts[key] = restarts
+			l.mu.Unlock()
+			go func(pod coreV1.Pod, container string) {
+				defer func() {
+					l.mu.Lock()
+					defer l.mu.Unlock()
+					l.following[key] = false
+				}()
+				if previous {
+					l.read(ctx, pod, container, key, restarts-1, true)
+				}
+				l.read(ctx, pod, container, key, restarts, false)
+			}(pod, c.Name)
+		}
+	}
+	return nil
+}
+
+// containerRestarts returns a restart count of a pod container
+func containerRestarts(pod coreV1.Pod, container string) int32 {
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Name == container {
+			return s.RestartCount
+		}
+	}
+	return 0
+}
+
+// read reads container logs after the last seen line, follows the current instance or reads the previous one of a restarted container
+func (l *Logs) read(ctx context.Context, pod coreV1.Pod, container string, key string, restarts int32, previous bool) {
+	l.mu.Lock()
+	since := l.lastSeen[key]
+	l.mu.Unlock()
+	opts := &coreV1.PodLogOptions{
+		Container:  container,
+		Follow:     !previous,
+		Previous:   previous,
+		Timestamps: true,
+	}
+	if !since.IsZero() {
+		opts.SinceTime = &metaV1.Time{Time: since.Add(time.Nanosecond)}
+	}
+	stream, err := l.Client.ClientSet.CoreV1().Pods(l.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		log.Debug().Err(err).Str("Pod", pod.Name).Str("Container", container).Bool("Previous", previous).Msg("Failed to read logs")
+		return
+	}
+	// nolint
+	defer stream.Close()
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), LogsMaxLineSize)
+	for scanner.Scan() {
+		ts, text := splitLogTimestamp(scanner.Text(), l.Client.Clock().Now())
+		line := LogLine{
+			Pod:       pod.Name,
+			Container: container,
+			Labels:    pod.Labels,
+			Time:      ts,
+			Text:      text,
+			Restarts:  restarts,
+		}
+		l.mu.Lock()
+		l.addLine(line)
+		l.writeLevels(line)
+		l.lastSeen[key] = ts
+		metrics := l.metrics
+		l.mu.Unlock()
+		if metrics != nil {
+			metrics.observe(line.Pod, line.Text)
+		}
+	}
+}
+
+// addLine keeps the line, the oldest line is overwritten when MaxLines lines are kept, must be called with the lock held
+func (l *Logs) addLine(line LogLine) {
+	max := l.MaxLines
+	if max <= 0 {
+		max = DefaultLogsMaxLines
+	}
+	if len(l.lines) < max {
+		l.lines = append(l.lines, line)
+		return
+	}
+	if l.dropped == 0 {
+		log.Warn().Str("Namespace", l.Namespace).Int("MaxLines", max).Msg("Too many collected log lines, dropping the oldest ones")
+	}
+	l.lines[l.head] = line
+	l.head = (l.head + 1) % len(l.lines)
+	l.dropped++
+}
+
+// collected returns kept lines, oldest first, must be called with the lock held
+func (l *Logs) collected() []LogLine {
+	if l.head == 0 {
+		return l.lines
+	}
+	lines := make([]LogLine, 0, len(l.lines))
+	lines = append(lines, l.lines[l.head:]...)
+	return append(lines, l.lines[:l.head]...)
+}
+
+// Dropped returns how many of the oldest lines were dropped, assertions don't see them
+func (l *Logs) Dropped() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// ExtractMetrics applies log metrics to lines collected from now on
+func (l *Logs) ExtractMetrics(metrics *LogMetrics) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.metrics = metrics
+}
+
+// splitLogTimestamp splits the RFC3339 timestamp K8s adds to a line when PodLogOptions.Timestamps is set,
+// lines without a timestamp are timed now
+func splitLogTimestamp(line string, now time.Time) (time.Time, string) {
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) == 2 {
+		if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+			return ts, parts[1]
+		}
+	}
+	return now, line
+}
+
+// Find returns all collected lines of pods matching the selector that match the pattern,
+// window limits the search to the last period of time, 0 means the whole run
+func (l *Logs) Find(selector string, pattern string, window time.Duration) ([]LogLine, error) {
+	var from time.Time
+	if window != 0 {
+		from = l.Client.Clock().Now().Add(-window)
+	}
+	return l.FindBetween(selector, pattern, from, time.Time{})
+}
+
+// FindBetween same as Find but for lines written from "from" and before "to", zero times are not limiting
+func (l *Logs) FindBetween(selector string, pattern string, from time.Time, to time.Time) ([]LogLine, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid selector: %s", selector)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern: %s", pattern)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	found := make([]LogLine, 0)
+	for _, line := range l.collected() {
+		if line.Time.Before(from) || (!to.IsZero() && !line.Time.Before(to)) || !sel.Matches(labels.Set(line.Labels)) {
+			continue
+		}
+		if re.MatchString(line.Text) {
+			found = append(found, line)
+		}
+	}
+	return found, nil
+}
+
+// NotContain checks that none of the patterns are present in logs of pods matching the selector
+func (l *Logs) NotContain(selector string, patterns []string, window time.Duration) error {
+	for _, p := range patterns {
+		found, err := l.Find(selector, p, window)
+		if err != nil {
+			return err
+		}
+		if len(found) > 0 {
+			return errors.Errorf("found %d lines matching '%s', first in pod %s, container %s: %s",
+				len(found), p, found[0].Pod, found[0].Container, found[0].Text)
+		}
+	}
+	return nil
+}
+
+// Contain checks that each pattern is present in logs of pods matching the selector at least the provided number of times
+func (l *Logs) Contain(selector string, patterns map[string]int, window time.Duration) error {
+	for p, count := range patterns {
+		found, err := l.Find(selector, p, window)
+		if err != nil {
+			return err
+		}
+		if len(found) < count {
+			return errors.Errorf("expected at least %d lines matching '%s', found %d", count, p, len(found))
+		}
+	}
+	return nil
+}
+
+// MustNotContain same as NotContain but fails the test if any of the patterns are found
+func (l *Logs) MustNotContain(t testing.TB, selector string, patterns []string, window time.Duration) {
+	t.Helper()
+	if err := l.NotContain(selector, patterns, window); err != nil {
+		t.Fatalf("logs assertion failed for selector '%s': %s", selector, err)
+	}
+}
+
+// MustContain same as Contain but fails the test if any of the patterns are not found enough times
+func (l *Logs) MustContain(t testing.TB, selector string, patterns map[string]int, window time.Duration) {
+	t.Helper()
+	if err := l.Contain(selector, patterns, window); err != nil {
+		t.Fatalf("logs assertion failed for selector '%s': %s", selector, err)
+	}
+}
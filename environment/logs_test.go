@@ -0,0 +1,97 @@
+package environment
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func testLogs() *Logs {
+	now := time.Unix(1700000000, 0)
+	c := &client.K8sClient{}
+	c.SetClock(clocktesting.NewFakeClock(now))
+	return &Logs{
+		Client: c,
+		mu:     &sync.Mutex{},
+		lines: []LogLine{
+			{Pod: "chainlink-0-a", Labels: map[string]string{"app": "chainlink-0"}, Time: now.Add(-time.Hour), Text: "panic: nil pointer"},
+			{Pod: "chainlink-0-a", Labels: map[string]string{"app": "chainlink-0"}, Time: now, Text: "[INFO] OCR round finished"},
+			{Pod: "chainlink-0-b", Labels: map[string]string{"app": "chainlink-0"}, Time: now, Text: "[INFO] OCR round finished"},
+			{Pod: "geth-a", Labels: map[string]string{"app": "geth"}, Time: now, Text: "ERROR peer dropped"},
+		},
+	}
+}
+
+func TestLogsAssertions(t *testing.T) {
+	t.Run("not contain respects selector", func(t *testing.T) {
+		l := testLogs()
+		require.NoError(t, l.NotContain("app=chainlink-0", []string{"ERROR"}, 0))
+		require.Error(t, l.NotContain("", []string{"ERROR"}, 0))
+	})
+	t.Run("not contain respects window", func(t *testing.T) {
+		l := testLogs()
+		require.NoError(t, l.NotContain("app=chainlink-0", []string{"panic"}, time.Minute))
+		require.Error(t, l.NotContain("app=chainlink-0", []string{"panic"}, 0))
+	})
+	t.Run("contain with counts", func(t *testing.T) {
+		l := testLogs()
+		require.NoError(t, l.Contain("app=chainlink-0", map[string]int{"OCR round": 2}, 0))
+		require.Error(t, l.Contain("app=chainlink-0", map[string]int{"OCR round": 3}, 0))
+	})
+	t.Run("invalid pattern", func(t *testing.T) {
+		l := testLogs()
+		require.Error(t, l.NotContain("", []string{"("}, 0))
+	})
+}
+
+// fatalRecorder records a test failure instead of stopping the test
+type fatalRecorder struct {
+	testing.TB
+	failure string
+}
+
+func (r *fatalRecorder) Helper() {}
+
+func (r *fatalRecorder) Fatalf(format string, args ...interface{}) {
+	r.failure = fmt.Sprintf(format, args...)
+}
+
+func TestLogsMustAssertions(t *testing.T) {
+	l := testLogs()
+	r := &fatalRecorder{TB: t}
+	l.MustContain(r, "app=chainlink-0", map[string]int{"OCR round": 2}, 0)
+	require.Empty(t, r.failure)
+	l.MustNotContain(r, "", []string{"ERROR"}, 0)
+	require.Contains(t, r.failure, "found 1 lines matching 'ERROR'")
+}
+
+func TestLogsMaxLines(t *testing.T) {
+	l := &Logs{mu: &sync.Mutex{}, MaxLines: 3}
+	for i := 0; i < 5; i++ {
+		l.addLine(LogLine{Text: fmt.Sprintf("line %d", i)})
+	}
+	texts := make([]string, 0)
+	for _, line := range l.collected() {
+		texts = append(texts, line.Text)
+	}
+	require.Equal(t, []string{"line 2", "line 3", "line 4"}, texts)
+	require.Equal(t, 2, l.Dropped())
+	found, err := l.Find("", "line [01]", 0)
+	require.NoError(t, err)
+	require.Empty(t, found)
+}
+
+func TestSplitLogTimestamp(t *testing.T) {
+	ts, text := splitLogTimestamp("2022-09-06T10:00:00.123456789Z some log line", time.Time{})
+	require.Equal(t, "some log line", text)
+	require.Equal(t, 2022, ts.Year())
+	now := time.Unix(1700000000, 0)
+	ts, text = splitLogTimestamp("no timestamp here", now)
+	require.Equal(t, "no timestamp here", text)
+	require.Equal(t, now, ts)
+}
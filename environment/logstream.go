@@ -0,0 +1,468 @@
+package environment
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+)
+
+const (
+	DefaultLogStreamMaxFileSize = 50 * 1024 * 1024
+	DefaultLogStreamMaxFiles    = 5
+	// LokiPushPath is a Loki push API path, appended to LogStreamConfig.LokiURL
+	LokiPushPath = "/loki/api/v1/push"
+	// LokiBatchSize lines are pushed to Loki in batches of this size or every LokiFlushInterval
+	LokiBatchSize      = 1000
+	LokiFlushInterval  = 2 * time.Second
+	LokiRequestTimeout = 10 * time.Second
+)
+
+// LogStreamConfig configures streaming of container logs into local files
+type LogStreamConfig struct {
+	// Dir logs are written into ${Dir}/${pod}/${container}.log
+	Dir string
+	// Selector streams only logs of pods matching it, all pods of the namespace if empty
+	Selector string
+	// MaxFileSize a file is rotated when it grows bigger, DefaultLogStreamMaxFileSize if 0
+	MaxFileSize int64
+	// MaxFiles how many rotated files are kept besides the current one, DefaultLogStreamMaxFiles if 0
+	MaxFiles int
+	// LokiURL if set, lines are pushed to Loki too, e.g. "http://localhost:3100"
+	LokiURL string
+	// LokiLabels are added to namespace, pod and container labels of Loki streams
+	LokiLabels map[string]string
+}
+
+// LogStream follows logs of all containers of pods matching a selector from the environment start and writes them
+// into rotated files as they come, so logs of restarted and OOM-killed containers are kept,
+// lines of a crashed instance missed by the follower are read from its previous logs after the restart
+type LogStream struct {
+	Namespace string
+	Client    *client.K8sClient
+	Cfg       *LogStreamConfig
+	// Filter drops noisy lines from files and Loki, log metrics see all lines
+	Filter    *LogFilter
+	mu        *sync.Mutex
+	following map[string]bool
+	lastSeen  map[string]time.Time
+	restarts  map[string]int32
+	files     map[string]*rotatedFile
+	loki      *lokiPusher
+	metrics   *LogMetrics
+	cancel    context.CancelFunc
+	wg        *sync.WaitGroup
+}
+
+// NewLogStream creates new logs streamer for a namespace
+func NewLogStream(client *client.K8sClient, namespace string, cfg *LogStreamConfig) (*LogStream, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("logs stream directory is not set")
+	}
+	if cfg.MaxFileSize == 0 {
+		cfg.MaxFileSize = DefaultLogStreamMaxFileSize
+	}
+	if cfg.MaxFiles == 0 {
+		cfg.MaxFiles = DefaultLogStreamMaxFiles
+	}
+	if err := mkdirIfNotExists(cfg.Dir); err != nil {
+		return nil, err
+	}
+	s := &LogStream{
+		Namespace: namespace,
+		Client:    client,
+		Cfg:       cfg,
+		mu:        &sync.Mutex{},
+		following: make(map[string]bool),
+		lastSeen:  make(map[string]time.Time),
+		restarts:  make(map[string]int32),
+		files:     make(map[string]*rotatedFile),
+		wg:        &sync.WaitGroup{},
+	}
+	if cfg.LokiURL != "" {
+		s.loki = newLokiPusher(cfg.LokiURL, namespace, cfg.LokiLabels, client.Clock())
+	}
+	return s, nil
+}
+
+// Start starts following logs, pods created or restarted later are picked up too
+func (s *LogStream) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	if s.loki != nil {
+		s.loki.start(ctx, s.wg)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			if err := s.followNewContainers(ctx); err != nil {
+				log.Warn().Err(err).Str("Namespace", s.Namespace).Msg("Failed to discover pods for logs streaming")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.Client.Clock().After(client.ContainerStatePollInterval):
+			}
+		}
+	}()
+	log.Info().Str("Dir", s.Cfg.Dir).Str("Selector", s.Cfg.Selector).Msg("Streaming logs")
+}
+
+// Stop stops all followers, pushes the rest of lines to Loki and closes files
+func (s *LogStream) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.cancel = nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, f := range s.files {
+		if f != nil {
+			_ = f.Close()
+		}
+		delete(s.files, key)
+	}
+}
+
+// startLogStream starts streaming before the deployment, so logs of pods crashing during the deployment are kept too,
+// existing is a namespace of an environment to connect to, if any
+func (m *Environment) startLogStream(existing string) error {
+	ns := m.Cfg.Namespace
+	if existing != "" && m.Client.NamespaceExists(existing) {
+		ns = existing
+	}
+	ls, err := NewLogStream(m.Client, ns, m.Cfg.LogStream)
+	if err != nil {
+		return err
+	}
+	if ls.Filter, err = m.logFilter(); err != nil {
+		return err
+	}
+	m.LogStream = ls
+	m.LogStream.Start()
+	return nil
+}
+
+func (s *LogStream) followNewContainers(ctx context.Context) error {
+	pods, err := s.Client.ListPods(s.Namespace, s.Cfg.Selector)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != coreV1.PodRunning {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			key := fmt.Sprintf("%s/%s", pod.Name, c.Name)
+			restarts := containerRestarts(pod, c.Name)
+			s.mu.Lock()
+			if s.following[key] {
+				s.mu.Unlock()
+				continue
+			}
+			s.following[key] = true
+			previous := restarts > s.restarts[key]
+			s.restarts[key] = restarts
+			s.mu.Unlock()
+			s.wg.Add(1)
+			go func(pod coreV1.Pod, container string) {
+				defer s.wg.Done()
+				defer func() {
+					s.mu.Lock()
+					defer s.mu.Unlock()
+					s.following[key] = false
+				}()
+				if previous {
+					s.read(ctx, pod, container, key, true)
+				}
+				s.read(ctx, pod, container, key, false)
+			}(pod, c.Name)
+		}
+	}
+	return nil
+}
+
+// read writes container logs after the last seen line, follows the current instance or reads the previous one of a restarted container
+func (s *LogStream) read(ctx context.Context, pod coreV1.Pod, container string, key string, previous bool) {
+	s.mu.Lock()
+	since := s.lastSeen[key]
+	s.mu.Unlock()
+	opts := &coreV1.PodLogOptions{
+		Container:  container,
+		Follow:     !previous,
+		Previous:   previous,
+		Timestamps: true,
+	}
+	if !since.IsZero() {
+		opts.SinceTime = &metaV1.Time{Time: since.Add(time.Nanosecond)}
+	}
+	stream, err := s.Client.ClientSet.CoreV1().Pods(s.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		log.Debug().Err(err).Str("Pod", pod.Name).Str("Container", container).Bool("Previous", previous).Msg("Failed to stream logs")
+		return
+	}
+	// nolint
+	defer stream.Close()
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), LogsMaxLineSize)
+	release := pod.Labels[pkg.ReleaseLabelKey]
+	for scanner.Scan() {
+		ts, text := splitLogTimestamp(scanner.Text(), s.Client.Clock().Now())
+		s.write(pod.Name, container, key, ts, text, s.Filter.Noisy(release, container, text))
+	}
+}
+
+// write writes a line into the container file and queues it for Loki, noisy lines are only observed by metrics
+func (s *LogStream) write(pod string, container string, key string, ts time.Time, text string, noisy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSeen[key] = ts
+	if noisy {
+		if s.metrics != nil {
+			s.metrics.observe(pod, text)
+		}
+		return
+	}
+	f, ok := s.files[key]
+	if !ok {
+		var err error
+		path := filepath.Join(s.Cfg.Dir, pod, fmt.Sprintf("%s.log", container))
+		if f, err = openRotatedFile(path, s.Cfg.MaxFileSize, s.Cfg.MaxFiles); err != nil {
+			log.Warn().Err(err).Str("Path", path).Msg("Failed to create logs stream file")
+		}
+		s.files[key] = f
+	}
+	if f != nil {
+		if err := f.Write([]byte(fmt.Sprintf("%s %s\n", ts.UTC().Format(time.RFC3339Nano), text))); err != nil {
+			log.Warn().Err(err).Str("Path", f.path).Msg("Failed to write logs stream file")
+		}
+	}
+	if s.loki != nil {
+		s.loki.add(lokiEntry{Pod: pod, Container: container, Time: ts, Text: text})
+	}
+	if s.metrics != nil {
+		s.metrics.observe(pod, text)
+	}
+}
+
+// ExtractMetrics applies log metrics to lines streamed from now on
+func (s *LogStream) ExtractMetrics(metrics *LogMetrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = metrics
+}
+
+// rotatedFile is a log file rotated by size, rotated files are ${path}.1 (the newest) to ${path}.${maxFiles}
+type rotatedFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	f        *os.File
+	size     int64
+}
+
+func openRotatedFile(path string, maxSize int64, maxFiles int) (*rotatedFile, error) {
+	if err := mkdirIfNotExists(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	r := &rotatedFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatedFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.f = f
+	r.size = st.Size()
+	return nil
+}
+
+// Write writes data, the file is rotated first if data doesn't fit, a single line is never split
+func (r *rotatedFile) Write(data []byte) error {
+	if r.size > 0 && r.size+int64(len(data)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.f.Write(data)
+	r.size += int64(n)
+	return err
+}
+
+func (r *rotatedFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxFiles))
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", r.path, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", r.path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Rename(r.path, fmt.Sprintf("%s.1", r.path)); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatedFile) Close() error {
+	return r.f.Close()
+}
+
+// lokiEntry is a single line pushed to Loki
+type lokiEntry struct {
+	Pod       string
+	Container string
+	Time      time.Time
+	Text      string
+}
+
+// lokiPusher pushes lines to Loki in batches, failed batches are dropped, files are the source of truth
+type lokiPusher struct {
+	url       string
+	namespace string
+	labels    map[string]string
+	http      *http.Client
+	mu        *sync.Mutex
+	entries   []lokiEntry
+	flush     chan struct{}
+	clock     clock.Clock
+}
+
+func newLokiPusher(url string, namespace string, labels map[string]string, clk clock.Clock) *lokiPusher {
+	return &lokiPusher{
+		clock:     clk,
+		url:       url,
+		namespace: namespace,
+		labels:    labels,
+		http:      &http.Client{Timeout: LokiRequestTimeout},
+		mu:        &sync.Mutex{},
+		entries:   make([]lokiEntry, 0),
+		flush:     make(chan struct{}, 1),
+	}
+}
+
+func (p *lokiPusher) add(e lokiEntry) {
+	p.mu.Lock()
+	p.entries = append(p.entries, e)
+	full := len(p.entries) >= LokiBatchSize
+	p.mu.Unlock()
+	if full {
+		select {
+		case p.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// start pushes batches until the context is done, the rest of lines is pushed on exit
+func (p *lokiPusher) start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				p.push()
+				return
+			case <-p.flush:
+			case <-p.clock.After(LokiFlushInterval):
+			}
+			p.push()
+		}
+	}()
+}
+
+func (p *lokiPusher) push() {
+	p.mu.Lock()
+	entries := p.entries
+	p.entries = make([]lokiEntry, 0)
+	p.mu.Unlock()
+	if len(entries) == 0 {
+		return
+	}
+	body, err := json.Marshal(lokiPushBody(p.namespace, p.labels, entries))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode Loki push request")
+		return
+	}
+	resp, err := p.http.Post(p.url+LokiPushPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Warn().Err(err).Int("Lines", len(entries)).Msg("Failed to push logs to Loki")
+		return
+	}
+	// nolint
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.Warn().Int("Status", resp.StatusCode).Int("Lines", len(entries)).Msg("Failed to push logs to Loki")
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+// lokiPushBody groups entries into streams per container, streams are sorted by pod and container
+func lokiPushBody(namespace string, labels map[string]string, entries []lokiEntry) lokiPush {
+	streams := make(map[string]*lokiStream)
+	keys := make([]string, 0)
+	for _, e := range entries {
+		key := fmt.Sprintf("%s/%s", e.Pod, e.Container)
+		st, ok := streams[key]
+		if !ok {
+			st = &lokiStream{
+				Stream: map[string]string{"namespace": namespace, "pod": e.Pod, "container": e.Container},
+				Values: make([][2]string, 0),
+			}
+			for k, v := range labels {
+				st.Stream[k] = v
+			}
+			streams[key] = st
+			keys = append(keys, key)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), e.Text})
+	}
+	sort.Strings(keys)
+	body := lokiPush{Streams: make([]lokiStream, 0, len(keys))}
+	for _, k := range keys {
+		body.Streams = append(body.Streams, *streams[k])
+	}
+	return body
+}
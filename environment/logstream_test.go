@@ -0,0 +1,39 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chainlink-0-0", "node.log")
+	f, err := openRotatedFile(path, 10, 2)
+	require.NoError(t, err)
+	for _, line := range []string{"line-1\n", "line-2\n", "line-3\n", "line-4\n"} {
+		require.NoError(t, f.Write([]byte(line)))
+	}
+	require.NoError(t, f.Close())
+	for file, data := range map[string]string{path: "line-4\n", path + ".1": "line-3\n", path + ".2": "line-2\n"} {
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.Equal(t, data, string(b))
+	}
+	_, err = os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err), "the oldest file is removed")
+}
+
+func TestLokiPushBody(t *testing.T) {
+	ts := time.Unix(0, 1000)
+	body := lokiPushBody("env-abcde", map[string]string{"test": "ocr"}, []lokiEntry{
+		{Pod: "geth-0", Container: "geth", Time: ts, Text: "block"},
+		{Pod: "chainlink-0-0", Container: "node", Time: ts, Text: "started"},
+		{Pod: "geth-0", Container: "geth", Time: ts.Add(1), Text: "block 2"},
+	})
+	require.Len(t, body.Streams, 2)
+	require.Equal(t, map[string]string{"namespace": "env-abcde", "pod": "chainlink-0-0", "container": "node", "test": "ocr"}, body.Streams[0].Stream)
+	require.Equal(t, [][2]string{{"1000", "block"}, {"1001", "block 2"}}, body.Streams[1].Values)
+}
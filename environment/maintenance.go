@@ -0,0 +1,38 @@
+package environment
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+// StartMaintenance marks the environment as in maintenance for the duration, so operators can work with a live environment,
+// the reaper doesn't remove it, its TTL is extended to cover the maintenance and chaos experiments can't be started,
+// the mark expires automatically
+func StartMaintenance(namespace string, d time.Duration, reason string) error {
+	mt, err := client.NewK8sClient().StartMaintenance(namespace, d, reason)
+	if err != nil {
+		return err
+	}
+	log.Info().
+		Str("Namespace", namespace).
+		Time("Until", mt.Until).
+		Str("Reason", mt.Reason).
+		Msg("Environment is in maintenance")
+	return nil
+}
+
+// EndMaintenance removes the maintenance mark of the environment before it expires
+func EndMaintenance(namespace string) error {
+	if err := client.NewK8sClient().EndMaintenance(namespace); err != nil {
+		return err
+	}
+	log.Info().Str("Namespace", namespace).Msg("Environment maintenance is ended")
+	return nil
+}
+
+// Maintenance returns the active maintenance of the environment, nil if there is none
+func Maintenance(namespace string) (*client.Maintenance, error) {
+	return client.NewK8sClient().Maintenance(namespace)
+}
@@ -0,0 +1,123 @@
+package environment
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// manifestObject is a part of K8s object we need to group manifests
+type manifestObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+	} `json:"spec"`
+}
+
+// pods number of pods created by the workload
+func (o manifestObject) pods() int {
+	return workloadPods(o.Kind, o.Spec.Replicas)
+}
+
+// workloadPods number of pods created by a workload of a kind, 0 for other objects
+func workloadPods(kind string, replicas *int) int {
+	if !isLabeledWorkload(kind) {
+		return 0
+	}
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// splitManifest splits manifest into separate documents with the same YAML decoder the manifest is applied with,
+// so separators inside block scalars don't split documents, documents are normalized, empty ones are dropped
+func splitManifest(manifest string) ([]string, error) {
+	dec := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	docs := make([]string, 0)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return docs, nil
+			}
+			return nil, errors.Wrap(err, "failed to parse manifest")
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		doc, err := yaml.JSONToYAML(raw)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse manifest")
+		}
+		docs = append(docs, string(doc))
+	}
+}
+
+// releaseManifest is a part of the manifest that belongs to one chart release
+type releaseManifest struct {
+	Manifest string
+	// HasPods true if release has workloads which pods are labeled with a release label
+	HasPods bool
+	// Pods number of pods of all release workloads
+	Pods int
+}
+
+// groupManifestByRelease groups manifest documents by chart release label,
+// documents without the label, like the namespace or cdk8s charts, are returned separately as common manifest
+func groupManifestByRelease(manifest string) (map[string]*releaseManifest, string, error) {
+	releases := make(map[string][]string)
+	hasPods := make(map[string]bool)
+	pods := make(map[string]int)
+	common := make([]string, 0)
+	docs, err := splitManifest(manifest)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, d := range docs {
+		var obj manifestObject
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil {
+			return nil, "", errors.Wrap(err, "failed to parse manifest")
+		}
+		release := obj.Metadata.Labels[pkg.ReleaseLabelKey]
+		if release == "" {
+			common = append(common, d)
+			continue
+		}
+		releases[release] = append(releases[release], d)
+		if isLabeledWorkload(obj.Kind) {
+			hasPods[release] = true
+		}
+		pods[release] += obj.pods()
+	}
+	grouped := make(map[string]*releaseManifest)
+	for r, docs := range releases {
+		grouped[r] = &releaseManifest{
+			Manifest: joinManifest(docs),
+			HasPods:  hasPods[r],
+			Pods:     pods[r],
+		}
+	}
+	return grouped, joinManifest(common), nil
+}
+
+// isLabeledWorkload workloads which pod templates always have labels, so we can add a release label to them
+func isLabeledWorkload(kind string) bool {
+	return kind == "Deployment" || kind == "StatefulSet"
+}
+
+func joinManifest(docs []string) string {
+	if len(docs) == 0 {
+		return ""
+	}
+	return strings.Join(docs, "\n---\n")
+}
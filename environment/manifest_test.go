@@ -0,0 +1,99 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+const testManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: chainlink-test-env-abcde
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: geth
+  labels:
+    chainlink-env/release: geth
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: mockserver-config
+  labels:
+    chainlink-env/release: mockserver-cfg
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: geth
+  labels:
+    chainlink-env/release: geth
+`
+
+func TestGroupManifestByRelease(t *testing.T) {
+	releases, common, err := groupManifestByRelease(testManifest)
+	require.NoError(t, err)
+	require.Contains(t, common, "kind: Namespace")
+	require.Len(t, releases, 2)
+	require.True(t, releases["geth"].HasPods)
+	require.Equal(t, 1, releases["geth"].Pods)
+	require.Equal(t, 0, releases["mockserver-cfg"].Pods)
+	require.Contains(t, releases["geth"].Manifest, "kind: Deployment")
+	require.Contains(t, releases["geth"].Manifest, "kind: Service")
+	require.False(t, releases["mockserver-cfg"].HasPods)
+	docs, err := splitManifest(releases["geth"].Manifest)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+}
+
+func TestSplitManifest(t *testing.T) {
+	manifest := `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: chainlink-cm
+data:
+  config.toml: |
+    [Log]
+    Level = "debug"
+    ---
+--- # comment
+---
+{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "geth"}}
+`
+	docs, err := splitManifest(manifest)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	var cm map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(docs[0]), &cm))
+	require.Equal(t, map[string]interface{}{"config.toml": "[Log]\nLevel = \"debug\"\n---\n"}, cm["data"])
+	require.Equal(t, "apiVersion: v1\nkind: Service\nmetadata:\n  name: geth\n", docs[1])
+}
+
+func TestReleasePods(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: chainlink
+  labels:
+    chainlink-env/release: chainlink
+spec:
+  replicas: 5
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: chainlink-db
+  labels:
+    chainlink-env/release: chainlink
+spec:
+  replicas: 0
+`
+	releases, _, err := groupManifestByRelease(manifest)
+	require.NoError(t, err)
+	require.Equal(t, 5, releases["chainlink"].Pods)
+}
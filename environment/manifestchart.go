@@ -0,0 +1,138 @@
+package environment
+
+import (
+	"os"
+
+	"github.com/cdk8s-team/cdk8s-core-go/cdk8s/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+	"sigs.k8s.io/yaml"
+)
+
+// ManifestChart is a chart of raw K8s manifests or cdk8s constructs, all its objects get the release label,
+// so it's deployed, readiness checked and connected like Helm charts and can implement optional chart interfaces
+type ManifestChart interface {
+	ConnectedChart
+	// Build defines objects of the chart in its own cdk8s chart, e.g. with imports/k8s constructs
+	Build(scope cdk8s.Chart) error
+}
+
+// Manifest is a ManifestChart of raw YAML manifests, Export exports its URLs if set
+type Manifest struct {
+	Name   string
+	YAML   string
+	Export func(e *Environment) error
+}
+
+func (m Manifest) IsDeploymentNeeded() bool {
+	return true
+}
+
+func (m Manifest) GetName() string {
+	return m.Name
+}
+
+func (m Manifest) GetPath() string {
+	return ""
+}
+
+func (m Manifest) GetProps() interface{} {
+	return nil
+}
+
+func (m Manifest) GetValues() *map[string]interface{} {
+	return nil
+}
+
+func (m Manifest) ExportData(e *Environment) error {
+	if m.Export == nil {
+		return nil
+	}
+	return m.Export(e)
+}
+
+// Build includes the manifest objects as they are, names are preserved
+func (m Manifest) Build(scope cdk8s.Chart) error {
+	f, err := os.CreateTemp("", "chainlink-env-manifest-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(m.YAML); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	cdk8s.NewInclude(scope, a.Str("manifest"), &cdk8s.IncludeProps{Url: a.Str(f.Name())})
+	return nil
+}
+
+// AddManifest adds a chart of raw manifests or cdk8s constructs, e.g.:
+//
+//	e.AddManifest(environment.Manifest{Name: "redis", YAML: redisYAML})
+func (m *Environment) AddManifest(chart ManifestChart) *Environment {
+	name := chart.GetName()
+	labels := make(map[string]string)
+	for k, v := range m.Cfg.Labels {
+		labels[k] = v
+	}
+	labels[pkg.ReleaseLabelKey] = name
+	scope := cdk8s.NewChart(m.App, a.Str(name), &cdk8s.ChartProps{
+		Labels:    a.ConvertLabelsMap(labels),
+		Namespace: a.Str(m.Cfg.Namespace),
+	})
+	if err := chart.Build(scope); err != nil {
+		log.Fatal().Err(err).Str("Chart", name).Msg("Failed to build manifest chart")
+	}
+	m.addMonitors(name, chart)
+	m.Charts = append(m.Charts, chart)
+	return m
+}
+
+// injectPodReleaseLabel adds the release label to pod templates of release workloads which don't have it,
+// Helm charts get it when they are rendered, manifest charts only have it on the workloads
+func injectPodReleaseLabel(manifest string, name string) (string, error) {
+	docs, err := splitManifest(manifest)
+	if err != nil {
+		return "", err
+	}
+	for i, d := range docs {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil {
+			return "", errors.Wrap(err, "failed to parse manifest")
+		}
+		kind, _ := obj["kind"].(string)
+		if !isLabeledWorkload(kind) {
+			continue
+		}
+		podLabels := nestedMap(obj, "spec", "template", "metadata", "labels")
+		if podLabels[pkg.ReleaseLabelKey] == name {
+			continue
+		}
+		podLabels[pkg.ReleaseLabelKey] = name
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		docs[i] = string(out)
+	}
+	return joinManifest(docs), nil
+}
+
+// nestedMap returns a map by path, missing maps are created
+func nestedMap(obj map[string]interface{}, path ...string) map[string]interface{} {
+	cur := obj
+	for _, k := range path {
+		next, ok := cur[k].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			cur[k] = next
+		}
+		cur = next
+	}
+	return cur
+}
@@ -0,0 +1,37 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestInjectPodReleaseLabel(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: redis
+  labels:
+    chainlink-env/release: redis
+spec:
+  template:
+    metadata:
+      labels:
+        app: redis
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: redis`
+	out, err := injectPodReleaseLabel(manifest, "redis")
+	require.NoError(t, err)
+	docs, err := splitManifest(out)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	var d map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(docs[0]), &d))
+	require.Equal(t, map[string]interface{}{"app": "redis", pkg.ReleaseLabelKey: "redis"}, nestedMap(d, "spec", "template", "metadata", "labels"))
+	require.Equal(t, "apiVersion: v1\nkind: Service\nmetadata:\n  name: redis\n", docs[1], "not workloads are kept as they are")
+}
@@ -0,0 +1,99 @@
+package environment
+
+import (
+	"fmt"
+
+	cdk8s "github.com/cdk8s-team/cdk8s-core-go/cdk8s/v2"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+)
+
+const (
+	MonitoringAPIVersion = "monitoring.coreos.com/v1"
+	// DefaultMetricsPath is a scrape path of metrics ports without one
+	DefaultMetricsPath = "/metrics"
+)
+
+// MetricsPort is a port serving Prometheus metrics
+type MetricsPort struct {
+	// Port is a name of a service port, or a container port if Pod is set
+	Port string
+	// Path is a scrape path, DefaultMetricsPath if empty
+	Path string
+	// Interval is a scrape interval, e.g. "15s", Prometheus default if empty
+	Interval string
+	// Pod scrapes pods directly with a PodMonitor, for charts whose services don't expose the metrics port
+	Pod bool
+	// Selector labels of scraped services or pods, the release label of the chart if empty
+	Selector map[string]string
+}
+
+// MetricsChart is a chart declaring metrics ports, ServiceMonitors and PodMonitors are generated for them
+// if Config.ServiceMonitors is set, so clusters running Prometheus Operator scrape the environment
+type MetricsChart interface {
+	ConnectedChart
+	MetricsPorts() []MetricsPort
+}
+
+// addMonitors adds a ServiceMonitor for service ports and a PodMonitor for pod ports of a chart
+func (m *Environment) addMonitors(name string, chart ConnectedChart) {
+	mc, ok := chart.(MetricsChart)
+	if !m.Cfg.ServiceMonitors || !ok {
+		return
+	}
+	for i, p := range mc.MetricsPorts() {
+		kind, spec := monitorSpec(name, p)
+		labels := map[string]string{pkg.ReleaseLabelKey: name}
+		for k, v := range m.Cfg.ServiceMonitorLabels {
+			labels[k] = v
+		}
+		obj := cdk8s.NewApiObject(m.root, a.Str(monitorID(name, i)), &cdk8s.ApiObjectProps{
+			ApiVersion: a.Str(MonitoringAPIVersion),
+			Kind:       a.Str(kind),
+			Metadata: &cdk8s.ApiObjectMetadata{
+				Name:   a.Str(fmt.Sprintf("%s-%s", name, p.Port)),
+				Labels: a.ConvertLabelsMap(labels),
+			},
+		})
+		obj.AddJsonPatch(cdk8s.JsonPatch_Add(a.Str("/spec"), spec))
+	}
+}
+
+// removeMonitors removes monitors of a chart, so it can be rendered again
+func (m *Environment) removeMonitors(name string) {
+	for i := 0; *m.root.Node().TryRemoveChild(a.Str(monitorID(name, i))); i++ {
+	}
+}
+
+func monitorID(name string, i int) string {
+	return fmt.Sprintf("%s-monitor-%d", name, i)
+}
+
+// monitorSpec returns a kind and a spec of a monitor scraping a metrics port of a release
+func monitorSpec(release string, p MetricsPort) (string, map[string]interface{}) {
+	selector := p.Selector
+	if len(selector) == 0 {
+		selector = map[string]string{pkg.ReleaseLabelKey: release}
+	}
+	endpoint := map[string]interface{}{
+		"port": p.Port,
+		"path": DefaultMetricsPath,
+	}
+	if p.Path != "" {
+		endpoint["path"] = p.Path
+	}
+	if p.Interval != "" {
+		endpoint["interval"] = p.Interval
+	}
+	spec := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": selector,
+		},
+	}
+	if p.Pod {
+		spec["podMetricsEndpoints"] = []interface{}{endpoint}
+		return "PodMonitor", spec
+	}
+	spec["endpoints"] = []interface{}{endpoint}
+	return "ServiceMonitor", spec
+}
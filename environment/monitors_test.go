@@ -0,0 +1,37 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitorSpec(t *testing.T) {
+	kind, spec := monitorSpec("chainlink-0", MetricsPort{Port: "access", Pod: true})
+	require.Equal(t, "PodMonitor", kind)
+	require.Equal(t, map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]string{pkg.ReleaseLabelKey: "chainlink-0"},
+		},
+		"podMetricsEndpoints": []interface{}{
+			map[string]interface{}{"port": "access", "path": DefaultMetricsPath},
+		},
+	}, spec)
+
+	kind, spec = monitorSpec("geth", MetricsPort{
+		Port:     "metrics",
+		Path:     "/debug/metrics/prometheus",
+		Interval: "30s",
+		Selector: map[string]string{"app": "geth"},
+	})
+	require.Equal(t, "ServiceMonitor", kind)
+	require.Equal(t, map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]string{"app": "geth"},
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{"port": "metrics", "path": "/debug/metrics/prometheus", "interval": "30s"},
+		},
+	}, spec)
+}
@@ -0,0 +1,150 @@
+package environment
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+// outputRefPattern matches output references in chart values, ${outputs.<chart>.<key>}
+var outputRefPattern = regexp.MustCompile(`\$\{outputs\.([a-z0-9-]+)\.([A-Za-z0-9_-]+)\}`)
+
+// OutputsChart is a chart publishing outputs after it's deployed, for example, an in-cluster RPC URL or credentials,
+// later charts consume them in values with OutputRef, references are resolved when the consuming chart is deployed
+type OutputsChart interface {
+	ConnectedChart
+	Outputs(e *Environment) (map[string]string, error)
+}
+
+// OutputRef returns a reference to a chart output to use in values of charts deployed after it
+func OutputRef(chart string, key string) string {
+	return fmt.Sprintf("${outputs.%s.%s}", chart, key)
+}
+
+func outputKey(chart string, key string) string {
+	return fmt.Sprintf("%s.%s", chart, key)
+}
+
+// Publish publishes an output of a chart, charts deployed later can reference it
+func (m *Environment) Publish(chart string, key string, value string) {
+	log.Debug().Str("Chart", chart).Str("Key", key).Str("Value", value).Msg("Publishing chart output")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Outputs[outputKey(chart, key)] = value
+}
+
+// Output returns a published output of a chart
+func (m *Environment) Output(chart string, key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.Outputs[outputKey(chart, key)]
+	return v, ok
+}
+
+// outputs returns a copy of published outputs
+func (m *Environment) outputs() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outputs := make(map[string]string, len(m.Outputs))
+	for k, v := range m.Outputs {
+		outputs[k] = v
+	}
+	return outputs
+}
+
+// publishOutputs publishes outputs of a deployed chart
+func (m *Environment) publishOutputs(name string) error {
+	oc, ok := m.chart(name).(OutputsChart)
+	if !ok {
+		return nil
+	}
+	outputs, err := oc.Outputs(m)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get outputs of chart %s", name)
+	}
+	keys := make([]string, 0, len(outputs))
+	for k := range outputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		m.Publish(name, k, outputs[k])
+	}
+	return nil
+}
+
+// resolveOutputRefs replaces output references in a string
+func resolveOutputRefs(s string, outputs map[string]string) (string, error) {
+	var missing []string
+	resolved := outputRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		g := outputRefPattern.FindStringSubmatch(ref)
+		v, ok := outputs[outputKey(g[1], g[2])]
+		if !ok {
+			missing = append(missing, outputKey(g[1], g[2]))
+			return ref
+		}
+		return v
+	})
+	if len(missing) != 0 {
+		return "", errors.Errorf("outputs %v are not published, charts publishing them must be deployed first", missing)
+	}
+	return resolved, nil
+}
+
+// resolveManifestOutputs replaces output references in all string values of the manifest
+func resolveManifestOutputs(manifest string, outputs map[string]string) (string, error) {
+	if !outputRefPattern.MatchString(manifest) {
+		return manifest, nil
+	}
+	docs, err := splitManifest(manifest)
+	if err != nil {
+		return "", err
+	}
+	for i, d := range docs {
+		if !outputRefPattern.MatchString(d) {
+			continue
+		}
+		var obj interface{}
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil {
+			return "", errors.Wrap(err, "failed to parse manifest")
+		}
+		resolved, err := resolveObjectOutputs(obj, outputs)
+		if err != nil {
+			return "", err
+		}
+		out, err := yaml.Marshal(resolved)
+		if err != nil {
+			return "", err
+		}
+		docs[i] = string(out)
+	}
+	return joinManifest(docs), nil
+}
+
+func resolveObjectOutputs(obj interface{}, outputs map[string]string) (interface{}, error) {
+	switch o := obj.(type) {
+	case string:
+		return resolveOutputRefs(o, outputs)
+	case map[string]interface{}:
+		for k, v := range o {
+			r, err := resolveObjectOutputs(v, outputs)
+			if err != nil {
+				return nil, err
+			}
+			o[k] = r
+		}
+	case []interface{}:
+		for i, v := range o {
+			r, err := resolveObjectOutputs(v, outputs)
+			if err != nil {
+				return nil, err
+			}
+			o[i] = r
+		}
+	}
+	return obj, nil
+}
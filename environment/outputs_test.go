@@ -0,0 +1,48 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveManifestOutputs(t *testing.T) {
+	outputs := map[string]string{
+		"geth.http_url":     "http://geth-node-0:8544",
+		"mockserver.url":    "http://mockserver:1080",
+		"deploy.link_token": "0xabc: with colon",
+	}
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: node
+        env:
+        - name: ETH_HTTP_URL
+          value: ${outputs.geth.http_url}
+        - name: LINK
+          value: "${outputs.deploy.link_token}"
+        args: ["--adapter=${outputs.mockserver.url}/price"]
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: node
+`
+	out, err := resolveManifestOutputs(manifest, outputs)
+	require.NoError(t, err)
+	require.Contains(t, out, "value: http://geth-node-0:8544")
+	require.Contains(t, out, "value: '0xabc: with colon'")
+	require.Contains(t, out, "--adapter=http://mockserver:1080/price")
+	require.Contains(t, out, "kind: Service")
+
+	_, err = resolveManifestOutputs("value: ${outputs.sol.url}", outputs)
+	require.Error(t, err)
+
+	same, err := resolveManifestOutputs("value: plain", outputs)
+	require.NoError(t, err)
+	require.Equal(t, "value: plain", same)
+	require.Equal(t, "${outputs.geth.http_url}", OutputRef("geth", "http_url"))
+}
@@ -0,0 +1,59 @@
+package environment
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// Pausable is a background subsystem disturbing pods, for example, an auto-heal guardian restarting them,
+// it's paused while artifacts are collected, so database dumps are not cut in the middle
+type Pausable interface {
+	Pause() error
+	Resume() error
+}
+
+// AddPausable adds a subsystem paused while artifacts are collected, Chaos Mesh experiments are always paused
+func (m *Environment) AddPausable(p Pausable) *Environment {
+	m.pausables = append(m.pausables, p)
+	if m.Artifacts != nil {
+		m.Artifacts.Pausables = m.pausables
+	}
+	return m
+}
+
+// pause pauses chaos of the namespace and pausables, then returns a function resuming them,
+// failures are only logged, artifacts are collected anyway
+func (a *Artifacts) pause() func() {
+	paused, err := a.Client.PauseChaos(a.Namespace, "collecting artifacts")
+	if err != nil {
+		log.Warn().Err(err).Str("Namespace", a.Namespace).Msg("Failed to pause chaos")
+	}
+	resumeAll := pauseAll(a.Pausables)
+	return func() {
+		resumeAll()
+		if !paused {
+			return
+		}
+		if err := a.Client.ResumeChaos(a.Namespace); err != nil {
+			log.Error().Err(err).Str("Namespace", a.Namespace).Msg("Failed to resume chaos")
+		}
+	}
+}
+
+// pauseAll pauses pausables in order and returns a function resuming the paused ones in reverse order
+func pauseAll(pausables []Pausable) func() {
+	paused := make([]Pausable, 0, len(pausables))
+	for _, p := range pausables {
+		if err := p.Pause(); err != nil {
+			log.Warn().Err(err).Msg("Failed to pause, collecting artifacts anyway")
+			continue
+		}
+		paused = append(paused, p)
+	}
+	return func() {
+		for i := len(paused) - 1; i >= 0; i-- {
+			if err := paused[i].Resume(); err != nil {
+				log.Error().Err(err).Msg("Failed to resume")
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package environment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testPausable struct {
+	name     string
+	pauseErr error
+	calls    *[]string
+}
+
+func (p testPausable) Pause() error {
+	*p.calls = append(*p.calls, "pause "+p.name)
+	return p.pauseErr
+}
+
+func (p testPausable) Resume() error {
+	*p.calls = append(*p.calls, "resume "+p.name)
+	return nil
+}
+
+func TestPauseAll(t *testing.T) {
+	var calls []string
+	resume := pauseAll([]Pausable{
+		testPausable{name: "chaos", calls: &calls},
+		testPausable{name: "broken", pauseErr: errors.New("failed"), calls: &calls},
+		testPausable{name: "guardian", calls: &calls},
+	})
+	require.Equal(t, []string{"pause chaos", "pause broken", "pause guardian"}, calls)
+	calls = nil
+	resume()
+	require.Equal(t, []string{"resume guardian", "resume chaos"}, calls)
+}
@@ -0,0 +1,383 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// PoolLabelKey marks namespaces of a pool with the pool name
+	PoolLabelKey = "chainlink-env/pool"
+	// PoolLeaseAnnotationKey namespace annotation with the current lease in JSON
+	PoolLeaseAnnotationKey = "chainlink-env/lease"
+	// PoolReadyAnnotationKey marks pooled namespaces which are deployed, only they are leased
+	PoolReadyAnnotationKey = "chainlink-env/pool-ready"
+	DefaultPoolLeaseTTL    = 30 * time.Minute
+	DefaultPoolAcquireWait = 20 * time.Minute
+	PoolPollInterval       = 5 * time.Second
+)
+
+// PoolConfig is a pool of pre-provisioned environments leased to parallel tests
+type PoolConfig struct {
+	Name string
+	Size int
+	// Config base config of pooled environments, the pool label is added to it
+	Config *Config
+	// NewEnv creates an environment with its charts from the config, for example, presets.EVMMinimalLocal
+	NewEnv func(cfg *Config) *Environment
+	// LeaseTTL a lease expires if it's not renewed in time, for example, when the test process is killed,
+	// leases are renewed every third of it while they are held
+	LeaseTTL time.Duration
+	// AcquireTimeout how long to wait for a free environment
+	AcquireTimeout time.Duration
+	// Reset resets the environment state when the lease is released, Environment.Reset by default,
+	// environments that fail to reset are removed
+	Reset func(e *Environment) error
+}
+
+// Pool leases pre-provisioned environments, leases are stored in namespace annotations and taken with optimistic
+// concurrency, so pools are safe to use from many test processes
+type Pool struct {
+	Cfg    PoolConfig
+	Client *client.K8sClient
+}
+
+// Lease is an environment leased from the pool until Release, it's renewed in the background, so tests may run longer than LeaseTTL
+type Lease struct {
+	Env    *Environment
+	Holder string
+	pool   *Pool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// poolLease is a lease stored in the namespace annotation
+type poolLease struct {
+	Holder string    `json:"holder"`
+	Until  time.Time `json:"until"`
+}
+
+// NewPool creates a pool, call Provision to create its environments
+func NewPool(cfg PoolConfig) *Pool {
+	if cfg.LeaseTTL == 0 {
+		cfg.LeaseTTL = DefaultPoolLeaseTTL
+	}
+	if cfg.AcquireTimeout == 0 {
+		cfg.AcquireTimeout = DefaultPoolAcquireWait
+	}
+	if cfg.Config == nil {
+		cfg.Config = &Config{}
+	}
+	if cfg.Reset == nil {
+		cfg.Reset = (*Environment).Reset
+	}
+	return &Pool{Cfg: cfg, Client: client.NewK8sClient()}
+}
+
+// envConfig returns a copy of the base config with the pool label
+func (p *Pool) envConfig() *Config {
+	cfg := *p.Cfg.Config
+	cfg.Labels = make(map[string]string)
+	for k, v := range p.Cfg.Config.Labels {
+		cfg.Labels[k] = v
+	}
+	cfg.Labels[PoolLabelKey] = p.Cfg.Name
+	if cfg.NamespacePrefix == "" {
+		cfg.NamespacePrefix = fmt.Sprintf("pool-%s", p.Cfg.Name)
+	}
+	return &cfg
+}
+
+func (p *Pool) namespaces() ([]coreV1.Namespace, error) {
+	nl, err := p.Client.ListNamespaces(fmt.Sprintf("%s=%s", PoolLabelKey, p.Cfg.Name))
+	if err != nil {
+		return nil, err
+	}
+	return nl.Items, nil
+}
+
+// Provision creates missing environments of the pool concurrently, deployed environments are marked ready to be leased
+func (p *Pool) Provision() error {
+	nss, err := p.namespaces()
+	if err != nil {
+		return err
+	}
+	missing := p.Cfg.Size - len(nss)
+	log.Info().Str("Pool", p.Cfg.Name).Int("Existing", len(nss)).Int("Missing", missing).Msg("Provisioning environments pool")
+	if missing <= 0 {
+		return nil
+	}
+	set := NewEnvironmentSet(missing)
+	for i := 0; i < missing; i++ {
+		set.Add(p.Cfg.NewEnv(p.envConfig()))
+	}
+	runErr := set.Run()
+	for _, e := range set.Environments {
+		if set.Err(e.Cfg.Namespace) != nil {
+			continue
+		}
+		if err := p.markReady(e.Cfg.Namespace); err != nil {
+			return err
+		}
+	}
+	return runErr
+}
+
+// markReady marks a deployed namespace, so it can be leased
+func (p *Pool) markReady(namespace string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{PoolReadyAnnotationKey: "true"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = p.Client.ClientSet.CoreV1().Namespaces().Patch(context.Background(), namespace, types.MergePatchType, patch, metaV1.PatchOptions{})
+	return err
+}
+
+// Acquire waits for a free environment, leases it to the holder and connects to it, an environment with an expired lease
+// was not released by its previous holder, so it's reset first and removed if reset fails
+func (p *Pool) Acquire(holder string) (*Lease, error) {
+	clk := p.Client.Clock()
+	deadline := clk.Now().Add(p.Cfg.AcquireTimeout)
+	for {
+		nss, err := p.namespaces()
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			expired := leaseExpired(ns)
+			ok, err := p.tryLease(ns, holder)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			log.Info().Str("Pool", p.Cfg.Name).Str("Namespace", ns.Name).Str("Holder", holder).Msg("Environment is leased")
+			e := p.Cfg.NewEnv(p.envConfig())
+			l := &Lease{Env: e, Holder: holder, pool: p, stop: make(chan struct{}), done: make(chan struct{})}
+			go l.renew(ns.Name)
+			if err := e.Connect(ns.Name); err != nil {
+				l.stopRenewal()
+				_ = p.releaseLease(ns.Name, holder)
+				return nil, errors.Wrapf(err, "failed to connect to pooled environment %s", ns.Name)
+			}
+			if expired {
+				log.Warn().Str("Namespace", ns.Name).Msg("Lease of pooled environment expired without release, resetting it")
+				if err := p.Cfg.Reset(e); err != nil {
+					l.stopRenewal()
+					log.Error().Err(err).Str("Namespace", ns.Name).Msg("Failed to reset pooled environment, removing it")
+					if err := e.Shutdown(); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			return l, nil
+		}
+		if clk.Now().After(deadline) {
+			return nil, errors.Errorf("no free environments in pool %s after %s", p.Cfg.Name, p.Cfg.AcquireTimeout)
+		}
+		clk.Sleep(PoolPollInterval)
+	}
+}
+
+// tryLease takes the namespace lease if it's leasable, false if somebody else holds it or took it concurrently
+func (p *Pool) tryLease(ns coreV1.Namespace, holder string) (bool, error) {
+	if !leasable(ns, p.Client.Clock().Now()) {
+		return false, nil
+	}
+	if err := p.writeLease(&ns, holder); err != nil {
+		if k8sErrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// writeLease writes a lease of the holder for LeaseTTL from now, the update fails with a conflict
+// if the namespace is changed after it was read
+func (p *Pool) writeLease(ns *coreV1.Namespace, holder string) error {
+	lease, err := json.Marshal(poolLease{Holder: holder, Until: p.Client.Clock().Now().Add(p.Cfg.LeaseTTL).UTC()})
+	if err != nil {
+		return err
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[PoolLeaseAnnotationKey] = string(lease)
+	_, err = p.Client.ClientSet.CoreV1().Namespaces().Update(context.Background(), ns, metaV1.UpdateOptions{})
+	return err
+}
+
+// renewLease extends the lease of the holder, an error is returned if the lease is lost
+func (p *Pool) renewLease(namespace string, holder string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ns, err := p.Client.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := checkLeaseHolder(ns.Annotations[PoolLeaseAnnotationKey], holder); err != nil {
+			return err
+		}
+		return p.writeLease(ns, holder)
+	})
+}
+
+// checkLease returns LeaseLostError if the namespace lease is not held by the holder
+func (p *Pool) checkLease(namespace string, holder string) error {
+	ns, err := p.Client.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return checkLeaseHolder(ns.Annotations[PoolLeaseAnnotationKey], holder)
+}
+
+// releaseLease removes the lease if it's still held by the holder, the namespace resource version is checked,
+// so a lease taken by somebody else in between is never removed
+func (p *Pool) releaseLease(namespace string, holder string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ns, err := p.Client.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := checkLeaseHolder(ns.Annotations[PoolLeaseAnnotationKey], holder); err != nil {
+			return err
+		}
+		delete(ns.Annotations, PoolLeaseAnnotationKey)
+		_, err = p.Client.ClientSet.CoreV1().Namespaces().Update(context.Background(), ns, metaV1.UpdateOptions{})
+		return err
+	})
+}
+
+// Remove removes all environments of the pool
+func (p *Pool) Remove() error {
+	nss, err := p.namespaces()
+	if err != nil {
+		return err
+	}
+	for _, ns := range nss {
+		if err := p.Client.RemoveNamespace(ns.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release resets the environment and returns it to the pool, the environment is removed if reset fails,
+// call Provision to replace it, the environment is neither reset nor removed if the lease is lost,
+// because it's used by another holder
+func (l *Lease) Release() error {
+	ns := l.Env.Cfg.Namespace
+	if err := l.pool.checkLease(ns, l.Holder); err != nil {
+		l.stopRenewal()
+		return errors.Wrapf(err, "failed to release pooled environment %s", ns)
+	}
+	if err := l.pool.Cfg.Reset(l.Env); err != nil {
+		l.stopRenewal()
+		log.Error().Err(err).Str("Namespace", ns).Msg("Failed to reset pooled environment, removing it")
+		return l.Env.Shutdown()
+	}
+	l.stopRenewal()
+	log.Info().Str("Pool", l.pool.Cfg.Name).Str("Namespace", ns).Str("Holder", l.Holder).Msg("Environment is released")
+	return l.pool.releaseLease(ns, l.Holder)
+}
+
+// renew renews the lease every third of LeaseTTL until it's released, renewal stops if the lease is lost
+func (l *Lease) renew(namespace string) {
+	defer close(l.done)
+	ticker := l.pool.Client.Clock().NewTicker(l.pool.Cfg.LeaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C():
+			if err := l.pool.renewLease(namespace, l.Holder); err != nil {
+				log.Error().Err(err).Str("Namespace", namespace).Str("Holder", l.Holder).Msg("Failed to renew pooled environment lease")
+				var lost *LeaseLostError
+				if errors.As(err, &lost) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *Lease) stopRenewal() {
+	select {
+	case <-l.stop:
+	default:
+		close(l.stop)
+	}
+	<-l.done
+}
+
+// LeaseLostError is returned when a lease expired and was taken by another holder, or was removed
+type LeaseLostError struct {
+	Holder string
+	// Current is a holder of the namespace now, empty if it's not leased
+	Current string
+}
+
+func (e *LeaseLostError) Error() string {
+	if e.Current == "" {
+		return fmt.Sprintf("lease of %s is lost", e.Holder)
+	}
+	return fmt.Sprintf("lease of %s is lost, the environment is held by %s", e.Holder, e.Current)
+}
+
+// checkLeaseHolder returns LeaseLostError if the stored lease is not held by the holder
+func checkLeaseHolder(raw string, holder string) error {
+	var l poolLease
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &l); err != nil {
+			return errors.Wrapf(err, "failed to parse pool lease %s", raw)
+		}
+	}
+	if l.Holder != holder {
+		return &LeaseLostError{Holder: holder, Current: l.Holder}
+	}
+	return nil
+}
+
+// leasable returns true if the namespace is deployed, not being removed and its lease is free or expired
+func leasable(ns coreV1.Namespace, now time.Time) bool {
+	if ns.DeletionTimestamp != nil || ns.Annotations[PoolReadyAnnotationKey] != "true" {
+		return false
+	}
+	return leaseAvailable(ns.Annotations[PoolLeaseAnnotationKey], now)
+}
+
+// leaseExpired returns true if a leasable namespace still has a lease, its holder didn't release it, so it's not reset
+func leaseExpired(ns coreV1.Namespace) bool {
+	return ns.Annotations[PoolLeaseAnnotationKey] != ""
+}
+
+// leaseAvailable returns true if there is no lease or it's expired
+func leaseAvailable(raw string, now time.Time) bool {
+	if raw == "" {
+		return true
+	}
+	var l poolLease
+	if err := json.Unmarshal([]byte(raw), &l); err != nil {
+		log.Warn().Err(err).Str("Lease", raw).Msg("Failed to parse pool lease, treating it as expired")
+		return true
+	}
+	return now.After(l.Until)
+}
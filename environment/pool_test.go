@@ -0,0 +1,56 @@
+package environment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLeaseAvailable(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	require.True(t, leaseAvailable("", now))
+	require.False(t, leaseAvailable(`{"holder":"TestOCR","until":"2022-10-01T12:30:00Z"}`, now))
+	require.True(t, leaseAvailable(`{"holder":"TestOCR","until":"2022-10-01T11:30:00Z"}`, now))
+	require.True(t, leaseAvailable("broken", now))
+}
+
+func TestLeasable(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	ns := func(annotations map[string]string) coreV1.Namespace {
+		return coreV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "pool-ocr-abcde", Annotations: annotations}}
+	}
+	require.True(t, leasable(ns(map[string]string{PoolReadyAnnotationKey: "true"}), now))
+	require.False(t, leasable(ns(nil), now), "namespaces being provisioned are not leased")
+	require.False(t, leasable(ns(map[string]string{
+		PoolReadyAnnotationKey: "true",
+		PoolLeaseAnnotationKey: `{"holder":"TestOCR","until":"2022-10-01T12:30:00Z"}`,
+	}), now))
+	deleting := ns(map[string]string{PoolReadyAnnotationKey: "true"})
+	deleting.DeletionTimestamp = &metaV1.Time{Time: now}
+	require.False(t, leasable(deleting, now))
+}
+
+func TestLeaseExpired(t *testing.T) {
+	ns := func(annotations map[string]string) coreV1.Namespace {
+		return coreV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "pool-ocr-abcde", Annotations: annotations}}
+	}
+	require.False(t, leaseExpired(ns(map[string]string{PoolReadyAnnotationKey: "true"})), "released environments are reset")
+	require.True(t, leaseExpired(ns(map[string]string{
+		PoolReadyAnnotationKey: "true",
+		PoolLeaseAnnotationKey: `{"holder":"TestOCR","until":"2022-10-01T11:30:00Z"}`,
+	})))
+}
+
+func TestCheckLeaseHolder(t *testing.T) {
+	require.NoError(t, checkLeaseHolder(`{"holder":"TestOCR","until":"2022-10-01T12:30:00Z"}`, "TestOCR"))
+	var lost *LeaseLostError
+	err := checkLeaseHolder(`{"holder":"TestVRF","until":"2022-10-01T12:30:00Z"}`, "TestOCR")
+	require.ErrorAs(t, err, &lost)
+	require.Equal(t, "TestVRF", lost.Current)
+	require.EqualError(t, err, "lease of TestOCR is lost, the environment is held by TestVRF")
+	require.EqualError(t, checkLeaseHolder("", "TestOCR"), "lease of TestOCR is lost")
+	require.Error(t, checkLeaseHolder("broken", "TestOCR"))
+}
@@ -0,0 +1,261 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	PostMortemEventsFile   = "events.txt"
+	PostMortemHistoryFile  = "history.json"
+	PostMortemManifestsDir = "manifests"
+	PostMortemPodFile      = "pod.yaml"
+	// PreviousLogSuffix logs of a previous instance of a restarted container are written as ${container}.previous.log
+	PreviousLogSuffix = ".previous.log"
+	// PodDescriptionSuffix kubectl describe output of a pod is written as ${pod}.describe.txt
+	PodDescriptionSuffix = ".describe.txt"
+	// postMortemUnlabeled a directory for pods without release and app labels
+	postMortemUnlabeled = "unlabeled"
+)
+
+// DumpNamespace dumps post-mortem artifacts of a namespace when there is no environment, for example, after a test crashed
+func DumpNamespace(namespace string, testDir string) error {
+	arts, err := NewArtifacts(client.NewK8sClient(), namespace)
+	if err != nil {
+		return err
+	}
+	return arts.DumpPostMortem(testDir)
+}
+
+// DumpPostMortem dumps logs of all containers, including previous instances of restarted ones, pods states, namespace events,
+// environment history and resources manifests. Pods are discovered by labels only, so it works for broken environments,
+// errors of single pods and containers are logged and the dump goes on
+func (a *Artifacts) DumpPostMortem(testDir string) error {
+	if err := mkdirIfNotExists(testDir); err != nil {
+		return err
+	}
+	log.Info().Str("Namespace", a.Namespace).Str("Dir", testDir).Msg("Writing post-mortem artifacts")
+	pods, err := a.podsClient.List(context.Background(), metaV1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if err := a.writePostMortemPod(testDir, pod); err != nil {
+			log.Warn().Err(err).Str("Pod", pod.Name).Msg("Failed to write pod artifacts")
+		}
+	}
+	if err := a.writeEvents(testDir); err != nil {
+		log.Warn().Err(err).Str("Namespace", a.Namespace).Msg("Failed to write events")
+	}
+	if err := a.writeHistory(testDir); err != nil {
+		log.Warn().Err(err).Str("Namespace", a.Namespace).Msg("Failed to write history")
+	}
+	if err := a.writeManifests(filepath.Join(testDir, PostMortemManifestsDir)); err != nil {
+		log.Warn().Err(err).Str("Namespace", a.Namespace).Msg("Failed to write manifests")
+	}
+	return a.writeReports(testDir)
+}
+
+func (a *Artifacts) writePostMortemPod(testDir string, pod coreV1.Pod) error {
+	podDir := filepath.Join(testDir, podArtifactsDir(pod))
+	if err := mkdirIfNotExists(podDir); err != nil {
+		return err
+	}
+	pod.ManagedFields = nil
+	data, err := yaml.Marshal(pod)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(podDir, PostMortemPodFile), data, 0644); err != nil {
+		return err
+	}
+	restarted := restartedContainers(pod)
+	containers := append(append([]coreV1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, c := range containers {
+		if err := a.writeLogs(filepath.Join(podDir, c.Name+".log"), pod, c.Name, false); err != nil {
+			log.Warn().Err(err).Str("Pod", pod.Name).Str("Container", c.Name).Msg("Failed to write container logs")
+		}
+		if !restarted[c.Name] {
+			continue
+		}
+		if err := a.writeLogs(filepath.Join(podDir, c.Name+PreviousLogSuffix), pod, c.Name, true); err != nil {
+			log.Warn().Err(err).Str("Pod", pod.Name).Str("Container", c.Name).Msg("Failed to write previous container logs")
+		}
+	}
+	return nil
+}
+
+func (a *Artifacts) writeEvents(testDir string) error {
+	events, err := a.Client.ClientSet.CoreV1().Events(a.Namespace).List(context.Background(), metaV1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(testDir, PostMortemEventsFile), []byte(formatEvents(events.Items)), 0644)
+}
+
+func (a *Artifacts) writeHistory(testDir string) error {
+	history, err := a.Client.History(a.Namespace)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(testDir, PostMortemHistoryFile), data, 0644)
+}
+
+// writeManifests writes workloads, services, config maps and volume claims of the namespace as they are in the cluster,
+// secrets are not written
+func (a *Artifacts) writeManifests(dir string) error {
+	if err := mkdirIfNotExists(dir); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	cs := a.Client.ClientSet
+	opts := metaV1.ListOptions{}
+	objs := make(map[string]interface{})
+	deployments, err := cs.AppsV1().Deployments(a.Namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, o := range deployments.Items {
+		o.ManagedFields = nil
+		o.TypeMeta = metaV1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+		objs["deployment-"+o.Name] = o
+	}
+	statefulSets, err := cs.AppsV1().StatefulSets(a.Namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, o := range statefulSets.Items {
+		o.ManagedFields = nil
+		o.TypeMeta = metaV1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"}
+		objs["statefulset-"+o.Name] = o
+	}
+	jobs, err := cs.BatchV1().Jobs(a.Namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, o := range jobs.Items {
+		o.ManagedFields = nil
+		o.TypeMeta = metaV1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"}
+		objs["job-"+o.Name] = o
+	}
+	services, err := cs.CoreV1().Services(a.Namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, o := range services.Items {
+		o.ManagedFields = nil
+		o.TypeMeta = metaV1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+		objs["service-"+o.Name] = o
+	}
+	configMaps, err := cs.CoreV1().ConfigMaps(a.Namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, o := range configMaps.Items {
+		o.ManagedFields = nil
+		o.TypeMeta = metaV1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+		objs["configmap-"+o.Name] = o
+	}
+	pvcs, err := cs.CoreV1().PersistentVolumeClaims(a.Namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, o := range pvcs.Items {
+		o.ManagedFields = nil
+		o.TypeMeta = metaV1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"}
+		objs["persistentvolumeclaim-"+o.Name] = o
+	}
+	for name, o := range objs {
+		data, err := yaml.Marshal(o)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".yaml"), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// podArtifactsDir groups pods by release or app label, every pod has its own directory
+func podArtifactsDir(pod coreV1.Pod) string {
+	group := pod.Labels[pkg.ReleaseLabelKey]
+	if group == "" {
+		group = pod.Labels[client.AppLabel]
+	}
+	if group == "" {
+		group = postMortemUnlabeled
+	}
+	return filepath.Join(group, pod.Name)
+}
+
+// restartedContainers returns names of containers which were restarted at least once, their previous logs are available
+func restartedContainers(pod coreV1.Pod) map[string]bool {
+	restarted := make(map[string]bool)
+	statuses := append(append([]coreV1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, s := range statuses {
+		if s.RestartCount > 0 {
+			restarted[s.Name] = true
+		}
+	}
+	return restarted
+}
+
+// eventTime returns the most recent time of an event, series and legacy events set different fields
+func eventTime(e coreV1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// sortEvents returns events sorted by time, oldest first
+func sortEvents(events []coreV1.Event) []coreV1.Event {
+	sorted := append([]coreV1.Event{}, events...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return eventTime(sorted[i]).Before(eventTime(sorted[j]))
+	})
+	return sorted
+}
+
+// formatEvents formats events as lines "time type reason kind/name (xcount): message", oldest first
+func formatEvents(events []coreV1.Event) string {
+	var sb strings.Builder
+	for _, e := range sortEvents(events) {
+		count := ""
+		if e.Count > 1 {
+			count = fmt.Sprintf(" (x%d)", e.Count)
+		}
+		sb.WriteString(fmt.Sprintf("%s %s %s %s/%s%s: %s\n",
+			eventTime(e).UTC().Format(time.RFC3339),
+			e.Type,
+			e.Reason,
+			e.InvolvedObject.Kind,
+			e.InvolvedObject.Name,
+			count,
+			strings.TrimSpace(e.Message),
+		))
+	}
+	return sb.String()
+}
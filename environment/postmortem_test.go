@@ -0,0 +1,57 @@
+package environment
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPostMortem(t *testing.T) {
+	t.Run("pods are grouped by release and app", func(t *testing.T) {
+		pod := func(labels map[string]string) coreV1.Pod {
+			return coreV1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: "p", Labels: labels}}
+		}
+		require.Equal(t, filepath.Join("chainlink-0", "p"), podArtifactsDir(pod(map[string]string{pkg.ReleaseLabelKey: "chainlink-0", "app": "x"})))
+		require.Equal(t, filepath.Join("geth", "p"), podArtifactsDir(pod(map[string]string{"app": "geth"})))
+		require.Equal(t, filepath.Join("unlabeled", "p"), podArtifactsDir(pod(nil)))
+	})
+	t.Run("restarted containers", func(t *testing.T) {
+		pod := coreV1.Pod{Status: coreV1.PodStatus{
+			InitContainerStatuses: []coreV1.ContainerStatus{{Name: "migrate", RestartCount: 2}},
+			ContainerStatuses: []coreV1.ContainerStatus{
+				{Name: "node", RestartCount: 1},
+				{Name: "chainlink-db"},
+			},
+		}}
+		require.Equal(t, map[string]bool{"migrate": true, "node": true}, restartedContainers(pod))
+	})
+	t.Run("events are sorted", func(t *testing.T) {
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		events := []coreV1.Event{
+			{
+				Type:           "Warning",
+				Reason:         "BackOff",
+				Message:        "Back-off restarting failed container\n",
+				Count:          3,
+				LastTimestamp:  metaV1.NewTime(now.Add(time.Minute)),
+				InvolvedObject: coreV1.ObjectReference{Kind: "Pod", Name: "chainlink-0-0"},
+			},
+			{
+				Type:           "Normal",
+				Reason:         "Scheduled",
+				Message:        "Successfully assigned",
+				EventTime:      metaV1.NewMicroTime(now),
+				InvolvedObject: coreV1.ObjectReference{Kind: "Pod", Name: "chainlink-0-0"},
+			},
+		}
+		require.Equal(t,
+			"2022-09-01T12:00:00Z Normal Scheduled Pod/chainlink-0-0: Successfully assigned\n"+
+				"2022-09-01T12:01:00Z Warning BackOff Pod/chainlink-0-0 (x3): Back-off restarting failed container\n",
+			formatEvents(events))
+	})
+}
@@ -0,0 +1,216 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+const (
+	// ReadinessCheckInterval default interval between readiness check attempts
+	ReadinessCheckInterval = 2 * time.Second
+	// ReadinessAttemptTimeout how long one attempt of a readiness check may take
+	ReadinessAttemptTimeout = 10 * time.Second
+)
+
+// ReadinessCheck checks that a component is ready beyond its pods readiness, e.g. that its API responds,
+// it returns an error if the component is not ready yet, see HTTPCheck, TCPCheck, GRPCCheck and FuncCheck
+type ReadinessCheck interface {
+	Check(ctx context.Context, e *Environment) error
+}
+
+// Readiness is a named readiness check of a component, it's retried until it passes or its timeout expires
+type Readiness struct {
+	Name string
+	// Timeout how long the check is retried, Config.ReadyCheckData.Timeout if 0
+	Timeout time.Duration
+	// Interval between attempts, ReadinessCheckInterval if 0
+	Interval time.Duration
+	Check    ReadinessCheck
+}
+
+// ReadinessCheckedChart is a chart declaring readiness checks, they are waited for one by one as a part of CheckHealth,
+// before health checks and conditions
+type ReadinessCheckedChart interface {
+	ConnectedChart
+	ReadinessChecks() []Readiness
+}
+
+// AddReadinessChecks adds readiness checks to a chart, they are run after checks declared by the chart,
+// see ReadinessCheckedChart
+func (m *Environment) AddReadinessChecks(chart string, checks ...Readiness) *Environment {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readiness[chart] = append(m.readiness[chart], checks...)
+	return m
+}
+
+// readinessChecks returns checks declared by the chart and added to it
+func (m *Environment) readinessChecks(c ConnectedChart) []Readiness {
+	checks := make([]Readiness, 0)
+	if rc, ok := c.(ReadinessCheckedChart); ok {
+		checks = append(checks, rc.ReadinessChecks()...)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append(checks, m.readiness[c.GetName()]...)
+}
+
+// checkReadiness waits for readiness checks of the chart one by one, every check with its own timeout
+func (m *Environment) checkReadiness(c ConnectedChart) error {
+	for _, r := range m.readinessChecks(c) {
+		timeout := r.Timeout
+		if timeout == 0 {
+			timeout = m.Cfg.ReadyCheckData.Timeout
+		}
+		interval := r.Interval
+		if interval == 0 {
+			interval = ReadinessCheckInterval
+		}
+		var lastErr error
+		err := client.PollImmediate(m.clock(), interval, timeout, func() (bool, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), ReadinessAttemptTimeout)
+			defer cancel()
+			if lastErr = r.Check.Check(ctx, m); lastErr != nil {
+				log.Debug().Err(lastErr).Str("Chart", c.GetName()).Str("Check", r.Name).Msg("Chart is not ready yet")
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			return errors.Wrapf(lastErr, "readiness check %s of chart %s failed in %s", r.Name, c.GetName(), timeout)
+		}
+		log.Debug().Str("Chart", c.GetName()).Str("Check", r.Name).Msg("Readiness check passed")
+	}
+	return nil
+}
+
+// PortRef selects a forwarded port of a pod container
+type PortRef struct {
+	App       string
+	Instance  int
+	Container string
+	Port      string
+}
+
+// address returns a local or in-cluster address of the port with the protocol
+func (p PortRef) address(e *Environment, proto client.Protocol) (string, error) {
+	return e.Fwd.FindPort(fmt.Sprintf("%s:%d", p.App, p.Instance), p.Container, p.Port).As(e.connectionMode(), proto)
+}
+
+// HTTPCheck requests an URL, or a path of a forwarded port, and checks the response status and body
+type HTTPCheck struct {
+	// URL is requested if set, Target port otherwise
+	URL    string
+	Target PortRef
+	Method string
+	Path   string
+	// Status is an expected response status, 200 if 0
+	Status int
+	// Body is an expected substring of the response body, not checked if empty
+	Body string
+}
+
+// Check implements ReadinessCheck
+func (h HTTPCheck) Check(ctx context.Context, e *Environment) error {
+	u := h.URL
+	if u == "" {
+		base, err := h.Target.address(e, client.HTTP)
+		if err != nil {
+			return err
+		}
+		u = base + h.Path
+	}
+	method := h.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return checkHTTPResponse(resp.StatusCode, string(body), h.Status, h.Body)
+}
+
+// checkHTTPResponse compares a response status and body to the expected ones
+func checkHTTPResponse(status int, body string, expectedStatus int, expectedBody string) error {
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	if status != expectedStatus {
+		return errors.Errorf("unexpected status %d, expected %d", status, expectedStatus)
+	}
+	if expectedBody != "" && !strings.Contains(body, expectedBody) {
+		return errors.Errorf("response body doesn't contain %q", expectedBody)
+	}
+	return nil
+}
+
+// TCPCheck checks that an address, or a forwarded port, accepts connections
+type TCPCheck struct {
+	// Address is dialed if set, Target port otherwise
+	Address string
+	Target  PortRef
+}
+
+// Check implements ReadinessCheck
+func (t TCPCheck) Check(ctx context.Context, e *Environment) error {
+	addr := t.Address
+	if addr == "" {
+		var err error
+		if addr, err = t.Target.address(e, client.GRPC); err != nil {
+			return err
+		}
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// GRPCCheck checks that a gRPC service is serving with grpc.health.v1
+type GRPCCheck struct {
+	Service GRPCService
+}
+
+// Check implements ReadinessCheck
+func (g GRPCCheck) Check(ctx context.Context, e *Environment) error {
+	gc, err := e.GRPCClient(g.Service)
+	if err != nil {
+		return err
+	}
+	status, err := gc.HealthCheck(ctx, g.Service.HealthService)
+	if err != nil {
+		return err
+	}
+	if status != client.GRPCStatusServing {
+		return errors.Errorf("gRPC service %s of %s:%d is %s", g.Service.HealthService, g.Service.App, g.Service.Instance, status)
+	}
+	return nil
+}
+
+// FuncCheck is a user-supplied readiness check
+type FuncCheck func(ctx context.Context) error
+
+// Check implements ReadinessCheck
+func (f FuncCheck) Check(ctx context.Context, _ *Environment) error {
+	return f(ctx)
+}
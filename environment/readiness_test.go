@@ -0,0 +1,64 @@
+package environment
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type readinessTestChart struct {
+	ConnectedChart
+	checks []Readiness
+}
+
+func (c readinessTestChart) GetName() string              { return "geth" }
+func (c readinessTestChart) ReadinessChecks() []Readiness { return c.checks }
+
+func TestCheckReadiness(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer srv.Close()
+	attempts := 0
+	e := &Environment{
+		Cfg:       &Config{ReadyCheckData: defaultEnvConfig().ReadyCheckData},
+		mu:        &sync.Mutex{},
+		readiness: make(map[string][]Readiness),
+	}
+	c := readinessTestChart{checks: []Readiness{
+		{Name: "http", Check: HTTPCheck{URL: srv.URL, Body: `"ok"`}},
+		{Name: "tcp", Check: TCPCheck{Address: srv.Listener.Addr().String()}},
+	}}
+	e.AddReadinessChecks("geth", Readiness{Name: "func", Interval: time.Millisecond, Check: FuncCheck(func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("not synced")
+		}
+		return nil
+	})})
+	require.NoError(t, e.checkReadiness(c))
+	require.Equal(t, 3, attempts)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	c.checks = []Readiness{{Name: "closed", Timeout: 20 * time.Millisecond, Interval: time.Millisecond, Check: TCPCheck{Address: addr}}}
+	err = e.checkReadiness(c)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "readiness check closed of chart geth failed")
+}
+
+func TestCheckHTTPResponse(t *testing.T) {
+	require.NoError(t, checkHTTPResponse(200, "ok", 0, ""))
+	require.NoError(t, checkHTTPResponse(204, "", 204, ""))
+	require.EqualError(t, checkHTTPResponse(503, "", 0, ""), "unexpected status 503, expected 200")
+	require.EqualError(t, checkHTTPResponse(200, "syncing", 0, "synced"), `response body doesn't contain "synced"`)
+}
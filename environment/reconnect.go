@@ -0,0 +1,176 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Component is a chart release found in a namespace connected to, see Connect
+type Component struct {
+	Name string `json:"name"`
+	// Pods names of the release pods, hook pods are skipped
+	Pods []string `json:"pods"`
+	// Apps forwarded ports keys of the release pods, "${app}:${instance}"
+	Apps []string `json:"apps"`
+}
+
+// Connect connects to an existing environment without applying anything, unlike Environment.Connect charts are not rendered
+// and presets are not re-run: releases are discovered by their label, ports of their pods are forwarded and URLs are rebuilt.
+// Charts, if passed, are used only to export their URLs, outputs and typed connections, URLs of other releases are exported
+// by "${release}_${container}_${port}" keys. Shutdown of the returned environment doesn't remove anything
+func Connect(namespace string, cfg *Config, charts ...ConnectedChart) (*Environment, error) {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	c.Namespace = namespace
+	m := New(&c)
+	m.connect = namespace
+	m.attached = true
+	if !m.Client.NamespaceExists(namespace) {
+		return nil, errors.Errorf("namespace %s not found", namespace)
+	}
+	if err := m.loadReproducibility(namespace); err != nil {
+		return nil, err
+	}
+	contracts, err := m.loadContracts()
+	if err != nil {
+		return nil, err
+	}
+	m.Contracts = contracts
+	if err := m.discoverComponents(); err != nil {
+		return nil, err
+	}
+	m.Charts = append(m.Charts, charts...)
+	if err := m.Fwd.Connect(namespace, "", m.Cfg.InsideK8s); err != nil {
+		return nil, err
+	}
+	if m.Cfg.PortsFile != "" {
+		if err := m.Fwd.WriteMapping(m.Cfg.PortsFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.PrintExportData(); err != nil {
+		return nil, err
+	}
+	if err := m.exportComponentURLs(); err != nil {
+		return nil, err
+	}
+	arts, err := NewArtifacts(m.Client, namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create artifacts client")
+	}
+	if arts.LogFilter, err = m.logFilter(); err != nil {
+		return nil, err
+	}
+	m.Artifacts = arts
+	for _, comp := range m.Components {
+		m.setChartStatus(comp.Name, ChartStatusReady)
+	}
+	log.Info().
+		Str("Namespace", namespace).
+		Interface("Components", m.Components).
+		Interface("URLs", m.URLs).
+		Msg("Connected to the environment")
+	return m, nil
+}
+
+// discoverComponents finds releases of the namespace with their pods
+func (m *Environment) discoverComponents() error {
+	installed, err := m.installedReleases()
+	if err != nil {
+		return err
+	}
+	pods, err := m.Client.ClientSet.CoreV1().Pods(m.Cfg.Namespace).List(context.Background(), metaV1.ListOptions{LabelSelector: pkg.ReleaseLabelKey})
+	if err != nil {
+		return err
+	}
+	m.Components = groupComponents(installed, pods.Items)
+	return nil
+}
+
+// groupComponents groups pods by releases, releases without pods are listed too, sorted by names
+func groupComponents(releases map[string]bool, pods []coreV1.Pod) []Component {
+	byName := make(map[string]*Component)
+	apps := make(map[string]map[string]bool)
+	add := func(name string) *Component {
+		if c, ok := byName[name]; ok {
+			return c
+		}
+		c := &Component{Name: name, Pods: []string{}, Apps: []string{}}
+		byName[name] = c
+		apps[name] = make(map[string]bool)
+		return c
+	}
+	for name := range releases {
+		add(name)
+	}
+	for _, p := range pods {
+		if _, hook := p.Labels[pkg.HookLabelKey]; hook {
+			continue
+		}
+		c := add(p.Labels[pkg.ReleaseLabelKey])
+		c.Pods = append(c.Pods, p.Name)
+		app := fmt.Sprintf("%s:%s", p.Labels["app"], p.Labels["instance"])
+		if !apps[c.Name][app] {
+			apps[c.Name][app] = true
+			c.Apps = append(c.Apps, app)
+		}
+	}
+	comps := make([]Component, 0, len(byName))
+	for _, c := range byName {
+		sort.Strings(c.Pods)
+		sort.Strings(c.Apps)
+		comps = append(comps, *c)
+	}
+	sort.Slice(comps, func(i, j int) bool { return comps[i].Name < comps[j].Name })
+	return comps
+}
+
+// exportComponentURLs exports URLs of forwarded ports of releases which have no chart passed to Connect
+func (m *Environment) exportComponentURLs() error {
+	exported := make(map[string]bool)
+	for _, comp := range m.Components {
+		if m.chart(comp.Name) != nil {
+			continue
+		}
+		for _, app := range comp.Apps {
+			containers, ok := m.Fwd.Info[app].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for container, ports := range containers {
+				pm, ok := ports.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for port, info := range pm {
+					ci, ok := info.(client.ConnectionInfo)
+					if !ok {
+						continue
+					}
+					ci.Credentials = m.Fwd.Credentials(container, port)
+					u, err := client.NewURLConverter(ci, nil).URL(m.connectionMode())
+					if err != nil {
+						return errors.Wrapf(err, "failed to get URL of %s %s/%s", app, container, port)
+					}
+					key := fmt.Sprintf("%s_%s_%s", comp.Name, container, port)
+					m.URLs[key] = append(m.URLs[key], u)
+					exported[key] = true
+				}
+			}
+		}
+	}
+	for k := range exported {
+		sort.Strings(m.URLs[k])
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComponents(t *testing.T) {
+	pod := func(name string, release string, app string, instance string) coreV1.Pod {
+		return coreV1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: name, Labels: map[string]string{
+			pkg.ReleaseLabelKey: release,
+			"app":               app,
+			"instance":          instance,
+		}}}
+	}
+	hook := pod("geth-hook", "geth", "geth-hook", "0")
+	hook.Labels[pkg.HookLabelKey] = "post-install"
+	comps := groupComponents(map[string]bool{"geth": true, "mockserver-config": true}, []coreV1.Pod{
+		pod("chainlink-0-b", "chainlink-0", "chainlink-0", "0"),
+		pod("geth-a", "geth", "geth", "0"),
+		pod("chainlink-0-a", "chainlink-0", "chainlink-0", "0"),
+		hook,
+	})
+	require.Equal(t, []Component{
+		{Name: "chainlink-0", Pods: []string{"chainlink-0-a", "chainlink-0-b"}, Apps: []string{"chainlink-0:0"}},
+		{Name: "geth", Pods: []string{"geth-a"}, Apps: []string{"geth:0"}},
+		{Name: "mockserver-config", Pods: []string{}, Apps: []string{}},
+	}, comps)
+}
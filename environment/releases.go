@@ -0,0 +1,16 @@
+package environment
+
+// markReleasesReady marks charts which have releases in the namespace connected to as ready,
+// so Update deploys only charts added after connecting
+func (m *Environment) markReleasesReady() error {
+	releases, err := m.Client.Releases(m.Cfg.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, name := range releases {
+		if m.chart(name) != nil {
+			m.setChartStatus(name, ChartStatusReady)
+		}
+	}
+	return nil
+}
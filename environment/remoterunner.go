@@ -0,0 +1,290 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RemoteRunnerName = "remote-runner"
+	// DefaultRemoteRunnerImage has glibc and tar, so test binaries can be copied and run in it
+	DefaultRemoteRunnerImage = "debian:bullseye-slim"
+	RemoteRunnerStartTimeout = 5 * time.Minute
+	// RemoteRunnerPollInterval how often the runner container state is checked
+	RemoteRunnerPollInterval = 5 * time.Second
+	// RemoteShutdownMessage is a termination message of the runner container when the test called Shutdown,
+	// the namespace is removed by the launcher then, the runner would be killed by the removal otherwise
+	RemoteShutdownMessage = "shutdown"
+	remoteRunnerDir       = "/runner"
+	remoteRunnerBinary    = remoteRunnerDir + "/test"
+	remoteRunnerReadyFile = remoteRunnerDir + "/ready"
+)
+
+// remoteRunnerEnvVars are passed from the launcher environment into the runner if they are set
+var remoteRunnerEnvVars = []string{
+	config.EnvVarCLImage,
+	config.EnvVarCLTag,
+	config.EnvVarUser,
+	config.EnvVarCLCommitSha,
+	config.EnvVarTestTrigger,
+	config.EnvVarLogLevel,
+	config.EnvVarSlackKey,
+	config.EnvVarSlackChannel,
+	config.EnvVarSlackUser,
+}
+
+// ErrRemotePassed is returned by Run when the test passed in the remote runner, the test must not continue locally,
+// Shutdown only closes local connections then
+var ErrRemotePassed = errors.New("test passed in the remote runner")
+
+// RemoteExitError is returned by Run when the test failed in the remote runner, the test must not continue locally,
+// main or TestMain exits with Code, Shutdown only closes local connections then
+type RemoteExitError struct {
+	Namespace string
+	// Code is the exit code of the test binary in the runner
+	Code int
+}
+
+func (e *RemoteExitError) Error() string {
+	return fmt.Sprintf("test finished in the remote runner of %s with exit code %d", e.Namespace, e.Code)
+}
+
+// insideRemoteRunner true if the test runs in the runner pod
+func insideRemoteRunner() bool {
+	return os.Getenv(config.EnvVarRemoteRunnerPod) != ""
+}
+
+// runRemote runs the current test binary with the same arguments in a Job inside the namespace, streams its logs
+// and returns its exit code, the binary must be built for Linux, see Config.RemoteRunnerBinary
+func (m *Environment) runRemote() (int, error) {
+	binary, err := m.remoteRunnerBinary()
+	if err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+	if err := m.createRemoteRunnerAccess(ctx); err != nil {
+		return 0, err
+	}
+	image := m.Cfg.RemoteRunnerImage
+	if image == "" {
+		image = DefaultRemoteRunnerImage
+	}
+	image = mirrorImage(image, m.Cfg.ImageMirror)
+	job := remoteRunnerJob(image, os.Args[1:], m.remoteRunnerEnv())
+	if _, err := m.Client.ClientSet.BatchV1().Jobs(m.Cfg.Namespace).Create(ctx, job, metaV1.CreateOptions{}); err != nil {
+		return 0, errors.Wrap(err, "failed to create remote runner job")
+	}
+	pod, err := m.waitRemoteRunnerPod(ctx)
+	if err != nil {
+		return 0, err
+	}
+	log.Info().Str("Pod", pod).Str("Binary", binary).Msg("Uploading test binary to the remote runner")
+	if err := m.Client.UploadToPod(m.Cfg.Namespace, pod, RemoteRunnerName, binary, remoteRunnerBinary); err != nil {
+		return 0, errors.Wrap(err, "failed to upload test binary")
+	}
+	if _, stderr, err := m.Client.ExecuteInPod(m.Cfg.Namespace, pod, RemoteRunnerName, []string{"touch", remoteRunnerReadyFile}); err != nil {
+		return 0, errors.Wrapf(err, "failed to start remote runner: %s", stderr)
+	}
+	log.Info().Str("Namespace", m.Cfg.Namespace).Str("Pod", pod).Msg("Test is running in the remote runner, streaming logs")
+	if err := m.streamRemoteRunnerLogs(ctx, pod, os.Stdout); err != nil {
+		log.Warn().Err(err).Str("Pod", pod).Msg("Remote runner logs stream is broken")
+	}
+	state, err := m.waitRemoteRunnerTerminated(ctx, pod)
+	if err != nil {
+		return 0, err
+	}
+	log.Info().Int32("ExitCode", state.ExitCode).Str("Reason", state.Reason).Msg("Remote runner finished")
+	if state.Message == RemoteShutdownMessage {
+		if err := m.Client.RemoveNamespace(m.Cfg.Namespace); err != nil {
+			return 0, err
+		}
+	}
+	return int(state.ExitCode), nil
+}
+
+// remoteRunnerBinary returns the binary to run remotely, the current one is used only if it's built for Linux
+func (m *Environment) remoteRunnerBinary() (string, error) {
+	if m.Cfg.RemoteRunnerBinary != "" {
+		return filepath.Abs(m.Cfg.RemoteRunnerBinary)
+	}
+	if runtime.GOOS != "linux" {
+		return "", errors.Errorf("test binary is built for %s, build it for Linux with 'GOOS=linux go test -c' and set RemoteRunnerBinary", runtime.GOOS)
+	}
+	return os.Executable()
+}
+
+// remoteRunnerEnv returns the runner env, the runner connects to the namespace and collects ports from the inside
+func (m *Environment) remoteRunnerEnv() map[string]string {
+	env := map[string]string{
+		config.EnvVarNamespace:       m.Cfg.Namespace,
+		config.EnvVarRemoteRunnerPod: "true",
+	}
+	for _, k := range remoteRunnerEnvVars {
+		if v, ok := os.LookupEnv(k); ok {
+			env[k] = v
+		}
+	}
+	for k, v := range m.Cfg.RemoteRunnerEnv {
+		env[k] = v
+	}
+	return env
+}
+
+// createRemoteRunnerAccess creates a service account of the runner with admin access to the namespace
+func (m *Environment) createRemoteRunnerAccess(ctx context.Context) error {
+	_, err := m.Client.ClientSet.CoreV1().ServiceAccounts(m.Cfg.Namespace).Create(ctx, &coreV1.ServiceAccount{
+		ObjectMeta: metaV1.ObjectMeta{Name: RemoteRunnerName},
+	}, metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create remote runner service account")
+	}
+	_, err = m.Client.ClientSet.RbacV1().RoleBindings(m.Cfg.Namespace).Create(ctx, &rbacV1.RoleBinding{
+		ObjectMeta: metaV1.ObjectMeta{Name: RemoteRunnerName},
+		Subjects: []rbacV1.Subject{{
+			Kind:      rbacV1.ServiceAccountKind,
+			Name:      RemoteRunnerName,
+			Namespace: m.Cfg.Namespace,
+		}},
+		RoleRef: rbacV1.RoleRef{
+			APIGroup: rbacV1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     "admin",
+		},
+	}, metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create remote runner role binding")
+	}
+	return nil
+}
+
+// remoteRunnerJob runs the test binary once it's uploaded, the job is never retried, so the exit code is the test result
+func remoteRunnerJob(image string, args []string, env map[string]string) *batchV1.Job {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	envVars := make([]coreV1.EnvVar, 0, len(env))
+	for _, k := range keys {
+		envVars = append(envVars, coreV1.EnvVar{Name: k, Value: env[k]})
+	}
+	backoffLimit := int32(0)
+	script := fmt.Sprintf(`until [ -f %s ]; do sleep 1; done; cd %s && exec %s "$@"`, remoteRunnerReadyFile, remoteRunnerDir, remoteRunnerBinary)
+	return &batchV1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   RemoteRunnerName,
+			Labels: map[string]string{"app": RemoteRunnerName},
+		},
+		Spec: batchV1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{
+					Labels: map[string]string{"app": RemoteRunnerName},
+				},
+				Spec: coreV1.PodSpec{
+					ServiceAccountName: RemoteRunnerName,
+					RestartPolicy:      coreV1.RestartPolicyNever,
+					Containers: []coreV1.Container{
+						{
+							Name:                     RemoteRunnerName,
+							Image:                    image,
+							Command:                  append([]string{"/bin/sh", "-c", script, RemoteRunnerName}, args...),
+							Env:                      envVars,
+							TerminationMessagePolicy: coreV1.TerminationMessageReadFile,
+							VolumeMounts: []coreV1.VolumeMount{
+								{Name: RemoteRunnerName, MountPath: remoteRunnerDir},
+							},
+							Resources: coreV1.ResourceRequirements{
+								Requests: coreV1.ResourceList{
+									coreV1.ResourceCPU:    resource.MustParse("250m"),
+									coreV1.ResourceMemory: resource.MustParse("512Mi"),
+								},
+							},
+						},
+					},
+					Volumes: []coreV1.Volume{
+						{Name: RemoteRunnerName, VolumeSource: coreV1.VolumeSource{EmptyDir: &coreV1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// waitRemoteRunnerPod returns the name of the runner pod when its container is running
+func (m *Environment) waitRemoteRunnerPod(ctx context.Context) (string, error) {
+	var pod string
+	err := client.PollImmediate(m.clock(), RemoteRunnerPollInterval, RemoteRunnerStartTimeout, func() (bool, error) {
+		pods, err := m.Client.ListPods(m.Cfg.Namespace, "job-name="+RemoteRunnerName)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range pods.Items {
+			if p.Status.Phase == coreV1.PodRunning {
+				pod = p.Name
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "remote runner pod is not running")
+	}
+	return pod, nil
+}
+
+// streamRemoteRunnerLogs follows the runner container logs until it exits
+func (m *Environment) streamRemoteRunnerLogs(ctx context.Context, pod string, out io.Writer) error {
+	stream, err := m.Client.ClientSet.CoreV1().Pods(m.Cfg.Namespace).GetLogs(pod, &coreV1.PodLogOptions{
+		Container: RemoteRunnerName,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	// nolint
+	defer stream.Close()
+	_, err = io.Copy(out, stream)
+	return err
+}
+
+// waitRemoteRunnerTerminated returns the terminated state of the runner container, logs stream may break before it exits
+func (m *Environment) waitRemoteRunnerTerminated(ctx context.Context, pod string) (*coreV1.ContainerStateTerminated, error) {
+	var state *coreV1.ContainerStateTerminated
+	err := client.PollImmediateInfinite(m.clock(), RemoteRunnerPollInterval, func() (bool, error) {
+		p, err := m.Client.ClientSet.CoreV1().Pods(m.Cfg.Namespace).Get(ctx, pod, metaV1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, cs := range p.Status.ContainerStatuses {
+			if cs.Name == RemoteRunnerName && cs.State.Terminated != nil {
+				state = cs.State.Terminated
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	return state, err
+}
+
+// writeRemoteShutdown asks the launcher to remove the namespace when the runner exits
+func writeRemoteShutdown() error {
+	return os.WriteFile(coreV1.TerminationMessagePathDefault, []byte(RemoteShutdownMessage), 0644)
+}
@@ -0,0 +1,37 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+func TestRemoteRunnerJob(t *testing.T) {
+	job := remoteRunnerJob("debian:bullseye-slim", []string{"-test.run", "^TestSoak$"}, map[string]string{
+		config.EnvVarRemoteRunnerPod: "true",
+		config.EnvVarNamespace:       "chainlink-test-env-abcde",
+	})
+	require.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	spec := job.Spec.Template.Spec
+	require.Equal(t, coreV1.RestartPolicyNever, spec.RestartPolicy)
+	require.Equal(t, RemoteRunnerName, spec.ServiceAccountName)
+	c := spec.Containers[0]
+	require.Equal(t, []string{"/bin/sh", "-c", `until [ -f /runner/ready ]; do sleep 1; done; cd /runner && exec /runner/test "$@"`, RemoteRunnerName, "-test.run", "^TestSoak$"}, c.Command)
+	require.Equal(t, []coreV1.EnvVar{
+		{Name: config.EnvVarRemoteRunnerPod, Value: "true"},
+		{Name: config.EnvVarNamespace, Value: "chainlink-test-env-abcde"},
+	}, c.Env)
+}
+
+func TestRemoteExitError(t *testing.T) {
+	err := errors.Wrap(&RemoteExitError{Namespace: "chainlink-test-env-abcde", Code: 1}, "run")
+	var remote *RemoteExitError
+	require.ErrorAs(t, err, &remote)
+	require.Equal(t, 1, remote.Code)
+	require.EqualError(t, err, "run: test finished in the remote runner of chainlink-test-env-abcde with exit code 1")
+	require.False(t, errors.Is(err, ErrRemotePassed))
+	require.ErrorIs(t, errors.Wrap(ErrRemotePassed, "chainlink-test-env-abcde"), ErrRemotePassed)
+}
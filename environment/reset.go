@@ -0,0 +1,106 @@
+package environment
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+)
+
+// ResettableChart is a chart which state can be reset in place, for example, wiping databases or restarting a chain from genesis
+type ResettableChart interface {
+	ConnectedChart
+	Reset(e *Environment) error
+}
+
+const (
+	// ResetStageInfra charts reset first, networks and mocks
+	ResetStageInfra = 0
+	// ResetStageNodes charts reset after infra, nodes connecting to networks and mocks
+	ResetStageNodes = 1
+)
+
+// StagedResetChart is a ResettableChart reset in a stage, charts reset in stages in ascending order,
+// and in their order within a stage, so nodes restart when networks and mocks they connect to are already reset,
+// charts without it reset in ResetStageInfra
+type StagedResetChart interface {
+	ResettableChart
+	ResetStage() int
+}
+
+// Reset resets state of all charts implementing ResettableChart in place in their stages, see StagedResetChart,
+// without recreating the namespace,
+// removes clock shifts and updates URLs, so the environment can be reused by another test
+func (m *Environment) Reset() error {
+	m.reportProgress("Resetting environment")
+	if err := m.Time.Reset(); err != nil {
+		return err
+	}
+	for _, rc := range resetOrder(m.Charts) {
+		log.Info().Str("Chart", rc.GetName()).Msg("Resetting chart")
+		if err := rc.Reset(m); err != nil {
+			m.recordEvent("reset", rc.GetName(), err)
+			return errors.Wrapf(err, "failed to reset chart %s", rc.GetName())
+		}
+	}
+	m.recordEvent("reset", "", nil)
+	m.URLs = make(map[string][]string)
+	return m.PrintExportData()
+}
+
+// resetOrder returns resettable charts sorted by their reset stage, the order of charts in a stage is kept
+func resetOrder(charts []ConnectedChart) []ResettableChart {
+	res := make([]ResettableChart, 0)
+	for _, c := range charts {
+		if rc, ok := c.(ResettableChart); ok {
+			res = append(res, rc)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return resetStage(res[i]) < resetStage(res[j])
+	})
+	return res
+}
+
+func resetStage(c ResettableChart) int {
+	if sc, ok := c.(StagedResetChart); ok {
+		return sc.ResetStage()
+	}
+	return ResetStageInfra
+}
+
+// RestartChart deletes pods of the chart, waits until their replacements are ready and forwards their ports,
+// call PrintExportData after it to update URLs
+func (m *Environment) RestartChart(name string) error {
+	log.Info().Str("Chart", name).Msg("Restarting chart pods")
+	// hook jobs pods are not restarted
+	selector := fmt.Sprintf("%s,!%s", releaseSelector(name), pkg.HookLabelKey)
+	pl, err := m.Client.ListPods(m.Cfg.Namespace, selector)
+	if err != nil {
+		return err
+	}
+	rcd := &client.ReadyCheckData{
+		ReadinessProbeCheckSelector: selector,
+		Timeout:                     m.readyTimeout(len(pl.Items)),
+	}
+	if err := m.Client.DeletePods(m.Cfg.Namespace, selector, rcd.Timeout); err != nil {
+		return err
+	}
+	if err := m.Client.WaitPodsCreated(m.Cfg.Namespace, rcd); err != nil {
+		return err
+	}
+	if err := m.Client.CheckReady(m.Cfg.Namespace, rcd); err != nil {
+		return err
+	}
+	if err := m.enumerateApps(); err != nil {
+		return err
+	}
+	m.Fwd.RemoveApp(name)
+	if err := m.Fwd.Connect(m.Cfg.Namespace, selector, m.Cfg.InsideK8s); err != nil {
+		return errors.Wrapf(err, "failed to connect restarted chart %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type resettableChart struct {
+	namedChart
+}
+
+func (c resettableChart) Reset(e *Environment) error {
+	return nil
+}
+
+type stagedResetChart struct {
+	resettableChart
+	stage int
+}
+
+func (c stagedResetChart) ResetStage() int {
+	return c.stage
+}
+
+func TestResetOrder(t *testing.T) {
+	charts := []ConnectedChart{
+		stagedResetChart{resettableChart{namedChart{name: "chainlink-0"}}, ResetStageNodes},
+		resettableChart{namedChart{name: "geth"}},
+		namedChart{name: "blockscout"},
+		stagedResetChart{resettableChart{namedChart{name: "chainlink-1"}}, ResetStageNodes},
+		resettableChart{namedChart{name: "mockserver"}},
+	}
+	names := make([]string, 0)
+	for _, c := range resetOrder(charts) {
+		names = append(names, c.GetName())
+	}
+	require.Equal(t, []string{"geth", "mockserver", "chainlink-0", "chainlink-1"}, names)
+}
@@ -0,0 +1,179 @@
+package environment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/constructs-go/constructs/v10"
+	cdk8s "github.com/cdk8s-team/cdk8s-core-go/cdk8s/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+)
+
+// Autoscaling generates a HorizontalPodAutoscaler of a chart workload
+type Autoscaling struct {
+	// Workload is a name of the scaled Deployment or StatefulSet, the first workload of the chart if empty
+	Workload    string
+	MinReplicas int
+	MaxReplicas int
+	// TargetCPUUtilization and TargetMemoryUtilization are average utilization percents of requests,
+	// memory targets need autoscaling/v2beta2 or newer
+	TargetCPUUtilization    int
+	TargetMemoryUtilization int
+}
+
+// Validate checks replicas bounds and targets
+func (s *Autoscaling) Validate() error {
+	if s.MinReplicas < 1 || s.MaxReplicas < s.MinReplicas {
+		return errors.Errorf("autoscaling needs 1 <= min replicas <= max replicas, got %d and %d", s.MinReplicas, s.MaxReplicas)
+	}
+	if s.TargetCPUUtilization <= 0 && s.TargetMemoryUtilization <= 0 {
+		return errors.New("autoscaling needs a CPU or memory utilization target")
+	}
+	return nil
+}
+
+// DisruptionBudget generates a PodDisruptionBudget of chart pods, so voluntary disruptions, like node drains, keep enough of them
+type DisruptionBudget struct {
+	// MinAvailable or MaxUnavailable is a number or a percent of pods, e.g. "1" or "50%"
+	MinAvailable   string
+	MaxUnavailable string
+	// Selector labels of pods, the release label of the chart if empty
+	Selector map[string]string
+}
+
+// Validate checks that exactly one of the bounds is set
+func (b *DisruptionBudget) Validate() error {
+	if (b.MinAvailable == "") == (b.MaxUnavailable == "") {
+		return errors.New("disruption budget needs either min available or max unavailable")
+	}
+	return nil
+}
+
+// ResilientChart is a chart with an autoscaler or a disruption budget, nil skips them,
+// they are generated in API versions served by the cluster as a part of the chart release
+type ResilientChart interface {
+	ConnectedChart
+	Autoscaling() *Autoscaling
+	DisruptionBudget() *DisruptionBudget
+}
+
+// workloadRef is a Deployment or a StatefulSet of a chart
+type workloadRef struct {
+	Kind string
+	Name string
+}
+
+// addResilience adds an autoscaler and a disruption budget of the chart
+func (m *Environment) addResilience(scope constructs.Construct, name string, chart ConnectedChart, workloads []workloadRef) {
+	rc, ok := chart.(ResilientChart)
+	if !ok {
+		return
+	}
+	hpaVersion, pdbVersion := client.HorizontalPodAutoscalerVersions[0], client.PodDisruptionBudgetVersions[0]
+	if caps, err := m.Client.Capabilities(); err == nil {
+		hpaVersion, pdbVersion = caps.HorizontalPodAutoscaler, caps.PodDisruptionBudget
+	}
+	labels := a.ConvertLabelsMap(map[string]string{pkg.ReleaseLabelKey: name})
+	if s := rc.Autoscaling(); s != nil {
+		spec, err := autoscalerSpec(*s, hpaVersion, workloads)
+		if err != nil {
+			log.Fatal().Err(err).Str("Chart", name).Msg("Failed to generate autoscaler")
+		}
+		obj := cdk8s.NewApiObject(scope, a.Str(fmt.Sprintf("%s-hpa", name)), &cdk8s.ApiObjectProps{
+			ApiVersion: a.Str(hpaVersion),
+			Kind:       a.Str("HorizontalPodAutoscaler"),
+			Metadata:   &cdk8s.ApiObjectMetadata{Name: a.Str(name), Labels: labels},
+		})
+		obj.AddJsonPatch(cdk8s.JsonPatch_Add(a.Str("/spec"), spec))
+	}
+	if b := rc.DisruptionBudget(); b != nil {
+		obj := cdk8s.NewApiObject(scope, a.Str(fmt.Sprintf("%s-pdb", name)), &cdk8s.ApiObjectProps{
+			ApiVersion: a.Str(pdbVersion),
+			Kind:       a.Str("PodDisruptionBudget"),
+			Metadata:   &cdk8s.ApiObjectMetadata{Name: a.Str(name), Labels: labels},
+		})
+		obj.AddJsonPatch(cdk8s.JsonPatch_Add(a.Str("/spec"), disruptionBudgetSpec(name, *b)))
+	}
+}
+
+// autoscalerSpec returns a spec of an autoscaler in the API version, autoscaling/v1 only supports a CPU target
+func autoscalerSpec(s Autoscaling, apiVersion string, workloads []workloadRef) (map[string]interface{}, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	var target workloadRef
+	for _, w := range workloads {
+		if s.Workload == "" || w.Name == s.Workload {
+			target = w
+			break
+		}
+	}
+	if target.Name == "" {
+		return nil, errors.Errorf("no workload %q to autoscale", s.Workload)
+	}
+	spec := map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       target.Kind,
+			"name":       target.Name,
+		},
+		"minReplicas": s.MinReplicas,
+		"maxReplicas": s.MaxReplicas,
+	}
+	if strings.HasSuffix(apiVersion, "/v1") {
+		if s.TargetMemoryUtilization > 0 {
+			return nil, errors.Errorf("memory utilization target is not supported by %s", apiVersion)
+		}
+		spec["targetCPUUtilizationPercentage"] = s.TargetCPUUtilization
+		return spec, nil
+	}
+	metrics := make([]interface{}, 0)
+	for _, t := range []struct {
+		resource    string
+		utilization int
+	}{{"cpu", s.TargetCPUUtilization}, {"memory", s.TargetMemoryUtilization}} {
+		if t.utilization <= 0 {
+			continue
+		}
+		metrics = append(metrics, map[string]interface{}{
+			"type": "Resource",
+			"resource": map[string]interface{}{
+				"name":   t.resource,
+				"target": map[string]interface{}{"type": "Utilization", "averageUtilization": t.utilization},
+			},
+		})
+	}
+	spec["metrics"] = metrics
+	return spec, nil
+}
+
+// disruptionBudgetSpec returns a spec of a disruption budget, it's the same in policy/v1beta1 and policy/v1
+func disruptionBudgetSpec(release string, b DisruptionBudget) map[string]interface{} {
+	selector := b.Selector
+	if len(selector) == 0 {
+		selector = map[string]string{pkg.ReleaseLabelKey: release}
+	}
+	spec := map[string]interface{}{
+		"selector": map[string]interface{}{"matchLabels": selector},
+	}
+	if b.MinAvailable != "" {
+		spec["minAvailable"] = intOrPercent(b.MinAvailable)
+	}
+	if b.MaxUnavailable != "" {
+		spec["maxUnavailable"] = intOrPercent(b.MaxUnavailable)
+	}
+	return spec
+}
+
+// intOrPercent returns a number of pods as an int and a percent as a string
+func intOrPercent(v string) interface{} {
+	if n, err := strconv.Atoi(v); err == nil {
+		return n
+	}
+	return v
+}
@@ -0,0 +1,37 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoscalerSpec(t *testing.T) {
+	workloads := []workloadRef{{Kind: "StatefulSet", Name: "chainlink-0"}, {Kind: "Deployment", Name: "chainlink-0-db"}}
+	s := Autoscaling{Workload: "chainlink-0-db", MinReplicas: 1, MaxReplicas: 3, TargetCPUUtilization: 80, TargetMemoryUtilization: 70}
+	spec, err := autoscalerSpec(s, "autoscaling/v2", workloads)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "chainlink-0-db"}, spec["scaleTargetRef"])
+	require.Len(t, spec["metrics"], 2)
+
+	_, err = autoscalerSpec(s, "autoscaling/v1", workloads)
+	require.Error(t, err, "memory target is not supported by autoscaling/v1")
+	s.Workload = ""
+	s.TargetMemoryUtilization = 0
+	spec, err = autoscalerSpec(s, "autoscaling/v1", workloads)
+	require.NoError(t, err)
+	require.Equal(t, "chainlink-0", spec["scaleTargetRef"].(map[string]interface{})["name"])
+	require.Equal(t, 80, spec["targetCPUUtilizationPercentage"])
+
+	s.Workload = "geth"
+	_, err = autoscalerSpec(s, "autoscaling/v2", workloads)
+	require.Error(t, err)
+}
+
+func TestDisruptionBudgetSpec(t *testing.T) {
+	require.Equal(t, map[string]interface{}{
+		"selector":     map[string]interface{}{"matchLabels": map[string]string{"chainlink-env/release": "chainlink-0"}},
+		"minAvailable": 1,
+	}, disruptionBudgetSpec("chainlink-0", DisruptionBudget{MinAvailable: "1"}))
+	require.Equal(t, "50%", disruptionBudgetSpec("chainlink-0", DisruptionBudget{MaxUnavailable: "50%"})["maxUnavailable"])
+}
@@ -0,0 +1,303 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ResourceBudget caps total requests and limits of all environment pods, quantities are in K8s notation,
+// e.g. "8" or "8000m" CPU and "16Gi" memory, empty ones and 0 pods are not checked
+type ResourceBudget struct {
+	CPURequests    string
+	CPULimits      string
+	MemoryRequests string
+	MemoryLimits   string
+	// Storage requested by PVCs and StatefulSet volume claim templates
+	Storage string
+	Pods    int
+}
+
+// ComponentResources is a sum of requests and limits of all pods of a chart, CPU is in millicores, memory and storage in bytes
+type ComponentResources struct {
+	Component      string `json:"component"`
+	Pods           int    `json:"pods"`
+	CPURequests    int64  `json:"cpu_requests_millicores"`
+	CPULimits      int64  `json:"cpu_limits_millicores"`
+	MemoryRequests int64  `json:"memory_requests_bytes"`
+	MemoryLimits   int64  `json:"memory_limits_bytes"`
+	Storage        int64  `json:"storage_bytes"`
+}
+
+func (c *ComponentResources) add(o ComponentResources) {
+	c.Pods += o.Pods
+	c.CPURequests += o.CPURequests
+	c.CPULimits += o.CPULimits
+	c.MemoryRequests += o.MemoryRequests
+	c.MemoryLimits += o.MemoryLimits
+	c.Storage += o.Storage
+}
+
+// Estimate is a summary of resources the environment requests, see Environment.Estimate
+type Estimate struct {
+	Components []ComponentResources `json:"components"`
+	Total      ComponentResources   `json:"total"`
+}
+
+// Estimate sums resources of the synthesized manifest before anything is applied, nothing is sent to the cluster,
+// so CI can reject oversized environments early, it fails if the total exceeds Config.ResourceBudget
+func (m *Environment) Estimate() (*Estimate, error) {
+	manifest, err := m.manifest()
+	if err != nil {
+		return nil, err
+	}
+	components, err := manifestResources(manifest)
+	if err != nil {
+		return nil, err
+	}
+	est := &Estimate{Components: components, Total: totalResources(components)}
+	log.Info().Str("Namespace", m.Cfg.Namespace).Msgf("Resources estimate\n%s", resourcesTable(components))
+	if m.Cfg.ResourceBudget != nil {
+		return est, checkBudget(est.Total, *m.Cfg.ResourceBudget)
+	}
+	return est, nil
+}
+
+// resourcesObject is a part of a workload we need to sum its resources
+type resourcesObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+		Template struct {
+			Spec coreV1.PodSpec `json:"spec"`
+		} `json:"template"`
+		VolumeClaimTemplates []coreV1.PersistentVolumeClaim `json:"volumeClaimTemplates"`
+		// Resources of a PVC
+		Resources coreV1.ResourceRequirements `json:"resources"`
+	} `json:"spec"`
+}
+
+// storage bytes requested by a PVC or volume claim templates of all StatefulSet pods
+func (o resourcesObject) storage(pods int) int64 {
+	if o.Kind == "PersistentVolumeClaim" {
+		return o.Spec.Resources.Requests.Storage().Value()
+	}
+	var storage int64
+	for _, t := range o.Spec.VolumeClaimTemplates {
+		storage += t.Spec.Resources.Requests.Storage().Value()
+	}
+	return storage * int64(pods)
+}
+
+// manifestResources sums requests and limits of workloads in the manifest per release, sorted by release name,
+// workloads without a release label are summed as "-"
+func manifestResources(manifest string) ([]ComponentResources, error) {
+	components := make(map[string]*ComponentResources)
+	docs, err := splitManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		var obj resourcesObject
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil {
+			return nil, errors.Wrap(err, "failed to parse manifest")
+		}
+		pods := workloadPods(obj.Kind, obj.Spec.Replicas)
+		storage := obj.storage(pods)
+		if pods == 0 && storage == 0 {
+			continue
+		}
+		name := obj.Metadata.Labels[pkg.ReleaseLabelKey]
+		if name == "" {
+			name = usageUnlabeled
+		}
+		c, ok := components[name]
+		if !ok {
+			c = &ComponentResources{Component: name}
+			components[name] = c
+		}
+		pod := podResources(obj.Spec.Template.Spec)
+		c.add(ComponentResources{
+			Pods:           pods,
+			CPURequests:    pod.CPURequests * int64(pods),
+			CPULimits:      pod.CPULimits * int64(pods),
+			MemoryRequests: pod.MemoryRequests * int64(pods),
+			MemoryLimits:   pod.MemoryLimits * int64(pods),
+			Storage:        storage,
+		})
+	}
+	res := make([]ComponentResources, 0, len(components))
+	for _, c := range components {
+		res = append(res, *c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Component < res[j].Component
+	})
+	return res, nil
+}
+
+// podResources sums requests and limits of pod containers, init containers run one at a time before them,
+// so the largest of them counts if it's larger, the same way the scheduler does
+func podResources(spec coreV1.PodSpec) ComponentResources {
+	var r ComponentResources
+	for _, c := range spec.Containers {
+		r.CPURequests += c.Resources.Requests.Cpu().MilliValue()
+		r.CPULimits += c.Resources.Limits.Cpu().MilliValue()
+		r.MemoryRequests += c.Resources.Requests.Memory().Value()
+		r.MemoryLimits += c.Resources.Limits.Memory().Value()
+	}
+	for _, c := range spec.InitContainers {
+		r.CPURequests = max64(r.CPURequests, c.Resources.Requests.Cpu().MilliValue())
+		r.CPULimits = max64(r.CPULimits, c.Resources.Limits.Cpu().MilliValue())
+		r.MemoryRequests = max64(r.MemoryRequests, c.Resources.Requests.Memory().Value())
+		r.MemoryLimits = max64(r.MemoryLimits, c.Resources.Limits.Memory().Value())
+	}
+	return r
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// totalResources sums resources of all components
+func totalResources(components []ComponentResources) ComponentResources {
+	total := ComponentResources{Component: "TOTAL"}
+	for _, c := range components {
+		total.add(c)
+	}
+	return total
+}
+
+// checkBudget returns an error if the total exceeds any of the budget quantities
+func checkBudget(total ComponentResources, budget ResourceBudget) error {
+	if budget.Pods != 0 && total.Pods > budget.Pods {
+		return errors.Errorf("environment pods %d exceed the budget %d", total.Pods, budget.Pods)
+	}
+	checks := []struct {
+		name   string
+		budget string
+		value  int64
+		milli  bool
+	}{
+		{"CPU requests", budget.CPURequests, total.CPURequests, true},
+		{"CPU limits", budget.CPULimits, total.CPULimits, true},
+		{"memory requests", budget.MemoryRequests, total.MemoryRequests, false},
+		{"memory limits", budget.MemoryLimits, total.MemoryLimits, false},
+		{"storage", budget.Storage, total.Storage, false},
+	}
+	for _, c := range checks {
+		if c.budget == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(c.budget)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s budget", c.name)
+		}
+		if c.milli && c.value > q.MilliValue() {
+			return errors.Errorf("environment %s %dm exceed the budget %s", c.name, c.value, c.budget)
+		}
+		if !c.milli && c.value > q.Value() {
+			return errors.Errorf("environment %s %s exceed the budget %s", c.name, formatBytes(c.value), c.budget)
+		}
+	}
+	return nil
+}
+
+// quotaBudget returns a budget of hard ResourceQuota limits, "cpu" and "memory" are the same as requests
+func quotaBudget(quota coreV1.ResourceQuota) ResourceBudget {
+	hard := func(names ...coreV1.ResourceName) string {
+		for _, n := range names {
+			if q, ok := quota.Spec.Hard[n]; ok {
+				return q.String()
+			}
+		}
+		return ""
+	}
+	budget := ResourceBudget{
+		CPURequests:    hard(coreV1.ResourceRequestsCPU, coreV1.ResourceCPU),
+		CPULimits:      hard(coreV1.ResourceLimitsCPU),
+		MemoryRequests: hard(coreV1.ResourceRequestsMemory, coreV1.ResourceMemory),
+		MemoryLimits:   hard(coreV1.ResourceLimitsMemory),
+		Storage:        hard(coreV1.ResourceRequestsStorage),
+	}
+	if q, ok := quota.Spec.Hard[coreV1.ResourcePods]; ok {
+		budget.Pods = int(q.Value())
+	}
+	return budget
+}
+
+// checkResources sums resources of the manifest and fails if they exceed Config.ResourceBudget or ResourceQuotas
+// of the namespace if it exists, the whole environment is compared with a quota, because it's all the namespace has
+func (m *Environment) checkResources(manifest string) error {
+	components, err := manifestResources(manifest)
+	if err != nil {
+		return err
+	}
+	m.Resources = components
+	total := totalResources(components)
+	if m.Cfg.ResourceBudget != nil {
+		if err := checkBudget(total, *m.Cfg.ResourceBudget); err != nil {
+			return err
+		}
+	}
+	if m.Cfg.DryRun || !m.Client.NamespaceExists(m.Cfg.Namespace) {
+		return nil
+	}
+	quotas, err := m.Client.ClientSet.CoreV1().ResourceQuotas(m.Cfg.Namespace).List(context.Background(), metaV1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list resource quotas")
+	}
+	for _, q := range quotas.Items {
+		if err := checkBudget(total, quotaBudget(q)); err != nil {
+			return errors.Wrapf(err, "resource quota %s", q.Name)
+		}
+	}
+	return nil
+}
+
+// printResources logs requests and limits of the deployed components
+func (m *Environment) printResources() {
+	if len(m.Resources) == 0 {
+		return
+	}
+	log.Info().Str("Namespace", m.Cfg.Namespace).Msgf("Resources summary\n%s", resourcesTable(m.Resources))
+}
+
+// resourcesTable renders components resources as a text table with the total row
+func resourcesTable(components []ComponentResources) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tPODS\tCPU REQUESTS\tCPU LIMITS\tMEMORY REQUESTS\tMEMORY LIMITS\tSTORAGE")
+	rows := make([]ComponentResources, 0, len(components)+1)
+	rows = append(rows, components...)
+	rows = append(rows, totalResources(components))
+	for _, c := range rows {
+		fmt.Fprintf(w, "%s\t%d\t%dm\t%dm\t%s\t%s\t%s\n",
+			c.Component,
+			c.Pods,
+			c.CPURequests,
+			c.CPULimits,
+			formatBytes(c.MemoryRequests),
+			formatBytes(c.MemoryLimits),
+			formatBytes(c.Storage),
+		)
+	}
+	_ = w.Flush()
+	return sb.String()
+}
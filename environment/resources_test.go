@@ -0,0 +1,122 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const resourcesManifest = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: chainlink-0
+  labels:
+    chainlink-env/release: chainlink-0
+spec:
+  replicas: 2
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          resources:
+            requests:
+              cpu: 2
+      containers:
+        - name: node
+          resources:
+            requests:
+              cpu: 500m
+              memory: 512Mi
+            limits:
+              cpu: 1
+              memory: 1Gi
+        - name: db
+          resources:
+            requests:
+              cpu: 250m
+              memory: 256Mi
+  volumeClaimTemplates:
+    - metadata:
+        name: data
+      spec:
+        resources:
+          requests:
+            storage: 1Gi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: chainlink-0
+  labels:
+    chainlink-env/release: chainlink-0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: geth
+  labels:
+    chainlink-env/release: geth
+spec:
+  template:
+    spec:
+      containers:
+        - name: geth
+          resources:
+            requests:
+              cpu: 100m
+              memory: 128Mi
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: geth-data
+  labels:
+    chainlink-env/release: geth
+spec:
+  resources:
+    requests:
+      storage: 5Gi`
+
+func TestManifestResources(t *testing.T) {
+	components, err := manifestResources(resourcesManifest)
+	require.NoError(t, err)
+	require.Equal(t, []ComponentResources{
+		{
+			Component:      "chainlink-0",
+			Pods:           2,
+			CPURequests:    4000,
+			CPULimits:      2000,
+			MemoryRequests: 2 * 768 * 1024 * 1024,
+			MemoryLimits:   2 * 1024 * 1024 * 1024,
+			Storage:        2 * 1024 * 1024 * 1024,
+		},
+		{
+			Component:      "geth",
+			Pods:           1,
+			CPURequests:    100,
+			MemoryRequests: 128 * 1024 * 1024,
+			Storage:        5 * 1024 * 1024 * 1024,
+		},
+	}, components)
+	total := totalResources(components)
+	require.Equal(t, 3, total.Pods)
+	require.Equal(t, int64(4100), total.CPURequests)
+	require.Equal(t, int64(7*1024*1024*1024), total.Storage)
+}
+
+func TestCheckBudget(t *testing.T) {
+	total := ComponentResources{
+		Pods:           3,
+		CPURequests:    4100,
+		MemoryRequests: 1664 * 1024 * 1024,
+		MemoryLimits:   2 * 1024 * 1024 * 1024,
+		Storage:        7 * 1024 * 1024 * 1024,
+	}
+	require.NoError(t, checkBudget(total, ResourceBudget{}))
+	require.NoError(t, checkBudget(total, ResourceBudget{CPURequests: "5", MemoryLimits: "2Gi"}))
+	require.Error(t, checkBudget(total, ResourceBudget{CPURequests: "4"}))
+	require.Error(t, checkBudget(total, ResourceBudget{MemoryRequests: "1Gi"}))
+	require.Error(t, checkBudget(total, ResourceBudget{CPULimits: "many"}))
+	require.Error(t, checkBudget(total, ResourceBudget{Storage: "5Gi"}))
+	require.Error(t, checkBudget(total, ResourceBudget{Pods: 2}))
+}
@@ -0,0 +1,109 @@
+package environment
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultRetryAttempts = 3
+)
+
+// RetryOptions test retry options
+type RetryOptions struct {
+	// Attempts how many times the test is run, each time in a fresh environment, DefaultRetryAttempts if 0
+	Attempts int
+	// ArtifactsDir artifacts of every failed attempt are written into ${ArtifactsDir}/${namespace}-attempt-${n}, "logs" if empty
+	ArtifactsDir string
+	// DBName database to dump, "chainlink" by default
+	DBName string
+}
+
+// AttemptResult result of one test attempt
+type AttemptResult struct {
+	Attempt      int
+	Namespace    string
+	Duration     time.Duration
+	ArtifactsDir string
+	Err          error
+}
+
+// RunWithRetry builds an environment with newEnv, runs it and the test, on failure it dumps artifacts, removes
+// the environment and retries with a fresh one, environment of the successful attempt is returned and should be shut down by the caller,
+// use it for suites failing because of infrastructure flakes
+func RunWithRetry(newEnv func() *Environment, opts *RetryOptions, test func(e *Environment) error) (*Environment, []AttemptResult, error) {
+	if opts == nil {
+		opts = &RetryOptions{}
+	}
+	if opts.Attempts == 0 {
+		opts.Attempts = DefaultRetryAttempts
+	}
+	if opts.ArtifactsDir == "" {
+		opts.ArtifactsDir = "logs"
+	}
+	if opts.DBName == "" {
+		opts.DBName = "chainlink"
+	}
+	results := make([]AttemptResult, 0)
+	for attempt := 1; attempt <= opts.Attempts; attempt++ {
+		e := newEnv()
+		// keep the namespace on deployment failure so artifacts can be collected
+		e.Cfg.FailureBehavior = FailureBehaviorKeep
+		start := e.Client.Clock().Now()
+		err := e.Run()
+		if err == nil {
+			err = test(e)
+		}
+		res := AttemptResult{
+			Attempt:   attempt,
+			Namespace: e.Cfg.Namespace,
+			Duration:  e.Client.Clock().Since(start),
+			Err:       err,
+		}
+		if err == nil {
+			log.Info().Int("Attempt", attempt).Str("Namespace", e.Cfg.Namespace).Msg("Test passed")
+			results = append(results, res)
+			return e, results, nil
+		}
+		log.Warn().Err(err).Int("Attempt", attempt).Int("Attempts", opts.Attempts).Str("Namespace", e.Cfg.Namespace).Msg("Test failed")
+		res.ArtifactsDir = filepath.Join(opts.ArtifactsDir, fmt.Sprintf("%s-attempt-%d", e.Cfg.Namespace, attempt))
+		if dumpErr := e.dumpAttempt(res.ArtifactsDir, opts.DBName); dumpErr != nil {
+			log.Error().Err(dumpErr).Str("Dir", res.ArtifactsDir).Msg("Failed to dump attempt artifacts")
+		}
+		if shutdownErr := e.Shutdown(); shutdownErr != nil {
+			log.Error().Err(shutdownErr).Str("Namespace", e.Cfg.Namespace).Msg("Failed to remove environment")
+		}
+		results = append(results, res)
+	}
+	return nil, results, errors.Errorf("test failed in all %d attempts: %s", opts.Attempts, attemptErrors(results))
+}
+
+// dumpAttempt writes artifacts of the environment, collected reports are written too if the environment was ready
+func (m *Environment) dumpAttempt(dir string, dbName string) error {
+	arts := m.Artifacts
+	if arts == nil {
+		var err error
+		if arts, err = NewArtifacts(m.Client, m.Cfg.Namespace); err != nil {
+			return err
+		}
+		arts.Drift = m.Drift
+		arts.Pausables = m.pausables
+		if arts.LogFilter, err = m.logFilter(); err != nil {
+			return err
+		}
+	}
+	return arts.DumpTestResult(dir, dbName)
+}
+
+func attemptErrors(results []AttemptResult) string {
+	errs := make([]string, 0)
+	for _, r := range results {
+		errs = append(errs, fmt.Sprintf("attempt %d: %s", r.Attempt, r.Err))
+	}
+	return strings.Join(errs, "; ")
+}
@@ -0,0 +1,192 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/config"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// SeedAnnotationKey namespace annotation with the seed of generated values
+	SeedAnnotationKey = "chainlink-env/seed"
+	// GeneratedAnnotationKey namespace annotation with generated values in JSON
+	GeneratedAnnotationKey = "chainlink-env/generated"
+	// ReproducibilityReport artifacts report with the seed and generated values
+	ReproducibilityReport = "reproducibility"
+)
+
+const (
+	generatedPortMin      = 20000
+	generatedPortRange    = 20000
+	generatedChainIDMin   = 1000
+	generatedChainIDRange = 1000000
+	passwordLength        = 16
+	passwordChars         = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// Reproducibility is a record of nondeterministic choices of an environment, values are generated from the seed by key,
+// so running with the same Config.Seed or CHAINLINK_ENV_SEED reproduces them regardless of the order they are generated in
+type Reproducibility struct {
+	Seed      int64
+	Namespace string
+	Generated map[string]string
+}
+
+// newSeed returns the seed of the config, the environment variable or a random one
+func newSeed(seed int64) (int64, error) {
+	if seed != 0 {
+		return seed, nil
+	}
+	if s := os.Getenv(config.EnvVarSeed); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid %s", config.EnvVarSeed)
+		}
+		return seed, nil
+	}
+	return time.Now().UnixNano(), nil
+}
+
+// keyRand returns a source of values of a key, it depends only on the seed and the key
+func keyRand(seed int64, key string) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+// Generate returns a value of the key generated from the environment seed and records it,
+// the same key returns the recorded value, e.g. of an environment connected to
+func (m *Environment) Generate(key string, gen func(r *rand.Rand) string) string {
+	m.seedMu.Lock()
+	defer m.seedMu.Unlock()
+	if v, ok := m.generated[key]; ok {
+		return v
+	}
+	v := gen(keyRand(m.Seed, key))
+	m.generated[key] = v
+	return v
+}
+
+// GeneratePassword returns a generated alphanumeric password of the key
+func (m *Environment) GeneratePassword(key string) string {
+	return m.Generate("password/"+key, func(r *rand.Rand) string {
+		b := make([]byte, passwordLength)
+		for i := range b {
+			b[i] = passwordChars[r.Intn(len(passwordChars))]
+		}
+		return string(b)
+	})
+}
+
+// GenerateChainID returns a generated EVM chain ID of the key
+func (m *Environment) GenerateChainID(key string) int64 {
+	v := m.Generate("chain-id/"+key, func(r *rand.Rand) string {
+		return strconv.FormatInt(generatedChainIDMin+r.Int63n(generatedChainIDRange), 10)
+	})
+	id, _ := strconv.ParseInt(v, 10, 64)
+	return id
+}
+
+// GeneratePort returns a generated local port of the key, forwarded ports are generated with it
+func (m *Environment) GeneratePort(key string) uint16 {
+	v := m.Generate("port/"+key, func(r *rand.Rand) string {
+		return strconv.Itoa(generatedPortMin + r.Intn(generatedPortRange))
+	})
+	port, _ := strconv.ParseUint(v, 10, 16)
+	return uint16(port)
+}
+
+// namespaceSuffix generates a namespace suffix, the same length as the former random one
+func namespaceSuffix(r *rand.Rand) string {
+	return fmt.Sprintf("%05x", r.Int63n(1<<20))
+}
+
+// Reproducibility returns the seed and all values generated so far
+func (m *Environment) Reproducibility() Reproducibility {
+	m.seedMu.Lock()
+	defer m.seedMu.Unlock()
+	generated := make(map[string]string, len(m.generated))
+	for k, v := range m.generated {
+		generated[k] = v
+	}
+	return Reproducibility{Seed: m.Seed, Namespace: m.Cfg.Namespace, Generated: generated}
+}
+
+// recordReproducibility stores the seed and generated values in the namespace and artifacts
+func (m *Environment) recordReproducibility() error {
+	r := m.Reproducibility()
+	generated, err := json.Marshal(r.Generated)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				SeedAnnotationKey:      strconv.FormatInt(r.Seed, 10),
+				GeneratedAnnotationKey: string(generated),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.Client.ClientSet.CoreV1().Namespaces().Patch(context.Background(), m.Cfg.Namespace, types.MergePatchType, patch, metaV1.PatchOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to record environment seed")
+	}
+	if m.Artifacts != nil {
+		m.Artifacts.AddReport(ReproducibilityReport, r)
+	}
+	log.Info().Int64("Seed", r.Seed).Str("Reproduce", fmt.Sprintf("%s=%d", config.EnvVarSeed, r.Seed)).Msg("Environment seed")
+	return nil
+}
+
+// loadReproducibility restores the seed and generated values of an environment connected to,
+// so values generated later are the same as in the run which created it
+func (m *Environment) loadReproducibility(namespace string) error {
+	ns, err := m.Client.ClientSet.CoreV1().Namespaces().Get(context.Background(), namespace, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	seed, generated, err := parseReproducibility(ns.Annotations)
+	if err != nil || seed == 0 {
+		return err
+	}
+	m.seedMu.Lock()
+	defer m.seedMu.Unlock()
+	m.Seed = seed
+	for k, v := range generated {
+		m.generated[k] = v
+	}
+	return nil
+}
+
+// parseReproducibility returns the seed and generated values from namespace annotations, 0 if there is no seed
+func parseReproducibility(annotations map[string]string) (int64, map[string]string, error) {
+	generated := make(map[string]string)
+	s, ok := annotations[SeedAnnotationKey]
+	if !ok {
+		return 0, generated, nil
+	}
+	seed, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "invalid %s annotation", SeedAnnotationKey)
+	}
+	if raw, ok := annotations[GeneratedAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(raw), &generated); err != nil {
+			return 0, nil, errors.Wrapf(err, "invalid %s annotation", GeneratedAnnotationKey)
+		}
+	}
+	return seed, generated, nil
+}
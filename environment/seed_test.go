@@ -0,0 +1,44 @@
+package environment
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	e := &Environment{Cfg: &Config{}, Seed: 42, seedMu: &sync.Mutex{}, generated: make(map[string]string)}
+	ns := e.Generate("namespace", namespaceSuffix)
+	require.Len(t, ns, 5)
+	pass := e.GeneratePassword("db")
+	require.Len(t, pass, passwordLength)
+	require.Equal(t, pass, e.GeneratePassword("db"))
+	require.NotEqual(t, pass, e.GeneratePassword("node"))
+	port := e.GeneratePort("geth:0/geth-network/ws-rpc")
+	require.GreaterOrEqual(t, port, uint16(generatedPortMin))
+
+	// the same seed reproduces values regardless of the order
+	other := &Environment{Cfg: &Config{}, Seed: 42, seedMu: &sync.Mutex{}, generated: make(map[string]string)}
+	require.Equal(t, port, other.GeneratePort("geth:0/geth-network/ws-rpc"))
+	require.Equal(t, pass, other.GeneratePassword("db"))
+	require.Equal(t, ns, other.Generate("namespace", namespaceSuffix))
+}
+
+func TestParseReproducibility(t *testing.T) {
+	seed, generated, err := parseReproducibility(map[string]string{})
+	require.NoError(t, err)
+	require.Zero(t, seed)
+	require.Empty(t, generated)
+
+	seed, generated, err = parseReproducibility(map[string]string{
+		SeedAnnotationKey:      "42",
+		GeneratedAnnotationKey: `{"namespace":"0a1b2"}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(42), seed)
+	require.Equal(t, map[string]string{"namespace": "0a1b2"}, generated)
+
+	_, _, err = parseReproducibility(map[string]string{SeedAnnotationKey: "seed"})
+	require.Error(t, err)
+}
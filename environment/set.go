@@ -0,0 +1,88 @@
+package environment
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	DefaultSetParallelism = 5
+)
+
+// EnvironmentSet runs and removes many environments concurrently with bounded parallelism,
+// for example, a test matrix deployed from one process
+type EnvironmentSet struct {
+	Environments []*Environment
+	Parallelism  int
+	mu           sync.Mutex
+	errs         map[string]error
+}
+
+// NewEnvironmentSet creates a new set, parallelism is a max number of environments deployed or removed at once
+func NewEnvironmentSet(parallelism int) *EnvironmentSet {
+	if parallelism <= 0 {
+		parallelism = DefaultSetParallelism
+	}
+	return &EnvironmentSet{
+		Environments: make([]*Environment, 0),
+		Parallelism:  parallelism,
+		errs:         make(map[string]error),
+	}
+}
+
+// Add adds environments to the set
+func (s *EnvironmentSet) Add(envs ...*Environment) *EnvironmentSet {
+	s.Environments = append(s.Environments, envs...)
+	return s
+}
+
+// Run runs all environments, it does not stop on the first failure, all environments are deployed,
+// errors are returned together, error of a particular environment is available with Err
+func (s *EnvironmentSet) Run() error {
+	return s.forEach("run", func(e *Environment) error {
+		return e.Run()
+	})
+}
+
+// Shutdown removes all environments
+func (s *EnvironmentSet) Shutdown() error {
+	return s.forEach("shutdown", func(e *Environment) error {
+		return e.Shutdown()
+	})
+}
+
+// Err returns the last error of the environment with the namespace
+func (s *EnvironmentSet) Err(namespace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errs[namespace]
+}
+
+func (s *EnvironmentSet) forEach(action string, f func(e *Environment) error) error {
+	eg := &errgroup.Group{}
+	eg.SetLimit(s.Parallelism)
+	failed := make([]string, 0)
+	for _, e := range s.Environments {
+		e := e
+		eg.Go(func() error {
+			err := f(e)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.errs[e.Cfg.Namespace] = err
+			if err != nil {
+				log.Error().Err(err).Str("Namespace", e.Cfg.Namespace).Str("Action", action).Msg("Environment failed")
+				failed = append(failed, e.Cfg.Namespace)
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	if len(failed) != 0 {
+		return errors.Errorf("%s failed for environments: %s", action, strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package environment
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// ShellTarget is a container of the environment a shell can be opened in
+type ShellTarget struct {
+	Pod       string
+	Container string
+	Release   string
+	App       string
+	Instance  string
+}
+
+func (t ShellTarget) String() string {
+	return fmt.Sprintf("%s:%s %s/%s", t.App, t.Instance, t.Pod, t.Container)
+}
+
+// ShellTargets lists running containers of the environment sorted by app and instance
+func (m *Environment) ShellTargets() ([]ShellTarget, error) {
+	pl, err := m.Client.ListPods(m.Cfg.Namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]ShellTarget, 0)
+	for _, p := range pl.Items {
+		if p.Status.Phase != coreV1.PodRunning {
+			continue
+		}
+		for _, c := range p.Spec.Containers {
+			targets = append(targets, ShellTarget{
+				Pod:       p.Name,
+				Container: c.Name,
+				Release:   p.Labels[pkg.ReleaseLabelKey],
+				App:       p.Labels["app"],
+				Instance:  p.Labels["instance"],
+			})
+		}
+	}
+	sortShellTargets(targets)
+	return targets, nil
+}
+
+func sortShellTargets(targets []ShellTarget) {
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].App != targets[j].App {
+			return targets[i].App < targets[j].App
+		}
+		if targets[i].Instance != targets[j].Instance {
+			return targets[i].Instance < targets[j].Instance
+		}
+		if targets[i].Pod != targets[j].Pod {
+			return targets[i].Pod < targets[j].Pod
+		}
+		return targets[i].Container < targets[j].Container
+	})
+}
+
+// Shell opens an interactive shell in a container, the command is DefaultShellCommand of the client if empty
+func (m *Environment) Shell(t ShellTarget, command ...string) error {
+	return m.Client.ExecInteractive(m.Cfg.Namespace, t.Pod, t.Container, command)
+}
+
+// PromptShell prints numbered containers of the environment to out, reads a choice from in and opens a shell into it
+func (m *Environment) PromptShell(in io.Reader, out io.Writer) error {
+	targets, err := m.ShellTargets()
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return errors.Errorf("no running pods in namespace %s", m.Cfg.Namespace)
+	}
+	for i, t := range targets {
+		fmt.Fprintf(out, "%d) %s\n", i+1, t)
+	}
+	fmt.Fprint(out, "Open a shell in: ")
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	idx, err := parseShellChoice(line, len(targets))
+	if err != nil {
+		return err
+	}
+	return m.Shell(targets[idx])
+}
+
+// parseShellChoice parses a 1-based choice and returns the target index
+func parseShellChoice(input string, targets int) (int, error) {
+	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil {
+		return 0, errors.Errorf("choice must be a number, got %q", strings.TrimSpace(input))
+	}
+	if choice < 1 || choice > targets {
+		return 0, errors.Errorf("choice must be between 1 and %d, got %d", targets, choice)
+	}
+	return choice - 1, nil
+}
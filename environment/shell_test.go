@@ -0,0 +1,29 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShellChoice(t *testing.T) {
+	idx, err := parseShellChoice(" 2\n", 3)
+	require.NoError(t, err)
+	require.Equal(t, 1, idx)
+	_, err = parseShellChoice("4", 3)
+	require.Error(t, err)
+	_, err = parseShellChoice("chainlink", 3)
+	require.Error(t, err)
+}
+
+func TestSortShellTargets(t *testing.T) {
+	targets := []ShellTarget{
+		{App: "geth", Instance: "0", Pod: "geth-0", Container: "geth"},
+		{App: "chainlink-0", Instance: "0", Pod: "chainlink-0-abc", Container: "node"},
+		{App: "chainlink-0", Instance: "0", Pod: "chainlink-0-abc", Container: "chainlink-db"},
+	}
+	sortShellTargets(targets)
+	require.Equal(t, "chainlink-db", targets[0].Container)
+	require.Equal(t, "node", targets[1].Container)
+	require.Equal(t, "geth", targets[2].App)
+}
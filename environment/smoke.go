@@ -0,0 +1,72 @@
+package environment
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// SmokeTest is a quick post-deploy check of a chart, for example, a single RPC call
+type SmokeTest struct {
+	Name string
+	Run  func(e *Environment) error
+}
+
+// SmokeTestedChart is a chart that registers smoke tests, they are executed after readiness as a part of Run
+type SmokeTestedChart interface {
+	ConnectedChart
+	SmokeTests() []SmokeTest
+}
+
+// SmokeTestResult is a result of one chart smoke test
+type SmokeTestResult struct {
+	Chart    string        `json:"chart"`
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RunSmokeTests runs smoke tests of all charts, including gRPC reflection checks of GRPCChart charts, prints per-chart report and returns an error if any test failed
+func (m *Environment) RunSmokeTests() ([]SmokeTestResult, error) {
+	results := make([]SmokeTestResult, 0)
+	failed := make([]string, 0)
+	for _, c := range m.Charts {
+		tests := make([]SmokeTest, 0)
+		if sc, ok := c.(SmokeTestedChart); ok {
+			tests = append(tests, sc.SmokeTests()...)
+		}
+		if gc, ok := c.(GRPCChart); ok {
+			tests = append(tests, grpcSmokeTests(gc)...)
+		}
+		if len(tests) == 0 {
+			continue
+		}
+		m.reportProgress(fmt.Sprintf("Running smoke tests of chart %s", c.GetName()))
+		for _, st := range tests {
+			start := m.clock().Now()
+			err := st.Run(m)
+			r := SmokeTestResult{
+				Chart:    c.GetName(),
+				Name:     st.Name,
+				Passed:   err == nil,
+				Duration: m.clock().Since(start),
+			}
+			if err != nil {
+				r.Error = err.Error()
+				failed = append(failed, fmt.Sprintf("%s/%s", r.Chart, r.Name))
+				log.Error().Err(err).Str("Chart", r.Chart).Str("Test", r.Name).Msg("Smoke test failed")
+			} else {
+				log.Info().Str("Chart", r.Chart).Str("Test", r.Name).Dur("Duration", r.Duration).Msg("Smoke test passed")
+			}
+			results = append(results, r)
+		}
+	}
+	if len(failed) != 0 {
+		return results, errors.Errorf("smoke tests failed: %s", strings.Join(failed, ", "))
+	}
+	return results, nil
+}
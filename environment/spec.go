@@ -0,0 +1,256 @@
+package environment
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"sigs.k8s.io/yaml"
+)
+
+// specEnvVarRe matches ${ENV_VAR} and ${ENV_VAR:-default} references in config files
+var specEnvVarRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Spec is a declarative environment definition, so environment variants can be version-controlled instead of written in Go,
+// durations are Go durations, e.g. "2h"
+type Spec struct {
+	NamespacePrefix   string            `json:"namespacePrefix"`
+	Namespace         string            `json:"namespace"`
+	Labels            map[string]string `json:"labels"`
+	TTL               string            `json:"ttl"`
+	ReadyTimeout      string            `json:"readyTimeout"`
+	ReadySelector     string            `json:"readySelector"`
+	DeployWorkers     int               `json:"deployWorkers"`
+	KeepConnection    bool              `json:"keepConnection"`
+	RemoveOnInterrupt bool              `json:"removeOnInterrupt"`
+	Debug             bool              `json:"debug"`
+	Components        []ComponentSpec   `json:"components"`
+}
+
+// ComponentSpec is a chart of a registered kind, see RegisterComponent
+type ComponentSpec struct {
+	// Kind is a registered component kind, e.g. "geth", "chainlink" or "helm"
+	Kind string `json:"kind"`
+	// Name is a release name of kinds which support custom names
+	Name string `json:"name"`
+	// Index is an index of indexed charts, e.g. chainlink-${index}
+	Index int `json:"index"`
+	// Repo, Chart and Version of a chart from a Helm repository, for the "helm" kind
+	Repo    string `json:"repo"`
+	Chart   string `json:"chart"`
+	Version string `json:"version"`
+	// Values chart values
+	Values map[string]interface{} `json:"values"`
+	// DependsOn charts deployed before this one, see DependsOn
+	DependsOn []string `json:"dependsOn"`
+}
+
+// ComponentFactory creates a chart of a component kind from its spec
+type ComponentFactory func(spec ComponentSpec) (ConnectedChart, error)
+
+var (
+	componentsMu sync.Mutex
+	components   = make(map[string]ComponentFactory)
+)
+
+// RegisterComponent registers a component kind of config files, chart packages register their kinds when they are imported,
+// e.g. importing presets registers all charts of this repository
+func RegisterComponent(kind string, factory ComponentFactory) {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	components[kind] = factory
+}
+
+// registeredComponents returns a copy of registered component kinds, so a spec is built with the same kinds
+// even if more are registered concurrently
+func registeredComponents() map[string]ComponentFactory {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	res := make(map[string]ComponentFactory, len(components))
+	for k, f := range components {
+		res[k] = f
+	}
+	return res
+}
+
+// componentKinds returns sorted kinds of components
+func componentKinds(factories map[string]ComponentFactory) []string {
+	kinds := make([]string, 0, len(factories))
+	for k := range factories {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// NewFromConfigFile creates an environment from a YAML, JSON or TOML spec file, the format is selected by the extension,
+// ${ENV_VAR} references in string values are substituted after parsing, ${ENV_VAR:-default} has a default,
+// unset variables without defaults are errors
+func NewFromConfigFile(path string) (*Environment, error) {
+	spec, err := LoadSpec(path)
+	if err != nil {
+		return nil, err
+	}
+	return spec.New()
+}
+
+// LoadSpec reads a spec file, see NewFromConfigFile
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := parseSpec(filepath.Ext(path), data, os.LookupEnv)
+	return spec, errors.Wrapf(err, "failed to load %s", path)
+}
+
+// parseSpec decodes a spec and expands env vars in its string values, so a substituted value can't break the document
+// or inject keys, unknown fields are errors, so typos don't silently deploy defaults
+func parseSpec(ext string, data []byte, lookup func(string) (string, bool)) (*Spec, error) {
+	var doc interface{}
+	if strings.EqualFold(ext, ".toml") {
+		m := make(map[string]interface{})
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		doc = m
+	} else if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if err := expandEnvVars(doc, lookup); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// expandEnvVars substitutes ${ENV_VAR} and ${ENV_VAR:-default} references in string values of a decoded document in place,
+// all unset variables are reported at once
+func expandEnvVars(doc interface{}, lookup func(string) (string, bool)) error {
+	missing := make(map[string]bool)
+	var expand func(v interface{}) interface{}
+	expand = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case string:
+			return specEnvVarRe.ReplaceAllStringFunc(v, func(ref string) string {
+				m := specEnvVarRe.FindStringSubmatch(ref)
+				if val, ok := lookup(m[1]); ok {
+					return val
+				}
+				if m[2] != "" {
+					return m[3]
+				}
+				missing[m[1]] = true
+				return ref
+			})
+		case map[string]interface{}:
+			for k, e := range v {
+				v[k] = expand(e)
+			}
+		case []interface{}:
+			for i, e := range v {
+				v[i] = expand(e)
+			}
+		case []map[string]interface{}:
+			for _, e := range v {
+				expand(e)
+			}
+		}
+		return v
+	}
+	expand(doc)
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return errors.Errorf("environment variables %s are not set", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// config returns an environment config of the spec
+func (s *Spec) config() (*Config, error) {
+	cfg := &Config{
+		NamespacePrefix:   s.NamespacePrefix,
+		Namespace:         s.Namespace,
+		Labels:            s.Labels,
+		DeployWorkers:     s.DeployWorkers,
+		KeepConnection:    s.KeepConnection,
+		RemoveOnInterrupt: s.RemoveOnInterrupt,
+		Debug:             s.Debug,
+	}
+	if s.TTL != "" {
+		ttl, err := time.ParseDuration(s.TTL)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid ttl")
+		}
+		cfg.TTL = ttl
+	}
+	if s.ReadyTimeout != "" || s.ReadySelector != "" {
+		cfg.ReadyCheckData = &client.ReadyCheckData{ReadinessProbeCheckSelector: s.ReadySelector, Timeout: 8 * time.Minute}
+		if s.ReadyTimeout != "" {
+			timeout, err := time.ParseDuration(s.ReadyTimeout)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid readyTimeout")
+			}
+			cfg.ReadyCheckData.Timeout = timeout
+		}
+	}
+	return cfg, nil
+}
+
+// charts creates charts of all components, nothing is created if any of them fails
+func (s *Spec) charts() ([]ConnectedChart, error) {
+	factories := registeredComponents()
+	charts := make([]ConnectedChart, 0, len(s.Components))
+	for i, c := range s.Components {
+		factory, ok := factories[c.Kind]
+		if !ok {
+			return nil, errors.Errorf("component %d has unknown kind %q, registered kinds are %v, import a package of the chart, e.g. presets",
+				i, c.Kind, componentKinds(factories))
+		}
+		chart, err := factory(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "component %d of kind %s", i, c.Kind)
+		}
+		charts = append(charts, chart)
+	}
+	return charts, nil
+}
+
+// New creates an environment of the spec, the spec is checked before connecting to the cluster
+func (s *Spec) New() (*Environment, error) {
+	cfg, err := s.config()
+	if err != nil {
+		return nil, err
+	}
+	charts, err := s.charts()
+	if err != nil {
+		return nil, err
+	}
+	e := New(cfg)
+	for i, c := range charts {
+		e.AddHelm(c)
+		if deps := s.Components[i].DependsOn; len(deps) > 0 {
+			e.DependsOn(c.GetName(), deps...)
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,99 @@
+package environment
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnvVars(t *testing.T) {
+	env := map[string]string{"CHAINLINK_VERSION": "1.10.0", "EMPTY": ""}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	doc := map[string]interface{}{
+		"version":    "${CHAINLINK_VERSION}",
+		"nodes":      []interface{}{"${NODES:-3}", int64(1)},
+		"components": []map[string]interface{}{{"empty": "${EMPTY:-x}"}},
+		"toml":       "$HOME",
+	}
+	require.NoError(t, expandEnvVars(doc, lookup))
+	require.Equal(t, map[string]interface{}{
+		"version":    "1.10.0",
+		"nodes":      []interface{}{"3", int64(1)},
+		"components": []map[string]interface{}{{"empty": ""}},
+		"toml":       "$HOME",
+	}, doc)
+	err := expandEnvVars([]interface{}{"${C}", map[string]interface{}{"a": "${A} ${B:-b}"}, "${A}"}, lookup)
+	require.EqualError(t, err, "environment variables A, C are not set")
+}
+
+func TestParseSpecExpandsValues(t *testing.T) {
+	injected := "x\"\nnamespace = \"prod"
+	lookup := func(k string) (string, bool) {
+		return injected, k == "PREFIX"
+	}
+	for ext, data := range map[string]string{
+		".yaml": "namespacePrefix: ${PREFIX}\n",
+		".toml": "namespacePrefix = \"${PREFIX}\"\n",
+	} {
+		spec, err := parseSpec(ext, []byte(data), lookup)
+		require.NoError(t, err, ext)
+		require.Equal(t, injected, spec.NamespacePrefix, ext)
+		require.Empty(t, spec.Namespace, ext)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	yamlSpec := `namespacePrefix: chainlink-ocr
+ttl: 2h
+readyTimeout: 10m
+labels:
+  team: qa
+components:
+  - kind: geth
+  - kind: chainlink
+    index: 1
+    values:
+      replicas: 2
+    dependsOn: [geth]
+`
+	tomlSpec := `namespacePrefix = "chainlink-ocr"
+ttl = "2h"
+readyTimeout = "10m"
+labels = { team = "qa" }
+
+[[components]]
+kind = "geth"
+
+[[components]]
+kind = "chainlink"
+index = 1
+values = { replicas = 2 }
+dependsOn = ["geth"]
+`
+	for ext, data := range map[string]string{".yaml": yamlSpec, ".toml": tomlSpec} {
+		spec, err := parseSpec(ext, []byte(data), os.LookupEnv)
+		require.NoError(t, err, ext)
+		require.Equal(t, &Spec{
+			NamespacePrefix: "chainlink-ocr",
+			TTL:             "2h",
+			ReadyTimeout:    "10m",
+			Labels:          map[string]string{"team": "qa"},
+			Components: []ComponentSpec{
+				{Kind: "geth"},
+				{Kind: "chainlink", Index: 1, Values: map[string]interface{}{"replicas": float64(2)}, DependsOn: []string{"geth"}},
+			},
+		}, spec, ext)
+		cfg, err := spec.config()
+		require.NoError(t, err)
+		require.Equal(t, "2h0m0s", cfg.TTL.String())
+		require.Equal(t, "10m0s", cfg.ReadyCheckData.Timeout.String())
+	}
+	_, err := parseSpec(".yaml", []byte("namespacePrefx: typo"), os.LookupEnv)
+	require.Error(t, err, "unknown fields are errors")
+	_, err = (&Spec{Components: []ComponentSpec{{Kind: "unknown"}}}).charts()
+	require.Error(t, err)
+}
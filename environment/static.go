@@ -0,0 +1,107 @@
+package environment
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// attach deploys charts which are not installed in an existing static namespace yet, so a long-lived shared environment
+// is enriched with more components, common resources, including the namespace itself, are not applied to a namespace we don't own
+func (m *Environment) attach() error {
+	m.attached = true
+	manifest, err := m.manifest()
+	if err != nil {
+		return err
+	}
+	releases, _, err := groupManifestByRelease(manifest)
+	if err != nil {
+		return err
+	}
+	installed, err := m.installedReleases()
+	if err != nil {
+		return err
+	}
+	missing := make(map[string]*releaseManifest)
+	for name, rm := range releases {
+		if installed[name] {
+			log.Info().Str("Chart", name).Msg("Chart is installed in the namespace, skipping")
+			continue
+		}
+		missing[name] = rm
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	log.Info().Str("Namespace", m.Cfg.Namespace).Int("Charts", len(missing)).Msg("Deploying charts into the existing namespace")
+	if err := m.deployCharts(missing); err != nil {
+		return err
+	}
+	if err := m.deployReleasesWithoutCharts(missing); err != nil {
+		return err
+	}
+	return m.enumerateApps()
+}
+
+// installedReleases returns releases which have resources in the namespace
+func (m *Environment) installedReleases() (map[string]bool, error) {
+	ctx := context.Background()
+	opts := metaV1.ListOptions{LabelSelector: pkg.ReleaseLabelKey}
+	core := m.Client.ClientSet.CoreV1()
+	installed := make(map[string]bool)
+	add := func(labels map[string]string) {
+		installed[labels[pkg.ReleaseLabelKey]] = true
+	}
+	pods, err := core.Pods(m.Cfg.Namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range pods.Items {
+		add(o.Labels)
+	}
+	services, err := core.Services(m.Cfg.Namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range services.Items {
+		add(o.Labels)
+	}
+	configMaps, err := core.ConfigMaps(m.Cfg.Namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range configMaps.Items {
+		add(o.Labels)
+	}
+	return installed, nil
+}
+
+// markInstalled records a release applied by this process, only these releases are removed from a static namespace
+func (m *Environment) markInstalled(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.installed {
+		if r == name {
+			return
+		}
+	}
+	m.installed = append(m.installed, name)
+}
+
+// removeInstalled removes releases applied by this process in the reverse order, other releases of the namespace stay
+func (m *Environment) removeInstalled() error {
+	m.mu.Lock()
+	installed := append([]string{}, m.installed...)
+	m.mu.Unlock()
+	for i := len(installed) - 1; i >= 0; i-- {
+		name := installed[i]
+		log.Info().Str("Namespace", m.Cfg.Namespace).Str("Chart", name).Msg("Removing chart installed by this process")
+		if err := m.deleteRelease(name); err != nil {
+			return errors.Wrapf(err, "failed to remove chart %s", name)
+		}
+	}
+	return nil
+}
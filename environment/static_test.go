@@ -0,0 +1,72 @@
+package environment
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestMarkInstalled(t *testing.T) {
+	e := &Environment{mu: &sync.Mutex{}}
+	e.markInstalled("geth")
+	e.markInstalled("chainlink-0")
+	e.markInstalled("geth")
+	require.Equal(t, []string{"geth", "chainlink-0"}, e.installed)
+}
+
+func TestInterruptedAttached(t *testing.T) {
+	mu := &sync.Mutex{}
+	deletes := make([]string, 0)
+	podDeleted := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			deletes = append(deletes, r.URL.Path)
+			podDeleted = podDeleted || r.URL.Path == "/api/v1/namespaces/shared/pods/chainlink-0"
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Success"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/api":
+			_, _ = w.Write([]byte(`{"kind":"APIVersions","versions":["v1"]}`))
+		case "/apis":
+			_, _ = w.Write([]byte(`{"kind":"APIGroupList","apiVersion":"v1","groups":[]}`))
+		case "/api/v1":
+			_, _ = w.Write([]byte(`{"kind":"APIResourceList","groupVersion":"v1","resources":[{"name":"pods","namespaced":true,"kind":"Pod","verbs":["list","delete"]}]}`))
+		case "/api/v1/namespaces/shared/pods":
+			if podDeleted || r.URL.Query().Get("labelSelector") != releaseSelector("chainlink-0") {
+				_, _ = w.Write([]byte(`{"kind":"PodList","apiVersion":"v1","items":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"kind":"PodList","apiVersion":"v1","items":[{"kind":"Pod","apiVersion":"v1","metadata":{"name":"chainlink-0","namespace":"shared"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cfg := &rest.Config{Host: srv.URL}
+	cs, err := kubernetes.NewForConfig(cfg)
+	require.NoError(t, err)
+	c := &client.K8sClient{ClientSet: cs, RESTConfig: cfg, LabelDeletedKinds: []schema.GroupKind{{Kind: "Pod"}}}
+	e := &Environment{
+		Cfg:       &Config{Namespace: "shared", KeepConnection: true, RemoveOnInterrupt: true},
+		Client:    c,
+		Fwd:       client.NewForwarder(c, true),
+		mu:        &sync.Mutex{},
+		attached:  true,
+		installed: []string{"chainlink-0"},
+	}
+	require.NoError(t, e.interrupted())
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"/api/v1/namespaces/shared/pods/chainlink-0"}, deletes, "only releases installed by this process are removed")
+}
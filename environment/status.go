@@ -0,0 +1,60 @@
+package environment
+
+// ChartStatus is a deployment status of a chart in the environment
+type ChartStatus string
+
+const (
+	// ChartStatusPending chart is added but not yet deployed
+	ChartStatusPending ChartStatus = "pending"
+	// ChartStatusDeployed chart manifest is applied, waiting for readiness
+	ChartStatusDeployed ChartStatus = "deployed"
+	// ChartStatusReady chart is deployed and all its pods are ready, it is skipped on the next Run
+	ChartStatusReady ChartStatus = "ready"
+	// ChartStatusFailed chart failed to deploy or to become ready, it is deployed again on the next Run
+	ChartStatusFailed ChartStatus = "failed"
+)
+
+// FailureBehavior defines what happens with the environment when deployment fails
+type FailureBehavior string
+
+const (
+	// FailureBehaviorTeardown removes the namespace, default
+	FailureBehaviorTeardown FailureBehavior = ""
+	// FailureBehaviorKeep keeps the namespace, Run can be called again to resume from the failed chart
+	FailureBehaviorKeep FailureBehavior = "keep"
+	// FailureBehaviorFreeze keeps the namespace when deployment, health checks or smoke tests fail, extends its TTL to Config.FreezeTTL,
+	// prints instructions to inspect it and returns a FrozenError
+	FailureBehaviorFreeze FailureBehavior = "freeze"
+)
+
+// ChartStatus returns deployment status of a chart
+func (m *Environment) ChartStatus(name string) ChartStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.chartStatus[name]
+	if !ok {
+		return ChartStatusPending
+	}
+	return st
+}
+
+func (m *Environment) setChartStatus(name string, st ChartStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chartStatus[name] = st
+}
+
+// ChartsStatus returns deployment status of every chart in the environment
+func (m *Environment) ChartsStatus() map[string]ChartStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make(map[string]ChartStatus)
+	for _, c := range m.Charts {
+		st, ok := m.chartStatus[c.GetName()]
+		if !ok {
+			st = ChartStatusPending
+		}
+		statuses[c.GetName()] = st
+	}
+	return statuses
+}
@@ -0,0 +1,245 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+const (
+	// StatusDialTimeout how long to wait for a forwarded port to accept a connection
+	StatusDialTimeout = 2 * time.Second
+	// StatusJSONPath serves the status as JSON, the page itself is served on "/"
+	StatusJSONPath = "/status.json"
+)
+
+// StatusPage is a state of an environment at a glance
+type StatusPage struct {
+	Namespace string
+	Time      time.Time
+	Charts    []ChartState
+	Pods      []PodState
+	Forwards  []ForwardState
+	URLs      map[string][]string
+}
+
+// ChartState is a deployment status of a chart and readiness of its pods
+type ChartState struct {
+	Name   string
+	Status ChartStatus
+	Pods   int
+	Ready  int
+}
+
+// PodState is a readiness of a pod
+type PodState struct {
+	Name     string
+	Release  string
+	Phase    coreV1.PodPhase
+	Ready    bool
+	Restarts int32
+}
+
+// ForwardState is a forwarded port and whether it accepts connections
+type ForwardState struct {
+	App       string
+	Instance  string
+	Container string
+	Port      string
+	Protocol  string
+	Address   string
+	Healthy   bool
+	Err       string
+}
+
+// Status collects charts statuses, pods readiness and checks every forwarded port
+func (m *Environment) Status() (*StatusPage, error) {
+	pods, err := m.Client.ListPods(m.Cfg.Namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	page := &StatusPage{
+		Namespace: m.Cfg.Namespace,
+		Time:      m.clock().Now(),
+		Pods:      podStates(pods.Items),
+		URLs:      m.URLs,
+	}
+	page.Charts = chartStates(m.ChartsStatus(), page.Pods)
+	for _, proto := range []client.Protocol{client.HTTP, client.WS, client.GRPC, client.Postgres} {
+		for _, c := range m.Fwd.Connections(proto) {
+			f := ForwardState{
+				App:       c.App,
+				Instance:  c.Instance,
+				Container: c.Container,
+				Port:      c.Port,
+				Protocol:  proto.String(),
+				Address:   fmt.Sprintf("localhost:%d", c.Info.Ports.Local),
+			}
+			if m.Cfg.InsideK8s {
+				f.Address = fmt.Sprintf("%s:%d", c.Info.Host, c.Info.Ports.Remote)
+			}
+			conn, err := net.DialTimeout("tcp", f.Address, StatusDialTimeout)
+			if err != nil {
+				f.Err = err.Error()
+			} else {
+				f.Healthy = true
+				_ = conn.Close()
+			}
+			page.Forwards = append(page.Forwards, f)
+		}
+	}
+	return page, nil
+}
+
+// podStates returns pods readiness sorted by release and name, hook jobs pods are skipped
+func podStates(pods []coreV1.Pod) []PodState {
+	states := make([]PodState, 0, len(pods))
+	for _, p := range pods {
+		if _, hook := p.Labels[pkg.HookLabelKey]; hook {
+			continue
+		}
+		st := PodState{
+			Name:    p.Name,
+			Release: p.Labels[pkg.ReleaseLabelKey],
+			Phase:   p.Status.Phase,
+			Ready:   p.Status.Phase == coreV1.PodRunning,
+		}
+		for _, cs := range p.Status.ContainerStatuses {
+			st.Restarts += cs.RestartCount
+			if !cs.Ready {
+				st.Ready = false
+			}
+		}
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Release != states[j].Release {
+			return states[i].Release < states[j].Release
+		}
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+// chartStates counts ready pods of every chart, charts are sorted by name
+func chartStates(statuses map[string]ChartStatus, pods []PodState) []ChartState {
+	states := make([]ChartState, 0, len(statuses))
+	for name, st := range statuses {
+		cs := ChartState{Name: name, Status: st}
+		for _, p := range pods {
+			if p.Release != name {
+				continue
+			}
+			cs.Pods++
+			if p.Ready {
+				cs.Ready++
+			}
+		}
+		states = append(states, cs)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+var statusTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>{{.Namespace}}</title>
+<style>
+body { font-family: monospace; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+.ok { color: #1a7f37; }
+.fail { color: #cf222e; }
+</style>
+</head>
+<body>
+<h2>{{.Namespace}}</h2>
+<p>Updated {{.Time.Format "15:04:05"}}</p>
+<h3>Charts</h3>
+<table>
+<tr><th>Chart</th><th>Status</th><th>Ready pods</th></tr>
+{{range .Charts}}<tr><td>{{.Name}}</td><td>{{.Status}}</td><td class="{{if eq .Ready .Pods}}ok{{else}}fail{{end}}">{{.Ready}}/{{.Pods}}</td></tr>
+{{end}}</table>
+<h3>Pods</h3>
+<table>
+<tr><th>Pod</th><th>Release</th><th>Phase</th><th>Ready</th><th>Restarts</th></tr>
+{{range .Pods}}<tr><td>{{.Name}}</td><td>{{.Release}}</td><td>{{.Phase}}</td><td class="{{if .Ready}}ok{{else}}fail{{end}}">{{.Ready}}</td><td>{{.Restarts}}</td></tr>
+{{end}}</table>
+<h3>Forwarded ports</h3>
+<table>
+<tr><th>App</th><th>Container</th><th>Port</th><th>Address</th><th>Healthy</th></tr>
+{{range .Forwards}}<tr><td>{{.App}}:{{.Instance}}</td><td>{{.Container}}</td><td>{{.Port}}</td><td>{{if eq .Protocol "http"}}<a href="http://{{.Address}}">{{.Address}}</a>{{else}}{{.Protocol}}://{{.Address}}{{end}}</td><td class="{{if .Healthy}}ok{{else}}fail{{end}}">{{if .Healthy}}ok{{else}}{{.Err}}{{end}}</td></tr>
+{{end}}</table>
+<h3>URLs</h3>
+<table>
+{{range $k, $v := .URLs}}<tr><td>{{$k}}</td><td>{{range $v}}{{.}}<br>{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// renderStatus writes the status page HTML
+func renderStatus(w io.Writer, page *StatusPage) error {
+	return statusTemplate.Execute(w, page)
+}
+
+// ServeStatus serves the environment status page on addr until ctx is done, the status is collected on every request,
+// JSON is served on StatusJSONPath
+func (m *Environment) ServeStatus(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		page, err := m.Status()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := renderStatus(w, page); err != nil {
+			log.Warn().Err(err).Msg("Failed to render status page")
+		}
+	})
+	mux.HandleFunc(StatusJSONPath, func(w http.ResponseWriter, r *http.Request) {
+		page, err := m.Status()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			log.Warn().Err(err).Msg("Failed to write status")
+		}
+	})
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serve status page on %s", addr)
+	}
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	log.Info().Str("URL", fmt.Sprintf("http://%s", l.Addr())).Msg("Serving environment status page")
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Err(err).Msg("Status page server stopped")
+		}
+	}()
+	return nil
+}
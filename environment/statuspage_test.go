@@ -0,0 +1,61 @@
+package environment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPod(name string, release string, ready bool, restarts int32) coreV1.Pod {
+	return coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, Labels: map[string]string{pkg.ReleaseLabelKey: release}},
+		Status: coreV1.PodStatus{
+			Phase:             coreV1.PodRunning,
+			ContainerStatuses: []coreV1.ContainerStatus{{Name: "node", Ready: ready, RestartCount: restarts}},
+		},
+	}
+}
+
+func TestStatusPage(t *testing.T) {
+	hook := testPod("geth-hook", "geth", false, 0)
+	hook.Labels[pkg.HookLabelKey] = "post-install"
+	pods := podStates([]coreV1.Pod{
+		testPod("chainlink-0-b", "chainlink-0", false, 2),
+		testPod("geth-a", "geth", true, 0),
+		testPod("chainlink-0-a", "chainlink-0", true, 0),
+		hook,
+	})
+	require.Equal(t, []PodState{
+		{Name: "chainlink-0-a", Release: "chainlink-0", Phase: coreV1.PodRunning, Ready: true},
+		{Name: "chainlink-0-b", Release: "chainlink-0", Phase: coreV1.PodRunning, Restarts: 2},
+		{Name: "geth-a", Release: "geth", Phase: coreV1.PodRunning, Ready: true},
+	}, pods)
+	charts := chartStates(map[string]ChartStatus{"geth": ChartStatusReady, "chainlink-0": ChartStatusDeployed}, pods)
+	require.Equal(t, []ChartState{
+		{Name: "chainlink-0", Status: ChartStatusDeployed, Pods: 2, Ready: 1},
+		{Name: "geth", Status: ChartStatusReady, Pods: 1, Ready: 1},
+	}, charts)
+
+	var sb strings.Builder
+	require.NoError(t, renderStatus(&sb, &StatusPage{
+		Namespace: "chainlink-test-env-abcde",
+		Time:      time.Now(),
+		Charts:    charts,
+		Pods:      pods,
+		Forwards: []ForwardState{
+			{App: "geth", Instance: "0", Container: "geth-network", Port: "http-rpc", Protocol: "http", Address: "localhost:8545", Healthy: true},
+			{App: "chainlink-0", Instance: "0", Container: "node", Port: "access", Protocol: "http", Address: "localhost:6688", Err: "connection refused"},
+		},
+		URLs: map[string][]string{"geth": {"http://localhost:8545"}},
+	}))
+	html := sb.String()
+	require.Contains(t, html, "chainlink-test-env-abcde")
+	require.Contains(t, html, `<a href="http://localhost:8545">localhost:8545</a>`)
+	require.Contains(t, html, "connection refused")
+	require.Contains(t, html, `<td class="fail">1/2</td>`)
+}
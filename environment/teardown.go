@@ -0,0 +1,272 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cdk8s-team/cdk8s-core-go/cdk8s/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/imports/k8s"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// HeartbeatAnnotationKey namespace annotation with the last heartbeat unix time of the test process
+	HeartbeatAnnotationKey = client.HeartbeatAnnotationKey
+	// DefaultHeartbeatInterval heartbeat refresh interval of KeepConnection mode without HeartbeatTimeout
+	DefaultHeartbeatInterval = 1 * time.Minute
+	// CompletionMarkerName is a ConfigMap name, when it's created the environment is removed by the reaper
+	CompletionMarkerName = "chainlink-env-completed"
+	// ReaperName is a name of the reaper deployment
+	ReaperName         = "reaper"
+	ReaperImage        = "bitnami/kubectl:1.24"
+	ReaperPollInterval = 30 * time.Second
+)
+
+// reaperScript removes the namespace when the completion marker exists or the heartbeat is older than the timeout,
+// a namespace without a heartbeat is stale once it's older than the timeout, e.g. the test process was killed during the deployment,
+// unless the environment is in maintenance, cluster-scoped RBAC objects are owned by the namespace, so they are garbage collected with it
+const reaperScript = `while true; do
+  reason=""
+  if kubectl get configmap %[1]s -n "$NAMESPACE" >/dev/null 2>&1; then
+    reason="completion marker found"
+  fi
+  hb=$(kubectl get namespace "$NAMESPACE" -o jsonpath='{.metadata.annotations.chainlink-env/heartbeat}')
+  if [ -n "$hb" ] && [ $(( $(date +%%s) - hb )) -gt %[2]d ]; then
+    reason="no heartbeat for %[2]d seconds"
+  fi
+  created=$(kubectl get namespace "$NAMESPACE" -o jsonpath='{.metadata.creationTimestamp}')
+  if [ -z "$hb" ] && [ -n "$created" ] && [ $(( $(date +%%s) - $(date -d "$created" +%%s) )) -gt %[2]d ]; then
+    reason="no heartbeat since the namespace was created more than %[2]d seconds ago"
+  fi
+  mt=$(kubectl get namespace "$NAMESPACE" -o jsonpath='{.metadata.annotations.chainlink-env/maintenance}')
+  if [ -n "$reason" ] && [ -n "$mt" ] && [ "$mt" -gt $(date +%%s) ]; then
+    echo "Environment is in maintenance, skipping removal: $reason"
+    reason=""
+  fi
+  if [ -n "$reason" ]; then
+    echo "Removing environment: $reason"
+    kubectl delete namespace "$NAMESPACE" --wait=false
+  fi
+  sleep %[3]d
+done
+`
+
+func reaperName(namespace string) string {
+	return fmt.Sprintf("%s-reaper", namespace)
+}
+
+// addReaper adds an in-cluster agent which removes the namespace when the test process is gone,
+// it protects against orphaned environments when the process is killed without a chance to call Shutdown
+func addReaper(root cdk8s.Chart, namespace string, timeout time.Duration) {
+	name := reaperName(namespace)
+	labels := &map[string]*string{"app": a.Str("reaper")}
+	k8s.NewKubeServiceAccount(root, a.Str("reaper-sa"), &k8s.KubeServiceAccountProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(name)},
+	})
+	k8s.NewKubeClusterRole(root, a.Str("reaper-cluster-role"), &k8s.KubeClusterRoleProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(name)},
+		Rules: &[]*k8s.PolicyRule{
+			{
+				ApiGroups:     &[]*string{a.Str("")},
+				Resources:     &[]*string{a.Str("namespaces")},
+				ResourceNames: &[]*string{a.Str(namespace)},
+				Verbs:         &[]*string{a.Str("get"), a.Str("delete")},
+			},
+		},
+	})
+	k8s.NewKubeClusterRoleBinding(root, a.Str("reaper-cluster-role-binding"), &k8s.KubeClusterRoleBindingProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(name)},
+		RoleRef: &k8s.RoleRef{
+			ApiGroup: a.Str("rbac.authorization.k8s.io"),
+			Kind:     a.Str("ClusterRole"),
+			Name:     a.Str(name),
+		},
+		Subjects: &[]*k8s.Subject{
+			{Kind: a.Str("ServiceAccount"), Name: a.Str(name), Namespace: a.Str(namespace)},
+		},
+	})
+	k8s.NewKubeRole(root, a.Str("reaper-role"), &k8s.KubeRoleProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(name)},
+		Rules: &[]*k8s.PolicyRule{
+			{
+				ApiGroups:     &[]*string{a.Str("")},
+				Resources:     &[]*string{a.Str("configmaps")},
+				ResourceNames: &[]*string{a.Str(CompletionMarkerName)},
+				Verbs:         &[]*string{a.Str("get")},
+			},
+		},
+	})
+	k8s.NewKubeRoleBinding(root, a.Str("reaper-role-binding"), &k8s.KubeRoleBindingProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(name)},
+		RoleRef: &k8s.RoleRef{
+			ApiGroup: a.Str("rbac.authorization.k8s.io"),
+			Kind:     a.Str("Role"),
+			Name:     a.Str(name),
+		},
+		Subjects: &[]*k8s.Subject{
+			{Kind: a.Str("ServiceAccount"), Name: a.Str(name), Namespace: a.Str(namespace)},
+		},
+	})
+	k8s.NewKubeDeployment(root, a.Str("reaper-deployment"), &k8s.KubeDeploymentProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(ReaperName)},
+		Spec: &k8s.DeploymentSpec{
+			Selector: &k8s.LabelSelector{MatchLabels: labels},
+			Template: &k8s.PodTemplateSpec{
+				Metadata: &k8s.ObjectMeta{Labels: labels},
+				Spec: &k8s.PodSpec{
+					ServiceAccountName: a.Str(name),
+					Containers: &[]*k8s.Container{
+						{
+							Name:    a.Str("reaper"),
+							Image:   a.Str(ReaperImage),
+							Command: &[]*string{a.Str("/bin/sh")},
+							Args: &[]*string{
+								a.Str("-c"),
+								a.Str(fmt.Sprintf(reaperScript, CompletionMarkerName, int(timeout.Seconds()), int(ReaperPollInterval.Seconds()))),
+							},
+							Env: &[]*k8s.EnvVar{
+								{
+									Name: a.Str("NAMESPACE"),
+									ValueFrom: &k8s.EnvVarSource{
+										FieldRef: &k8s.ObjectFieldSelector{FieldPath: a.Str("metadata.namespace")},
+									},
+								},
+							},
+							Resources: a.ContainerResources("10m", "32Mi", "10m", "32Mi"),
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// setReaperOwner makes the namespace an owner of cluster-scoped reaper RBAC objects, so they are removed with it
+func (m *Environment) setReaperOwner() error {
+	ns, err := m.Client.ClientSet.CoreV1().Namespaces().Get(context.Background(), m.Cfg.Namespace, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"ownerReferences": []metaV1.OwnerReference{
+				{APIVersion: "v1", Kind: "Namespace", Name: ns.Name, UID: ns.UID},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	name := reaperName(m.Cfg.Namespace)
+	rbac := m.Client.ClientSet.RbacV1()
+	if _, err := rbac.ClusterRoles().Patch(context.Background(), name, types.MergePatchType, patch, metaV1.PatchOptions{}); err != nil {
+		return err
+	}
+	_, err = rbac.ClusterRoleBindings().Patch(context.Background(), name, types.MergePatchType, patch, metaV1.PatchOptions{})
+	return err
+}
+
+// startHeartbeat refreshes the heartbeat annotation of the namespace until Shutdown, with the reaper or when the connection is kept,
+// so client.StaleNamespaces tells active soaks from abandoned environments
+func (m *Environment) startHeartbeat() {
+	if (m.Cfg.HeartbeatTimeout == 0 && !m.Cfg.KeepConnection) || m.Cfg.DryRun || m.heartbeatStop != nil {
+		return
+	}
+	m.heartbeatStop = make(chan struct{})
+	interval := DefaultHeartbeatInterval
+	if m.Cfg.HeartbeatTimeout != 0 {
+		interval = m.Cfg.HeartbeatTimeout / 4
+	}
+	go func(stop chan struct{}) {
+		ticker := m.clock().NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := m.heartbeat(); err != nil {
+				log.Debug().Err(err).Msg("Failed to update heartbeat")
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C():
+			}
+		}
+	}(m.heartbeatStop)
+}
+
+func (m *Environment) stopHeartbeat() {
+	if m.heartbeatStop == nil {
+		return
+	}
+	close(m.heartbeatStop)
+	m.heartbeatStop = nil
+}
+
+func (m *Environment) heartbeat() error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				HeartbeatAnnotationKey: strconv.FormatInt(m.clock().Now().Unix(), 10),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.Client.ClientSet.CoreV1().Namespaces().Patch(context.Background(), m.Cfg.Namespace, types.MergePatchType, patch, metaV1.PatchOptions{})
+	return err
+}
+
+// keep leaves the namespace running after this process: its heartbeat annotation and the reaper it deployed are removed,
+// so neither the reaper nor client.CleanupStaleNamespaces remove a kept environment once heartbeats stop
+func (m *Environment) keep(heartbeating bool) error {
+	ctx := context.Background()
+	if heartbeating {
+		patch, err := heartbeatRemovalPatch()
+		if err != nil {
+			return err
+		}
+		if _, err := m.Client.ClientSet.CoreV1().Namespaces().Patch(ctx, m.Cfg.Namespace, types.MergePatchType, patch, metaV1.PatchOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to remove heartbeat of kept namespace %s", m.Cfg.Namespace)
+		}
+	}
+	// the reaper is a common resource, it's deployed only into namespaces this process created
+	if m.Cfg.HeartbeatTimeout != 0 && !m.attached {
+		err := m.Client.ClientSet.AppsV1().Deployments(m.Cfg.Namespace).Delete(ctx, ReaperName, metaV1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to remove reaper of kept namespace %s", m.Cfg.Namespace)
+		}
+	}
+	return nil
+}
+
+// heartbeatRemovalPatch removes the heartbeat annotation, namespaces without heartbeats are never stale
+func heartbeatRemovalPatch() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				HeartbeatAnnotationKey: nil,
+			},
+		},
+	})
+}
+
+// MarkCompleted creates a completion marker, the reaper removes the environment in the background,
+// works only if Config.HeartbeatTimeout is set
+func (m *Environment) MarkCompleted() error {
+	m.stopHeartbeat()
+	m.recordEvent("completed", "", nil)
+	_, err := m.Client.ClientSet.CoreV1().ConfigMaps(m.Cfg.Namespace).Create(context.Background(), &coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{Name: CompletionMarkerName},
+	}, metaV1.CreateOptions{})
+	return err
+}
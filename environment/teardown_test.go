@@ -0,0 +1,43 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatRemovalPatch(t *testing.T) {
+	patch, err := heartbeatRemovalPatch()
+	require.NoError(t, err)
+	// null removes the annotation with a merge patch, other annotations stay
+	require.JSONEq(t, `{"metadata":{"annotations":{"chainlink-env/heartbeat":null}}}`, string(patch))
+}
+
+func TestReaperScriptWithoutHeartbeat(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the reaper script runs in a Linux container")
+	}
+	dir := t.TempDir()
+	deleted := filepath.Join(dir, "deleted")
+	// kubectl has no completion marker and no heartbeat, the namespace is created an hour ago, sleep ends the loop
+	kubectl := fmt.Sprintf(`#!/bin/sh
+case "$*" in
+  *creationTimestamp*) date -u -d "1 hour ago" +%%Y-%%m-%%dT%%H:%%M:%%SZ ;;
+  *"delete namespace"*) echo "$*" > %s ;;
+  *configmap*) exit 1 ;;
+esac
+`, deleted)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kubectl"), []byte(kubectl), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sleep"), []byte("#!/bin/sh\nkill $PPID\n"), 0755))
+	cmd := exec.Command("sh", "-c", fmt.Sprintf(reaperScript, CompletionMarkerName, 600, 1))
+	cmd.Env = append(os.Environ(), "PATH="+dir+":"+os.Getenv("PATH"), "NAMESPACE=chainlink-test-env-abcde")
+	_ = cmd.Run()
+	out, err := os.ReadFile(deleted)
+	require.NoError(t, err, "namespace without a heartbeat older than the timeout is removed")
+	require.Contains(t, string(out), "delete namespace chainlink-test-env-abcde")
+}
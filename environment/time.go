@@ -0,0 +1,113 @@
+package environment
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/chaos"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+)
+
+// ClockShiftedChart is a chart which time-dependent behavior follows the system clock of its containers,
+// for example, cron jobs of Chainlink nodes or Geth dev block timestamps, the clock is shifted with a time chaos experiment
+type ClockShiftedChart interface {
+	ConnectedChart
+	// ClockContainers names of containers which clocks are shifted
+	ClockContainers() []string
+}
+
+// ChainTimeChart is a chart of a chain which time can be moved forward with an API, for example, a devnet
+type ChainTimeChart interface {
+	ConnectedChart
+	AdvanceChainTime(e *Environment, d time.Duration) error
+}
+
+// Time moves time of the environment forward, both chain time and clocks of the nodes
+type Time struct {
+	env         *Environment
+	mu          sync.Mutex
+	offset      time.Duration
+	experiments []string
+}
+
+func newTime(e *Environment) *Time {
+	return &Time{
+		env:         e,
+		experiments: make([]string, 0),
+	}
+}
+
+// Offset returns the total time offset
+func (t *Time) Offset() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.offset
+}
+
+// Advance moves time forward by d, chains implementing ChainTimeChart advance their time,
+// clocks of charts implementing ClockShiftedChart are shifted by the total offset
+func (t *Time) Advance(d time.Duration) error {
+	if d <= 0 {
+		return errors.New("time can only be advanced by a positive duration")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.env.Charts {
+		if cc, ok := c.(ChainTimeChart); ok {
+			if err := cc.AdvanceChainTime(t.env, d); err != nil {
+				return errors.Wrapf(err, "failed to advance time of chart %s", c.GetName())
+			}
+		}
+	}
+	if err := t.stopClockShift(); err != nil {
+		return err
+	}
+	t.offset += d
+	for _, c := range t.env.Charts {
+		sc, ok := c.(ClockShiftedChart)
+		if !ok {
+			continue
+		}
+		containers := make([]*string, 0)
+		for _, cn := range sc.ClockContainers() {
+			containers = append(containers, a.Str(cn))
+		}
+		id, err := t.env.Chaos.Run(chaos.NewTimeOffset(t.env.Cfg.Namespace, &chaos.Props{
+			Name:           fmt.Sprintf("time-%s", c.GetName()),
+			LabelsSelector: &map[string]*string{pkg.ReleaseLabelKey: a.Str(c.GetName())},
+			ContainerNames: &containers,
+			TimeOffset:     t.offset.String(),
+		}))
+		if err != nil {
+			return errors.Wrapf(err, "failed to shift clock of chart %s", c.GetName())
+		}
+		t.experiments = append(t.experiments, id)
+	}
+	log.Info().Str("Offset", t.offset.String()).Msg("Time advanced")
+	return nil
+}
+
+// Reset removes clock shifts, chain time can't be moved back
+func (t *Time) Reset() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.stopClockShift(); err != nil {
+		return err
+	}
+	t.offset = 0
+	return nil
+}
+
+func (t *Time) stopClockShift() error {
+	for _, id := range t.experiments {
+		if err := t.env.Chaos.Stop(id); err != nil {
+			return err
+		}
+	}
+	t.experiments = t.experiments[:0]
+	return nil
+}
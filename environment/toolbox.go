@@ -0,0 +1,125 @@
+package environment
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ToolboxName = "toolbox"
+	// DefaultToolboxImage has curl, websocat and other network tools, use an image with psql and cast to query databases and chains
+	DefaultToolboxImage = "nicolaka/netshoot:latest"
+	ToolboxReadyTimeout = 3 * time.Minute
+)
+
+// Toolbox is a utility pod inside the environment namespace to run in-cluster checks from tests or for manual debugging
+type Toolbox struct {
+	env       *Environment
+	Pod       string
+	Container string
+	Image     string
+}
+
+// DeployToolbox starts a toolbox pod with the image, DefaultToolboxImage if empty, an existing toolbox is reused
+func (m *Environment) DeployToolbox(image string) (*Toolbox, error) {
+	if image == "" {
+		image = DefaultToolboxImage
+	}
+	image = mirrorImage(image, m.Cfg.ImageMirror)
+	t := &Toolbox{env: m, Pod: ToolboxName, Container: ToolboxName, Image: image}
+	pods := m.Client.ClientSet.CoreV1().Pods(m.Cfg.Namespace)
+	_, err := pods.Create(context.Background(), toolboxPod(image), metaV1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return nil, errors.Wrap(err, "failed to create toolbox pod")
+	}
+	log.Info().Str("Namespace", m.Cfg.Namespace).Str("Image", image).Msg("Waiting for toolbox pod")
+	if err := m.Client.WaitForPodBySelectorRunning(m.Cfg.Namespace, &client.ReadyCheckData{
+		ReadinessProbeCheckSelector: "app=" + ToolboxName,
+		Timeout:                     ToolboxReadyTimeout,
+	}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func toolboxPod(image string) *coreV1.Pod {
+	return &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   ToolboxName,
+			Labels: map[string]string{"app": ToolboxName},
+		},
+		Spec: coreV1.PodSpec{
+			Containers: []coreV1.Container{
+				{
+					Name:    ToolboxName,
+					Image:   image,
+					Command: []string{"/bin/sh", "-c", "trap : TERM INT; sleep infinity & wait"},
+					Resources: coreV1.ResourceRequirements{
+						Requests: coreV1.ResourceList{
+							coreV1.ResourceCPU:    resource.MustParse("50m"),
+							coreV1.ResourceMemory: resource.MustParse("64Mi"),
+						},
+						Limits: coreV1.ResourceList{
+							coreV1.ResourceCPU:    resource.MustParse("500m"),
+							coreV1.ResourceMemory: resource.MustParse("256Mi"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Exec runs a command in the toolbox and returns its stdout and stderr
+func (t *Toolbox) Exec(command ...string) (string, string, error) {
+	stdout, stderr, err := t.env.Client.ExecuteInPod(t.env.Cfg.Namespace, t.Pod, t.Container, command)
+	if err != nil {
+		return string(stdout), string(stderr), errors.Wrapf(err, "command %v failed: %s", command, strings.TrimSpace(string(stderr)))
+	}
+	return string(stdout), string(stderr), nil
+}
+
+// Sh runs a shell script in the toolbox and returns its stdout
+func (t *Toolbox) Sh(script string) (string, error) {
+	stdout, _, err := t.Exec("/bin/sh", "-c", script)
+	return stdout, err
+}
+
+// Curl runs curl in the toolbox, fails on HTTP errors
+func (t *Toolbox) Curl(args ...string) (string, error) {
+	stdout, _, err := t.Exec(append([]string{"curl", "-sSf"}, args...)...)
+	return stdout, err
+}
+
+// Psql runs a query with psql in the toolbox and returns unaligned tuples, use an in-cluster DSN
+func (t *Toolbox) Psql(dsn string, query string) (string, error) {
+	stdout, _, err := t.Exec("psql", dsn, "-At", "-c", query)
+	return stdout, err
+}
+
+// Websocat sends a message to a websocket in the toolbox and returns the first response
+func (t *Toolbox) Websocat(url string, message string) (string, error) {
+	stdout, _, err := t.Exec("/bin/sh", "-c", `printf '%s\n' "$1" | websocat -n1 "$2"`, "websocat", message, url)
+	return stdout, err
+}
+
+// Cast runs foundry cast in the toolbox, for example, Cast("block-number", "--rpc-url", url)
+func (t *Toolbox) Cast(args ...string) (string, error) {
+	stdout, _, err := t.Exec(append([]string{"cast"}, args...)...)
+	return stdout, err
+}
+
+// Remove deletes the toolbox pod
+func (t *Toolbox) Remove() error {
+	return t.env.Client.ClientSet.CoreV1().Pods(t.env.Cfg.Namespace).
+		Delete(context.Background(), t.Pod, metaV1.DeleteOptions{})
+}
@@ -0,0 +1,198 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ValuesLayerCurrent are values a chart was deployed with, upgrade values are merged over them
+	ValuesLayerCurrent = "current"
+	// RolloutPollInterval how often workloads of an upgraded release are checked
+	RolloutPollInterval = 2 * time.Second
+)
+
+// UpgradeHelm upgrades a deployed release in place, values are merged over the current ones, e.g. a Chainlink version bump:
+//
+//	e.UpgradeHelm("chainlink-0", map[string]interface{}{"chainlink": map[string]interface{}{"image": map[string]interface{}{"version": "1.10.0"}}})
+//
+// the release manifest is applied without running hooks, it waits until all workloads of the release are rolled out
+// and pods are ready, then forwards ports of the new pods to the same local ports if they are free, so URLs don't change,
+// if the chart has a Canary, it's analyzed after the upgrade and rolled back to the previous values if any check fails
+func (m *Environment) UpgradeHelm(name string, values map[string]interface{}) error {
+	c := m.chart(name)
+	if c == nil {
+		return errors.Errorf("chart %s not found in the environment", name)
+	}
+	if !c.IsDeploymentNeeded() || c.GetValues() == nil {
+		return errors.Errorf("chart %s is not a Helm chart deployed by the environment", name)
+	}
+	if m.chartStatus[name] != ChartStatusReady {
+		return errors.Errorf("chart %s is not deployed yet, use ModifyHelm and Run", name)
+	}
+	canary := m.canaries[name]
+	var baseline []float64
+	if canary != nil {
+		var err error
+		if baseline, err = m.canaryBaseline(name, canary, m.clock().Now()); err != nil {
+			return err
+		}
+	}
+	log.Info().Str("Chart", name).Interface("Values", values).Msg("Upgrading chart")
+	m.reportProgress(fmt.Sprintf("Upgrading chart %s", name))
+	// values are updated in place, so the chart keeps its type and optional capabilities
+	current := c.GetValues()
+	previous := *current
+	*current = config.MustMergeValues(name,
+		config.ValuesLayer{Name: ValuesLayerCurrent, Values: *current},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: values},
+	)
+	err := m.redeployRelease(name, c)
+	m.recordEvent("upgrade", name, err)
+	if err != nil {
+		return errors.Wrapf(err, "failed to upgrade chart %s", name)
+	}
+	if canary == nil {
+		return nil
+	}
+	analysis := m.analyzeCanary(name, canary, baseline)
+	if analysis.Passed {
+		m.reportCanary(analysis)
+		return nil
+	}
+	log.Warn().Str("Chart", name).Msg("Canary analysis failed, rolling back")
+	*current = previous
+	err = m.redeployRelease(name, c)
+	m.recordEvent("rollback", name, err)
+	analysis.RolledBack = err == nil
+	m.reportCanary(analysis)
+	if err != nil {
+		return errors.Wrapf(err, "failed to roll back chart %s after failed canary analysis", name)
+	}
+	return errors.Errorf("canary analysis of chart %s failed, chart is rolled back", name)
+}
+
+// redeployRelease applies a release of the chart with its current values and reconnects it
+func (m *Environment) redeployRelease(name string, c ConnectedChart) error {
+	m.root.Node().TryRemoveChild(a.Str(name))
+	m.removeMonitors(name)
+	m.newHelm(name, c)
+	if err := m.upgradeRelease(name); err != nil {
+		m.chartStatus[name] = ChartStatusFailed
+		return err
+	}
+	m.chartStatus[name] = ChartStatusReady
+	if err := m.Fwd.Reconnect(m.Cfg.Namespace, releaseSelector(name), m.Cfg.InsideK8s); err != nil {
+		return errors.Wrapf(err, "failed to connect chart %s", name)
+	}
+	m.URLs = make(map[string][]string)
+	return m.PrintExportData()
+}
+
+// upgradeRelease applies a manifest of a release and waits for its rollout
+func (m *Environment) upgradeRelease(name string) error {
+	manifest, err := m.manifest()
+	if err != nil {
+		return err
+	}
+	releases, _, err := groupManifestByRelease(manifest)
+	if err != nil {
+		return err
+	}
+	rm := releases[name]
+	if rm == nil {
+		return errors.Errorf("release %s has no resources", name)
+	}
+	release, err := m.renderRelease(name, rm)
+	if err != nil {
+		return err
+	}
+	m.chartStatus[name] = ChartStatusDeployed
+	if err := m.Client.ApplyNamed(name, release); err != nil {
+		return err
+	}
+	if !rm.HasPods {
+		return nil
+	}
+	rcd := &client.ReadyCheckData{
+		ReadinessProbeCheckSelector: releaseSelector(name),
+		Timeout:                     m.readyTimeout(rm.Pods),
+	}
+	if err := m.waitRollout(name, rcd.Timeout); err != nil {
+		return err
+	}
+	if err := m.Client.CheckReady(m.Cfg.Namespace, rcd); err != nil {
+		return err
+	}
+	return m.enumerateApps()
+}
+
+// waitRollout waits until all deployments and stateful sets of a release run ready pods of the applied spec
+func (m *Environment) waitRollout(name string, timeout time.Duration) error {
+	ctx := context.Background()
+	opts := metaV1.ListOptions{LabelSelector: releaseSelector(name)}
+	apps := m.Client.ClientSet.AppsV1()
+	err := client.PollImmediate(m.clock(), RolloutPollInterval, timeout, func() (bool, error) {
+		deployments, err := apps.Deployments(m.Cfg.Namespace).List(ctx, opts)
+		if err != nil {
+			return false, err
+		}
+		for _, d := range deployments.Items {
+			if !deploymentRolledOut(d) {
+				log.Debug().Str("Deployment", d.Name).Int32("Updated", d.Status.UpdatedReplicas).Int32("Available", d.Status.AvailableReplicas).Msg("Waiting for rollout")
+				return false, nil
+			}
+		}
+		statefulSets, err := apps.StatefulSets(m.Cfg.Namespace).List(ctx, opts)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range statefulSets.Items {
+			if !statefulSetRolledOut(s) {
+				log.Debug().Str("StatefulSet", s.Name).Int32("Updated", s.Status.UpdatedReplicas).Int32("Ready", s.Status.ReadyReplicas).Msg("Waiting for rollout")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	return errors.Wrapf(err, "release %s is not rolled out in %s", name, timeout)
+}
+
+// deploymentRolledOut true if the latest spec is observed and all replicas are updated and available, old ones are gone
+func deploymentRolledOut(d appsV1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas
+}
+
+// statefulSetRolledOut true if the latest spec is observed and all replicas are updated and ready,
+// stateful sets with OnDelete strategy are never updated by the controller, so they are rolled out once observed
+func statefulSetRolledOut(s appsV1.StatefulSet) bool {
+	if s.Status.ObservedGeneration < s.Generation {
+		return false
+	}
+	if s.Spec.UpdateStrategy.Type == appsV1.OnDeleteStatefulSetStrategyType {
+		return true
+	}
+	replicas := int32(1)
+	if s.Spec.Replicas != nil {
+		replicas = *s.Spec.Replicas
+	}
+	return s.Status.UpdatedReplicas == replicas &&
+		s.Status.ReadyReplicas == replicas &&
+		s.Status.CurrentRevision == s.Status.UpdateRevision
+}
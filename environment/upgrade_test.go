@@ -0,0 +1,45 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentRolledOut(t *testing.T) {
+	replicas := int32(2)
+	d := appsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Generation: 2},
+		Spec:       appsV1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsV1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+	}
+	require.False(t, deploymentRolledOut(d), "new spec is not observed yet")
+	d.Status = appsV1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2}
+	require.False(t, deploymentRolledOut(d), "old pod is still running")
+	d.Status.Replicas = 2
+	require.True(t, deploymentRolledOut(d))
+}
+
+func TestStatefulSetRolledOut(t *testing.T) {
+	s := appsV1.StatefulSet{
+		ObjectMeta: metaV1.ObjectMeta{Generation: 2},
+		Status: appsV1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			ReadyReplicas:      1,
+			UpdatedReplicas:    1,
+			CurrentRevision:    "db-1",
+			UpdateRevision:     "db-2",
+		},
+	}
+	require.False(t, statefulSetRolledOut(s), "revision is not updated yet")
+	s.Status.CurrentRevision = "db-2"
+	require.True(t, statefulSetRolledOut(s))
+	s.Status.ObservedGeneration = 1
+	s.Spec.UpdateStrategy.Type = appsV1.OnDeleteStatefulSetStrategyType
+	require.False(t, statefulSetRolledOut(s), "new spec is not observed yet")
+	s.Status.ObservedGeneration = 2
+	s.Status.CurrentRevision = "db-1"
+	require.True(t, statefulSetRolledOut(s))
+}
@@ -0,0 +1,286 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/pkg"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	UsageReportFile = "resources.json"
+	UsageTableFile  = "resources.txt"
+	// DefaultUsageSampleInterval metrics-server scrapes kubelets every 15s by default, sampling more often gives no new data
+	DefaultUsageSampleInterval = 15 * time.Second
+	// usageUnlabeled is a chart name for pods without a release label
+	usageUnlabeled = "-"
+)
+
+// ChartUsage is a requested vs peak actual resources usage of all chart pods,
+// CPU is in millicores, memory in bytes
+type ChartUsage struct {
+	Chart           string `json:"chart"`
+	RequestedCPU    int64  `json:"requested_cpu_millicores"`
+	PeakCPU         int64  `json:"peak_cpu_millicores"`
+	RequestedMemory int64  `json:"requested_memory_bytes"`
+	PeakMemory      int64  `json:"peak_memory_bytes"`
+	Samples         int    `json:"samples"`
+}
+
+// usageSample is a per chart sum of pods resources at one point in time
+type usageSample struct {
+	cpu    int64
+	memory int64
+}
+
+// podMetricsList is a part of metrics.k8s.io/v1beta1 PodMetricsList we need
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Containers []struct {
+			Name  string            `json:"name"`
+			Usage map[string]string `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// UsageSampler periodically samples pods usage from metrics-server and keeps peak usage per chart,
+// used to right-size resources passed to charts
+type UsageSampler struct {
+	Namespace string
+	Client    *client.K8sClient
+	Interval  time.Duration
+	mu        *sync.Mutex
+	usage     map[string]*ChartUsage
+	cancel    context.CancelFunc
+}
+
+// NewUsageSampler creates a new resources usage sampler for a namespace
+func NewUsageSampler(client *client.K8sClient, namespace string) *UsageSampler {
+	return &UsageSampler{
+		Namespace: namespace,
+		Client:    client,
+		Interval:  DefaultUsageSampleInterval,
+		mu:        &sync.Mutex{},
+		usage:     make(map[string]*ChartUsage),
+	}
+}
+
+// Start starts sampling in background until Stop is called
+func (u *UsageSampler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	u.cancel = cancel
+	go func() {
+		for {
+			if err := u.sample(ctx); err != nil {
+				log.Debug().Err(err).Str("Namespace", u.Namespace).Msg("Failed to sample resources usage")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-u.Client.Clock().After(u.Interval):
+			}
+		}
+	}()
+}
+
+// Stop stops sampling, collected usage is kept
+func (u *UsageSampler) Stop() {
+	if u.cancel != nil {
+		u.cancel()
+	}
+}
+
+func (u *UsageSampler) sample(ctx context.Context) error {
+	pods, err := u.Client.ListPods(u.Namespace, "")
+	if err != nil {
+		return err
+	}
+	data, err := u.Client.ClientSet.CoreV1().RESTClient().Get().
+		AbsPath(fmt.Sprintf("/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods", u.Namespace)).
+		DoRaw(ctx)
+	if err != nil {
+		return errors.Wrap(err, "metrics-server is not available")
+	}
+	var metrics podMetricsList
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return err
+	}
+	podCharts := make(map[string]string)
+	for _, p := range pods.Items {
+		podCharts[p.Name] = podChart(p)
+	}
+	actual, err := aggregateUsage(metrics, podCharts)
+	if err != nil {
+		return err
+	}
+	requested := requestedUsage(pods.Items)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.record(actual, requested)
+	return nil
+}
+
+// record keeps peak actual and requested usage, requests are taken as a maximum too,
+// so pods removed before the dump are still accounted
+func (u *UsageSampler) record(actual map[string]usageSample, requested map[string]usageSample) {
+	for chart, s := range actual {
+		cu := u.chartUsage(chart)
+		cu.Samples++
+		if s.cpu > cu.PeakCPU {
+			cu.PeakCPU = s.cpu
+		}
+		if s.memory > cu.PeakMemory {
+			cu.PeakMemory = s.memory
+		}
+	}
+	for chart, s := range requested {
+		cu := u.chartUsage(chart)
+		if s.cpu > cu.RequestedCPU {
+			cu.RequestedCPU = s.cpu
+		}
+		if s.memory > cu.RequestedMemory {
+			cu.RequestedMemory = s.memory
+		}
+	}
+}
+
+func (u *UsageSampler) chartUsage(chart string) *ChartUsage {
+	cu, ok := u.usage[chart]
+	if !ok {
+		cu = &ChartUsage{Chart: chart}
+		u.usage[chart] = cu
+	}
+	return cu
+}
+
+// Summary returns usage of all charts sorted by chart name
+func (u *UsageSampler) Summary() []ChartUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	summary := make([]ChartUsage, 0, len(u.usage))
+	for _, cu := range u.usage {
+		summary = append(summary, *cu)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Chart < summary[j].Chart
+	})
+	return summary
+}
+
+// WriteReport writes usage summary as json and as a text table into the test dir
+func (u *UsageSampler) WriteReport(testDir string) error {
+	summary := u.Summary()
+	if len(summary) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(testDir, UsageReportFile), data, 0644); err != nil {
+		return err
+	}
+	table := usageTable(summary)
+	log.Info().Str("Namespace", u.Namespace).Msgf("Resources usage summary\n%s", table)
+	return os.WriteFile(filepath.Join(testDir, UsageTableFile), []byte(table), 0644)
+}
+
+func podChart(p coreV1.Pod) string {
+	if chart, ok := p.Labels[pkg.ReleaseLabelKey]; ok {
+		return chart
+	}
+	return usageUnlabeled
+}
+
+// aggregateUsage sums containers usage of all pods per chart, pods unknown to podCharts are skipped
+func aggregateUsage(metrics podMetricsList, podCharts map[string]string) (map[string]usageSample, error) {
+	usage := make(map[string]usageSample)
+	for _, item := range metrics.Items {
+		chart, ok := podCharts[item.Metadata.Name]
+		if !ok {
+			continue
+		}
+		s := usage[chart]
+		for _, c := range item.Containers {
+			if v, ok := c.Usage["cpu"]; ok {
+				q, err := resource.ParseQuantity(v)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to parse cpu usage of pod %s", item.Metadata.Name)
+				}
+				s.cpu += q.MilliValue()
+			}
+			if v, ok := c.Usage["memory"]; ok {
+				q, err := resource.ParseQuantity(v)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to parse memory usage of pod %s", item.Metadata.Name)
+				}
+				s.memory += q.Value()
+			}
+		}
+		usage[chart] = s
+	}
+	return usage, nil
+}
+
+// requestedUsage sums containers resource requests of all pods per chart
+func requestedUsage(pods []coreV1.Pod) map[string]usageSample {
+	requested := make(map[string]usageSample)
+	for _, p := range pods {
+		chart := podChart(p)
+		s := requested[chart]
+		for _, c := range p.Spec.Containers {
+			s.cpu += c.Resources.Requests.Cpu().MilliValue()
+			s.memory += c.Resources.Requests.Memory().Value()
+		}
+		requested[chart] = s
+	}
+	return requested
+}
+
+// usageTable renders a summary as a text table, peak usage is also shown as a percent of requested
+func usageTable(summary []ChartUsage) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHART\tCPU REQUESTED\tCPU PEAK\tCPU %\tMEMORY REQUESTED\tMEMORY PEAK\tMEMORY %")
+	for _, cu := range summary {
+		fmt.Fprintf(w, "%s\t%dm\t%dm\t%s\t%s\t%s\t%s\n",
+			cu.Chart,
+			cu.RequestedCPU,
+			cu.PeakCPU,
+			usagePercent(cu.PeakCPU, cu.RequestedCPU),
+			formatBytes(cu.RequestedMemory),
+			formatBytes(cu.PeakMemory),
+			usagePercent(cu.PeakMemory, cu.RequestedMemory),
+		)
+	}
+	_ = w.Flush()
+	return sb.String()
+}
+
+func usagePercent(peak, requested int64) string {
+	if requested == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", peak*100/requested)
+}
+
+func formatBytes(b int64) string {
+	const mi = 1024 * 1024
+	return fmt.Sprintf("%dMi", b/mi)
+}
@@ -0,0 +1,59 @@
+package environment
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourcesUsage(t *testing.T) {
+	t.Run("aggregates containers usage per chart", func(t *testing.T) {
+		var metrics podMetricsList
+		require.NoError(t, json.Unmarshal([]byte(`{"items": [
+			{"metadata": {"name": "chainlink-0-a"}, "containers": [
+				{"name": "node", "usage": {"cpu": "250m", "memory": "512Mi"}},
+				{"name": "chainlink-db", "usage": {"cpu": "2000000n", "memory": "64Mi"}}
+			]},
+			{"metadata": {"name": "geth-a"}, "containers": [{"name": "geth", "usage": {"cpu": "1", "memory": "1Gi"}}]},
+			{"metadata": {"name": "unknown"}, "containers": [{"name": "x", "usage": {"cpu": "1"}}]}
+		]}`), &metrics))
+		usage, err := aggregateUsage(metrics, map[string]string{
+			"chainlink-0-a": "chainlink-0",
+			"geth-a":        "geth",
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]usageSample{
+			"chainlink-0": {cpu: 252, memory: 576 * 1024 * 1024},
+			"geth":        {cpu: 1000, memory: 1024 * 1024 * 1024},
+		}, usage)
+	})
+	t.Run("invalid quantity", func(t *testing.T) {
+		var metrics podMetricsList
+		require.NoError(t, json.Unmarshal([]byte(`{"items": [
+			{"metadata": {"name": "geth-a"}, "containers": [{"name": "geth", "usage": {"cpu": "lots"}}]}
+		]}`), &metrics))
+		_, err := aggregateUsage(metrics, map[string]string{"geth-a": "geth"})
+		require.Error(t, err)
+	})
+	t.Run("keeps peaks", func(t *testing.T) {
+		u := &UsageSampler{mu: &sync.Mutex{}, usage: make(map[string]*ChartUsage)}
+		u.record(map[string]usageSample{"geth": {cpu: 100, memory: 10}}, map[string]usageSample{"geth": {cpu: 500, memory: 100}})
+		u.record(map[string]usageSample{"geth": {cpu: 50, memory: 20}}, map[string]usageSample{})
+		require.Equal(t, []ChartUsage{
+			{Chart: "geth", RequestedCPU: 500, PeakCPU: 100, RequestedMemory: 100, PeakMemory: 20, Samples: 2},
+		}, u.Summary())
+	})
+	t.Run("table", func(t *testing.T) {
+		table := usageTable([]ChartUsage{
+			{Chart: "geth", RequestedCPU: 1000, PeakCPU: 250, RequestedMemory: 1024 * 1024 * 1024, PeakMemory: 256 * 1024 * 1024},
+			{Chart: "mockserver", PeakCPU: 10, PeakMemory: 64 * 1024 * 1024},
+		})
+		lines := strings.Split(strings.TrimSpace(table), "\n")
+		require.Len(t, lines, 3)
+		require.Equal(t, []string{"geth", "1000m", "250m", "25%", "1024Mi", "256Mi", "25%"}, strings.Fields(lines[1]))
+		require.Equal(t, []string{"mockserver", "0m", "10m", "-", "0Mi", "64Mi", "-"}, strings.Fields(lines[2]))
+	})
+}
@@ -0,0 +1,130 @@
+// Package envtest wires environments into go tests: teardown with t.Cleanup, logs to t.Log and artifacts of failed tests
+package envtest
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+const (
+	// ArtifactsDir is where artifacts of failed tests are dumped, one directory per test
+	ArtifactsDir = "logs"
+	// maxPrefixLength leaves room for a random namespace suffix, namespaces are limited to 63 characters
+	maxPrefixLength  = 50
+	defaultNamespace = "chainlink-test-env"
+)
+
+var invalidNamespaceChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// testWriter writes logs with t.Log, so they are shown with the test output
+type testWriter struct {
+	t testing.TB
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Log(strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
+// NewEnvForTest creates an environment for the test, its namespace is named after the test and its logs are written with t.Log,
+// when the test is finished artifacts are dumped into ArtifactsDir if it failed and the environment is removed,
+// failed environments are kept if FailureBehavior is keep or freeze. Logs are redirected with the global logger,
+// so tests using it shouldn't run in parallel
+func NewEnvForTest(t testing.TB, cfg *environment.Config) *environment.Environment {
+	t.Helper()
+	if cfg == nil {
+		cfg = &environment.Config{}
+	}
+	if cfg.NamespacePrefix == "" {
+		cfg.NamespacePrefix = namespacePrefix(t.Name())
+	}
+	e := environment.New(cfg)
+	prev := log.Logger
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: testWriter{t}, NoColor: true}).Level(prev.GetLevel())
+	t.Cleanup(func() {
+		log.Logger = prev
+	})
+	t.Cleanup(func() {
+		teardown(t, e)
+	})
+	return e
+}
+
+// Run deploys the environment and fails the test if deployment fails, with RemoteRunner the local test stops when the runner
+// finishes, it fails with the runner exit code and is skipped otherwise, so its result isn't counted twice
+func Run(t testing.TB, e *environment.Environment) {
+	t.Helper()
+	err := e.Run()
+	if errors.Is(err, environment.ErrRemotePassed) {
+		t.Skip(err)
+	}
+	var remote *environment.RemoteExitError
+	if errors.As(err, &remote) {
+		t.Fatal(err)
+	}
+	if err != nil {
+		t.Fatalf("failed to deploy environment %s: %s", e.Cfg.Namespace, err)
+	}
+}
+
+func teardown(t testing.TB, e *environment.Environment) {
+	if e.Cfg.DryRun {
+		return
+	}
+	if t.Failed() {
+		dir := filepath.Join(ArtifactsDir, namespacePrefix(t.Name()))
+		if err := e.DumpLogs(dir); err != nil {
+			t.Logf("failed to dump artifacts of environment %s: %s", e.Cfg.Namespace, err)
+		} else {
+			t.Logf("artifacts of environment %s are dumped into %s", e.Cfg.Namespace, dir)
+		}
+		if e.Cfg.FailureBehavior == environment.FailureBehaviorKeep || e.Cfg.FailureBehavior == environment.FailureBehaviorFreeze {
+			t.Logf("environment %s is kept, set %s=%s to connect to it", e.Cfg.Namespace, config.EnvVarNamespace, e.Cfg.Namespace)
+			return
+		}
+	}
+	if err := e.Shutdown(); err != nil {
+		t.Errorf("failed to remove environment %s: %s", e.Cfg.Namespace, err)
+	}
+}
+
+// namespacePrefix converts a test name to a valid namespace prefix, "TestOCR/5_nodes" becomes "testocr-5-nodes"
+func namespacePrefix(name string) string {
+	prefix := invalidNamespaceChars.ReplaceAllString(strings.ToLower(name), "-")
+	prefix = strings.Trim(prefix, "-")
+	if len(prefix) > maxPrefixLength {
+		prefix = strings.TrimRight(prefix[:maxPrefixLength], "-")
+	}
+	if prefix == "" {
+		return defaultNamespace
+	}
+	return prefix
+}
+
+// AssertGolden compares the environment snapshot and key metrics with a golden file and fails the test on differences,
+// run tests with config.EnvVarUpdateGolden set to create or update golden files
+func AssertGolden(t testing.TB, e *environment.Environment, path string, metrics map[string]interface{}) {
+	t.Helper()
+	s, err := e.Snapshot()
+	if err != nil {
+		t.Fatalf("failed to take a snapshot of environment %s: %s", e.Cfg.Namespace, err)
+	}
+	for name, v := range metrics {
+		s.AddMetric(name, v)
+	}
+	diffs, err := environment.CompareGolden(s, path)
+	if err != nil {
+		t.Fatalf("failed to compare environment %s with golden file: %s", e.Cfg.Namespace, err)
+	}
+	for _, d := range diffs {
+		t.Errorf("environment differs from %s: %s %s: expected %q, got %q", path, d.Release, d.Field, d.A, d.B)
+	}
+}
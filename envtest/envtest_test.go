@@ -0,0 +1,18 @@
+package envtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespacePrefix(t *testing.T) {
+	require.Equal(t, "testocr-5-nodes", namespacePrefix("TestOCR/5_nodes"))
+	require.Equal(t, defaultNamespace, namespacePrefix("/_/"))
+	long := namespacePrefix("Test" + strings.Repeat("a", 48) + "_b")
+	require.Len(t, long, 50)
+	require.False(t, strings.HasSuffix(long, "-"))
+	trimmed := namespacePrefix("Test" + strings.Repeat("a", 45) + "_bcd")
+	require.Equal(t, "test"+strings.Repeat("a", 45), trimmed)
+}
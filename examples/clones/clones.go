@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"github.com/smartcontractkit/chainlink-env/environment"
 	"github.com/smartcontractkit/chainlink-env/pkg"
 	"github.com/smartcontractkit/chainlink-env/pkg/helm/chainlink"
@@ -11,7 +10,7 @@ import (
 func main() {
 	// Multiple environments of the same type/chart
 	err := environment.New(&environment.Config{
-		Labels:            []string{fmt.Sprintf("envType=%s", pkg.EnvTypeEVM5)},
+		Labels:            map[string]string{"envType": pkg.EnvTypeEVM5},
 		KeepConnection:    true,
 		RemoveOnInterrupt: true,
 	}).
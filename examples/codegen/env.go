@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// prints Go code recreating an environment exported with Export, e.g. go run ./examples/codegen env.tar.gz > env.go
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Println("usage: codegen <exported environment archive>")
+		os.Exit(1)
+	}
+	code, err := environment.GoCodeFromArchive(os.Args[1])
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(code))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Connects to an existing environment without applying anything and prints its components and URLs,
+// go run examples/connect/env.go -namespace chainlink-test-env-abcde [-keep]
+func main() {
+	ns := flag.String("namespace", "", "namespace of the environment")
+	keep := flag.Bool("keep", false, "keep forwarded ports until interrupted")
+	flag.Parse()
+	e, err := environment.Connect(*ns, &environment.Config{KeepConnection: *keep})
+	if err != nil {
+		panic(err)
+	}
+	// closes forwarded ports only, nothing is removed from the namespace connected to
+	defer func() { _ = e.Shutdown() }()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tPODS")
+	for _, c := range e.Components {
+		fmt.Fprintf(w, "%s\t%s\n", c.Name, strings.Join(c.Pods, ","))
+	}
+	fmt.Fprintln(w, "\nURL\t")
+	keys := make([]string, 0, len(e.URLs))
+	for k := range e.URLs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", k, strings.Join(e.URLs[k], ","))
+	}
+	_ = w.Flush()
+	if *keep {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+	}
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"flag"
+
 	"github.com/smartcontractkit/chainlink-env/environment"
 	"github.com/smartcontractkit/chainlink-env/pkg/helm/chainlink"
 	"github.com/smartcontractkit/chainlink-env/pkg/helm/ethereum"
 )
 
+// Dumps logs without noisy lines declared by charts, go run examples/dump/env.go [-raw]
 func main() {
-	e := environment.New(nil).
+	raw := flag.Bool("raw", false, "keep noisy log lines declared by charts")
+	flag.Parse()
+	e := environment.New(&environment.Config{RawLogs: *raw}).
 		AddHelm(ethereum.New(nil)).
 		AddHelm(chainlink.New(0, nil))
 	if err := e.Run(); err != nil {
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Tails warning events of an environment, ENV_NAMESPACE=chainlink-test-env-abcde go run examples/events/env.go [-all] [-no-color]
+func main() {
+	all := flag.Bool("all", false, "show Normal events too")
+	noColor := flag.Bool("no-color", false, "disable colors")
+	flag.Parse()
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	err := environment.TailEvents(ctx, client.NewK8sClient(), os.Getenv(config.EnvVarNamespace), environment.EventsOptions{
+		AllTypes: *all,
+		NoColor:  *noColor,
+	}, os.Stdout)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Runs a chaos scenario against an environment,
+// ENV_NAMESPACE=chainlink-test-env-abcde go run examples/gameday/env.go -scenario examples/gameday/scenario.yaml
+func main() {
+	scenario := flag.String("scenario", "examples/gameday/scenario.yaml", "chaos scenario file")
+	flag.Parse()
+	e := environment.New(nil)
+	if err := e.Run(); err != nil {
+		panic(err)
+	}
+	results, err := e.RunScenarioFile(*scenario)
+	for _, r := range results {
+		log.Info().
+			Str("Step", r.Step).
+			Str("Experiment", r.Experiment).
+			Dur("Recovered", r.Recovered).
+			AnErr("Err", r.Err).
+			Msg("Step result")
+	}
+	if err != nil {
+		panic(err)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"github.com/smartcontractkit/chainlink-env/environment"
 	"github.com/smartcontractkit/chainlink-env/pkg/cdk8s/blockscout"
 	"github.com/smartcontractkit/chainlink-env/pkg/helm/chainlink"
@@ -11,7 +10,7 @@ import (
 func main() {
 	e := environment.New(&environment.Config{
 		NamespacePrefix: "modified-env",
-		Labels:          []string{fmt.Sprintf("envType=Modified")},
+		Labels:          map[string]string{"envType": "Modified"},
 	}).
 		AddChart(blockscout.New(&blockscout.Props{
 			WsURL:   "ws://geth:8546",
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"github.com/smartcontractkit/chainlink-env/environment"
 	"github.com/smartcontractkit/chainlink-env/pkg/helm/chainlink"
 	"github.com/smartcontractkit/chainlink-env/pkg/helm/ethereum"
@@ -12,7 +11,7 @@ import (
 func main() {
 	e := environment.New(&environment.Config{
 		NamespacePrefix: "modified-env",
-		Labels:          []string{fmt.Sprintf("envType=Modified")},
+		Labels:          map[string]string{"envType": "Modified"},
 	}).
 		AddHelm(mockservercfg.New(nil)).
 		AddHelm(mockserver.New(nil)).
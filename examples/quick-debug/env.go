@@ -8,7 +8,7 @@ import (
 
 func main() {
 	err := environment.New(&environment.Config{
-		Labels:            []string{"type=construction-in-progress"},
+		Labels:            map[string]string{"type": "construction-in-progress"},
 		NamespacePrefix:   "new-environment",
 		KeepConnection:    true,
 		RemoveOnInterrupt: true,
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/smartcontractkit/chainlink-env/environment"
 	"github.com/smartcontractkit/chainlink-env/pkg"
 	"github.com/smartcontractkit/chainlink-env/pkg/helm/chainlink"
@@ -15,7 +13,7 @@ import (
 func main() {
 	// example of quick usage to debug env, removed on SIGINT
 	err := environment.New(&environment.Config{
-		Labels:         []string{fmt.Sprintf("envType=%s", pkg.EnvTypeEVM5RemoteRunner)},
+		Labels:         map[string]string{"envType": pkg.EnvTypeEVM5RemoteRunner},
 		KeepConnection: true,
 	}).
 		AddHelm(mockservercfg.New(nil)).
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/smartcontractkit/chainlink-env/environment"
 	"github.com/smartcontractkit/chainlink-env/pkg"
@@ -11,7 +10,7 @@ import (
 
 func main() {
 	e := environment.New(&environment.Config{
-		Labels: []string{fmt.Sprintf("envType=%s", pkg.EnvTypeEVM5)},
+		Labels: map[string]string{"envType": pkg.EnvTypeEVM5},
 	}).
 		AddHelm(ethereum.New(nil)).
 		AddHelm(chainlink.New(0, nil))
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+)
+
+// Lists environments whose heartbeat is stale, go run examples/stale/env.go [-max-age 30m] [-remove]
+func main() {
+	maxAge := flag.Duration("max-age", client.DefaultStaleHeartbeatAge, "heartbeat age after which an environment is stale")
+	remove := flag.Bool("remove", false, "remove stale environments")
+	flag.Parse()
+	c := client.NewK8sClient()
+	if *remove {
+		removed, err := c.CleanupStaleNamespaces(*maxAge)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("removed %d stale environments\n", len(removed))
+		return
+	}
+	stale, err := c.StaleNamespaces(*maxAge)
+	if err != nil {
+		panic(err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tLAST HEARTBEAT\tSILENT FOR\tCREATED")
+	for _, s := range stale {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Namespace, s.Heartbeat.Format(time.RFC3339), s.Silence.Round(time.Minute), s.Created.Format(time.RFC3339))
+	}
+	_ = w.Flush()
+}
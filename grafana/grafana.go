@@ -0,0 +1,9 @@
+// Package grafana embeds Grafana dashboards of the repository, the monitoring chart provisions them
+package grafana
+
+import "embed"
+
+// Dashboards are JSON models of dashboards, file names without extensions are dashboard names
+//
+//go:embed *.json
+var Dashboards embed.FS
@@ -0,0 +1,121 @@
+package load
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/chainlink"
+	"k8s.io/utils/clock"
+)
+
+const (
+	DefaultNodeEmail    = client.DefaultChainlinkEmail
+	DefaultNodePassword = client.DefaultChainlinkPassword
+)
+
+// NodeClient is a minimal Chainlink node API client to create jobs and trigger runs
+type NodeClient = client.ChainlinkClient
+
+// NewNodeClient creates a new node client with a session cookie jar
+func NewNodeClient(url string, email string, password string) (*NodeClient, error) {
+	return client.NewChainlinkClient(url, email, password)
+}
+
+// NodesFromEnvironment creates clients with default credentials for all deployed Chainlink nodes
+func NodesFromEnvironment(e *environment.Environment) ([]*NodeClient, error) {
+	nodes := make([]*NodeClient, 0)
+	for _, u := range e.URLs[chainlink.NodesLocalURLsKey] {
+		n, err := NewNodeClient(u, DefaultNodeEmail, DefaultNodePassword)
+		if err != nil {
+			return nil, err
+		}
+		if err := n.Login(); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// WebhookJobGun triggers runs of a webhook job and waits for their completion, so latency is a full run time
+type WebhookJobGun struct {
+	Node  *NodeClient
+	JobID string
+}
+
+// NewWebhookJobGun creates a webhook job from the spec on the node
+func NewWebhookJobGun(node *NodeClient, spec string) (*WebhookJobGun, error) {
+	id, err := node.CreateJob(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create webhook job")
+	}
+	return &WebhookJobGun{Node: node, JobID: id}, nil
+}
+
+func (g *WebhookJobGun) Call() error {
+	runID, err := g.Node.RunJob(g.JobID)
+	if err != nil {
+		return err
+	}
+	return g.Node.WaitRun(g.JobID, runID)
+}
+
+// DirectRequestGun sends an oracle request on chain and waits for the completion of a run of the direct request job,
+// so latency includes the block time, this repository has no chain client, so Request sends the request,
+// e.g. with an operator contract and a LINK token deployed by a ChainBootstrapper
+type DirectRequestGun struct {
+	Node    *NodeClient
+	JobID   string
+	Request func() error
+	mu      sync.Mutex
+	claimed map[string]bool
+}
+
+// NewDirectRequestGun creates a direct request job from the spec on the node, request sends one oracle request
+func NewDirectRequestGun(node *NodeClient, spec string, request func() error) (*DirectRequestGun, error) {
+	id, err := node.CreateJob(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create direct request job")
+	}
+	return &DirectRequestGun{Node: node, JobID: id, Request: request, claimed: make(map[string]bool)}, nil
+}
+
+func (g *DirectRequestGun) Call() error {
+	if err := g.Request(); err != nil {
+		return errors.Wrap(err, "failed to send direct request")
+	}
+	runID, err := g.claimRun()
+	if err != nil {
+		return err
+	}
+	return g.Node.WaitRun(g.JobID, runID)
+}
+
+// claimRun waits for the oldest run of the job which is not claimed by another call yet,
+// runs can't be matched to requests, so concurrent calls take them in order
+func (g *DirectRequestGun) claimRun() (string, error) {
+	var clk clock.Clock = clock.RealClock{}
+	if g.Node.Clock != nil {
+		clk = g.Node.Clock
+	}
+	deadline := clk.Now().Add(client.ChainlinkRunTimeout)
+	for clk.Now().Before(deadline) {
+		runs, err := g.Node.JobRuns(g.JobID)
+		if err != nil {
+			return "", err
+		}
+		g.mu.Lock()
+		for i := len(runs) - 1; i >= 0; i-- {
+			if !g.claimed[runs[i]] {
+				g.claimed[runs[i]] = true
+				g.mu.Unlock()
+				return runs[i], nil
+			}
+		}
+		g.mu.Unlock()
+		clk.Sleep(client.ChainlinkRunPollInterval)
+	}
+	return "", errors.Errorf("no run of direct request job %s in %s", g.JobID, client.ChainlinkRunTimeout)
+}
@@ -0,0 +1,221 @@
+package load
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"k8s.io/utils/clock"
+)
+
+const (
+	DefaultMaxInFlight = 100
+	// MaxErrorsInStats max number of distinct errors kept in stats
+	MaxErrorsInStats = 20
+)
+
+// Gun makes one call of a load test, for example, triggers a job run and waits until it's completed
+type Gun interface {
+	Call() error
+}
+
+// GunFunc allows to use a function as a Gun
+type GunFunc func() error
+
+func (f GunFunc) Call() error {
+	return f()
+}
+
+// Profile is a load profile, calls are made with a constant rate during the duration
+type Profile struct {
+	// Rate calls per second
+	Rate int
+	// Duration of the load
+	Duration time.Duration
+	// MaxInFlight max number of concurrent calls, calls are delayed when it's reached
+	MaxInFlight int
+}
+
+// Stats is a latency and failure statistics of a load run
+type Stats struct {
+	Name     string        `json:"name"`
+	Rate     int           `json:"rate"`
+	Duration time.Duration `json:"duration"`
+	Requests int           `json:"requests"`
+	Failures int           `json:"failures"`
+	Errors   []string      `json:"errors,omitempty"`
+	Min      time.Duration `json:"min"`
+	Max      time.Duration `json:"max"`
+	Mean     time.Duration `json:"mean"`
+	P50      time.Duration `json:"p50"`
+	P95      time.Duration `json:"p95"`
+	P99      time.Duration `json:"p99"`
+}
+
+// Runner makes calls of the gun according to the profile and collects stats
+type Runner struct {
+	Name    string
+	Profile Profile
+	Gun     Gun
+	// Clock of the rate, the duration and latencies, the real clock if nil
+	Clock     clock.WithTicker
+	mu        sync.Mutex
+	latencies []time.Duration
+	failures  int
+	errs      map[string]struct{}
+}
+
+// NewRunner creates a new load runner
+func NewRunner(name string, profile Profile, gun Gun) (*Runner, error) {
+	if profile.Rate <= 0 {
+		return nil, errors.New("rate must be positive")
+	}
+	if profile.Duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+	if profile.MaxInFlight <= 0 {
+		profile.MaxInFlight = DefaultMaxInFlight
+	}
+	return &Runner{
+		Name:      name,
+		Profile:   profile,
+		Gun:       gun,
+		latencies: make([]time.Duration, 0),
+		errs:      make(map[string]struct{}),
+	}, nil
+}
+
+// Run runs the load until profile duration passes or context is cancelled, waits for all calls in flight
+func (r *Runner) Run(ctx context.Context) *Stats {
+	log.Info().
+		Str("Name", r.Name).
+		Int("Rate", r.Profile.Rate).
+		Str("Duration", r.Profile.Duration.String()).
+		Msg("Starting load")
+	clk := r.clock()
+	start := clk.Now()
+	ticker := clk.NewTicker(time.Second / time.Duration(r.Profile.Rate))
+	defer ticker.Stop()
+	// the deadline is counted from the start, so the ticker is never ahead of it
+	timer := clk.NewTimer(start.Add(r.Profile.Duration).Sub(clk.Now()))
+	defer timer.Stop()
+	inFlight := make(chan struct{}, r.Profile.MaxInFlight)
+	wg := &sync.WaitGroup{}
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-timer.C():
+			break loop
+		case <-ticker.C():
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				break loop
+			case <-timer.C():
+				break loop
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-inFlight }()
+				r.call()
+			}()
+		}
+	}
+	wg.Wait()
+	stats := r.stats(clk.Since(start))
+	log.Info().
+		Str("Name", stats.Name).
+		Int("Requests", stats.Requests).
+		Int("Failures", stats.Failures).
+		Str("P50", stats.P50.String()).
+		Str("P95", stats.P95.String()).
+		Str("P99", stats.P99.String()).
+		Msg("Load finished")
+	return stats
+}
+
+func (r *Runner) clock() clock.WithTicker {
+	if r.Clock == nil {
+		return clock.RealClock{}
+	}
+	return r.Clock
+}
+
+func (r *Runner) call() {
+	clk := r.clock()
+	start := clk.Now()
+	err := r.Gun.Call()
+	latency := clk.Since(start)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failures++
+		if len(r.errs) < MaxErrorsInStats {
+			r.errs[err.Error()] = struct{}{}
+		}
+		log.Debug().Err(err).Str("Name", r.Name).Msg("Load call failed")
+		return
+	}
+	r.latencies = append(r.latencies, latency)
+}
+
+func (r *Runner) stats(d time.Duration) *Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := newStats(r.Name, r.latencies, r.failures)
+	s.Rate = r.Profile.Rate
+	s.Duration = d
+	for e := range r.errs {
+		s.Errors = append(s.Errors, e)
+	}
+	sort.Strings(s.Errors)
+	return s
+}
+
+// newStats calculates latency statistics of successful calls
+func newStats(name string, latencies []time.Duration, failures int) *Stats {
+	s := &Stats{
+		Name:     name,
+		Requests: len(latencies) + failures,
+		Failures: failures,
+	}
+	if len(latencies) == 0 {
+		return s
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Mean = total / time.Duration(len(sorted))
+	s.P50 = percentile(sorted, 50)
+	s.P95 = percentile(sorted, 95)
+	s.P99 = percentile(sorted, 99)
+	return s
+}
+
+// percentile returns nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// String returns a short summary of stats
+func (s *Stats) String() string {
+	return fmt.Sprintf("%s: %d requests, %d failures, p50 %s, p95 %s, p99 %s",
+		s.Name, s.Requests, s.Failures, s.P50, s.P95, s.P99)
+}
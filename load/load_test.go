@@ -0,0 +1,109 @@
+package load
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestNewStats(t *testing.T) {
+	latencies := make([]time.Duration, 0)
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	s := newStats("test", latencies, 5)
+	require.Equal(t, 105, s.Requests)
+	require.Equal(t, 5, s.Failures)
+	require.Equal(t, time.Millisecond, s.Min)
+	require.Equal(t, 100*time.Millisecond, s.Max)
+	require.Equal(t, 50500*time.Microsecond, s.Mean)
+	require.Equal(t, 50*time.Millisecond, s.P50)
+	require.Equal(t, 95*time.Millisecond, s.P95)
+	require.Equal(t, 99*time.Millisecond, s.P99)
+
+	empty := newStats("empty", nil, 1)
+	require.Equal(t, 1, empty.Requests)
+	require.Equal(t, time.Duration(0), empty.P99)
+}
+
+func TestRunner(t *testing.T) {
+	var calls int64
+	clk := clocktesting.NewFakeClock(time.Now())
+	r, err := NewRunner("test", Profile{Rate: 100, Duration: 295 * time.Millisecond}, GunFunc(func() error {
+		if atomic.AddInt64(&calls, 1)%2 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	}))
+	require.NoError(t, err)
+	r.Clock = clk
+	done := make(chan *Stats)
+	go func() {
+		done <- r.Run(context.Background())
+	}()
+	require.Eventually(t, clk.HasWaiters, time.Second, time.Millisecond)
+	for i := int64(1); i <= 29; i++ {
+		clk.Step(10 * time.Millisecond)
+		require.Eventually(t, func() bool { return atomic.LoadInt64(&calls) == i }, time.Second, time.Millisecond)
+	}
+	clk.Step(5 * time.Millisecond)
+	s := <-done
+	require.Equal(t, 29, s.Requests)
+	require.Equal(t, 14, s.Failures)
+	require.Equal(t, 295*time.Millisecond, s.Duration)
+	require.Equal(t, []string{"failed"}, s.Errors)
+
+	_, err = NewRunner("bad", Profile{}, nil)
+	require.Error(t, err)
+}
+
+func TestDirectRequestGun(t *testing.T) {
+	var mu sync.Mutex
+	runs := make([]string, 0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/jobs", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"id": "7"}})
+	})
+	mux.HandleFunc("/v2/jobs/7/runs", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		data := make([]map[string]interface{}, 0)
+		for i := len(runs) - 1; i >= 0; i-- {
+			data = append(data, map[string]interface{}{"id": runs[i]})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	})
+	mux.HandleFunc("/v2/jobs/7/runs/", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"id": path.Base(r.URL.Path), "attributes": map[string]interface{}{"state": "completed"}},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	node, err := NewNodeClient(srv.URL, DefaultNodeEmail, DefaultNodePassword)
+	require.NoError(t, err)
+	g, err := NewDirectRequestGun(node, "type = \"directrequest\"", func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, strconv.Itoa(len(runs)+1))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "7", g.JobID)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, g.Call())
+	}
+	require.Equal(t, map[string]bool{"1": true, "2": true, "3": true}, g.claimed)
+}
@@ -0,0 +1,44 @@
+package load
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/mockserver"
+)
+
+// PriceFlipGun switches a mockserver price expectation between values on every call,
+// OCR feeds reading the path observe a new answer each round
+type PriceFlipGun struct {
+	Client *client.MockserverClient
+	Path   string
+	Prices []int
+	mu     sync.Mutex
+	next   int
+}
+
+// NewPriceFlipGun creates a gun flipping prices of an external adapter path of the deployed mockserver
+func NewPriceFlipGun(e *environment.Environment, path string, prices ...int) (*PriceFlipGun, error) {
+	urls := e.URLs[mockserver.URLsKey]
+	if len(urls) == 0 {
+		return nil, errors.New("mockserver is not deployed")
+	}
+	if len(prices) == 0 {
+		return nil, errors.New("no prices to flip")
+	}
+	return &PriceFlipGun{
+		Client: client.NewMockserverClient(urls[0]),
+		Path:   path,
+		Prices: prices,
+	}, nil
+}
+
+func (g *PriceFlipGun) Call() error {
+	g.mu.Lock()
+	price := g.Prices[g.next%len(g.Prices)]
+	g.next++
+	g.mu.Unlock()
+	return errors.Wrap(g.Client.SetValuePath(g.Path, price), "failed to set price")
+}
@@ -0,0 +1,31 @@
+package load
+
+import (
+	"context"
+	"sync"
+
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+const (
+	ReportName = "load"
+)
+
+// RunAll runs all runners concurrently and adds their stats to the environment artifacts as a "load" report
+func RunAll(ctx context.Context, e *environment.Environment, runners ...*Runner) []*Stats {
+	stats := make([]*Stats, len(runners))
+	wg := &sync.WaitGroup{}
+	for i, r := range runners {
+		i, r := i, r
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats[i] = r.Run(ctx)
+		}()
+	}
+	wg.Wait()
+	if e.Artifacts != nil {
+		e.Artifacts.AddReport(ReportName, stats)
+	}
+	return stats
+}
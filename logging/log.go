@@ -2,13 +2,21 @@ package logging
 
 import (
 	"os"
+	"sync"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/smartcontractkit/chainlink-env/config"
 )
 
+var initOnce sync.Once
+
+// Init sets up the global logger once, so it's safe to create environments concurrently
 func Init() {
+	initOnce.Do(setup)
+}
+
+func setup() {
 	lvlStr := os.Getenv(config.EnvVarLogLevel)
 	if lvlStr == "" {
 		lvlStr = "info"
@@ -17,6 +17,10 @@ func Num(value float64) *float64 {
 	return jsii.Number(value)
 }
 
+func Bool(value bool) *bool {
+	return jsii.Bool(value)
+}
+
 // ShortDur is a helper method for kube-janitor duration format
 func ShortDur(d time.Duration) *string {
 	s := d.String()
@@ -41,6 +45,15 @@ func ConvertLabels(labels []string) (*map[string]*string, error) {
 	return &cdk8sLabels, nil
 }
 
+// ConvertLabelsMap converts labels map to cdk8s labels
+func ConvertLabelsMap(labels map[string]string) *map[string]*string {
+	cdk8sLabels := make(map[string]*string)
+	for k, v := range labels {
+		cdk8sLabels[k] = Str(v)
+	}
+	return &cdk8sLabels
+}
+
 // EnvVarStr quick shortcut for string/string key/value var
 func EnvVarStr(k, v string) *k8s.EnvVar {
 	return &k8s.EnvVar{
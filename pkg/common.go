@@ -5,6 +5,12 @@ import a "github.com/smartcontractkit/chainlink-env/pkg/alias"
 // Common labels for k8s envs
 const (
 	TTLLabelKey = "janitor/ttl"
+	// NamespaceTTLLabelKey is a TTL of an environment namespace, expired namespaces are removed by client.CleanupExpiredNamespaces
+	NamespaceTTLLabelKey = "chainlink-env/ttl"
+	// ReleaseLabelKey marks all resources of a chart, used to remove only one chart from the environment
+	ReleaseLabelKey = "chainlink-env/release"
+	// HookLabelKey marks pods of chart hook jobs, they are not checked for readiness
+	HookLabelKey = "chainlink-env/hook"
 )
 
 // Environment types, envs got selected by having a label of that type
@@ -2,6 +2,7 @@ package chainlink
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/rs/zerolog/log"
 	"github.com/smartcontractkit/chainlink-env/client"
@@ -11,13 +12,11 @@ import (
 
 const (
 	AppName              = "chainlink"
-	NodesLocalURLsKey    = "chainlink_local"
+	NodesLocalURLsKey    = environment.ChainlinkURLsKey
 	NodesInternalURLsKey = "chainlink_internal"
 	DBsLocalURLsKey      = "chainlink_db"
 )
 
-type Props struct{}
-
 type Chart struct {
 	Name   string
 	Index  int
@@ -46,42 +45,80 @@ func (m Chart) GetValues() *map[string]interface{} {
 	return m.Values
 }
 
-func (m Chart) ExportData(e *environment.Environment) error {
+// Dependencies nodes are deployed after the default simulated chains, other chains are connected with output references
+func (m Chart) Dependencies() []string {
+	return []string{"geth", "sol"}
+}
+
+// Connections are URLs of nodes of a chart, in-cluster URLs inside K8s, forwarded ones otherwise
+type Connections struct {
+	nodes         []string
+	internalNodes []string
+	dbs           []string
+}
+
+// NodeURLs returns URLs of node APIs
+func (c *Connections) NodeURLs() []string {
+	return c.nodes
+}
+
+// InternalNodeURLs returns in-cluster URLs of node APIs
+func (c *Connections) InternalNodeURLs() []string {
+	return c.internalNodes
+}
+
+// DBURLs returns forwarded URLs of node databases
+func (c *Connections) DBURLs() []string {
+	return c.dbs
+}
+
+// Connections returns typed URLs of the chart nodes, see environment.Get
+func (m Chart) Connections(e *environment.Environment) (interface{}, error) {
+	e.Fwd.SetCredentials("chainlink-db", "postgres", client.Credentials{User: "postgres", Password: "node", Database: "chainlink"})
 	// fetching all apps with label app=chainlink-${deploymentIndex}:${instanceIndex}
 	pods, err := e.Fwd.Client.ListPods(e.Cfg.Namespace, fmt.Sprintf("app=%s", m.Name))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	c := &Connections{nodes: make([]string, 0), internalNodes: make([]string, 0), dbs: make([]string, 0)}
 	for i := 0; i < len(pods.Items); i++ {
-		n, err := e.Fwd.FindPort(fmt.Sprintf("%s:%d", m.Name, i), "node", "access").
-			As(client.LocalConnection, client.HTTP)
+		app := fmt.Sprintf("%s:%d", m.Name, i)
+		local, err := e.Fwd.FindPort(app, "node", "access").As(client.LocalConnection, client.HTTP)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		e.URLs[NodesLocalURLsKey] = append(e.URLs[NodesLocalURLsKey], n)
-		log.Info().Str("Deployment", m.Name).Int("Node", i).Str("URL", n).Msg("Local connection")
-	}
-	for i := 0; i < len(pods.Items); i++ {
-		n, err := e.Fwd.FindPort(fmt.Sprintf("%s:%d", m.Name, i), "node", "access").
-			As(client.RemoteConnection, client.HTTP)
+		internal, err := e.Fwd.FindPort(app, "node", "access").As(client.RemoteConnection, client.HTTP)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		db, err := e.Fwd.FindPort(app, "chainlink-db", "postgres").As(client.LocalConnection, client.HTTP)
+		if err != nil {
+			return nil, err
 		}
-		e.URLs[NodesInternalURLsKey] = append(e.URLs[NodesInternalURLsKey], n)
 		if e.Cfg.InsideK8s {
-			e.URLs[NodesLocalURLsKey] = e.URLs[NodesInternalURLsKey]
+			local = internal
 		}
-		log.Info().Str("Deployment", m.Name).Int("Node", i).Str("URL", n).Msg("Remote (in cluster) connection")
+		c.nodes = append(c.nodes, local)
+		c.internalNodes = append(c.internalNodes, internal)
+		c.dbs = append(c.dbs, db)
 	}
-	for i := 0; i < len(pods.Items); i++ {
-		n, err := e.Fwd.FindPort(fmt.Sprintf("%s:%d", m.Name, i), "chainlink-db", "postgres").
-			As(client.LocalConnection, client.HTTP)
-		if err != nil {
-			return err
-		}
-		e.URLs[DBsLocalURLsKey] = append(e.URLs[DBsLocalURLsKey], n)
-		log.Info().Str("Deployment", m.Name).Int("Node", i).Str("URL", n).Msg("DB local Connection")
+	return c, nil
+}
+
+func (m Chart) ExportData(e *environment.Environment) error {
+	conns, err := m.Connections(e)
+	if err != nil {
+		return err
+	}
+	c := conns.(*Connections)
+	for i := range c.nodes {
+		log.Info().Str("Deployment", m.Name).Int("Node", i).Str("URL", c.nodes[i]).Msg("Local connection")
+		log.Info().Str("Deployment", m.Name).Int("Node", i).Str("URL", c.internalNodes[i]).Msg("Remote (in cluster) connection")
+		log.Info().Str("Deployment", m.Name).Int("Node", i).Str("URL", c.dbs[i]).Msg("DB local Connection")
 	}
+	e.URLs[NodesLocalURLsKey] = append(e.URLs[NodesLocalURLsKey], c.nodes...)
+	e.URLs[NodesInternalURLsKey] = append(e.URLs[NodesInternalURLsKey], c.internalNodes...)
+	e.URLs[DBsLocalURLsKey] = append(e.URLs[DBsLocalURLsKey], c.dbs...)
 	return nil
 }
 
@@ -127,13 +164,79 @@ func defaultProps() map[string]interface{} {
 }
 
 func New(index int, props map[string]interface{}) environment.ConnectedChart {
-	dp := defaultProps()
-	config.MustEnvOverrideVersion(&dp)
-	config.MustMerge(&dp, props)
+	name := fmt.Sprintf("%s-%d", AppName, index)
+	dp := config.MustMergeValues(name,
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props},
+		config.ValuesLayer{Name: config.ValuesLayerEnv, Values: config.EnvOverrideValues()},
+	)
 	return Chart{
 		Index:  index,
-		Name:   fmt.Sprintf("%s-%d", AppName, index),
+		Name:   name,
 		Path:   "chainlink-qa/chainlink",
 		Values: &dp,
 	}
 }
+
+// init registers the "chainlink" component kind of config files, nodes are named by index
+func init() {
+	environment.RegisterComponent(AppName, func(spec environment.ComponentSpec) (environment.ConnectedChart, error) {
+		return New(spec.Index, spec.Values), nil
+	})
+}
+
+// Autoscaling autoscaler of nodes from props
+func (m Chart) Autoscaling() *environment.Autoscaling {
+	if m.Props == nil {
+		return nil
+	}
+	return m.Props.Autoscaling
+}
+
+// DisruptionBudget disruption budget of nodes from props
+func (m Chart) DisruptionBudget() *environment.DisruptionBudget {
+	if m.Props == nil {
+		return nil
+	}
+	return m.Props.DisruptionBudget
+}
+
+// ClockContainers node clock is shifted to trigger cron jobs and heartbeats, database clock stays the same
+func (m Chart) ClockContainers() []string {
+	return []string{"node"}
+}
+
+// StableServices a headless service for every node, "${chart}-node-${instance}", with several replicas pods are selected
+// by instance labels assigned on deployment, use one replica per chart, as NodeSet does, for names stable across pod restarts
+func (m Chart) StableServices() []environment.StableService {
+	replicas := m.replicas()
+	services := make([]environment.StableService, 0)
+	for i := 0; i < replicas; i++ {
+		selector := map[string]string{"app": m.Name}
+		if replicas > 1 {
+			selector["instance"] = strconv.Itoa(i)
+		}
+		services = append(services, environment.StableService{
+			Name:     fmt.Sprintf("%s-node-%d", m.Name, i),
+			Selector: selector,
+		})
+	}
+	return services
+}
+
+func (m Chart) replicas() int {
+	if m.Values == nil {
+		return 1
+	}
+	switch r := (*m.Values)["replicas"].(type) {
+	case int:
+		return r
+	case float64:
+		return int(r)
+	case string:
+		if n, err := strconv.Atoi(r); err == nil {
+			return n
+		}
+	}
+	return 1
+}
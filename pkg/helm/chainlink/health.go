@@ -0,0 +1,107 @@
+package chainlink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+const (
+	HealthCheckPassing   = "passing"
+	HealthRequestTimeout = 10 * time.Second
+)
+
+// HealthResponse is a JSON:API response of the node /health endpoint
+type HealthResponse struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Output string `json:"output"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// CheckHealth checks that every node reports all subsystems as passing on /health endpoint,
+// if EVM is enabled at least one EVM check must be present, so the node is connected to the chain
+func (m Chart) CheckHealth(e *environment.Environment) error {
+	pods, err := e.Fwd.Client.ListPods(e.Cfg.Namespace, fmt.Sprintf("app=%s", m.Name))
+	if err != nil {
+		return err
+	}
+	connType := client.LocalConnection
+	if e.Cfg.InsideK8s {
+		connType = client.RemoteConnection
+	}
+	c := &http.Client{Timeout: HealthRequestTimeout}
+	for i := 0; i < len(pods.Items); i++ {
+		u, err := e.Fwd.FindPort(fmt.Sprintf("%s:%d", m.Name, i), "node", "access").
+			As(connType, client.HTTP)
+		if err != nil {
+			return err
+		}
+		if err := m.checkNodeHealth(c, u); err != nil {
+			return errors.Wrapf(err, "node %d", i)
+		}
+	}
+	return nil
+}
+
+func (m Chart) checkNodeHealth(c *http.Client, url string) error {
+	resp, err := c.Get(fmt.Sprintf("%s/health", url))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var hr HealthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&hr); err != nil {
+		return errors.Wrapf(err, "failed to decode /health response, status: %d", resp.StatusCode)
+	}
+	return checkHealthResponse(hr, m.evmEnabled())
+}
+
+// checkHealthResponse returns an error describing all failing checks
+func checkHealthResponse(hr HealthResponse, evmEnabled bool) error {
+	if len(hr.Data) == 0 {
+		return errors.New("no health checks reported")
+	}
+	failing := make([]string, 0)
+	hasEVM := false
+	for _, check := range hr.Data {
+		if strings.HasPrefix(check.Attributes.Name, "EVM") {
+			hasEVM = true
+		}
+		if check.Attributes.Status != HealthCheckPassing {
+			failing = append(failing, fmt.Sprintf("%s: %s %s", check.Attributes.Name, check.Attributes.Status, check.Attributes.Output))
+		}
+	}
+	if len(failing) != 0 {
+		return errors.Errorf("failing checks: %s", strings.Join(failing, ", "))
+	}
+	if evmEnabled && !hasEVM {
+		return errors.New("no EVM checks reported, node is not connected to the chain yet")
+	}
+	return nil
+}
+
+func (m Chart) evmEnabled() bool {
+	if m.Values == nil {
+		return true
+	}
+	env, ok := (*m.Values)["env"].(map[string]interface{})
+	if !ok {
+		return true
+	}
+	enabled, ok := env["EVM_ENABLED"]
+	if !ok {
+		return true
+	}
+	return fmt.Sprint(enabled) != "false"
+}
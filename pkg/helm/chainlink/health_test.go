@@ -0,0 +1,31 @@
+package chainlink
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealthResponse(t *testing.T) {
+	parse := func(s string) HealthResponse {
+		var hr HealthResponse
+		require.NoError(t, json.Unmarshal([]byte(s), &hr))
+		return hr
+	}
+	passing := parse(`{"data":[
+{"type":"checks","id":"EVM.1337.HeadTracker","attributes":{"name":"EVM.1337.HeadTracker","status":"passing","output":""}},
+{"type":"checks","id":"PipelineRunner","attributes":{"name":"PipelineRunner","status":"passing","output":""}}]}`)
+	require.NoError(t, checkHealthResponse(passing, true))
+
+	failing := parse(`{"data":[
+{"type":"checks","id":"EVM.1337.HeadTracker","attributes":{"name":"EVM.1337.HeadTracker","status":"failing","output":"no heads"}}]}`)
+	require.ErrorContains(t, checkHealthResponse(failing, true), "EVM.1337.HeadTracker: failing no heads")
+
+	noEVM := parse(`{"data":[
+{"type":"checks","id":"PipelineRunner","attributes":{"name":"PipelineRunner","status":"passing","output":""}}]}`)
+	require.Error(t, checkHealthResponse(noEVM, true))
+	require.NoError(t, checkHealthResponse(noEVM, false))
+
+	require.Error(t, checkHealthResponse(HealthResponse{}, false))
+}
@@ -0,0 +1,12 @@
+package chainlink
+
+import (
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// NoisyLogs Postgres checkpoint lines of node databases are filtered out of dumped and streamed logs
+func (m Chart) NoisyLogs() []environment.LogNoise {
+	return []environment.LogNoise{
+		{Container: "chainlink-db", Pattern: `checkpoint (starting|complete)`},
+	}
+}
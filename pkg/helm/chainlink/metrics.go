@@ -0,0 +1,12 @@
+package chainlink
+
+import (
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// MetricsPorts nodes serve Prometheus metrics on the access port, the port is scraped on pods, so every node is a target
+func (m Chart) MetricsPorts() []environment.MetricsPort {
+	return []environment.MetricsPort{
+		{Port: "access", Path: "/metrics", Pod: true},
+	}
+}
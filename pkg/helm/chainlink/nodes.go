@@ -0,0 +1,159 @@
+package chainlink
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// NodesHook is called after nodes are added or removed, for example, to connect peers or to fund new nodes
+type NodesHook func(e *environment.Environment, added []int, removed []int) error
+
+// NodeSet extends or shrinks Chainlink nodes of a running environment, every added node is a separate chart instance
+type NodeSet struct {
+	env *environment.Environment
+	// Values of new nodes, values of the last node chart with one replica are used if nil
+	Values map[string]interface{}
+	// Hooks are called in order after every change
+	Hooks []NodesHook
+}
+
+// NewNodeSet creates a node set for the environment, hooks are called after every change
+func NewNodeSet(e *environment.Environment, hooks ...NodesHook) *NodeSet {
+	return &NodeSet{env: e, Hooks: hooks}
+}
+
+// ConnectNodeSet connects to an existing environment and returns a node set of Chainlink nodes found in the namespace,
+// so nodes of a long-lived environment are added and removed from another process, found nodes and added ones use values,
+// nodes found in the namespace are not redeployed
+func ConnectNodeSet(namespace string, cfg *environment.Config, values map[string]interface{}, hooks ...NodesHook) (*NodeSet, error) {
+	e := environment.New(cfg)
+	if !e.Client.NamespaceExists(namespace) {
+		return nil, errors.Errorf("namespace %s not found", namespace)
+	}
+	releases, err := e.Client.Releases(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range nodeIndices(releases) {
+		e.AddHelm(New(idx, values))
+	}
+	if err := e.Connect(namespace); err != nil {
+		return nil, err
+	}
+	s := NewNodeSet(e, hooks...)
+	s.Values = values
+	return s, nil
+}
+
+// nodeIndices returns sorted indices of Chainlink node releases, "${AppName}-${index}"
+func nodeIndices(releases []string) []int {
+	indices := make([]int, 0)
+	for _, name := range releases {
+		if !strings.HasPrefix(name, AppName+"-") {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimPrefix(name, AppName+"-"))
+		if err != nil {
+			continue
+		}
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// Releases returns sorted names of all chart releases of the environment namespace, not only Chainlink nodes
+func (s *NodeSet) Releases() ([]string, error) {
+	return s.env.Client.Releases(s.env.Cfg.Namespace)
+}
+
+// Environment returns the environment of the node set
+func (s *NodeSet) Environment() *environment.Environment {
+	return s.env
+}
+
+// Indices returns sorted indices of all Chainlink charts of the environment
+func (s *NodeSet) Indices() []int {
+	indices := make([]int, 0)
+	for _, c := range s.env.Charts {
+		if ch, ok := c.(Chart); ok {
+			indices = append(indices, ch.Index)
+		}
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+func (s *NodeSet) chart(index int) (Chart, bool) {
+	for _, c := range s.env.Charts {
+		if ch, ok := c.(Chart); ok && ch.Index == index {
+			return ch, true
+		}
+	}
+	return Chart{}, false
+}
+
+// newNodeValues returns values of a new node
+func (s *NodeSet) newNodeValues(indices []int) map[string]interface{} {
+	base := s.Values
+	if base == nil && len(indices) > 0 {
+		if last, ok := s.chart(indices[len(indices)-1]); ok && last.Values != nil {
+			base = *last.Values
+		}
+	}
+	return config.MergeValues(
+		config.ValuesLayer{Name: config.ValuesLayerPreset, Values: base},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: map[string]interface{}{"replicas": "1"}},
+	).Values
+}
+
+// AddNodes deploys n new nodes and returns their indices
+func (s *NodeSet) AddNodes(n int) ([]int, error) {
+	indices := s.Indices()
+	next := 0
+	if len(indices) > 0 {
+		next = indices[len(indices)-1] + 1
+	}
+	added := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		s.env.AddHelm(New(next+i, s.newNodeValues(indices)))
+		added = append(added, next+i)
+	}
+	log.Info().Ints("Nodes", added).Msg("Adding Chainlink nodes")
+	if err := s.env.Update(); err != nil {
+		return nil, errors.Wrap(err, "failed to add nodes")
+	}
+	return added, s.runHooks(added, nil)
+}
+
+// RemoveNodes removes nodes with the provided indices
+func (s *NodeSet) RemoveNodes(indices ...int) error {
+	for _, idx := range indices {
+		if _, ok := s.chart(idx); !ok {
+			return errors.Errorf("chainlink node %d not found in the environment", idx)
+		}
+	}
+	log.Info().Ints("Nodes", indices).Msg("Removing Chainlink nodes")
+	for _, idx := range indices {
+		if err := s.env.RemoveChart(fmt.Sprintf("%s-%d", AppName, idx)); err != nil {
+			return errors.Wrapf(err, "failed to remove node %d", idx)
+		}
+	}
+	return s.runHooks(nil, indices)
+}
+
+func (s *NodeSet) runHooks(added []int, removed []int) error {
+	for _, h := range s.Hooks {
+		if err := h(s.env, added, removed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
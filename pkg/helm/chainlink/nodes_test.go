@@ -0,0 +1,11 @@
+package chainlink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeIndices(t *testing.T) {
+	require.Equal(t, []int{0, 2, 10}, nodeIndices([]string{"chainlink-10", "geth", "chainlink-0", "chainlink-db", "chainlink-2"}))
+}
@@ -0,0 +1,19 @@
+package chainlink
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Outputs in-cluster URLs and database DSNs of the nodes, "node_${i}_url" and "node_${i}_db_url", "url" is the first node URL
+func (m Chart) Outputs(_ *environment.Environment) (map[string]string, error) {
+	outputs := make(map[string]string)
+	for i := 0; i < m.replicas(); i++ {
+		host := fmt.Sprintf("%s-node-%d", m.Name, i)
+		outputs[fmt.Sprintf("node_%d_url", i)] = fmt.Sprintf("http://%s:6688", host)
+		outputs[fmt.Sprintf("node_%d_db_url", i)] = fmt.Sprintf("postgresql://postgres:node@%s:5432/chainlink?sslmode=disable", host)
+	}
+	outputs["url"] = outputs["node_0_url"]
+	return outputs, nil
+}
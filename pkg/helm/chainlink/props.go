@@ -0,0 +1,202 @@
+package chainlink
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Props typed chart options, zero values keep chart defaults, Values override them for anything not typed here
+type Props struct {
+	// Replicas number of nodes
+	Replicas int
+	// Image node image repository
+	Image string
+	// Version node image tag
+	Version string
+	// Resources of the node container
+	Resources *Resources
+	// DB node database options
+	DB *DBProps
+	// EnvVars node environment variables
+	EnvVars map[string]string
+	// TOML node config
+	TOML string
+	// Autoscaling generates a HorizontalPodAutoscaler of nodes
+	Autoscaling *environment.Autoscaling
+	// DisruptionBudget generates a PodDisruptionBudget of nodes
+	DisruptionBudget *environment.DisruptionBudget
+	// Values raw chart values, applied over typed options
+	Values map[string]interface{}
+}
+
+// DBProps node database options
+type DBProps struct {
+	// Stateful keeps the database on a persistent volume
+	Stateful bool
+	// Capacity of the persistent volume, e.g. "10Gi"
+	Capacity string
+	// Resources of the database container
+	Resources *Resources
+}
+
+// Resources container requests and limits, quantities are K8s quantities, e.g. "500m" and "1Gi"
+type Resources struct {
+	RequestsCPU    string
+	RequestsMemory string
+	LimitsCPU      string
+	LimitsMemory   string
+}
+
+// NewFromProps creates a chart from typed options, options are validated, so a typo fails instead of deploying defaults
+func NewFromProps(index int, props *Props) (environment.ConnectedChart, error) {
+	if props == nil {
+		props = &Props{}
+	}
+	if err := props.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid props of %s-%d", AppName, index)
+	}
+	name := fmt.Sprintf("%s-%d", AppName, index)
+	dp := config.MustMergeValues(name,
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps()},
+		config.ValuesLayer{Name: config.ValuesLayerProps, Values: props.values()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props.Values},
+		config.ValuesLayer{Name: config.ValuesLayerEnv, Values: config.EnvOverrideValues()},
+	)
+	return Chart{
+		Index:  index,
+		Name:   name,
+		Path:   "chainlink-qa/chainlink",
+		Props:  props,
+		Values: &dp,
+	}, nil
+}
+
+// Validate checks replicas and resource quantities
+func (p *Props) Validate() error {
+	if p.Replicas < 0 {
+		return errors.Errorf("replicas must not be negative, got %d", p.Replicas)
+	}
+	if p.Image != "" && p.Version == "" {
+		return errors.New("image is set without a version")
+	}
+	if err := p.Resources.validate(); err != nil {
+		return errors.Wrap(err, "node resources")
+	}
+	if p.DB != nil {
+		if p.DB.Capacity != "" {
+			if _, err := resource.ParseQuantity(p.DB.Capacity); err != nil {
+				return errors.Wrapf(err, "db capacity %s", p.DB.Capacity)
+			}
+		}
+		if p.DB.Capacity != "" && !p.DB.Stateful {
+			return errors.New("db capacity is set, but db is not stateful")
+		}
+		if err := p.DB.Resources.validate(); err != nil {
+			return errors.Wrap(err, "db resources")
+		}
+	}
+	if p.Autoscaling != nil {
+		if err := p.Autoscaling.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.DisruptionBudget != nil {
+		if err := p.DisruptionBudget.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Resources) validate() error {
+	if r == nil {
+		return nil
+	}
+	for field, q := range map[string]string{
+		"requests cpu":    r.RequestsCPU,
+		"requests memory": r.RequestsMemory,
+		"limits cpu":      r.LimitsCPU,
+		"limits memory":   r.LimitsMemory,
+	} {
+		if q == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(q); err != nil {
+			return errors.Wrapf(err, "%s %s", field, q)
+		}
+	}
+	return nil
+}
+
+// values renders options as chart values
+func (p *Props) values() map[string]interface{} {
+	v := make(map[string]interface{})
+	if p.Replicas != 0 {
+		v["replicas"] = p.Replicas
+	}
+	cl := make(map[string]interface{})
+	if p.Version != "" {
+		image := map[string]interface{}{"version": p.Version}
+		if p.Image != "" {
+			image["image"] = p.Image
+		}
+		cl["image"] = image
+	}
+	if r := p.Resources.values(); r != nil {
+		cl["resources"] = r
+	}
+	if len(cl) != 0 {
+		v["chainlink"] = cl
+	}
+	if p.DB != nil {
+		db := map[string]interface{}{"stateful": p.DB.Stateful}
+		if p.DB.Capacity != "" {
+			db["capacity"] = p.DB.Capacity
+		}
+		if r := p.DB.Resources.values(); r != nil {
+			db["resources"] = r
+		}
+		v["db"] = db
+	}
+	if len(p.EnvVars) != 0 {
+		env := make(map[string]interface{}, len(p.EnvVars))
+		for k, val := range p.EnvVars {
+			env[k] = val
+		}
+		v["env"] = env
+	}
+	if p.TOML != "" {
+		v["toml"] = p.TOML
+	}
+	return v
+}
+
+func (r *Resources) values() map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	res := make(map[string]interface{})
+	for kind, q := range map[string][2]string{
+		"requests": {r.RequestsCPU, r.RequestsMemory},
+		"limits":   {r.LimitsCPU, r.LimitsMemory},
+	} {
+		m := make(map[string]interface{})
+		if q[0] != "" {
+			m["cpu"] = q[0]
+		}
+		if q[1] != "" {
+			m["memory"] = q[1]
+		}
+		if len(m) != 0 {
+			res[kind] = m
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
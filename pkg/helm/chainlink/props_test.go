@@ -0,0 +1,59 @@
+package chainlink
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropsValues(t *testing.T) {
+	p := &Props{
+		Replicas:  3,
+		Version:   "1.10.0",
+		Resources: &Resources{RequestsCPU: "500m", LimitsMemory: "2Gi"},
+		DB:        &DBProps{Stateful: true, Capacity: "10Gi"},
+		EnvVars:   map[string]string{"ETH_CHAIN_ID": "1337"},
+		TOML:      "[Log]\nLevel = 'debug'",
+	}
+	require.NoError(t, p.Validate())
+	require.Equal(t, map[string]interface{}{
+		"replicas": 3,
+		"chainlink": map[string]interface{}{
+			"image": map[string]interface{}{"version": "1.10.0"},
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"cpu": "500m"},
+				"limits":   map[string]interface{}{"memory": "2Gi"},
+			},
+		},
+		"db":   map[string]interface{}{"stateful": true, "capacity": "10Gi"},
+		"env":  map[string]interface{}{"ETH_CHAIN_ID": "1337"},
+		"toml": "[Log]\nLevel = 'debug'",
+	}, p.values())
+	require.Empty(t, (&Props{}).values())
+}
+
+func TestPropsValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		props Props
+		err   string
+	}{
+		{"negative replicas", Props{Replicas: -1}, "replicas must not be negative"},
+		{"image without version", Props{Image: "chainlink"}, "image is set without a version"},
+		{"bad cpu", Props{Resources: &Resources{LimitsCPU: "lots"}}, "node resources: limits cpu lots"},
+		{"bad capacity", Props{DB: &DBProps{Stateful: true, Capacity: "10GB"}}, "db capacity 10GB"},
+		{"capacity without stateful", Props{DB: &DBProps{Capacity: "10Gi"}}, "db is not stateful"},
+		{"bad db memory", Props{DB: &DBProps{Resources: &Resources{RequestsMemory: "1 Gi"}}}, "db resources: requests memory"},
+		{"bad autoscaling", Props{Autoscaling: &environment.Autoscaling{MinReplicas: 3, MaxReplicas: 2, TargetCPUUtilization: 80}}, "min replicas <= max replicas"},
+		{"autoscaling without targets", Props{Autoscaling: &environment.Autoscaling{MinReplicas: 1, MaxReplicas: 2}}, "utilization target"},
+		{"disruption budget without bounds", Props{DisruptionBudget: &environment.DisruptionBudget{}}, "min available or max unavailable"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.props.Validate()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
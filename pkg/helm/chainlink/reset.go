@@ -0,0 +1,39 @@
+package chainlink
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// resetDBStatements recreate the node database, new connections are disallowed first, so the node can't reconnect in between
+var resetDBStatements = []string{
+	"UPDATE pg_database SET datallowconn = false WHERE datname = 'chainlink'",
+	"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = 'chainlink'",
+	"DROP DATABASE IF EXISTS chainlink",
+	"CREATE DATABASE chainlink",
+}
+
+// ResetStage nodes are reset after networks and mocks they connect to
+func (m Chart) ResetStage() int {
+	return environment.ResetStageNodes
+}
+
+// Reset wipes databases of all nodes and restarts them, nodes run migrations and create their keys again
+func (m Chart) Reset(e *environment.Environment) error {
+	pods, err := e.Client.ListPods(e.Cfg.Namespace, fmt.Sprintf("app=%s", m.Name))
+	if err != nil {
+		return err
+	}
+	cmd := []string{"psql", "-U", "postgres", "-d", "postgres"}
+	for _, s := range resetDBStatements {
+		cmd = append(cmd, "-c", s)
+	}
+	for _, p := range pods.Items {
+		if _, _, err := e.Client.ExecuteInPod(e.Cfg.Namespace, p.Name, "chainlink-db", cmd); err != nil {
+			return errors.Wrapf(err, "failed to wipe database of pod %s", p.Name)
+		}
+	}
+	return e.RestartChart(m.Name)
+}
@@ -0,0 +1,116 @@
+package ea
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// ResponseExpectationID all requests to an adapter are answered by a single expectation, it's replaced on every change
+const ResponseExpectationID = "ea-response"
+
+// Response is a programmed adapter behaviour
+type Response struct {
+	// Value is returned as {"data":{"result":value},"result":value}
+	Value interface{}
+	// Latency delays every response
+	Latency time.Duration
+	// ErrorStatus if not 0, requests fail with this HTTP status instead of returning the value
+	ErrorStatus int
+}
+
+// Adapters sets responses of the environment adapters at runtime, e.g. for OCR soak tests:
+//
+//	adapters := ea.NewAdapters(e)
+//	err := adapters.SetPrice(0, 1500)
+//	err = adapters.SetLatency(1, 3*time.Second)
+//	err = adapters.SetError(2, http.StatusServiceUnavailable)
+type Adapters struct {
+	env       *environment.Environment
+	mu        sync.Mutex
+	responses map[int]*Response
+}
+
+// NewAdapters creates an API of the environment adapters
+func NewAdapters(e *environment.Environment) *Adapters {
+	return &Adapters{
+		env:       e,
+		responses: make(map[int]*Response),
+	}
+}
+
+// SetPrice sets a value returned by the adapter
+func (a *Adapters) SetPrice(index int, value interface{}) error {
+	return a.update(index, func(r *Response) {
+		r.Value = value
+	})
+}
+
+// SetLatency delays responses of the adapter, 0 removes the delay
+func (a *Adapters) SetLatency(index int, latency time.Duration) error {
+	return a.update(index, func(r *Response) {
+		r.Latency = latency
+	})
+}
+
+// SetError makes the adapter fail with an HTTP status, 0 makes it return the value again
+func (a *Adapters) SetError(index int, status int) error {
+	return a.update(index, func(r *Response) {
+		r.ErrorStatus = status
+	})
+}
+
+// Set replaces the adapter behaviour
+func (a *Adapters) Set(index int, resp Response) error {
+	return a.update(index, func(r *Response) {
+		*r = resp
+	})
+}
+
+func (a *Adapters) update(index int, f func(r *Response)) error {
+	urls := a.env.URLs[Name(index)]
+	if len(urls) == 0 {
+		return errors.Errorf("no adapter %d in the environment", index)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r, ok := a.responses[index]
+	if !ok {
+		r = &Response{}
+		a.responses[index] = r
+	}
+	f(r)
+	err := client.NewMockserverClient(urls[0]).PutExpectation(responseExpectation(*r))
+	return errors.Wrapf(err, "failed to program adapter %d", index)
+}
+
+// responseExpectation returns a mockserver expectation answering all requests with the response
+func responseExpectation(r Response) map[string]interface{} {
+	resp := map[string]interface{}{
+		"statusCode": 200,
+		"body": map[string]interface{}{
+			"data":   map[string]interface{}{"result": r.Value},
+			"result": r.Value,
+		},
+	}
+	if r.ErrorStatus != 0 {
+		resp = map[string]interface{}{
+			"statusCode": r.ErrorStatus,
+			"body":       map[string]interface{}{"error": "injected error"},
+		}
+	}
+	if r.Latency != 0 {
+		resp["delay"] = map[string]interface{}{
+			"timeUnit": "MILLISECONDS",
+			"value":    r.Latency.Milliseconds(),
+		}
+	}
+	return map[string]interface{}{
+		"id":           ResponseExpectationID,
+		"httpRequest":  map[string]interface{}{},
+		"httpResponse": resp,
+	}
+}
@@ -0,0 +1,21 @@
+package ea
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseExpectation(t *testing.T) {
+	exp := responseExpectation(Response{Value: 1500, Latency: 3 * time.Second})
+	resp := exp["httpResponse"].(map[string]interface{})
+	require.Equal(t, 200, resp["statusCode"])
+	require.Equal(t, 1500, resp["body"].(map[string]interface{})["result"])
+	require.Equal(t, map[string]interface{}{"timeUnit": "MILLISECONDS", "value": int64(3000)}, resp["delay"])
+
+	exp = responseExpectation(Response{Value: 1500, ErrorStatus: 503})
+	resp = exp["httpResponse"].(map[string]interface{})
+	require.Equal(t, 503, resp["statusCode"])
+	require.Nil(t, resp["delay"])
+}
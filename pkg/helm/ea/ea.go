@@ -0,0 +1,118 @@
+package ea
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Chart is a simulated external adapter, a mockserver instance whose responses are set at runtime with Adapters,
+// it uses the "mockserver-config" config map, so mockserver-cfg must be deployed before adapters
+type Chart struct {
+	Name   string
+	Path   string
+	Index  int
+	Values *map[string]interface{}
+}
+
+func (m Chart) IsDeploymentNeeded() bool {
+	return true
+}
+
+func (m Chart) GetName() string {
+	return m.Name
+}
+
+func (m Chart) GetPath() string {
+	return m.Path
+}
+
+func (m Chart) GetProps() interface{} {
+	return nil
+}
+
+func (m Chart) GetValues() *map[string]interface{} {
+	return m.Values
+}
+
+// ExportData exports local and in-cluster adapter URLs as e.URLs["ea-${index}"]
+func (m Chart) ExportData(e *environment.Environment) error {
+	local, err := e.Fwd.FindPort(fmt.Sprintf("%s:0", m.Name), "mockserver", "serviceport").As(client.LocalConnection, client.HTTP)
+	if err != nil {
+		return err
+	}
+	internal, err := e.Fwd.FindPort(fmt.Sprintf("%s:0", m.Name), "mockserver", "serviceport").As(client.RemoteConnection, client.HTTP)
+	if err != nil {
+		return err
+	}
+	if e.Cfg.InsideK8s {
+		local = internal
+	}
+	e.URLs[m.Name] = []string{local, internal}
+	log.Info().Str("Adapter", m.Name).Str("URL", local).Msg("External adapter connection")
+	return nil
+}
+
+// Outputs in-cluster adapter URL for bridges, "url"
+func (m Chart) Outputs(_ *environment.Environment) (map[string]string, error) {
+	return map[string]string{"url": fmt.Sprintf("http://%s:1080", m.Name)}, nil
+}
+
+// Name returns a chart name of an adapter
+func Name(index int) string {
+	return fmt.Sprintf("ea-%d", index)
+}
+
+func defaultProps() map[string]interface{} {
+	return map[string]interface{}{
+		"replicaCount": "1",
+		"service": map[string]interface{}{
+			"type": "NodePort",
+			"port": "1080",
+		},
+		"app": map[string]interface{}{
+			"logLevel":               "WARN",
+			"serverPort":             "1080",
+			"mountedConfigMapName":   "mockserver-config",
+			"propertiesFileName":     "mockserver.properties",
+			"readOnlyRootFilesystem": "false",
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{
+					"cpu":    "100m",
+					"memory": "256Mi",
+				},
+				"limits": map[string]interface{}{
+					"cpu":    "100m",
+					"memory": "256Mi",
+				},
+			},
+		},
+		"image": map[string]interface{}{
+			"repository": "mockserver",
+			"snapshot":   false,
+			"pullPolicy": "IfNotPresent",
+		},
+	}
+}
+
+// New creates an adapter instance, add one per index to simulate N adapters:
+//
+//	for i := 0; i < 3; i++ {
+//		e.AddHelm(ea.New(i, nil))
+//	}
+func New(index int, props map[string]interface{}) environment.ConnectedChart {
+	name := Name(index)
+	dp := config.MustMergeValues(name,
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props},
+	)
+	return Chart{
+		Name:   name,
+		Path:   "chainlink-qa/mockserver",
+		Index:  index,
+		Values: &dp,
+	}
+}
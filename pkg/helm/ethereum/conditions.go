@@ -0,0 +1,27 @@
+package ethereum
+
+import (
+	"net/http"
+
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Conditions the simulated network must produce blocks
+func (m Chart) Conditions() []environment.Condition {
+	if !m.Props.Simulated {
+		return nil
+	}
+	return []environment.Condition{
+		{
+			Name:      "block height",
+			App:       m.HelmProps.Name,
+			Container: "geth-network",
+			Port:      "http-rpc",
+			Method:    http.MethodPost,
+			Body:      `{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`,
+			JSONPath:  "result",
+			Op:        ">",
+			Value:     1,
+		},
+	}
+}
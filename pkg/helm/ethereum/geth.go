@@ -1,6 +1,8 @@
 package ethereum
 
 import (
+	"fmt"
+
 	"github.com/rs/zerolog/log"
 
 	"github.com/smartcontractkit/chainlink-env/client"
@@ -9,11 +11,15 @@ import (
 )
 
 type Props struct {
+	// Name is a release name, "geth" by default, set it to deploy more than one network
+	Name        string
 	NetworkName string   `envconfig:"network_name"`
 	Simulated   bool     `envconfig:"network_simulated"`
 	HttpURLs    []string `envconfig:"http_url"`
 	WsURLs      []string `envconfig:"ws_url"`
-	Values      map[string]interface{}
+	// Geth typed node options, rendered into values, Values override them
+	Geth   *GethOptions
+	Values map[string]interface{}
 }
 
 type HelmProps struct {
@@ -47,36 +53,86 @@ func (m Chart) GetValues() *map[string]interface{} {
 	return m.HelmProps.Values
 }
 
-func (m Chart) ExportData(e *environment.Environment) error {
-	if m.Props.Simulated {
-		gethLocalHttp, err := e.Fwd.FindPort("geth:0", "geth-network", "http-rpc").As(client.LocalConnection, client.HTTP)
-		if err != nil {
-			return err
-		}
-		gethInternalHttp, err := e.Fwd.FindPort("geth:0", "geth-network", "http-rpc").As(client.RemoteConnection, client.HTTP)
-		if err != nil {
-			return err
-		}
-		gethLocalWs, err := e.Fwd.FindPort("geth:0", "geth-network", "ws-rpc").As(client.LocalConnection, client.WS)
-		if err != nil {
-			return err
-		}
-		gethInternalWs, err := e.Fwd.FindPort("geth:0", "geth-network", "ws-rpc").As(client.RemoteConnection, client.WS)
-		if err != nil {
-			return err
+// Connections are RPC URLs of the network, in-cluster URLs inside K8s, forwarded ones otherwise,
+// external networks have only URLs of props
+type Connections struct {
+	ws           string
+	http         string
+	internalWS   string
+	internalHTTP string
+}
+
+// WSURL returns a websocket RPC URL
+func (c *Connections) WSURL() string {
+	return c.ws
+}
+
+// HTTPURL returns an HTTP RPC URL
+func (c *Connections) HTTPURL() string {
+	return c.http
+}
+
+// InternalWSURL returns an in-cluster websocket RPC URL of a simulated network
+func (c *Connections) InternalWSURL() string {
+	return c.internalWS
+}
+
+// InternalHTTPURL returns an in-cluster HTTP RPC URL of a simulated network
+func (c *Connections) InternalHTTPURL() string {
+	return c.internalHTTP
+}
+
+// Connections returns typed RPC URLs of the network, see environment.Get
+func (m Chart) Connections(e *environment.Environment) (interface{}, error) {
+	if !m.Props.Simulated {
+		c := &Connections{}
+		if len(m.Props.WsURLs) > 0 {
+			c.ws = m.Props.WsURLs[0]
 		}
-		if e.Cfg.InsideK8s {
-			e.URLs[m.Props.NetworkName] = []string{gethInternalWs}
-		} else {
-			e.URLs[m.Props.NetworkName] = []string{gethLocalWs}
+		if len(m.Props.HttpURLs) > 0 {
+			c.http = m.Props.HttpURLs[0]
 		}
-		e.URLs[m.Props.NetworkName+"_http"] = []string{gethLocalHttp}
+		return c, nil
+	}
+	app := fmt.Sprintf("%s:0", m.HelmProps.Name)
+	localHTTP, err := e.Fwd.FindPort(app, "geth-network", "http-rpc").As(client.LocalConnection, client.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	internalHTTP, err := e.Fwd.FindPort(app, "geth-network", "http-rpc").As(client.RemoteConnection, client.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	localWS, err := e.Fwd.FindPort(app, "geth-network", "ws-rpc").As(client.LocalConnection, client.WS)
+	if err != nil {
+		return nil, err
+	}
+	internalWS, err := e.Fwd.FindPort(app, "geth-network", "ws-rpc").As(client.RemoteConnection, client.WS)
+	if err != nil {
+		return nil, err
+	}
+	c := &Connections{ws: localWS, http: localHTTP, internalWS: internalWS, internalHTTP: internalHTTP}
+	if e.Cfg.InsideK8s {
+		c.ws, c.http = internalWS, internalHTTP
+	}
+	return c, nil
+}
+
+func (m Chart) ExportData(e *environment.Environment) error {
+	conns, err := m.Connections(e)
+	if err != nil {
+		return err
+	}
+	c := conns.(*Connections)
+	if m.Props.Simulated {
+		e.URLs[m.Props.NetworkName] = []string{c.ws}
+		e.URLs[m.Props.NetworkName+"_http"] = []string{c.http}
 
 		// For cases like starknet we need the internalHttp address to set up the L1<>L2 interaction
-		e.URLs[m.Props.NetworkName+"_internal"] = []string{gethInternalWs}
-		e.URLs[m.Props.NetworkName+"_internal_http"] = []string{gethInternalHttp}
+		e.URLs[m.Props.NetworkName+"_internal"] = []string{c.internalWS}
+		e.URLs[m.Props.NetworkName+"_internal_http"] = []string{c.internalHTTP}
 
-		log.Info().Str("Name", "Geth").Str("URLs", gethLocalWs).Msg("Geth network")
+		log.Info().Str("Name", "Geth").Str("URLs", c.ws).Msg("Geth network")
 	} else {
 		e.URLs[m.Props.NetworkName] = m.Props.WsURLs
 		log.Info().Str("Name", m.Props.NetworkName).Strs("URLs", m.Props.WsURLs).Msg("Ethereum network")
@@ -84,8 +140,14 @@ func (m Chart) ExportData(e *environment.Environment) error {
 	return nil
 }
 
+// ClockContainers Geth dev network uses the system clock for block timestamps
+func (m Chart) ClockContainers() []string {
+	return []string{"geth-network"}
+}
+
 func defaultProps() *Props {
 	return &Props{
+		Name:        "geth",
 		NetworkName: "Simulated Geth",
 		Simulated:   true,
 		Values: map[string]interface{}{
@@ -116,12 +178,16 @@ func New(props *Props) environment.ConnectedChart {
 		props = targetProps
 	}
 	config.MustMerge(targetProps, props)
-	config.MustMerge(&targetProps.Values, props.Values)
+	targetProps.Values = config.MustMergeValues(targetProps.Name,
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps().Values},
+		config.ValuesLayer{Name: config.ValuesLayerProps, Values: props.Geth.values()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props.Values},
+	)
 	targetProps.Simulated = props.Simulated // Mergo has issues with boolean merging for simulated networks
 	if targetProps.Simulated {
 		return Chart{
 			HelmProps: &HelmProps{
-				Name:   "geth",
+				Name:   targetProps.Name,
 				Path:   "chainlink-qa/geth",
 				Values: &targetProps.Values,
 			},
@@ -132,3 +198,23 @@ func New(props *Props) environment.ConnectedChart {
 		Props: targetProps,
 	}
 }
+
+// init registers the "geth" component kind of config files
+func init() {
+	environment.RegisterComponent("geth", func(spec environment.ComponentSpec) (environment.ConnectedChart, error) {
+		return New(&Props{Name: spec.Name, Simulated: true, Values: spec.Values}), nil
+	})
+}
+
+// StableServices a headless service for the Geth node, "${chart}-node-0"
+func (m Chart) StableServices() []environment.StableService {
+	if !m.Props.Simulated {
+		return nil
+	}
+	return []environment.StableService{
+		{
+			Name:     fmt.Sprintf("%s-node-0", m.HelmProps.Name),
+			Selector: map[string]string{"app": m.HelmProps.Name},
+		},
+	}
+}
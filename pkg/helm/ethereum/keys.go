@@ -0,0 +1,115 @@
+package ethereum
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// secp256k1 curve parameters, keys of private networks are derived without go-ethereum dependencies
+var (
+	secpP, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	secpN, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secpGx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	secpGy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+)
+
+// networkKey is a secp256k1 key of a validator, a bootnode or a funded account
+type networkKey struct {
+	private *big.Int
+	x, y    *big.Int
+}
+
+// deriveKey derives a key of the role from the seed, so a network with the same seed has the same accounts on every run
+func deriveKey(seed string, role string, i int) *networkKey {
+	d := new(big.Int).SetBytes(keccak256([]byte(fmt.Sprintf("%s/%s/%d", seed, role, i))))
+	// private keys are in [1, n-1]
+	d.Mod(d, new(big.Int).Sub(secpN, big.NewInt(1)))
+	d.Add(d, big.NewInt(1))
+	return newNetworkKey(d)
+}
+
+func newNetworkKey(d *big.Int) *networkKey {
+	x, y := scalarBaseMult(d)
+	return &networkKey{private: d, x: x, y: y}
+}
+
+// PrivateHex returns a hex private key without 0x, as geth account import expects it
+func (k *networkKey) PrivateHex() string {
+	return hex.EncodeToString(k.private.FillBytes(make([]byte, 32)))
+}
+
+// PublicHex returns a hex uncompressed public key without the 04 prefix, it's a node ID of enode URLs
+func (k *networkKey) PublicHex() string {
+	return hex.EncodeToString(k.public())
+}
+
+// Address returns a lower case hex address with 0x
+func (k *networkKey) Address() string {
+	return "0x" + hex.EncodeToString(keccak256(k.public())[12:])
+}
+
+func (k *networkKey) public() []byte {
+	pub := make([]byte, 64)
+	k.x.FillBytes(pub[:32])
+	k.y.FillBytes(pub[32:])
+	return pub
+}
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// scalarBaseMult multiplies the generator point by double-and-add in affine coordinates
+func scalarBaseMult(d *big.Int) (*big.Int, *big.Int) {
+	var rx, ry *big.Int
+	px, py := new(big.Int).Set(secpGx), new(big.Int).Set(secpGy)
+	for i := 0; i < d.BitLen(); i++ {
+		if d.Bit(i) == 1 {
+			rx, ry = pointAdd(rx, ry, px, py)
+		}
+		px, py = pointAdd(px, py, px, py)
+	}
+	return rx, ry
+}
+
+// pointAdd adds points of the curve, nil coordinates are the point at infinity
+func pointAdd(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1 == nil {
+		return x2, y2
+	}
+	if x2 == nil {
+		return x1, y1
+	}
+	var l *big.Int
+	if x1.Cmp(x2) == 0 {
+		if y1.Cmp(y2) != 0 || y1.Sign() == 0 {
+			return nil, nil
+		}
+		// l = 3x^2 / 2y
+		num := new(big.Int).Mul(x1, x1)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(y1, 1)
+		l = num.Mul(num, den.ModInverse(den, secpP))
+	} else {
+		// l = (y2 - y1) / (x2 - x1)
+		num := new(big.Int).Sub(y2, y1)
+		den := new(big.Int).Sub(x2, x1)
+		den.Mod(den, secpP)
+		l = num.Mul(num, den.ModInverse(den, secpP))
+	}
+	l.Mod(l, secpP)
+	x3 := new(big.Int).Mul(l, l)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, secpP)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, l)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, secpP)
+	return x3, y3
+}
@@ -0,0 +1,25 @@
+package ethereum
+
+import (
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// gethLogNoise peer discovery, sealing and RPC serving lines of Geth nodes
+var gethLogNoise = []environment.LogNoise{
+	{Container: "geth-network", Pattern: `Looking for peers`},
+	{Container: "geth-network", Pattern: `Commit new sealing work`},
+	{Container: "geth-network", Pattern: `Served (eth|net|web3|txpool)_`},
+}
+
+// NoisyLogs Geth peer discovery, sealing and RPC serving lines are filtered out of dumped and streamed logs
+func (m Chart) NoisyLogs() []environment.LogNoise {
+	if !m.Props.Simulated {
+		return nil
+	}
+	return gethLogNoise
+}
+
+// NoisyLogs the same lines as of the simulated Geth are filtered out of validator logs
+func (m *Network) NoisyLogs() []environment.LogNoise {
+	return gethLogNoise
+}
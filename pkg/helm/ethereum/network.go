@@ -0,0 +1,491 @@
+package ethereum
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cdk8s-team/cdk8s-core-go/cdk8s/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"github.com/smartcontractkit/chainlink-env/imports/k8s"
+	a "github.com/smartcontractkit/chainlink-env/pkg/alias"
+)
+
+const (
+	// ConsensusClique proof of authority, validators take turns to seal blocks
+	ConsensusClique = "clique"
+
+	DefaultNetworkValidators  = 3
+	DefaultNetworkBlockPeriod = 2
+	DefaultNetworkGasLimit    = 30_000_000
+	DefaultNetworkChainID     = 1337
+	DefaultNetworkFundedKeys  = 10
+	DefaultNetworkImage       = "ethereum/client-go:v1.10.17"
+	DefaultBootnodeImage      = "ethereum/client-go:alltools-v1.10.17"
+
+	networkHTTPPort     = 8544
+	networkWSPort       = 8546
+	networkP2PPort      = 30303
+	networkBootnodePort = 30301
+)
+
+// DefaultNetworkBalance returns a balance of funded accounts, 1M ETH, every call returns a new value
+func DefaultNetworkBalance() *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)
+}
+
+// NetworkProps a private multi-node Geth network, zero values are defaults
+type NetworkProps struct {
+	// Name is a release name, "geth-network" by default
+	Name string
+	// NetworkName is a key of network URLs, "Simulated Geth" by default, so the network replaces the dev node
+	NetworkName string
+	// Consensus is ConsensusClique, proof of stake needs beacon nodes and is not supported
+	Consensus string
+	// Validators number of sealing nodes, DefaultNetworkValidators if 0
+	Validators int
+	// BlockPeriod block time in seconds, DefaultNetworkBlockPeriod if 0
+	BlockPeriod int
+	// GasLimit block gas limit, DefaultNetworkGasLimit if 0
+	GasLimit uint64
+	// ChainID chain and network ID, DefaultNetworkChainID if 0
+	ChainID int64
+	// FundedKeys number of generated funded accounts, their keys are in Network.FundedKeys, DefaultNetworkFundedKeys if 0
+	FundedKeys int
+	// FundedAccounts addresses funded in genesis in addition to generated ones
+	FundedAccounts []string
+	// Balance of every funded account and validator in wei, DefaultNetworkBalance if nil
+	Balance *big.Int
+	// Seed keys are derived from, the same seed gives the same accounts on every run, Name if empty
+	Seed string
+	// Image of validators, DefaultNetworkImage if empty
+	Image string
+	// BootnodeImage has the bootnode tool, DefaultBootnodeImage if empty
+	BootnodeImage string
+}
+
+// Network is a private Geth network: a bootnode and validators, each one is a deployment labeled with its index,
+// keys are derived from the seed, the genesis funds validators and funded accounts
+type Network struct {
+	Props      *NetworkProps
+	validators []*networkKey
+	funded     []*networkKey
+	bootnode   *networkKey
+}
+
+// NewNetwork creates a private network chart, add it with Environment.AddManifest, e.g.:
+//
+//	e.AddManifest(ethereum.NewNetwork(&ethereum.NetworkProps{Validators: 4, BlockPeriod: 1}))
+func NewNetwork(props *NetworkProps) *Network {
+	p := NetworkProps{}
+	if props != nil {
+		p = *props
+	}
+	if p.Name == "" {
+		p.Name = "geth-network"
+	}
+	if p.NetworkName == "" {
+		p.NetworkName = "Simulated Geth"
+	}
+	if p.Consensus == "" {
+		p.Consensus = ConsensusClique
+	}
+	if p.Validators == 0 {
+		p.Validators = DefaultNetworkValidators
+	}
+	if p.BlockPeriod == 0 {
+		p.BlockPeriod = DefaultNetworkBlockPeriod
+	}
+	if p.GasLimit == 0 {
+		p.GasLimit = DefaultNetworkGasLimit
+	}
+	if p.ChainID == 0 {
+		p.ChainID = DefaultNetworkChainID
+	}
+	if p.FundedKeys == 0 {
+		p.FundedKeys = DefaultNetworkFundedKeys
+	}
+	if p.Balance == nil {
+		p.Balance = DefaultNetworkBalance()
+	}
+	if p.Seed == "" {
+		p.Seed = p.Name
+	}
+	if p.Image == "" {
+		p.Image = DefaultNetworkImage
+	}
+	if p.BootnodeImage == "" {
+		p.BootnodeImage = DefaultBootnodeImage
+	}
+	n := &Network{Props: &p, bootnode: deriveKey(p.Seed, "bootnode", 0)}
+	for i := 0; i < p.Validators; i++ {
+		n.validators = append(n.validators, deriveKey(p.Seed, "validator", i))
+	}
+	for i := 0; i < p.FundedKeys; i++ {
+		n.funded = append(n.funded, deriveKey(p.Seed, "funded", i))
+	}
+	return n
+}
+
+func (m *Network) IsDeploymentNeeded() bool {
+	return true
+}
+
+func (m *Network) GetName() string {
+	return m.Props.Name
+}
+
+func (m *Network) GetPath() string {
+	return ""
+}
+
+func (m *Network) GetProps() interface{} {
+	return m.Props
+}
+
+func (m *Network) GetValues() *map[string]interface{} {
+	return nil
+}
+
+// Validators returns addresses of validators, they are unlocked on their nodes, so eth_sendTransaction works
+func (m *Network) Validators() []string {
+	addrs := make([]string, 0, len(m.validators))
+	for _, k := range m.validators {
+		addrs = append(addrs, k.Address())
+	}
+	return addrs
+}
+
+// FundedKeys returns hex private keys of generated funded accounts
+func (m *Network) FundedKeys() []string {
+	keys := make([]string, 0, len(m.funded))
+	for _, k := range m.funded {
+		keys = append(keys, k.PrivateHex())
+	}
+	return keys
+}
+
+// FundedAddresses returns addresses of generated funded accounts and NetworkProps.FundedAccounts
+func (m *Network) FundedAddresses() []string {
+	addrs := make([]string, 0, len(m.funded)+len(m.Props.FundedAccounts))
+	for _, k := range m.funded {
+		addrs = append(addrs, k.Address())
+	}
+	return append(addrs, m.Props.FundedAccounts...)
+}
+
+// Build defines the genesis, validator keys, the bootnode and validators
+func (m *Network) Build(scope cdk8s.Chart) error {
+	if m.Props.Consensus != ConsensusClique {
+		return errors.Errorf("consensus %q is not supported, only %q is", m.Props.Consensus, ConsensusClique)
+	}
+	if m.Props.Validators < 1 {
+		return errors.New("network needs at least one validator")
+	}
+	if m.Props.BlockPeriod < 1 {
+		return errors.New("block period must be at least 1 second")
+	}
+	genesis, err := m.genesis()
+	if err != nil {
+		return err
+	}
+	k8s.NewKubeConfigMap(scope, a.Str("genesis"), &k8s.KubeConfigMapProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(m.genesisName())},
+		Data:     &map[string]*string{"genesis.json": a.Str(string(genesis))},
+	})
+	keys := make(map[string]*string)
+	for i, k := range m.validators {
+		keys[fmt.Sprintf("validator-%d", i)] = a.Str(k.PrivateHex())
+	}
+	k8s.NewKubeSecret(scope, a.Str("keys"), &k8s.KubeSecretProps{
+		Metadata:   &k8s.ObjectMeta{Name: a.Str(m.keysName())},
+		StringData: &keys,
+	})
+	m.bootnodeObjects(scope)
+	for i := range m.validators {
+		m.validatorDeployment(scope, i)
+	}
+	k8s.NewKubeService(scope, a.Str("rpc"), &k8s.KubeServiceProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(m.Props.Name)},
+		Spec: &k8s.ServiceSpec{
+			Ports: &[]*k8s.ServicePort{
+				{Name: a.Str("http-rpc"), Port: a.Num(networkHTTPPort)},
+				{Name: a.Str("ws-rpc"), Port: a.Num(networkWSPort)},
+			},
+			// the first validator serves RPC, its account is unlocked for eth_sendTransaction
+			Selector: m.validatorLabels(0),
+		},
+	})
+	return nil
+}
+
+func (m *Network) genesisName() string {
+	return fmt.Sprintf("%s-genesis", m.Props.Name)
+}
+
+func (m *Network) keysName() string {
+	return fmt.Sprintf("%s-keys", m.Props.Name)
+}
+
+func (m *Network) bootnodeName() string {
+	return fmt.Sprintf("%s-bootnode", m.Props.Name)
+}
+
+func (m *Network) validatorLabels(i int) *map[string]*string {
+	return &map[string]*string{
+		"app":       a.Str(m.Props.Name),
+		"validator": a.Str(fmt.Sprint(i)),
+	}
+}
+
+// bootnodeObjects a discovery only bootnode behind a headless service, validators resolve it on start,
+// so it's published before it's ready
+func (m *Network) bootnodeObjects(scope cdk8s.Chart) {
+	labels := &map[string]*string{"app": a.Str(m.bootnodeName())}
+	k8s.NewKubeService(scope, a.Str("bootnode-service"), &k8s.KubeServiceProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(m.bootnodeName())},
+		Spec: &k8s.ServiceSpec{
+			ClusterIp:                a.Str("None"),
+			PublishNotReadyAddresses: a.Bool(true),
+			Ports: &[]*k8s.ServicePort{
+				{Name: a.Str("discovery"), Port: a.Num(networkBootnodePort), Protocol: a.Str("UDP")},
+			},
+			Selector: labels,
+		},
+	})
+	k8s.NewKubeDeployment(scope, a.Str("bootnode"), &k8s.KubeDeploymentProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(m.bootnodeName())},
+		Spec: &k8s.DeploymentSpec{
+			Selector: &k8s.LabelSelector{MatchLabels: labels},
+			Template: &k8s.PodTemplateSpec{
+				Metadata: &k8s.ObjectMeta{Labels: labels},
+				Spec: &k8s.PodSpec{
+					Containers: &[]*k8s.Container{
+						{
+							Name:    a.Str("bootnode"),
+							Image:   a.Str(m.Props.BootnodeImage),
+							Command: &[]*string{a.Str("bootnode")},
+							Args: &[]*string{
+								a.Str("-nodekeyhex"), a.Str(m.bootnode.PrivateHex()),
+								a.Str("-addr"), a.Str(fmt.Sprintf(":%d", networkBootnodePort)),
+							},
+							Ports: &[]*k8s.ContainerPort{
+								{Name: a.Str("discovery"), ContainerPort: a.Num(networkBootnodePort), Protocol: a.Str("UDP")},
+							},
+							Resources: a.ContainerResources("100m", "64Mi", "100m", "64Mi"),
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// validatorDeployment a sealing node, the chain is initialized once per pod, container restarts keep it
+func (m *Network) validatorDeployment(scope cdk8s.Chart, i int) {
+	labels := m.validatorLabels(i)
+	k8s.NewKubeDeployment(scope, a.Str(fmt.Sprintf("validator-%d", i)), &k8s.KubeDeploymentProps{
+		Metadata: &k8s.ObjectMeta{Name: a.Str(fmt.Sprintf("%s-validator-%d", m.Props.Name, i))},
+		Spec: &k8s.DeploymentSpec{
+			Selector: &k8s.LabelSelector{MatchLabels: labels},
+			Template: &k8s.PodTemplateSpec{
+				Metadata: &k8s.ObjectMeta{Labels: labels},
+				Spec: &k8s.PodSpec{
+					Containers: &[]*k8s.Container{
+						{
+							Name:    a.Str("geth-network"),
+							Image:   a.Str(m.Props.Image),
+							Command: &[]*string{a.Str("/bin/sh")},
+							Args:    &[]*string{a.Str("-c"), a.Str(m.validatorScript(i))},
+							Env: &[]*k8s.EnvVar{
+								{
+									Name:      a.Str("POD_IP"),
+									ValueFrom: &k8s.EnvVarSource{FieldRef: &k8s.ObjectFieldSelector{FieldPath: a.Str("status.podIP")}},
+								},
+							},
+							Ports: &[]*k8s.ContainerPort{
+								{Name: a.Str("http-rpc"), ContainerPort: a.Num(networkHTTPPort)},
+								{Name: a.Str("ws-rpc"), ContainerPort: a.Num(networkWSPort)},
+								{Name: a.Str("p2p"), ContainerPort: a.Num(networkP2PPort)},
+							},
+							ReadinessProbe: &k8s.Probe{
+								TcpSocket:           &k8s.TcpSocketAction{Port: k8s.IntOrString_FromNumber(a.Num(networkHTTPPort))},
+								InitialDelaySeconds: a.Num(5),
+								PeriodSeconds:       a.Num(2),
+							},
+							VolumeMounts: &[]*k8s.VolumeMount{
+								{Name: a.Str("data"), MountPath: a.Str("/data")},
+								{Name: a.Str("genesis"), MountPath: a.Str("/genesis")},
+								{Name: a.Str("keys"), MountPath: a.Str("/keys"), ReadOnly: a.Bool(true)},
+							},
+							Resources: a.ContainerResources("500m", "512Mi", "500m", "512Mi"),
+						},
+					},
+					Volumes: &[]*k8s.Volume{
+						{Name: a.Str("data"), EmptyDir: &k8s.EmptyDirVolumeSource{}},
+						{Name: a.Str("genesis"), ConfigMap: &k8s.ConfigMapVolumeSource{Name: a.Str(m.genesisName())}},
+						{Name: a.Str("keys"), Secret: &k8s.SecretVolumeSource{SecretName: a.Str(m.keysName())}},
+					},
+				},
+			},
+		},
+	})
+}
+
+// validatorScript initializes the chain, imports the validator key and starts sealing, validators advertise pod IPs
+func (m *Network) validatorScript(i int) string {
+	addr := m.validators[i].Address()
+	return strings.Join([]string{
+		"set -e",
+		"if [ ! -d /data/geth/chaindata ]; then",
+		"  geth --datadir /data init /genesis/genesis.json",
+		"  : > /data/password",
+		fmt.Sprintf("  geth --datadir /data account import --password /data/password /keys/validator-%d", i),
+		"fi",
+		strings.Join([]string{
+			"exec geth --datadir /data",
+			fmt.Sprintf("--networkid %d --syncmode full", m.Props.ChainID),
+			fmt.Sprintf("--port %d --nat extip:$POD_IP", networkP2PPort),
+			fmt.Sprintf("--bootnodes enode://%s@%s:%d", m.bootnode.PublicHex(), m.bootnodeName(), networkBootnodePort),
+			fmt.Sprintf("--mine --miner.etherbase %s --miner.gaslimit %d", addr, m.Props.GasLimit),
+			fmt.Sprintf("--unlock %s --password /data/password --allow-insecure-unlock", addr),
+			fmt.Sprintf("--http --http.addr 0.0.0.0 --http.port %d --http.api eth,net,web3,txpool,clique", networkHTTPPort),
+			"--http.vhosts '*' --http.corsdomain '*'",
+			fmt.Sprintf("--ws --ws.addr 0.0.0.0 --ws.port %d --ws.api eth,net,web3,txpool --ws.origins '*'", networkWSPort),
+		}, " "),
+	}, "\n")
+}
+
+// genesis returns a Clique genesis with all forks up to London enabled, validators are signers,
+// validators and funded accounts get the balance
+func (m *Network) genesis() ([]byte, error) {
+	signers := m.Validators()
+	sort.Strings(signers)
+	balance := map[string]string{"balance": "0x" + m.Props.Balance.Text(16)}
+	alloc := make(map[string]interface{})
+	for _, addr := range append(m.Validators(), m.FundedAddresses()...) {
+		alloc[strings.ToLower(strings.TrimPrefix(addr, "0x"))] = balance
+	}
+	cfg := map[string]interface{}{
+		"chainId": m.Props.ChainID,
+		"clique": map[string]interface{}{
+			"period": m.Props.BlockPeriod,
+			"epoch":  30000,
+		},
+	}
+	for _, fork := range []string{
+		"homesteadBlock", "eip150Block", "eip155Block", "eip158Block", "byzantiumBlock",
+		"constantinopleBlock", "petersburgBlock", "istanbulBlock", "berlinBlock", "londonBlock",
+	} {
+		cfg[fork] = 0
+	}
+	return json.MarshalIndent(map[string]interface{}{
+		"config":     cfg,
+		"difficulty": "0x1",
+		"gasLimit":   fmt.Sprintf("0x%x", m.Props.GasLimit),
+		"extraData":  cliqueExtraData(signers),
+		"alloc":      alloc,
+	}, "", "  ")
+}
+
+// cliqueExtraData 32 vanity bytes, signer addresses and 65 bytes of an empty seal
+func cliqueExtraData(signers []string) string {
+	var sb strings.Builder
+	sb.WriteString("0x")
+	sb.WriteString(strings.Repeat("00", 32))
+	for _, s := range signers {
+		sb.WriteString(strings.ToLower(strings.TrimPrefix(s, "0x")))
+	}
+	sb.WriteString(strings.Repeat("00", 65))
+	return sb.String()
+}
+
+// Conditions every validator must have all other validators as peers and the network must produce blocks
+func (m *Network) Conditions() []environment.Condition {
+	conds := make([]environment.Condition, 0, len(m.validators)+1)
+	for i := range m.validators {
+		conds = append(conds, environment.Condition{
+			Name:      fmt.Sprintf("peer count of instance %d", i),
+			App:       m.Props.Name,
+			Instance:  i,
+			Container: "geth-network",
+			Port:      "http-rpc",
+			Method:    http.MethodPost,
+			Body:      `{"jsonrpc":"2.0","method":"net_peerCount","params":[],"id":1}`,
+			JSONPath:  "result",
+			Op:        ">=",
+			Value:     float64(len(m.validators) - 1),
+		})
+	}
+	return append(conds, environment.Condition{
+		Name:      "block height",
+		App:       m.Props.Name,
+		Container: "geth-network",
+		Port:      "http-rpc",
+		Method:    http.MethodPost,
+		Body:      `{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`,
+		JSONPath:  "result",
+		Op:        ">",
+		Value:     1,
+	})
+}
+
+// Connections returns typed RPC URLs of a validator, see environment.Get
+func (m *Network) Connections(e *environment.Environment) (interface{}, error) {
+	app := fmt.Sprintf("%s:0", m.Props.Name)
+	localHTTP, err := e.Fwd.FindPort(app, "geth-network", "http-rpc").As(client.LocalConnection, client.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	internalHTTP, err := e.Fwd.FindPort(app, "geth-network", "http-rpc").As(client.RemoteConnection, client.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	localWS, err := e.Fwd.FindPort(app, "geth-network", "ws-rpc").As(client.LocalConnection, client.WS)
+	if err != nil {
+		return nil, err
+	}
+	internalWS, err := e.Fwd.FindPort(app, "geth-network", "ws-rpc").As(client.RemoteConnection, client.WS)
+	if err != nil {
+		return nil, err
+	}
+	c := &Connections{ws: localWS, http: localHTTP, internalWS: internalWS, internalHTTP: internalHTTP}
+	if e.Cfg.InsideK8s {
+		c.ws, c.http = internalWS, internalHTTP
+	}
+	return c, nil
+}
+
+// ExportData exports URLs with the same keys as the simulated Geth chart
+func (m *Network) ExportData(e *environment.Environment) error {
+	conns, err := m.Connections(e)
+	if err != nil {
+		return err
+	}
+	c := conns.(*Connections)
+	e.URLs[m.Props.NetworkName] = []string{c.ws}
+	e.URLs[m.Props.NetworkName+"_http"] = []string{c.http}
+	e.URLs[m.Props.NetworkName+"_internal"] = []string{c.internalWS}
+	e.URLs[m.Props.NetworkName+"_internal_http"] = []string{c.internalHTTP}
+	log.Info().
+		Str("Name", m.Props.NetworkName).
+		Int("Validators", len(m.validators)).
+		Str("URL", c.ws).
+		Msg("Geth private network")
+	return nil
+}
+
+// Outputs in-cluster RPC URLs of the first validator, "http_url" and "ws_url"
+func (m *Network) Outputs(_ *environment.Environment) (map[string]string, error) {
+	return map[string]string{
+		"http_url": fmt.Sprintf("http://%s:%d", m.Props.Name, networkHTTPPort),
+		"ws_url":   fmt.Sprintf("ws://%s:%d", m.Props.Name, networkWSPort),
+	}, nil
+}
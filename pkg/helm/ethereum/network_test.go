@@ -0,0 +1,74 @@
+package ethereum
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkKey(t *testing.T) {
+	k := newNetworkKey(big.NewInt(1))
+	require.Equal(t, "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf", k.Address())
+	require.Equal(t, "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", k.PublicHex()[:64])
+	require.Equal(t, strings.Repeat("0", 63)+"1", k.PrivateHex())
+	require.Equal(t, "0x2b5ad5c4795c026514f8317c7a215e218dccd6cf", newNetworkKey(big.NewInt(2)).Address())
+	require.Equal(t, "0x6813eb9362372eef6200f3b1dbc3f819671cba69", newNetworkKey(big.NewInt(3)).Address())
+
+	require.Equal(t, deriveKey("seed", "validator", 0).Address(), deriveKey("seed", "validator", 0).Address())
+	require.NotEqual(t, deriveKey("seed", "validator", 0).Address(), deriveKey("seed", "validator", 1).Address())
+	require.NotEqual(t, deriveKey("seed", "validator", 0).Address(), deriveKey("other", "validator", 0).Address())
+}
+
+func TestNetworkGenesis(t *testing.T) {
+	n := NewNetwork(&NetworkProps{
+		Validators:     2,
+		BlockPeriod:    5,
+		GasLimit:       8_000_000,
+		ChainID:        2337,
+		FundedKeys:     3,
+		FundedAccounts: []string{"0xF39FD6E51AAD88F6F4CE6AB8827279CFFFB92266"},
+		Balance:        big.NewInt(255),
+	})
+	require.Len(t, n.Validators(), 2)
+	require.Len(t, n.FundedKeys(), 3)
+	require.Len(t, n.FundedAddresses(), 4)
+
+	data, err := n.genesis()
+	require.NoError(t, err)
+	var g struct {
+		Config struct {
+			ChainID int64 `json:"chainId"`
+			Clique  struct {
+				Period int `json:"period"`
+			} `json:"clique"`
+		} `json:"config"`
+		GasLimit  string                       `json:"gasLimit"`
+		ExtraData string                       `json:"extraData"`
+		Alloc     map[string]map[string]string `json:"alloc"`
+	}
+	require.NoError(t, json.Unmarshal(data, &g))
+	require.Equal(t, int64(2337), g.Config.ChainID)
+	require.Equal(t, 5, g.Config.Clique.Period)
+	require.Equal(t, "0x7a1200", g.GasLimit)
+	require.Len(t, g.Alloc, 6)
+	require.Equal(t, "0xff", g.Alloc["f39fd6e51aad88f6f4ce6ab8827279cfffb92266"]["balance"])
+	for _, v := range n.Validators() {
+		require.Contains(t, g.ExtraData, strings.TrimPrefix(v, "0x"))
+		require.Contains(t, g.Alloc, strings.TrimPrefix(v, "0x"))
+	}
+	require.Len(t, g.ExtraData, 2+2*(32+2*20+65))
+}
+
+func TestCliqueExtraData(t *testing.T) {
+	extra := cliqueExtraData([]string{"0x7E5F4552091A69125D5DFCB7B8C2659029395BDF"})
+	require.Equal(t, "0x"+strings.Repeat("00", 32)+"7e5f4552091a69125d5dfcb7b8c2659029395bdf"+strings.Repeat("00", 65), extra)
+}
+
+func TestNetworkDefaultBalance(t *testing.T) {
+	n := NewNetwork(nil)
+	n.Props.Balance.SetInt64(1)
+	require.Equal(t, DefaultNetworkBalance(), NewNetwork(nil).Props.Balance, "defaults are not shared between networks")
+}
@@ -0,0 +1,72 @@
+package ethereum
+
+const (
+	// GCModeFull pruned node, default
+	GCModeFull = "full"
+	// GCModeArchive keeps all historical states
+	GCModeArchive = "archive"
+)
+
+// GethOptions typed Geth node options for tests that need to tune the chain, zero values keep chart defaults
+type GethOptions struct {
+	// GCMode garbage collection mode, GCModeFull or GCModeArchive
+	GCMode string
+	// TxPoolGlobalSlots max number of executable transactions in the pool
+	TxPoolGlobalSlots int
+	// TxPoolGlobalQueue max number of non-executable transactions in the pool
+	TxPoolGlobalQueue int
+	// TxPoolAccountSlots max number of executable transactions per account
+	TxPoolAccountSlots int
+	// BlockPeriod dev mode block period in seconds
+	BlockPeriod int
+	// GasLimit block gas limit
+	GasLimit uint64
+	// FundedAccounts addresses funded in genesis
+	FundedAccounts []string
+	// ChainID network ID of the dev chain
+	ChainID int64
+}
+
+// values renders options as chart values
+func (o *GethOptions) values() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	geth := make(map[string]interface{})
+	if o.GCMode != "" {
+		geth["gcMode"] = o.GCMode
+	}
+	txPool := make(map[string]interface{})
+	if o.TxPoolGlobalSlots != 0 {
+		txPool["globalSlots"] = o.TxPoolGlobalSlots
+	}
+	if o.TxPoolGlobalQueue != 0 {
+		txPool["globalQueue"] = o.TxPoolGlobalQueue
+	}
+	if o.TxPoolAccountSlots != 0 {
+		txPool["accountSlots"] = o.TxPoolAccountSlots
+	}
+	if len(txPool) != 0 {
+		geth["txpool"] = txPool
+	}
+	if o.BlockPeriod != 0 {
+		geth["blocktime"] = o.BlockPeriod
+	}
+	if o.GasLimit != 0 {
+		geth["gasLimit"] = o.GasLimit
+	}
+	if len(o.FundedAccounts) != 0 {
+		accounts := make([]interface{}, 0, len(o.FundedAccounts))
+		for _, a := range o.FundedAccounts {
+			accounts = append(accounts, a)
+		}
+		geth["fundedAccounts"] = accounts
+	}
+	if o.ChainID != 0 {
+		geth["networkId"] = o.ChainID
+	}
+	if len(geth) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"geth": geth}
+}
@@ -0,0 +1,24 @@
+package ethereum
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Outputs in-cluster RPC URLs of the network, "http_url" and "ws_url"
+func (m Chart) Outputs(_ *environment.Environment) (map[string]string, error) {
+	outputs := make(map[string]string)
+	if !m.Props.Simulated {
+		if len(m.Props.HttpURLs) != 0 {
+			outputs["http_url"] = m.Props.HttpURLs[0]
+		}
+		if len(m.Props.WsURLs) != 0 {
+			outputs["ws_url"] = m.Props.WsURLs[0]
+		}
+		return outputs, nil
+	}
+	outputs["http_url"] = fmt.Sprintf("http://%s:8544", m.HelmProps.Name)
+	outputs["ws_url"] = fmt.Sprintf("ws://%s:8546", m.HelmProps.Name)
+	return outputs, nil
+}
@@ -0,0 +1,16 @@
+package ethereum
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Reset restarts the simulated network from a fresh genesis, the dev node keeps its chain data in the pod only,
+// external networks are not reset
+func (m Chart) Reset(e *environment.Environment) error {
+	if !m.Props.Simulated {
+		log.Warn().Str("Network", m.Props.NetworkName).Msg("External network can't be reset, skipping")
+		return nil
+	}
+	return e.RestartChart(m.HelmProps.Name)
+}
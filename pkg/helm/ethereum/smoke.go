@@ -0,0 +1,79 @@
+package ethereum
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+const (
+	SmokeRequestTimeout = 10 * time.Second
+)
+
+func (m Chart) SmokeTests() []environment.SmokeTest {
+	return []environment.SmokeTest{
+		{Name: "eth_blockNumber", Run: m.smokeBlockNumber},
+	}
+}
+
+// smokeBlockNumber checks that the network produces blocks
+func (m Chart) smokeBlockNumber(e *environment.Environment) error {
+	url := m.httpURL(e)
+	if url == "" {
+		return errors.New("no HTTP RPC URL")
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "eth_blockNumber",
+		"params":  []interface{}{},
+		"id":      1,
+	})
+	c := &http.Client{Timeout: SmokeRequestTimeout}
+	resp, err := c.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var rpcResp struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return err
+	}
+	if rpcResp.Error != nil {
+		return errors.New(rpcResp.Error.Message)
+	}
+	bn, err := strconv.ParseUint(strings.TrimPrefix(rpcResp.Result, "0x"), 16, 64)
+	if err != nil {
+		return errors.Wrapf(err, "bad block number: %s", rpcResp.Result)
+	}
+	if bn == 0 {
+		return errors.New("block number is 0, network is not producing blocks")
+	}
+	return nil
+}
+
+func (m Chart) httpURL(e *environment.Environment) string {
+	var urls []string
+	switch {
+	case !m.Props.Simulated:
+		urls = m.Props.HttpURLs
+	case e.Cfg.InsideK8s:
+		urls = e.URLs[m.Props.NetworkName+"_internal_http"]
+	default:
+		urls = e.URLs[m.Props.NetworkName+"_http"]
+	}
+	if len(urls) == 0 {
+		return ""
+	}
+	return urls[0]
+}
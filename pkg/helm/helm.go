@@ -0,0 +1,148 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Chart is a third-party chart from a Helm repository, it's downloaded once and deployed from the local cache
+type Chart struct {
+	Name    string
+	Path    string
+	RepoURL string
+	Chart   string
+	Version string
+	Values  *map[string]interface{}
+}
+
+func (m Chart) IsDeploymentNeeded() bool {
+	return true
+}
+
+func (m Chart) GetName() string {
+	return m.Name
+}
+
+func (m Chart) GetPath() string {
+	return m.Path
+}
+
+func (m Chart) GetProps() interface{} {
+	return nil
+}
+
+func (m Chart) GetValues() *map[string]interface{} {
+	return m.Values
+}
+
+func (m Chart) ExportData(e *environment.Environment) error {
+	return nil
+}
+
+// NewFromRepo creates a chart from a Helm repository, or an OCI registry if the URL starts with oci://,
+// the chart is released with its own name, e.g. helm.NewFromRepo("https://charts.bitnami.com/bitnami", "postgresql", "12.1.0", values)
+func NewFromRepo(repoURL string, chart string, version string, values map[string]interface{}) environment.ConnectedChart {
+	c, err := NewFromRepoE(repoURL, chart, version, values)
+	if err != nil {
+		log.Fatal().Err(err).Str("Repo", repoURL).Str("Chart", chart).Str("Version", version).Msg("Failed to download chart")
+	}
+	return c
+}
+
+// NewFromRepoE is NewFromRepo returning an error if the chart can't be downloaded
+func NewFromRepoE(repoURL string, chart string, version string, values map[string]interface{}) (environment.ConnectedChart, error) {
+	path, err := Pull(repoURL, chart, version)
+	if err != nil {
+		return nil, err
+	}
+	merged := config.MergeValues(config.ValuesLayer{Name: config.ValuesLayerUser, Values: values})
+	merged.Print(chart)
+	return Chart{
+		Name:    chart,
+		Path:    path,
+		RepoURL: repoURL,
+		Chart:   chart,
+		Version: version,
+		Values:  &merged.Values,
+	}, nil
+}
+
+// init registers the "helm" component kind of config files, charts from Helm repositories, see NewFromRepo
+func init() {
+	environment.RegisterComponent("helm", func(spec environment.ComponentSpec) (environment.ConnectedChart, error) {
+		if spec.Repo == "" || spec.Chart == "" {
+			return nil, errors.New("helm component needs repo and chart")
+		}
+		return NewFromRepoE(spec.Repo, spec.Chart, spec.Version, spec.Values)
+	})
+}
+
+// Pull downloads a chart archive into the cache directory and returns its path, cached archives are not downloaded again,
+// the version is required, so the cache is never stale
+func Pull(repoURL string, chart string, version string) (string, error) {
+	if version == "" {
+		return "", errors.Errorf("chart %s from %s has no version", chart, repoURL)
+	}
+	cacheDir, err := chartsCacheDir()
+	if err != nil {
+		return "", err
+	}
+	path := cachedChartPath(cacheDir, repoURL, chart, version)
+	if _, err := os.Stat(path); err == nil {
+		log.Debug().Str("Chart", chart).Str("Version", version).Str("Path", path).Msg("Using cached chart")
+		return path, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+	// charts are pulled into a temporary directory and renamed, so parallel tests never read a partial archive
+	tmpDir, err := os.MkdirTemp(filepath.Dir(path), "pull-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	log.Info().Str("Repo", repoURL).Str("Chart", chart).Str("Version", version).Msg("Downloading chart")
+	if err := client.ExecArgs("helm", pullArgs(repoURL, chart, version, tmpDir)...); err != nil {
+		return "", errors.Wrapf(err, "failed to pull chart %s %s from %s", chart, version, repoURL)
+	}
+	if err := os.Rename(filepath.Join(tmpDir, filepath.Base(path)), path); err != nil {
+		return "", errors.Wrapf(err, "failed to cache chart %s %s", chart, version)
+	}
+	return path, nil
+}
+
+// chartsCacheDir is CHAINLINK_ENV_CHARTS_CACHE_DIR or a directory in the user cache
+func chartsCacheDir() (string, error) {
+	if dir := os.Getenv(config.EnvVarChartsCacheDir); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "chainlink-env", "charts"), nil
+}
+
+// cachedChartPath returns ${cacheDir}/${repo hash}/${chart}-${version}.tgz, the name helm pull gives the archive
+func cachedChartPath(cacheDir string, repoURL string, chart string, version string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSuffix(repoURL, "/")))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:])[:12], fmt.Sprintf("%s-%s.tgz", chart, version))
+}
+
+// pullArgs returns arguments of helm pull, they are passed separately, so paths with spaces are not split
+func pullArgs(repoURL string, chart string, version string, dir string) []string {
+	if strings.HasPrefix(repoURL, "oci://") {
+		return []string{"pull", fmt.Sprintf("%s/%s", strings.TrimSuffix(repoURL, "/"), chart), "--version", version, "--destination", dir}
+	}
+	return []string{"pull", chart, "--repo", repoURL, "--version", version, "--destination", dir}
+}
@@ -0,0 +1,31 @@
+package helm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedChartPath(t *testing.T) {
+	p := cachedChartPath("/cache", "https://charts.bitnami.com/bitnami", "postgresql", "12.1.0")
+	require.Equal(t, "postgresql-12.1.0.tgz", filepath.Base(p))
+	require.Equal(t, p, cachedChartPath("/cache", "https://charts.bitnami.com/bitnami/", "postgresql", "12.1.0"))
+	require.NotEqual(t, p, cachedChartPath("/cache", "https://example.com/charts", "postgresql", "12.1.0"))
+}
+
+func TestPullArgs(t *testing.T) {
+	require.Equal(t,
+		[]string{"pull", "postgresql", "--repo", "https://charts.bitnami.com/bitnami", "--version", "12.1.0", "--destination", "/Users/John Doe/pull"},
+		pullArgs("https://charts.bitnami.com/bitnami", "postgresql", "12.1.0", "/Users/John Doe/pull"),
+	)
+	require.Equal(t,
+		[]string{"pull", "oci://registry-1.docker.io/bitnamicharts/redis", "--version", "17.3.7", "--destination", "/tmp/pull"},
+		pullArgs("oci://registry-1.docker.io/bitnamicharts/", "redis", "17.3.7", "/tmp/pull"),
+	)
+}
+
+func TestNewFromRepoE(t *testing.T) {
+	_, err := NewFromRepoE("https://charts.bitnami.com/bitnami", "postgresql", "", nil)
+	require.Error(t, err, "errors are returned instead of exiting")
+}
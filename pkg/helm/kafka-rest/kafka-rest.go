@@ -63,8 +63,10 @@ func defaultProps() map[string]interface{} {
 }
 
 func New(props map[string]interface{}) environment.ConnectedChart {
-	dp := defaultProps()
-	config.MustMerge(&dp, props)
+	dp := config.MustMergeValues("cp-kafka-rest",
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props},
+	)
 	return Chart{
 		Name:   "cp-kafka-rest",
 		Path:   "chainlink-qa/kafka-rest",
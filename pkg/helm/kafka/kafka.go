@@ -105,8 +105,10 @@ func defaultProps() map[string]interface{} {
 }
 
 func New(props map[string]interface{}) environment.ConnectedChart {
-	dp := defaultProps()
-	config.MustMerge(&dp, props)
+	dp := config.MustMergeValues("kafka",
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props},
+	)
 	return Chart{
 		Name:   "kafka",
 		Path:   "bitnami/kafka",
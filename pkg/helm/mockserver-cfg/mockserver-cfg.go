@@ -45,3 +45,10 @@ func New(props map[string]interface{}) environment.ConnectedChart {
 		Values: &props,
 	}
 }
+
+// init registers the "mockserver-cfg" component kind of config files
+func init() {
+	environment.RegisterComponent("mockserver-cfg", func(spec environment.ComponentSpec) (environment.ConnectedChart, error) {
+		return New(spec.Values), nil
+	})
+}
@@ -41,24 +41,53 @@ func (m Chart) GetValues() *map[string]interface{} {
 	return m.Values
 }
 
-func (m Chart) ExportData(e *environment.Environment) error {
-	urls := make([]string, 0)
-	mock, err := e.Fwd.FindPort("mockserver:0", "mockserver", "serviceport").As(client.LocalConnection, client.HTTP)
+// Dependencies mockserver mounts the expectations config map of mockserver-cfg
+func (m Chart) Dependencies() []string {
+	return []string{"mockserver-cfg"}
+}
+
+// Connections are mockserver URLs, the in-cluster URL inside K8s, the forwarded one otherwise
+type Connections struct {
+	url         string
+	internalURL string
+}
+
+// URL returns a mockserver URL to set expectations
+func (c *Connections) URL() string {
+	return c.url
+}
+
+// InternalURL returns an in-cluster mockserver URL, e.g. for bridges
+func (c *Connections) InternalURL() string {
+	return c.internalURL
+}
+
+// Connections returns typed mockserver URLs, see environment.Get
+func (m Chart) Connections(e *environment.Environment) (interface{}, error) {
+	local, err := e.Fwd.FindPort("mockserver:0", "mockserver", "serviceport").As(client.LocalConnection, client.HTTP)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	mockInternal, err := e.Fwd.FindPort("mockserver:0", "mockserver", "serviceport").As(client.RemoteConnection, client.HTTP)
+	internal, err := e.Fwd.FindPort("mockserver:0", "mockserver", "serviceport").As(client.RemoteConnection, client.HTTP)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	c := &Connections{url: local, internalURL: internal}
 	if e.Cfg.InsideK8s {
-		urls = append(urls, mockInternal, mockInternal)
-	} else {
-		urls = append(urls, mock, mockInternal)
+		c.url = internal
 	}
-	e.URLs[URLsKey] = urls
-	log.Info().Str("URL", mock).Msg("Mockserver local connection")
-	log.Info().Str("URL", mockInternal).Msg("Mockserver remote connection")
+	return c, nil
+}
+
+func (m Chart) ExportData(e *environment.Environment) error {
+	conns, err := m.Connections(e)
+	if err != nil {
+		return err
+	}
+	c := conns.(*Connections)
+	e.URLs[URLsKey] = []string{c.url, c.internalURL}
+	log.Info().Str("URL", c.url).Msg("Mockserver local connection")
+	log.Info().Str("URL", c.internalURL).Msg("Mockserver remote connection")
 	return nil
 }
 
@@ -95,11 +124,20 @@ func defaultProps() map[string]interface{} {
 }
 
 func New(props map[string]interface{}) environment.ConnectedChart {
-	dp := defaultProps()
-	config.MustMerge(&dp, props)
+	dp := config.MustMergeValues("mockserver",
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props},
+	)
 	return Chart{
 		Name:   "mockserver",
 		Path:   "chainlink-qa/mockserver",
 		Values: &dp,
 	}
 }
+
+// init registers the "mockserver" component kind of config files
+func init() {
+	environment.RegisterComponent("mockserver", func(spec environment.ComponentSpec) (environment.ConnectedChart, error) {
+		return New(spec.Values), nil
+	})
+}
@@ -0,0 +1,12 @@
+package mockserver
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Outputs in-cluster mockserver URL, "url"
+func (m Chart) Outputs(_ *environment.Environment) (map[string]string, error) {
+	return map[string]string{"url": fmt.Sprintf("http://%s:1080", m.Name)}, nil
+}
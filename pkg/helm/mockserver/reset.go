@@ -0,0 +1,16 @@
+package mockserver
+
+import (
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+// Reset clears all expectations and recorded requests
+func (m Chart) Reset(e *environment.Environment) error {
+	urls := e.URLs[URLsKey]
+	if len(urls) == 0 {
+		return errors.New("no mockserver URL")
+	}
+	return client.NewMockserverClient(urls[0]).Reset()
+}
@@ -0,0 +1,53 @@
+package mockserver
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+const (
+	SmokeRequestTimeout  = 10 * time.Second
+	SmokeExpectationPath = "/chainlink-env-smoke"
+	SmokeExpectationBody = "ok"
+)
+
+func (m Chart) SmokeTests() []environment.SmokeTest {
+	return []environment.SmokeTest{
+		{Name: "expectation", Run: m.smokeExpectation},
+	}
+}
+
+// smokeExpectation sets an expectation, fetches it and clears it
+func (m Chart) smokeExpectation(e *environment.Environment) error {
+	urls := e.URLs[URLsKey]
+	if len(urls) == 0 {
+		return errors.New("no mockserver URL")
+	}
+	mc := client.NewMockserverClient(urls[0])
+	err := mc.PutExpectation(map[string]interface{}{
+		"httpRequest":  map[string]interface{}{"path": SmokeExpectationPath},
+		"httpResponse": map[string]interface{}{"body": SmokeExpectationBody},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to set expectation")
+	}
+	c := &http.Client{Timeout: SmokeRequestTimeout}
+	resp, err := c.Get(urls[0] + SmokeExpectationPath)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if string(b) != SmokeExpectationBody {
+		return errors.Errorf("unexpected expectation response, status: %d, body: %s", resp.StatusCode, string(b))
+	}
+	return errors.Wrap(mc.Clear(SmokeExpectationPath), "failed to clear expectation")
+}
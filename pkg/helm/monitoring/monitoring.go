@@ -0,0 +1,332 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"github.com/smartcontractkit/chainlink-env/grafana"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm"
+)
+
+const (
+	PrometheusRepo    = "https://prometheus-community.github.io/helm-charts"
+	PrometheusVersion = "19.7.2"
+	GrafanaRepo       = "https://grafana.github.io/helm-charts"
+	GrafanaVersion    = "6.50.7"
+	// PrometheusURLsKey and GrafanaURLsKey are keys of environment URLs, Grafana has a local and an in-cluster URL
+	PrometheusURLsKey = "prometheus"
+	GrafanaURLsKey    = "grafana"
+	// DatasourceUID is a uid of the provisioned Prometheus datasource, dashboards of the grafana package refer to it
+	DatasourceUID = "PBFA97CFB590B2093"
+	// GethMetricsPath Geth serves metrics on a container port named "metrics" if its chart exposes one
+	GethMetricsPath = "/debug/metrics/prometheus"
+	// prometheusService is a service of the Prometheus server of the "prometheus" release
+	prometheusService = "prometheus-server"
+)
+
+// Props slim Prometheus and Grafana, Prometheus only scrapes the environment namespace, so nothing cluster-wide is installed
+type Props struct {
+	// PrometheusVersion and GrafanaVersion are chart versions, PrometheusVersion and GrafanaVersion constants if empty
+	PrometheusVersion string
+	GrafanaVersion    string
+	// ScrapeInterval e.g. "15s", Prometheus default if empty
+	ScrapeInterval string
+	// GrafanaAdminPassword "admin" if empty
+	GrafanaAdminPassword string
+	// Dashboards are JSON models of Grafana dashboards by names, provisioned along with dashboards of the grafana package
+	Dashboards map[string]string
+	// PrometheusValues and GrafanaValues override chart values
+	PrometheusValues map[string]interface{}
+	GrafanaValues    map[string]interface{}
+}
+
+// Chart is a monitoring chart from a Helm repository, its URLs are exported
+type Chart struct {
+	helm.Chart
+	export func(e *environment.Environment) error
+}
+
+func (m Chart) ExportData(e *environment.Environment) error {
+	return m.export(e)
+}
+
+// New creates Prometheus and Grafana charts, Prometheus scrapes Chainlink nodes, Geth metrics ports and pods annotated
+// with prometheus.io/scrape, Grafana has Prometheus as the default datasource and dashboards of the grafana package
+func New(props *Props) []environment.ConnectedChart {
+	charts, err := NewE(props)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create monitoring charts")
+	}
+	return charts
+}
+
+// NewE is New returning an error if a chart can't be downloaded or dashboards can't be read
+func NewE(props *Props) ([]environment.ConnectedChart, error) {
+	if props == nil {
+		props = &Props{}
+	}
+	p, err := NewPrometheusE(props)
+	if err != nil {
+		return nil, err
+	}
+	g, err := NewGrafanaE(props)
+	if err != nil {
+		return nil, err
+	}
+	return []environment.ConnectedChart{p, g}, nil
+}
+
+// NewPrometheus creates a Prometheus server chart, see New
+func NewPrometheus(props *Props) environment.ConnectedChart {
+	c, err := NewPrometheusE(props)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create Prometheus chart")
+	}
+	return c
+}
+
+// NewPrometheusE is NewPrometheus returning an error if the chart can't be downloaded
+func NewPrometheusE(props *Props) (environment.ConnectedChart, error) {
+	if props == nil {
+		props = &Props{}
+	}
+	return newChart(PrometheusRepo, "prometheus", orDefault(props.PrometheusVersion, PrometheusVersion),
+		prometheusValues(props), props.PrometheusValues, exportPrometheus)
+}
+
+// NewGrafana creates a Grafana chart, see New
+func NewGrafana(props *Props) environment.ConnectedChart {
+	c, err := NewGrafanaE(props)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create Grafana chart")
+	}
+	return c
+}
+
+// NewGrafanaE is NewGrafana returning an error if the chart can't be downloaded or dashboards can't be read
+func NewGrafanaE(props *Props) (environment.ConnectedChart, error) {
+	if props == nil {
+		props = &Props{}
+	}
+	values, err := grafanaValues(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Grafana dashboards: %w", err)
+	}
+	return newChart(GrafanaRepo, "grafana", orDefault(props.GrafanaVersion, GrafanaVersion), values, props.GrafanaValues, exportGrafana)
+}
+
+// init registers the "prometheus" and "grafana" component kinds of config files, values override chart values
+func init() {
+	environment.RegisterComponent("prometheus", func(spec environment.ComponentSpec) (environment.ConnectedChart, error) {
+		return NewPrometheusE(&Props{PrometheusVersion: spec.Version, PrometheusValues: spec.Values})
+	})
+	environment.RegisterComponent("grafana", func(spec environment.ComponentSpec) (environment.ConnectedChart, error) {
+		return NewGrafanaE(&Props{GrafanaVersion: spec.Version, GrafanaValues: spec.Values})
+	})
+}
+
+func newChart(repo string, chart string, version string, defaults map[string]interface{}, values map[string]interface{},
+	export func(e *environment.Environment) error) (environment.ConnectedChart, error) {
+	path, err := helm.Pull(repo, chart, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download chart %s %s from %s: %w", chart, version, repo, err)
+	}
+	dp := config.MustMergeValues(chart,
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaults},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: values},
+	)
+	return Chart{
+		Chart: helm.Chart{
+			Name:    chart,
+			Path:    path,
+			RepoURL: repo,
+			Chart:   chart,
+			Version: version,
+			Values:  &dp,
+		},
+		export: export,
+	}, nil
+}
+
+// exportPrometheus publishes the in-cluster URL and uses it for PromQL conditions inside K8s if PrometheusURL is not set
+func exportPrometheus(e *environment.Environment) error {
+	internal := fmt.Sprintf("http://%s.%s.svc.cluster.local", prometheusService, e.Cfg.Namespace)
+	e.URLs[PrometheusURLsKey] = []string{internal}
+	if e.Cfg.InsideK8s && e.Cfg.PrometheusURL == "" {
+		e.Cfg.PrometheusURL = internal
+	}
+	log.Info().Str("URL", internal).Msg("Prometheus remote connection")
+	return nil
+}
+
+// exportGrafana publishes the forwarded and in-cluster Grafana URLs
+func exportGrafana(e *environment.Environment) error {
+	local, err := e.Fwd.FindPort("grafana:0", "grafana", "grafana").As(client.LocalConnection, client.HTTP)
+	if err != nil {
+		return err
+	}
+	internal, err := e.Fwd.FindPort("grafana:0", "grafana", "grafana").As(client.RemoteConnection, client.HTTP)
+	if err != nil {
+		return err
+	}
+	if e.Cfg.InsideK8s {
+		local = internal
+	}
+	e.URLs[GrafanaURLsKey] = []string{local, internal}
+	log.Info().Str("URL", local).Msg("Grafana local connection")
+	return nil
+}
+
+func prometheusValues(props *Props) map[string]interface{} {
+	global := map[string]interface{}{}
+	if props.ScrapeInterval != "" {
+		global["scrape_interval"] = props.ScrapeInterval
+	}
+	return map[string]interface{}{
+		"alertmanager":             map[string]interface{}{"enabled": false},
+		"kube-state-metrics":       map[string]interface{}{"enabled": false},
+		"prometheus-node-exporter": map[string]interface{}{"enabled": false},
+		"prometheus-pushgateway":   map[string]interface{}{"enabled": false},
+		"server": map[string]interface{}{
+			"podLabels": map[string]interface{}{"app": "prometheus"},
+			// a Role instead of a ClusterRole, so parallel environments don't conflict
+			"releaseNamespace": true,
+			"persistentVolume": map[string]interface{}{"enabled": false},
+			"global":           global,
+		},
+		"serverFiles": map[string]interface{}{
+			"prometheus.yml": map[string]interface{}{
+				"scrape_configs": scrapeConfigs(),
+			},
+		},
+	}
+}
+
+// scrapeConfigs scrape pods of the environment namespace, targets are labeled with namespace and pod, as dashboards expect
+func scrapeConfigs() []interface{} {
+	job := func(name string, metricsPath string, keep ...map[string]interface{}) map[string]interface{} {
+		relabel := make([]interface{}, 0)
+		for _, k := range keep {
+			relabel = append(relabel, k)
+		}
+		relabel = append(relabel,
+			map[string]interface{}{"source_labels": []interface{}{"__meta_kubernetes_namespace"}, "target_label": "namespace"},
+			map[string]interface{}{"source_labels": []interface{}{"__meta_kubernetes_pod_name"}, "target_label": "pod"},
+		)
+		j := map[string]interface{}{
+			"job_name": name,
+			"kubernetes_sd_configs": []interface{}{
+				map[string]interface{}{"role": "pod", "namespaces": map[string]interface{}{"own_namespace": true}},
+			},
+			"relabel_configs": relabel,
+		}
+		if metricsPath != "" {
+			j["metrics_path"] = metricsPath
+		}
+		return j
+	}
+	keep := func(label string, regex string) map[string]interface{} {
+		return map[string]interface{}{"source_labels": []interface{}{label}, "regex": regex, "action": "keep"}
+	}
+	return []interface{}{
+		job("chainlink", environment.DefaultMetricsPath,
+			keep("__meta_kubernetes_pod_label_app", "chainlink-.+"),
+			keep("__meta_kubernetes_pod_container_port_name", "access"),
+		),
+		job("geth", GethMetricsPath,
+			keep("__meta_kubernetes_pod_label_app", "geth.*"),
+			keep("__meta_kubernetes_pod_container_port_name", "metrics"),
+		),
+		job("annotated", "",
+			keep("__meta_kubernetes_pod_annotation_prometheus_io_scrape", "true"),
+			map[string]interface{}{
+				"source_labels": []interface{}{"__meta_kubernetes_pod_annotation_prometheus_io_path"},
+				"regex":         "(.+)",
+				"target_label":  "__metrics_path__",
+			},
+			map[string]interface{}{
+				"source_labels": []interface{}{"__address__", "__meta_kubernetes_pod_annotation_prometheus_io_port"},
+				"regex":         `([^:]+)(?::\d+)?;(\d+)`,
+				"replacement":   "$1:$2",
+				"target_label":  "__address__",
+			},
+		),
+	}
+}
+
+func grafanaValues(props *Props) (map[string]interface{}, error) {
+	provisioned, err := packageDashboards()
+	if err != nil {
+		return nil, err
+	}
+	for name, model := range props.Dashboards {
+		provisioned[name] = map[string]interface{}{"json": model}
+	}
+	return map[string]interface{}{
+		"podLabels":     map[string]interface{}{"app": "grafana"},
+		"adminUser":     "admin",
+		"adminPassword": orDefault(props.GrafanaAdminPassword, "admin"),
+		"rbac":          map[string]interface{}{"namespaced": true},
+		"testFramework": map[string]interface{}{"enabled": false},
+		"datasources": map[string]interface{}{
+			"datasources.yaml": map[string]interface{}{
+				"apiVersion": 1,
+				"datasources": []interface{}{
+					map[string]interface{}{
+						"name":      "Prometheus",
+						"type":      "prometheus",
+						"uid":       DatasourceUID,
+						"url":       fmt.Sprintf("http://%s", prometheusService),
+						"access":    "proxy",
+						"isDefault": true,
+					},
+				},
+			},
+		},
+		"dashboardProviders": map[string]interface{}{
+			"dashboardproviders.yaml": map[string]interface{}{
+				"apiVersion": 1,
+				"providers": []interface{}{
+					map[string]interface{}{
+						"name":            "default",
+						"orgId":           1,
+						"folder":          "",
+						"type":            "file",
+						"disableDeletion": false,
+						"editable":        true,
+						"options":         map[string]interface{}{"path": "/var/lib/grafana/dashboards/default"},
+					},
+				},
+			},
+		},
+		"dashboards": map[string]interface{}{"default": provisioned},
+	}, nil
+}
+
+// packageDashboards returns dashboards of the grafana package by file names without extensions
+func packageDashboards() (map[string]interface{}, error) {
+	entries, err := grafana.Dashboards.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+	provisioned := make(map[string]interface{})
+	for _, entry := range entries {
+		data, err := grafana.Dashboards.ReadFile(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		provisioned[strings.TrimSuffix(entry.Name(), ".json")] = map[string]interface{}{"json": string(data)}
+	}
+	return provisioned, nil
+}
+
+func orDefault(v string, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,29 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaValues(t *testing.T) {
+	values, err := grafanaValues(&Props{Dashboards: map[string]string{"soak": `{"title":"Soak"}`}})
+	require.NoError(t, err)
+	require.Equal(t, "admin", values["adminPassword"])
+	provisioned := values["dashboards"].(map[string]interface{})["default"].(map[string]interface{})
+	require.Equal(t, `{"title":"Soak"}`, provisioned["soak"].(map[string]interface{})["json"])
+	insights := provisioned["cl_insights"].(map[string]interface{})["json"].(string)
+	require.True(t, strings.Contains(insights, DatasourceUID), "package dashboards use the provisioned datasource")
+}
+
+func TestScrapeConfigs(t *testing.T) {
+	jobs := make([]string, 0)
+	for _, j := range scrapeConfigs() {
+		job := j.(map[string]interface{})
+		jobs = append(jobs, job["job_name"].(string))
+		relabel := job["relabel_configs"].([]interface{})
+		require.Equal(t, "pod", relabel[len(relabel)-1].(map[string]interface{})["target_label"])
+	}
+	require.Equal(t, []string{"chainlink", "geth", "annotated"}, jobs)
+}
@@ -79,8 +79,10 @@ func defaultProps() map[string]interface{} {
 }
 
 func New(props map[string]interface{}) environment.ConnectedChart {
-	dp := defaultProps()
-	config.MustMerge(&dp, props)
+	dp := config.MustMergeValues("remote-test-runner",
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props},
+	)
 	return Chart{
 		Name:   "remote-test-runner",
 		Path:   "chainlink-qa/remote-test-runner",
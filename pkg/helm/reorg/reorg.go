@@ -120,7 +120,10 @@ func defaultProps() *Props {
 func New(props *Props) environment.ConnectedChart {
 	targetProps := defaultProps()
 	config.MustMerge(targetProps, props)
-	config.MustMerge(&targetProps.Values, props.Values)
+	targetProps.Values = config.MustMergeValues(targetProps.NetworkName,
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps().Values},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props.Values},
+	)
 	return Chart{
 		Name:   targetProps.NetworkName,
 		Path:   "chainlink-qa/ethereum",
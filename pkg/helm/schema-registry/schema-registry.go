@@ -44,8 +44,10 @@ func defaultProps() map[string]interface{} {
 }
 
 func New(props map[string]interface{}) environment.ConnectedChart {
-	dp := defaultProps()
-	config.MustMerge(&dp, props)
+	dp := config.MustMergeValues("cp-schema-registry",
+		config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: defaultProps()},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: props},
+	)
 	return Chart{
 		Name:   "cp-schema-registry",
 		Path:   "chainlink-qa/schema-registry",
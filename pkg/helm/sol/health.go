@@ -0,0 +1,52 @@
+package sol
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+const (
+	// HealthOK is returned by the validator /health endpoint when it's caught up with the cluster
+	HealthOK             = "ok"
+	HealthRequestTimeout = 10 * time.Second
+)
+
+// CheckHealth checks that the validator RPC /health endpoint reports "ok", so the node is caught up and serves requests
+func (m Chart) CheckHealth(e *environment.Environment) error {
+	connType := client.LocalConnection
+	if e.Cfg.InsideK8s {
+		connType = client.RemoteConnection
+	}
+	u, err := e.Fwd.FindPort(fmt.Sprintf("%s:0", m.HelmProps.Name), "sol-val", "http-rpc").As(connType, client.HTTP)
+	if err != nil {
+		return err
+	}
+	c := &http.Client{Timeout: HealthRequestTimeout}
+	resp, err := c.Get(fmt.Sprintf("%s/health", u))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return checkHealthResponse(resp.StatusCode, string(body))
+}
+
+// checkHealthResponse returns an error if the validator is behind or its health is unknown,
+// e.g. "behind { distance: 10 }" while it's catching up after the start
+func checkHealthResponse(status int, body string) error {
+	body = strings.TrimSpace(body)
+	if status != http.StatusOK || body != HealthOK {
+		return errors.Errorf("validator is not healthy, status: %d, health: %s", status, body)
+	}
+	return nil
+}
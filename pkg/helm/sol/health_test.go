@@ -0,0 +1,14 @@
+package sol
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealthResponse(t *testing.T) {
+	require.NoError(t, checkHealthResponse(http.StatusOK, "ok\n"))
+	require.ErrorContains(t, checkHealthResponse(http.StatusServiceUnavailable, "behind { distance: 10 }"), "behind { distance: 10 }")
+	require.Error(t, checkHealthResponse(http.StatusServiceUnavailable, "unknown"))
+}
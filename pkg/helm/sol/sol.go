@@ -1,13 +1,18 @@
 package sol
 
 import (
+	"fmt"
+
 	"github.com/rs/zerolog/log"
 
 	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/config"
 	"github.com/smartcontractkit/chainlink-env/environment"
 )
 
 type Props struct {
+	// Name is a release name, "sol" by default, set it to deploy more than one validator
+	Name        string
 	NetworkName string   `envconfig:"network_name"`
 	HttpURLs    []string `envconfig:"http_url"`
 	WsURLs      []string `envconfig:"ws_url"`
@@ -46,15 +51,16 @@ func (m Chart) GetValues() *map[string]interface{} {
 }
 
 func (m Chart) ExportData(e *environment.Environment) error {
-	netLocal, err := e.Fwd.FindPort("sol:0", "sol-val", "http-rpc").As(client.LocalConnection, client.HTTP)
+	app := fmt.Sprintf("%s:0", m.HelmProps.Name)
+	netLocal, err := e.Fwd.FindPort(app, "sol-val", "http-rpc").As(client.LocalConnection, client.HTTP)
 	if err != nil {
 		return err
 	}
-	netLocalWS, err := e.Fwd.FindPort("sol:0", "sol-val", "ws-rpc").As(client.LocalConnection, client.WS)
+	netLocalWS, err := e.Fwd.FindPort(app, "sol-val", "ws-rpc").As(client.LocalConnection, client.WS)
 	if err != nil {
 		return err
 	}
-	netInternal, err := e.Fwd.FindPort("sol:0", "sol-val", "http-rpc").As(client.RemoteConnection, client.HTTP)
+	netInternal, err := e.Fwd.FindPort(app, "sol-val", "http-rpc").As(client.RemoteConnection, client.HTTP)
 	if err != nil {
 		return err
 	}
@@ -66,8 +72,10 @@ func (m Chart) ExportData(e *environment.Environment) error {
 	return nil
 }
 
-func defaultProps() *Props {
+// DefaultProps a single solana-test-validator with an RPC endpoint exposed as "sol" network URLs
+func DefaultProps() *Props {
 	return &Props{
+		Name:        "sol",
 		NetworkName: "sol",
 		Values: map[string]interface{}{
 			"replicas": "1",
@@ -93,14 +101,32 @@ func defaultProps() *Props {
 
 func New(props *Props) environment.ConnectedChart {
 	if props == nil {
-		props = defaultProps()
+		props = DefaultProps()
+	}
+	if props.Name == "" {
+		props.Name = "sol"
 	}
 	return Chart{
 		HelmProps: &HelmProps{
-			Name:   "sol",
+			Name:   props.Name,
 			Path:   "chainlink-qa/solana-validator",
 			Values: &props.Values,
 		},
 		Props: props,
 	}
 }
+
+// init registers the "sol" component kind of config files
+func init() {
+	environment.RegisterComponent("sol", func(spec environment.ComponentSpec) (environment.ConnectedChart, error) {
+		props := DefaultProps()
+		if spec.Name != "" {
+			props.Name = spec.Name
+		}
+		props.Values = config.MustMergeValues(props.Name,
+			config.ValuesLayer{Name: config.ValuesLayerDefaults, Values: props.Values},
+			config.ValuesLayer{Name: config.ValuesLayerUser, Values: spec.Values},
+		)
+		return New(props), nil
+	})
+}
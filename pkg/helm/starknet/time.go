@@ -0,0 +1,41 @@
+package starknet
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/environment"
+)
+
+const (
+	DevnetRequestTimeout = 10 * time.Second
+)
+
+// AdvanceChainTime moves devnet block timestamps forward
+func (m Chart) AdvanceChainTime(e *environment.Environment, d time.Duration) error {
+	urls := e.URLs[m.Props.NetworkName]
+	if len(urls) < 2 {
+		return errors.New("no devnet URLs")
+	}
+	u := urls[0]
+	if e.Cfg.InsideK8s {
+		u = urls[1]
+	}
+	body, err := json.Marshal(map[string]interface{}{"time": int64(d.Seconds())})
+	if err != nil {
+		return err
+	}
+	c := &http.Client{Timeout: DevnetRequestTimeout}
+	resp, err := c.Post(u+"/increase_time", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to increase devnet time, status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package presets
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/config"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"github.com/smartcontractkit/chainlink-env/pkg/cdk8s/blockscout"
+	// registers the "helm" component kind of config files
+	_ "github.com/smartcontractkit/chainlink-env/pkg/helm"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/chainlink"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/ethereum"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/mockserver"
+	mockservercfg "github.com/smartcontractkit/chainlink-env/pkg/helm/mockserver-cfg"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/monitoring"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/reorg"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/sol"
+)
+
+// ChainBackend is a chain deployed for the nodes
+type ChainBackend string
+
+const (
+	// ChainGeth simulated Geth dev network
+	ChainGeth ChainBackend = "geth"
+	// ChainGethReorg two Geth networks for re-org tests
+	ChainGethReorg ChainBackend = "geth-reorg"
+	// ChainSolana solana-test-validator, nodes have EVM disabled and Solana enabled
+	ChainSolana ChainBackend = "solana"
+	// ChainNone no chain is deployed, nodes connect to an external network
+	ChainNone ChainBackend = "none"
+)
+
+// Option is a composable building block of a preset
+type Option func(p *preset)
+
+type preset struct {
+	nodes                int
+	nodeValues           map[string]interface{}
+	chain                ChainBackend
+	chainValues          map[string]interface{}
+	observability        bool
+	serviceMonitors      bool
+	serviceMonitorLabels map[string]string
+	monitoring           *monitoring.Props
+	mocks                bool
+	chains               []ChainSpec
+	charts               []environment.ConnectedChart
+}
+
+// WithNodes deploys n Chainlink nodes
+func WithNodes(n int) Option {
+	return func(p *preset) {
+		p.nodes = n
+	}
+}
+
+// WithNodeValues adds Chainlink chart values, for example, resources or env
+func WithNodeValues(values map[string]interface{}) Option {
+	return func(p *preset) {
+		p.nodeValues = mergeValues(p.nodeValues, values)
+	}
+}
+
+// WithChain deploys a chain backend, Geth by default
+func WithChain(backend ChainBackend) Option {
+	return func(p *preset) {
+		p.chain = backend
+	}
+}
+
+// WithChainValues adds chain chart values
+func WithChainValues(values map[string]interface{}) Option {
+	return func(p *preset) {
+		p.chainValues = mergeValues(p.chainValues, values)
+	}
+}
+
+// WithObservability deploys Blockscout
+func WithObservability() Option {
+	return func(p *preset) {
+		p.observability = true
+	}
+}
+
+// WithServiceMonitors deploys Blockscout and generates Prometheus Operator monitors for charts declaring metrics ports,
+// labels are added to monitors, so the cluster Prometheus selects them
+func WithServiceMonitors(labels map[string]string) Option {
+	return func(p *preset) {
+		p.observability = true
+		p.serviceMonitors = true
+		p.serviceMonitorLabels = labels
+	}
+}
+
+// WithMonitoring deploys Prometheus scraping the environment and Grafana with Chainlink dashboards, nil props are defaults
+func WithMonitoring(props *monitoring.Props) Option {
+	return func(p *preset) {
+		if props == nil {
+			props = &monitoring.Props{}
+		}
+		p.monitoring = props
+	}
+}
+
+// WithMocks deploys mockserver with the default external adapters config
+func WithMocks() Option {
+	return func(p *preset) {
+		p.mocks = true
+	}
+}
+
+// WithHelm adds any Helm chart after the preset charts
+func WithHelm(chart environment.ConnectedChart) Option {
+	return func(p *preset) {
+		p.charts = append(p.charts, chart)
+	}
+}
+
+// New assembles an environment from options, charts are added in order: observability, mocks, chain, nodes, custom charts
+func New(cfg *environment.Config, opts ...Option) *environment.Environment {
+	p := &preset{
+		nodes:       1,
+		nodeValues:  map[string]interface{}{},
+		chain:       ChainGeth,
+		chainValues: map[string]interface{}{},
+		charts:      make([]environment.ConnectedChart, 0),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	e := environment.New(cfg)
+	if p.serviceMonitors {
+		e.Cfg.ServiceMonitors = true
+		e.Cfg.ServiceMonitorLabels = p.serviceMonitorLabels
+	}
+	if p.observability {
+		e.AddChart(blockscout.New(&blockscout.Props{}))
+	}
+	if p.monitoring != nil {
+		for _, c := range monitoring.New(p.monitoring) {
+			e.AddHelm(c)
+		}
+	}
+	if p.mocks {
+		e.AddHelm(mockservercfg.New(nil)).
+			AddHelm(mockserver.New(nil))
+	}
+	nodeValues := map[string]interface{}{}
+	if len(p.chains) > 0 {
+		p.chain = ChainNone
+		charts, nodeEnv, err := chainCharts(e, p.chains)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid preset chains")
+		}
+		for _, c := range charts {
+			e.AddHelm(c)
+		}
+		nodeValues["env"] = nodeEnv
+	}
+	switch p.chain {
+	case ChainGeth:
+		e.AddHelm(ethereum.New(&ethereum.Props{
+			Simulated: true,
+			Values:    p.chainValues,
+		}))
+	case ChainGethReorg:
+		e.AddHelm(reorg.New(&reorg.Props{
+			NetworkName: "geth",
+			NetworkType: "geth-reorg",
+			Values: mergeValues(map[string]interface{}{
+				"geth": map[string]interface{}{
+					"genesis": map[string]interface{}{
+						"networkId": "1337",
+					},
+				},
+			}, p.chainValues),
+		})).
+			AddHelm(reorg.New(&reorg.Props{
+				NetworkName: "geth-2",
+				NetworkType: "geth-reorg",
+				Values: mergeValues(map[string]interface{}{
+					"geth": map[string]interface{}{
+						"genesis": map[string]interface{}{
+							"networkId": "2337",
+						},
+					},
+				}, p.chainValues),
+			}))
+		nodeValues["env"] = map[string]interface{}{
+			"eth_url": "ws://geth-reorg-ethereum-geth:8546",
+		}
+	case ChainSolana:
+		props := sol.DefaultProps()
+		props.Values = mergeValues(props.Values, p.chainValues)
+		e.AddHelm(sol.New(props))
+		nodeValues["env"] = map[string]interface{}{
+			"SOLANA_ENABLED":  "true",
+			"EVM_ENABLED":     "false",
+			"EVM_RPC_ENABLED": "false",
+		}
+	}
+	if p.nodes > 0 {
+		nodeValues["replicas"] = p.nodes
+		e.AddHelm(chainlink.New(0, mergeValues(nodeValues, p.nodeValues)))
+	}
+	for _, c := range p.charts {
+		e.AddHelm(c)
+	}
+	return e
+}
+
+// mergeValues deep merges preset values with values provided by options
+func mergeValues(presetValues map[string]interface{}, values map[string]interface{}) map[string]interface{} {
+	return config.MergeValues(
+		config.ValuesLayer{Name: config.ValuesLayerPreset, Values: presetValues},
+		config.ValuesLayer{Name: config.ValuesLayerUser, Values: values},
+	).Values
+}
@@ -0,0 +1,109 @@
+package presets
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/ethereum"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/sol"
+)
+
+// ChainSpec declares one of the preset chains, see WithChains
+type ChainSpec struct {
+	// Type ChainGeth or ChainSolana
+	Type ChainBackend
+	// Name is a release name and URLs key of the chain, "${type}-${index}" by type if empty, e.g. "geth-1"
+	Name string
+	// Version chain node image tag, chart default if empty
+	Version string
+	// Nodes number of chain nodes, chart default if 0
+	Nodes int
+	// ChainID network ID, EVM chains only, generated from the environment seed if 0
+	ChainID int64
+	// Faucet accounts funded in genesis, EVM chains only
+	Faucet []string
+	// Values raw chart values, applied over the spec
+	Values map[string]interface{}
+}
+
+// WithChains deploys a list of chains instead of the WithChain backend, e.g. 2 Geth networks and a Solana validator,
+// every chain publishes its outputs under its name, nodes are connected to the first EVM chain and get Solana enabled
+// if there is a Solana chain
+func WithChains(specs ...ChainSpec) Option {
+	return func(p *preset) {
+		p.chains = append(p.chains, specs...)
+	}
+}
+
+// chainCharts returns charts of the chains and env of nodes wiring them
+func chainCharts(e *environment.Environment, specs []ChainSpec) ([]environment.ConnectedChart, map[string]interface{}, error) {
+	charts := make([]environment.ConnectedChart, 0, len(specs))
+	nodeEnv := make(map[string]interface{})
+	names := make(map[string]bool)
+	indexes := make(map[ChainBackend]int)
+	evm := ""
+	for _, s := range specs {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-%d", s.Type, indexes[s.Type])
+		}
+		indexes[s.Type]++
+		if names[name] {
+			return nil, nil, errors.Errorf("duplicate chain %s, chains must have unique names", name)
+		}
+		names[name] = true
+		switch s.Type {
+		case ChainGeth:
+			if s.ChainID == 0 {
+				s.ChainID = e.GenerateChainID(name)
+			}
+			charts = append(charts, ethereum.New(&ethereum.Props{
+				Name:        name,
+				NetworkName: name,
+				Simulated:   true,
+				Geth: &ethereum.GethOptions{
+					ChainID:        s.ChainID,
+					FundedAccounts: s.Faucet,
+				},
+				Values: mergeValues(chainSpecValues("geth", s), s.Values),
+			}))
+			if evm == "" {
+				evm = name
+				nodeEnv["eth_url"] = environment.OutputRef(name, "ws_url")
+				nodeEnv["ETH_CHAIN_ID"] = fmt.Sprint(s.ChainID)
+			}
+		case ChainSolana:
+			if s.ChainID != 0 || len(s.Faucet) != 0 {
+				return nil, nil, errors.Errorf("chain %s: ChainID and Faucet are supported by EVM chains only", name)
+			}
+			props := sol.DefaultProps()
+			props.Name = name
+			props.NetworkName = name
+			props.Values = mergeValues(props.Values, mergeValues(chainSpecValues("sol", s), s.Values))
+			charts = append(charts, sol.New(props))
+			nodeEnv["SOLANA_ENABLED"] = "true"
+		default:
+			return nil, nil, errors.Errorf("chain %s: unsupported chain type '%s'", name, s.Type)
+		}
+	}
+	if evm == "" {
+		nodeEnv["EVM_ENABLED"] = "false"
+		nodeEnv["EVM_RPC_ENABLED"] = "false"
+	}
+	return charts, nodeEnv, nil
+}
+
+// chainSpecValues renders the spec version and nodes as chart values, image values are under the container key
+func chainSpecValues(container string, s ChainSpec) map[string]interface{} {
+	values := make(map[string]interface{})
+	if s.Version != "" {
+		values[container] = map[string]interface{}{
+			"image": map[string]interface{}{"version": s.Version},
+		}
+	}
+	if s.Nodes != 0 {
+		values["replicas"] = fmt.Sprint(s.Nodes)
+	}
+	return values
+}
@@ -0,0 +1,198 @@
+package pricefeed
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/smartcontractkit/chainlink-env/client"
+	"github.com/smartcontractkit/chainlink-env/environment"
+	"github.com/smartcontractkit/chainlink-env/pkg/helm/mockserver"
+)
+
+const (
+	DefaultUpdateInterval = 5 * time.Second
+)
+
+// Step sets the feed price to Value once At time passed since the simulator start
+type Step struct {
+	At    time.Duration
+	Value float64
+}
+
+// Feed is a simulated price feed served by mockserver as an external adapter response on Path
+type Feed struct {
+	// Path is a mockserver path, for example, "/variable"
+	Path string
+	// Initial price
+	Initial float64
+	// Interval between price updates
+	Interval time.Duration
+	// Volatility is a standard deviation of a random walk step relative to the price, 0 disables random walk
+	Volatility float64
+	// Min and Max bound the random walk, ignored if 0
+	Min float64
+	Max float64
+	// Steps are scheduled price changes, random walk continues from the new price
+	Steps []Step
+	// OutlierProbability is a probability to publish a single outlier price on an update
+	OutlierProbability float64
+	// OutlierMultiplier outlier price is the current price multiplied by it
+	OutlierMultiplier float64
+	// Integer rounds published prices
+	Integer bool
+}
+
+// feedState is a price state of one feed, every feed has its own random source,
+// so its price sequence doesn't depend on updates of other feeds
+type feedState struct {
+	feed      Feed
+	price     float64
+	nextStep  int
+	published float64
+	rng       *rand.Rand
+}
+
+func newFeedState(f Feed, seed int64) *feedState {
+	steps := make([]Step, len(f.Steps))
+	copy(steps, f.Steps)
+	sort.Slice(steps, func(i, j int) bool { return steps[i].At < steps[j].At })
+	f.Steps = steps
+	return &feedState{feed: f, price: f.Initial, rng: rand.New(rand.NewSource(seed))}
+}
+
+// next calculates a price to publish after elapsed time since start
+func (s *feedState) next(elapsed time.Duration) float64 {
+	f := s.feed
+	stepped := false
+	for s.nextStep < len(f.Steps) && f.Steps[s.nextStep].At <= elapsed {
+		s.price = f.Steps[s.nextStep].Value
+		s.nextStep++
+		stepped = true
+	}
+	if !stepped && f.Volatility != 0 {
+		s.price += s.price * f.Volatility * s.rng.NormFloat64()
+		if f.Min != 0 && s.price < f.Min {
+			s.price = f.Min
+		}
+		if f.Max != 0 && s.price > f.Max {
+			s.price = f.Max
+		}
+	}
+	price := s.price
+	if f.OutlierProbability != 0 && s.rng.Float64() < f.OutlierProbability {
+		price *= f.OutlierMultiplier
+	}
+	if f.Integer {
+		price = math.Round(price)
+	}
+	s.published = price
+	return price
+}
+
+// Simulator continuously updates mockserver expectations of simulated feeds
+type Simulator struct {
+	Client *client.MockserverClient
+	feeds  []*feedState
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     *sync.WaitGroup
+}
+
+// NewSimulator creates a simulator for the mockserver of the environment, seed makes price sequences reproducible,
+// a feed is seeded with seed plus its index
+func NewSimulator(e *environment.Environment, seed int64, feeds ...Feed) (*Simulator, error) {
+	urls := e.URLs[mockserver.URLsKey]
+	if len(urls) == 0 {
+		return nil, errors.New("mockserver is not deployed")
+	}
+	return NewSimulatorWithClient(client.NewMockserverClient(urls[0]), seed, feeds...)
+}
+
+// NewSimulatorWithClient creates a simulator with a mockserver client
+func NewSimulatorWithClient(c *client.MockserverClient, seed int64, feeds ...Feed) (*Simulator, error) {
+	states := make([]*feedState, 0)
+	for i, f := range feeds {
+		if f.Path == "" {
+			return nil, errors.New("feed path is empty")
+		}
+		if f.Interval == 0 {
+			f.Interval = DefaultUpdateInterval
+		}
+		states = append(states, newFeedState(f, seed+int64(i)))
+	}
+	return &Simulator{
+		Client: c,
+		feeds:  states,
+		wg:     &sync.WaitGroup{},
+	}, nil
+}
+
+// Start publishes initial prices and starts updating every feed with its interval
+func (s *Simulator) Start() error {
+	for _, f := range s.feeds {
+		f.published = f.price
+		if err := s.Client.SetValuePath(f.feed.Path, f.published); err != nil {
+			return errors.Wrapf(err, "failed to set initial price of %s", f.feed.Path)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	start := time.Now()
+	for _, f := range s.feeds {
+		f := f
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.run(ctx, f, start)
+		}()
+	}
+	return nil
+}
+
+func (s *Simulator) run(ctx context.Context, f *feedState, start time.Time) {
+	ticker := time.NewTicker(f.feed.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			price := f.next(time.Since(start))
+			s.mu.Unlock()
+			if err := s.Client.SetValuePath(f.feed.Path, price); err != nil {
+				log.Error().Err(err).Str("Path", f.feed.Path).Msg("Failed to update price")
+				continue
+			}
+			log.Debug().Str("Path", f.feed.Path).Float64("Price", price).Msg("Price updated")
+		}
+	}
+}
+
+// Stop stops updating prices, last prices stay in mockserver
+func (s *Simulator) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.cancel = nil
+}
+
+// Price returns the last published price of the feed
+func (s *Simulator) Price(path string) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.feeds {
+		if f.feed.Path == path {
+			return f.published, true
+		}
+	}
+	return 0, false
+}
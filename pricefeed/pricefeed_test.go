@@ -0,0 +1,76 @@
+package pricefeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedStateSteps(t *testing.T) {
+	s := newFeedState(Feed{
+		Path:    "/variable",
+		Initial: 100,
+		Steps: []Step{
+			{At: 20 * time.Second, Value: 300},
+			{At: 10 * time.Second, Value: 200},
+		},
+	}, 1)
+	require.Equal(t, 100.0, s.next(5*time.Second))
+	require.Equal(t, 200.0, s.next(10*time.Second))
+	require.Equal(t, 200.0, s.next(15*time.Second))
+	require.Equal(t, 300.0, s.next(25*time.Second))
+}
+
+func TestFeedStateRandomWalkBounds(t *testing.T) {
+	s := newFeedState(Feed{
+		Path:       "/variable",
+		Initial:    100,
+		Volatility: 0.5,
+		Min:        90,
+		Max:        110,
+		Integer:    true,
+	}, 1)
+	for i := 0; i < 100; i++ {
+		p := s.next(time.Duration(i) * time.Second)
+		require.GreaterOrEqual(t, p, 90.0)
+		require.LessOrEqual(t, p, 110.0)
+		require.Equal(t, float64(int64(p)), p)
+	}
+}
+
+func TestFeedStateOutliers(t *testing.T) {
+	s := newFeedState(Feed{
+		Path:               "/variable",
+		Initial:            100,
+		OutlierProbability: 1,
+		OutlierMultiplier:  10,
+	}, 1)
+	require.Equal(t, 1000.0, s.next(time.Second))
+	// outlier doesn't change the underlying price
+	require.Equal(t, 100.0, s.price)
+}
+
+func TestSimulatorFeedsSeeds(t *testing.T) {
+	feed := func(path string) Feed {
+		return Feed{Path: path, Initial: 100, Volatility: 0.1}
+	}
+	prices := func(s *feedState, n int) []float64 {
+		res := make([]float64, 0, n)
+		for i := 0; i < n; i++ {
+			res = append(res, s.next(time.Duration(i)*time.Second))
+		}
+		return res
+	}
+	s, err := NewSimulatorWithClient(nil, 42, feed("/a"), feed("/b"))
+	require.NoError(t, err)
+	other, err := NewSimulatorWithClient(nil, 42, feed("/a"), feed("/b"))
+	require.NoError(t, err)
+	// sequences of a feed are the same, no matter how many updates of other feeds happened in between
+	_ = prices(other.feeds[0], 10)
+	b := prices(s.feeds[1], 5)
+	require.Equal(t, b, prices(other.feeds[1], 5))
+	require.Equal(t, prices(s.feeds[0], 5), prices(newFeedState(feed("/a"), 42), 5))
+	require.Equal(t, b, prices(newFeedState(feed("/b"), 43), 5))
+	require.NotEqual(t, b, prices(newFeedState(feed("/a"), 42), 5))
+}